@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"os"
@@ -9,19 +10,40 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/go-redis/redis"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
 	"github.com/myrunes/backend/pkg/ddragon"
 	"github.com/myrunes/backend/pkg/lifecycletimer"
 
 	"github.com/myrunes/backend/internal/assets"
 	"github.com/myrunes/backend/internal/caching"
+	"github.com/myrunes/backend/internal/communitystats"
 	"github.com/myrunes/backend/internal/config"
 	"github.com/myrunes/backend/internal/database"
+	"github.com/myrunes/backend/internal/events"
+	"github.com/myrunes/backend/internal/health"
 	"github.com/myrunes/backend/internal/logger"
 	"github.com/myrunes/backend/internal/mailserver"
+	"github.com/myrunes/backend/internal/metrics"
+	"github.com/myrunes/backend/internal/objects"
 	"github.com/myrunes/backend/internal/storage"
+	"github.com/myrunes/backend/internal/tracing"
+	"github.com/myrunes/backend/internal/webhooks"
 	"github.com/myrunes/backend/internal/webserver"
 )
 
+const (
+	// interval of the lifecycle timer
+	// executing refetches and cleanups
+	lifecycleInterval = 24 * time.Hour
+	// timeout of each readiness check
+	healthCheckTimeout = 5 * time.Second
+	// maximum duration of the shutdown until
+	// remaining requests and jobs are cut off
+	shutdownTimeout = 20 * time.Second
+)
+
 var (
 	flagConfig    = flag.String("c", "config.yml", "config file location")
 	flagSkipFetch = flag.Bool("skipFetch", false, "skip avatar asset fetching")
@@ -50,7 +72,7 @@ func initStorage(c *config.Main) (st storage.Middleware, err error) {
 	return
 }
 
-func fetchAssets(a *assets.AvatarHandler) error {
+func fetchAssets(ctx context.Context, a *assets.AvatarHandler, r *assets.RuneIconHandler) error {
 	if *flagSkipFetch {
 		return nil
 	}
@@ -58,13 +80,39 @@ func fetchAssets(a *assets.AvatarHandler) error {
 	cChamps := make(chan string)
 	cError := make(chan error)
 
-	go a.FetchAll(cChamps, cError)
+	go a.FetchAll(ctx, cChamps, cError)
 
 	go func() {
+		defer close(cChamps)
 		for _, c := range ddragon.DDragonInstance.Champions {
-			cChamps <- c.UID
+			select {
+			case cChamps <- c.UID:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for err := range cError {
+		if err != nil {
+			return err
+		}
+	}
+
+	cIcons := make(chan *assets.RuneIcon)
+	cError = make(chan error)
+
+	go r.FetchAll(ctx, cIcons, cError)
+
+	go func() {
+		defer close(cIcons)
+		for _, i := range assets.RuneIcons(ddragon.DDragonInstance) {
+			select {
+			case cIcons <- i:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(cChamps)
 	}()
 
 	for err := range cError {
@@ -76,22 +124,91 @@ func fetchAssets(a *assets.AvatarHandler) error {
 	return nil
 }
 
-func refetch(a *assets.AvatarHandler) {
+func refetch(ctx context.Context, a *assets.AvatarHandler, r *assets.RuneIconHandler) {
 	var err error
 
 	logger.Info("DDRAGON :: refetch")
-	if ddragon.DDragonInstance, err = ddragon.Fetch("latest"); err != nil {
+	d, err := ddragon.Fetch("latest")
+	metrics.DDragonRefreshes.WithLabelValues(metrics.Result(err)).Inc()
+	if err != nil {
 		logger.Error("DDRAGON :: failed polling data from ddragon: %s", err.Error())
+	} else {
+		ddragon.DDragonInstance = d
+	}
+
+	if ctx.Err() != nil {
+		return
 	}
 
 	logger.Info("ASSETHANDLER :: refetch")
-	if err = fetchAssets(a); err != nil {
-		logger.Fatal("ASSETHANDLER :: failed fetching assets: %s", err.Error())
+	// Fetches are cancelled on shutdown, which
+	// is not logged as failure.
+	if err = fetchAssets(ctx, a, r); err != nil && ctx.Err() == nil {
+		logger.Error("ASSETHANDLER :: failed fetching assets: %s", err.Error())
+	}
+}
+
+func initHealth(db database.Middleware, redisCache *caching.Redis, st storage.Middleware,
+	ms *mailserver.MailServer) *health.Health {
+
+	hc := health.New(healthCheckTimeout)
+
+	hc.Register("mongodb", func(ctx context.Context) (interface{}, error) {
+		return nil, db.Ping(ctx)
+	})
+
+	if redisCache != nil {
+		hc.Register("redis", func(ctx context.Context) (interface{}, error) {
+			return nil, redisCache.Ping(ctx)
+		})
+	}
+
+	hc.Register("storage", func(ctx context.Context) (interface{}, error) {
+		buckets := make(map[string]bool, len(assets.Buckets))
+		for _, b := range assets.Buckets {
+			exists, err := st.BucketExists(ctx, b)
+			if err != nil {
+				return nil, err
+			}
+			buckets[b] = exists
+		}
+		return buckets, nil
+	})
+
+	if ms != nil {
+		hc.Register("smtp", func(ctx context.Context) (interface{}, error) {
+			return nil, ms.Ping(ctx)
+		})
 	}
+
+	hc.Register("ddragon", func(context.Context) (interface{}, error) {
+		d := ddragon.DDragonInstance
+		if d == nil {
+			return nil, errors.New("data not loaded")
+		}
+
+		age := time.Since(d.Fetched)
+		details := map[string]interface{}{
+			"version": d.Version,
+			"fetched": d.Fetched,
+			"age":     int64(age.Seconds()),
+		}
+
+		// Refetches are executed by the lifecycle timer,
+		// so data older than two intervals indicates that
+		// at least the last refetch failed.
+		if age > 2*lifecycleInterval {
+			return details, health.Degraded(errors.New("data is stale"))
+		}
+
+		return details, nil
+	})
+
+	return hc
 }
 
-func cleanupExpiredRefreshTokens(db database.Middleware) {
-	n, err := db.CleanupExpiredTokens()
+func cleanupExpiredRefreshTokens(ctx context.Context, db database.Middleware) {
+	n, err := db.CleanupExpiredTokens(ctx)
 	if err != nil {
 		logger.Error("DATABASE :: failed cleaning up expired refresh tokens: %s", err.Error())
 	} else {
@@ -99,9 +216,67 @@ func cleanupExpiredRefreshTokens(db database.Middleware) {
 	}
 }
 
+func cleanupPageTombstones(ctx context.Context, db database.Middleware) {
+	n, err := db.CleanupPageTombstones(ctx, time.Now().Add(-objects.PageTombstoneLifetime))
+	if err != nil {
+		logger.Error("DATABASE :: failed cleaning up page tombstones: %s", err.Error())
+	} else {
+		logger.Info("SYNC :: cleaned %d page tombstones", n)
+	}
+}
+
+func cleanupWebhookDeliveries(ctx context.Context, db database.Middleware) {
+	n, err := db.CleanupWebhookDeliveries(ctx, time.Now().Add(-objects.WebhookDeliveryLifetime))
+	if err != nil {
+		logger.Error("DATABASE :: failed cleaning up webhook deliveries: %s", err.Error())
+	} else {
+		logger.Info("WEBHOOKS :: cleaned %d webhook deliveries", n)
+	}
+}
+
+func cleanupShareAccesses(ctx context.Context, db database.Middleware) {
+	n, err := db.CleanupShareAccesses(ctx, time.Now().Add(-objects.ShareAccessLifetime))
+	if err != nil {
+		logger.Error("DATABASE :: failed cleaning up share accesses: %s", err.Error())
+	} else {
+		logger.Info("SHARES :: cleaned %d share accesses", n)
+	}
+}
+
+func cleanupPageImages(ctx context.Context, r *assets.PageImageRenderer, db database.Middleware) {
+	n, err := r.Cleanup(ctx, db.GetPage)
+	if err != nil {
+		logger.Error("ASSETHANDLER :: failed cleaning up page images: %s", err.Error())
+	} else {
+		logger.Info("ASSETHANDLER :: cleaned %d outdated page images", n)
+	}
+}
+
+func aggregateChampionStats(ctx context.Context, db database.Middleware, cache caching.CacheMiddleware) {
+	n, err := communitystats.Aggregate(ctx, db, cache)
+	if err != nil {
+		logger.Error("COMMUNITYSTATS :: failed aggregating champion stats: %s", err.Error())
+	} else {
+		logger.Info("COMMUNITYSTATS :: aggregated stats of %d champions", n)
+	}
+}
+
 func main() {
 	flag.Parse()
 
+	// The root context is cancelled on the first
+	// termination signal. A second signal kills
+	// the process immediately.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		sc := make(chan os.Signal, 1)
+		signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+		<-sc
+		signal.Stop(sc)
+		cancel()
+	}()
+
 	logger.Setup(`%{color}▶  %{level:.4s} %{id:03d}%{color:reset} %{message}`, 5)
 
 	logger.Info("CONFIG :: initialization")
@@ -114,6 +289,18 @@ func main() {
 		return
 	}
 
+	if err = logger.Configure(cfg.Logging); err != nil {
+		logger.Fatal("CONFIG :: invalid logging config: %s", err.Error())
+	}
+
+	var tracerProvider *sdktrace.TracerProvider
+	if cfg.Tracing != nil && cfg.Tracing.Endpoint != "" {
+		logger.Info("TRACING :: exporting spans to %s", cfg.Tracing.Endpoint)
+		if tracerProvider, err = tracing.Setup(cfg.Tracing); err != nil {
+			logger.Fatal("TRACING :: failed setting up tracing: %s", err.Error())
+		}
+	}
+
 	if v := os.Getenv("DB_HOST"); v != "" {
 		cfg.MongoDB.Host = v
 	}
@@ -143,32 +330,34 @@ func main() {
 	}
 
 	logger.Info("DDRAGON :: initialization")
-	if ddragon.DDragonInstance, err = ddragon.Fetch("latest"); err != nil {
+	ddragon.DDragonInstance, err = ddragon.Fetch("latest")
+	metrics.DDragonRefreshes.WithLabelValues(metrics.Result(err)).Inc()
+	if err != nil {
 		logger.Fatal("DDRAGON :: failed polling data from ddragon: %s", err.Error())
 	}
 	logger.Info("DDRAGON :: initialized")
 
-	db := new(database.MongoDB)
+	var db database.Middleware = new(database.MongoDB)
 	logger.Info("DATABASE :: initialization")
 	if err = db.Connect(cfg.MongoDB); err != nil {
 		logger.Fatal("DATABASE :: failed establishing connection to database: %s", err.Error())
 	}
-	defer func() {
-		logger.Info("DATABASE :: teardown")
-		db.Close()
-	}()
+	db = database.NewTracing(database.NewMetrics(db), "mongodb")
 
 	logger.Info("STORAGE :: initialization")
 	st, err := initStorage(cfg)
 	if err != nil {
 		logger.Fatal("STORAGE :: failed initializing storage: %s", err.Error())
 	}
+	st = storage.NewTracing(st)
 
 	logger.Info("ASSETHANDLER :: initialization")
 	avatarAssetsHandler := assets.NewAvatarHandler(st)
-	if err = fetchAssets(avatarAssetsHandler); err != nil {
+	runeIconAssetsHandler := assets.NewRuneIconHandler(st)
+	if err = fetchAssets(ctx, avatarAssetsHandler, runeIconAssetsHandler); err != nil {
 		logger.Fatal("ASSETHANDLER :: failed fetching assets: %s", err.Error())
 	}
+	pageImageRenderer := assets.NewPageImageRenderer(st, avatarAssetsHandler, runeIconAssetsHandler)
 
 	var ms *mailserver.MailServer
 	if cfg.MailServer != nil {
@@ -183,15 +372,31 @@ func main() {
 	}
 
 	var cache caching.CacheMiddleware
+	var redisCache *caching.Redis
 	if cfg.Redis != nil && cfg.Redis.Enabled {
-		cache = caching.NewRedis(cfg.Redis)
+		redisCache = caching.NewRedis(cfg.Redis)
+		cache = redisCache
 	} else {
 		cache = caching.NewInternal()
 	}
 	cache.SetDatabase(db)
 
+	var evt events.Broker
+	if cfg.Redis != nil && cfg.Redis.Enabled {
+		evt = events.NewRedis(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+	} else {
+		evt = events.NewInternal()
+	}
+	evt = webhooks.New(evt, db)
+
+	hc := initHealth(db, redisCache, st, ms)
+
 	logger.Info("WEBSERVER :: initialization")
-	ws, err := webserver.NewWebServer(db, cache, ms, avatarAssetsHandler, cfg.WebServer)
+	ws, err := webserver.NewWebServer(db, cache, evt, ms, hc, avatarAssetsHandler, pageImageRenderer, cfg.WebServer)
 	if err != nil {
 		logger.Fatal("WEBSERVER :: failed creating web server: %s", err.Error())
 	}
@@ -202,14 +407,112 @@ func main() {
 	}()
 	logger.Info("WEBSERVER :: started")
 
-	lct := lifecycletimer.New(24 * time.Hour).
-		Handle(func() { refetch(avatarAssetsHandler) }).
-		Handle(func() { cleanupExpiredRefreshTokens(db) }).
+	var metricsServer *metrics.Server
+	if cfg.Metrics != nil && cfg.Metrics.Addr != "" {
+		logger.Info("METRICS :: initialization")
+		metrics.RegisterActiveRefreshTokens(func() (int, error) {
+			return db.CountRefreshTokens(context.Background())
+		})
+		if cfg.Metrics.Token == "" {
+			logger.Warning("METRICS :: no token set, access to metrics is only restricted by the bind address")
+		}
+		metricsServer = metrics.NewServer(cfg.Metrics)
+		go func() {
+			if err := metricsServer.ListenAndServeBlocking(); err != nil {
+				logger.Fatal("METRICS :: failed starting metrics server: %s", err.Error())
+			}
+		}()
+		logger.Info("METRICS :: started")
+	}
+
+	lct := lifecycletimer.New(lifecycleInterval).
+		Handle(func(ctx context.Context) { refetch(ctx, avatarAssetsHandler, runeIconAssetsHandler) }).
+		Handle(func(ctx context.Context) { cleanupExpiredRefreshTokens(ctx, db) }).
+		Handle(func(ctx context.Context) { cleanupPageTombstones(ctx, db) }).
+		Handle(func(ctx context.Context) { cleanupWebhookDeliveries(ctx, db) }).
+		Handle(func(ctx context.Context) { cleanupShareAccesses(ctx, db) }).
+		Handle(func(ctx context.Context) { cleanupPageImages(ctx, pageImageRenderer, db) }).
+		Handle(func(ctx context.Context) { aggregateChampionStats(ctx, db, cache) }).
 		Start()
-	defer lct.Stop()
 	logger.Info("LIFECYCLETIMER :: started")
 
-	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, os.Kill)
-	<-sc
+	cAggregated := make(chan struct{})
+	go func() {
+		aggregateChampionStats(ctx, db, cache)
+		close(cAggregated)
+	}()
+
+	<-ctx.Done()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+
+	logger.Info("WEBSERVER :: shutdown")
+	if err = ws.Shutdown(shutdownCtx); err != nil {
+		logger.Error("WEBSERVER :: failed draining requests: %s", err.Error())
+	}
+
+	if metricsServer != nil {
+		logger.Info("METRICS :: shutdown")
+		if err = metricsServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error("METRICS :: failed draining requests: %s", err.Error())
+		}
+	}
+
+	if ms != nil {
+		logger.Info("MAILSERVER :: shutdown")
+		cClosed := make(chan error, 1)
+		go func() {
+			cClosed <- ms.Close()
+		}()
+		select {
+		case err = <-cClosed:
+		case <-shutdownCtx.Done():
+			err = shutdownCtx.Err()
+		}
+		if err != nil {
+			logger.Error("MAILSERVER :: failed finishing sent mails: %s", err.Error())
+		}
+	}
+
+	logger.Info("LIFECYCLETIMER :: shutdown")
+	if err = lct.Shutdown(shutdownCtx); err != nil {
+		logger.Error("LIFECYCLETIMER :: failed finishing running jobs: %s", err.Error())
+	}
+	select {
+	case <-cAggregated:
+	case <-shutdownCtx.Done():
+	}
+
+	// Closing the broker also waits for the
+	// enqueued webhook deliveries, which are
+	// recorded in the database.
+	logger.Info("EVENTS :: teardown")
+	cEvtClosed := make(chan error, 1)
+	go func() {
+		cEvtClosed <- evt.Close()
+	}()
+	select {
+	case err = <-cEvtClosed:
+	case <-shutdownCtx.Done():
+		err = shutdownCtx.Err()
+	}
+	if err != nil {
+		logger.Error("EVENTS :: failed closing event broker: %s", err.Error())
+	}
+
+	logger.Info("CACHE :: teardown")
+	if err = cache.Close(); err != nil {
+		logger.Error("CACHE :: failed closing cache: %s", err.Error())
+	}
+
+	logger.Info("DATABASE :: teardown")
+	db.Close()
+
+	if tracerProvider != nil {
+		logger.Info("TRACING :: shutdown")
+		if err = tracerProvider.Shutdown(shutdownCtx); err != nil {
+			logger.Error("TRACING :: failed exporting remaining spans: %s", err.Error())
+		}
+	}
 }
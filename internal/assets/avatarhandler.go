@@ -1,11 +1,13 @@
 package assets
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/myrunes/backend/internal/logger"
+	"github.com/myrunes/backend/internal/metrics"
 	"github.com/myrunes/backend/internal/storage"
 	"github.com/myrunes/backend/pkg/workerpool"
 )
@@ -24,37 +26,44 @@ func NewAvatarHandler(st storage.Middleware) *AvatarHandler {
 	return &AvatarHandler{st}
 }
 
-func (ah *AvatarHandler) Get(champ string) (io.ReadCloser, int64, error) {
-	return ah.storage.GetObject(avatarBucketName, getObjectName(champ))
+func (ah *AvatarHandler) Get(ctx context.Context, champ string) (io.ReadCloser, int64, error) {
+	return ah.storage.GetObject(ctx, avatarBucketName, getObjectName(champ))
 }
 
-func (ah *AvatarHandler) FetchAll(cChampNames chan string, cError chan error) {
+func (ah *AvatarHandler) FetchAll(ctx context.Context, cChampNames chan string, cError chan error) {
 	wp := workerpool.New(5)
 
+	// The results must be received completely
+	// before cError is closed.
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		for res := range wp.Results() {
 			if err, _ := res.(error); err != nil {
+				metrics.AssetFetchFailures.WithLabelValues("avatar").Inc()
 				cError <- err
 			}
 		}
 	}()
 
 	for champ := range cChampNames {
-		wp.Push(ah.jobFetchSingle, champ)
+		wp.Push(ah.jobFetchSingle, ctx, champ)
 	}
 	wp.Close()
 
 	wp.WaitBlocking()
+	<-done
 	close(cError)
 }
 
 func (ah *AvatarHandler) jobFetchSingle(workerId int, params ...interface{}) interface{} {
-	champ := params[0].(string)
+	ctx := params[0].(context.Context)
+	champ := params[1].(string)
 
 	logger.Info("ASSETSHANDLER :: [%d] fetch champion avatar asset of '%s'...", workerId, champ)
 
 	url := fmt.Sprintf(avatarCDNURL, champ)
-	resp, err := http.Get(url)
+	resp, err := httpGet(ctx, url)
 	if err != nil {
 		return err
 	}
@@ -63,13 +72,24 @@ func (ah *AvatarHandler) jobFetchSingle(workerId int, params ...interface{}) int
 		return fmt.Errorf("resuest failed with code %d", resp.StatusCode)
 	}
 
-	return ah.put(champ, resp.Body, resp.ContentLength)
+	return ah.put(ctx, champ, resp.Body, resp.ContentLength)
 }
 
-func (ah *AvatarHandler) put(champ string, reader io.Reader, size int64) error {
-	return ah.storage.PutObject(avatarBucketName, getObjectName(champ), reader, size, avatarMimeType)
+func (ah *AvatarHandler) put(ctx context.Context, champ string, reader io.Reader, size int64) error {
+	return ah.storage.PutObject(ctx, avatarBucketName, getObjectName(champ), reader, size, avatarMimeType)
 }
 
 func getObjectName(champ string) string {
 	return fmt.Sprintf("%s.png", champ)
 }
+
+// httpGet executes a GET request to the passed
+// URL which is cancelled when the passed context
+// is done.
+func httpGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req.WithContext(ctx))
+}
@@ -0,0 +1,11 @@
+package assets
+
+// Buckets contains the names of all storage
+// buckets used to store assets. Buckets are
+// created on demand when the first object is
+// stored in them.
+var Buckets = []string{
+	avatarBucketName,
+	runeIconBucketName,
+	pageImageBucketName,
+}
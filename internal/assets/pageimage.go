@@ -0,0 +1,296 @@
+package assets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/myrunes/backend/internal/logger"
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/internal/storage"
+	"github.com/myrunes/backend/pkg/ddragon"
+)
+
+const (
+	pageImageBucketName = "myrunes-pageimages"
+	pageImageMimeType   = "image/png"
+
+	// maximum number of characters of the
+	// page title rendered in the image
+	pageImageMaxTitleLen = 48
+)
+
+// Dimensions of page images and the
+// rendered icons and their spacings.
+const (
+	PageImageWidth  = 640
+	PageImageHeight = 320
+
+	pageImagePadding      = 24
+	pageImageGap          = 12
+	pageImageAvatarSize   = 96
+	pageImageTreeSize     = 32
+	pageImageKeystoneSize = 64
+	pageImageRuneSize     = 44
+	pageImageShardSize    = 28
+)
+
+var (
+	pageImageBackground = color.RGBA{0x1c, 0x1c, 0x2b, 0xff}
+	pageImageForeground = color.RGBA{0xf0, 0xe6, 0xd2, 0xff}
+	pageImageSubtle     = color.RGBA{0xa0, 0x9b, 0x8c, 0xff}
+)
+
+// PageImageRenderer renders images of rune pages
+// showing the champion avatar, the selected trees,
+// runes and stat shards. Rendered images are cached
+// in the storage.
+type PageImageRenderer struct {
+	storage   storage.Middleware
+	avatars   *AvatarHandler
+	runeIcons *RuneIconHandler
+}
+
+// NewPageImageRenderer creates a new instance of
+// PageImageRenderer using the passed storage to
+// cache rendered images and the passed asset
+// handlers to get the avatar and icon images.
+func NewPageImageRenderer(st storage.Middleware, avatars *AvatarHandler, runeIcons *RuneIconHandler) *PageImageRenderer {
+	return &PageImageRenderer{st, avatars, runeIcons}
+}
+
+// Get returns the PNG image data of the passed
+// page. If an image of the current state of the
+// page was rendered before, the cached image is
+// returned. Otherwise, the image is rendered and
+// stored in the cache.
+func (pr *PageImageRenderer) Get(ctx context.Context, page *objects.Page) ([]byte, error) {
+	objectName := getPageImageObjectName(page)
+
+	if reader, _, err := pr.storage.GetObject(ctx, pageImageBucketName, objectName); err == nil {
+		defer reader.Close()
+		return ioutil.ReadAll(reader)
+	}
+
+	img := pr.render(ctx, page)
+
+	buff := new(bytes.Buffer)
+	if err := png.Encode(buff, img); err != nil {
+		return nil, err
+	}
+
+	data := buff.Bytes()
+	err := pr.storage.PutObject(ctx, pageImageBucketName, objectName,
+		bytes.NewReader(data), int64(len(data)), pageImageMimeType)
+	if err != nil {
+		logger.Error("ASSETSHANDLER :: failed caching page image: %s", err.Error())
+	}
+
+	return data, nil
+}
+
+// Delete removes the cached image of the
+// current state of the passed page.
+func (pr *PageImageRenderer) Delete(ctx context.Context, page *objects.Page) error {
+	return pr.storage.DeleteObject(ctx, pageImageBucketName, getPageImageObjectName(page))
+}
+
+// Cleanup removes all cached images of pages which
+// were deleted or edited after the image was
+// rendered. The current state of pages is obtained
+// by the passed getPage function, which returns nil
+// for pages which do not exist. The number of
+// removed images is returned.
+func (pr *PageImageRenderer) Cleanup(
+	ctx context.Context,
+	getPage func(ctx context.Context, uid snowflake.ID) (*objects.Page, error),
+) (n int, err error) {
+	names, err := pr.storage.ListObjects(ctx, pageImageBucketName)
+	if err != nil {
+		return
+	}
+
+	for _, name := range names {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		uid, ok := parsePageImageObjectName(name)
+		if ok {
+			var page *objects.Page
+			if page, err = getPage(ctx, uid); err != nil {
+				return
+			}
+			if page != nil && getPageImageObjectName(page) == name {
+				continue
+			}
+		}
+
+		if err = pr.storage.DeleteObject(ctx, pageImageBucketName, name); err != nil {
+			return
+		}
+		n++
+	}
+
+	return
+}
+
+// render draws the image of the passed page.
+// Missing assets are skipped.
+func (pr *PageImageRenderer) render(ctx context.Context, page *objects.Page) image.Image {
+	dd := ddragon.DDragonInstance
+	img := image.NewRGBA(image.Rect(0, 0, PageImageWidth, PageImageHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(pageImageBackground), image.Point{}, draw.Src)
+
+	x, y := pageImagePadding, pageImagePadding
+
+	if len(page.Champions) > 0 {
+		pr.drawAsset(ctx, img, pr.avatars.Get, page.Champions[0], x, y, pageImageAvatarSize)
+	}
+
+	textX := x + pageImageAvatarSize + pageImagePadding
+	drawText(img, truncate(page.Title, pageImageMaxTitleLen), textX, y+13, pageImageForeground)
+
+	champs := ""
+	for i, uid := range page.Champions {
+		if i > 0 {
+			champs += ", "
+		}
+		if c := dd.GetChampion(uid); c != nil {
+			champs += c.Name
+		} else {
+			champs += uid
+		}
+	}
+	drawText(img, truncate(champs, pageImageMaxTitleLen), textX, y+33, pageImageSubtle)
+
+	if page.Primary != nil {
+		y = pageImagePadding*2 + pageImageAvatarSize
+		x = pageImagePadding
+
+		pr.drawAsset(ctx, img, pr.runeIcons.Get, page.Primary.Tree, x, y+(pageImageKeystoneSize-pageImageTreeSize)/2, pageImageTreeSize)
+		x += pageImageTreeSize + pageImageGap
+
+		for i, uid := range page.Primary.Rows {
+			size := pageImageRuneSize
+			if i == 0 {
+				size = pageImageKeystoneSize
+			}
+			pr.drawAsset(ctx, img, pr.runeIcons.Get, uid, x, y+(pageImageKeystoneSize-size)/2, size)
+			x += size + pageImageGap
+		}
+	}
+
+	if page.Secondary != nil {
+		x += pageImagePadding
+		y = pageImagePadding*2 + pageImageAvatarSize + (pageImageKeystoneSize-pageImageRuneSize)/2
+
+		pr.drawAsset(ctx, img, pr.runeIcons.Get, page.Secondary.Tree, x, y+(pageImageRuneSize-pageImageTreeSize)/2, pageImageTreeSize)
+		x += pageImageTreeSize + pageImageGap
+
+		for _, uid := range page.Secondary.Rows {
+			pr.drawAsset(ctx, img, pr.runeIcons.Get, uid, x, y, pageImageRuneSize)
+			x += pageImageRuneSize + pageImageGap
+		}
+	}
+
+	if page.Perks != nil {
+		x = pageImagePadding + pageImageTreeSize + pageImageGap
+		y = PageImageHeight - pageImagePadding - pageImageShardSize
+
+		for _, perk := range page.Perks.Rows {
+			pr.drawAsset(ctx, img, pr.runeIcons.Get, StatShardUID(perk), x, y, pageImageShardSize)
+			x += pageImageShardSize + pageImageGap
+		}
+	}
+
+	return img
+}
+
+// drawAsset gets the asset image by the passed uid
+// using the passed getter, scales it to a square
+// of the passed size and draws it at the passed
+// position to img.
+func (pr *PageImageRenderer) drawAsset(
+	ctx context.Context,
+	img draw.Image,
+	get func(ctx context.Context, uid string) (io.ReadCloser, int64, error),
+	uid string,
+	x, y, size int,
+) {
+	if uid == "" {
+		return
+	}
+
+	reader, _, err := get(ctx, uid)
+	if err != nil {
+		logger.Warning("ASSETSHANDLER :: missing asset '%s' for page image: %s", uid, err.Error())
+		return
+	}
+	defer reader.Close()
+
+	src, _, err := image.Decode(reader)
+	if err != nil {
+		logger.Warning("ASSETSHANDLER :: failed decoding asset '%s': %s", uid, err.Error())
+		return
+	}
+
+	draw.CatmullRom.Scale(img, image.Rect(x, y, x+size, y+size), src, src.Bounds(), draw.Over, nil)
+}
+
+// drawText draws the passed text with its
+// baseline at the passed position to img.
+func drawText(img draw.Image, text string, x, y int, c color.Color) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+// truncate shortens the passed string to the
+// passed number of characters, if it is longer.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
+
+// getPageImageObjectName returns the storage object
+// name of the page image of the passed page, which
+// is derived from the page ID and the time of the
+// last modification of the page in milliseconds.
+func getPageImageObjectName(page *objects.Page) string {
+	return fmt.Sprintf("%d-%d.png", page.UID, page.Edited.UnixNano()/int64(time.Millisecond))
+}
+
+// parsePageImageObjectName returns the page ID of
+// the passed page image object name. If the name
+// is not a valid page image object name, false
+// is returned.
+func parsePageImageObjectName(name string) (snowflake.ID, bool) {
+	i := strings.IndexByte(name, '-')
+	if i < 0 || !strings.HasSuffix(name, ".png") {
+		return 0, false
+	}
+
+	uid, err := snowflake.ParseString(name[:i])
+	return uid, err == nil
+}
@@ -0,0 +1,88 @@
+package assets
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/internal/storage"
+)
+
+func TestParsePageImageObjectName(t *testing.T) {
+	cases := []struct {
+		name string
+		uid  snowflake.ID
+		ok   bool
+	}{
+		{"123-456.png", 123, true},
+		{"123.png", 0, false},
+		{"abc-456.png", 0, false},
+		{"123-456.jpg", 0, false},
+	}
+
+	for _, c := range cases {
+		uid, ok := parsePageImageObjectName(c.name)
+		if ok != c.ok || (ok && uid != c.uid) {
+			t.Errorf("%s: expected (%d, %t), got (%d, %t)", c.name, c.uid, c.ok, uid, ok)
+		}
+	}
+}
+
+func TestPageImageRendererCleanup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pageimages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	st := new(storage.File)
+	if err = st.Init(storage.FileConfig{Location: dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	current := &objects.Page{UID: 1, Edited: time.Unix(0, 200*int64(time.Millisecond))}
+	names := []string{"1-100.png", getPageImageObjectName(current), "2-100.png", "invalid.txt"}
+	for _, name := range names {
+		if err = st.PutObject(ctx, pageImageBucketName, name, bytes.NewReader([]byte{0}), 1, pageImageMimeType); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pr := NewPageImageRenderer(st, nil, nil)
+	n, err := pr.Cleanup(ctx, func(ctx context.Context, uid snowflake.ID) (*objects.Page, error) {
+		if uid == current.UID {
+			return current, nil
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 removed images, got %d", n)
+	}
+
+	left, err := st.ListObjects(ctx, pageImageBucketName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(left)
+	if len(left) != 1 || left[0] != "1-200.png" {
+		t.Errorf("unexpected remaining images: %v", left)
+	}
+
+	if err = pr.Delete(ctx, current); err != nil {
+		t.Fatal(err)
+	}
+	if left, _ = st.ListObjects(ctx, pageImageBucketName); len(left) != 0 {
+		t.Errorf("image not deleted: %v", left)
+	}
+}
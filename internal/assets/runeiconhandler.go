@@ -0,0 +1,130 @@
+package assets
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/myrunes/backend/internal/logger"
+	"github.com/myrunes/backend/internal/metrics"
+	"github.com/myrunes/backend/internal/storage"
+	"github.com/myrunes/backend/pkg/ddragon"
+	"github.com/myrunes/backend/pkg/workerpool"
+)
+
+const (
+	runeIconCDNURL     = "https://ddragon.leagueoflegends.com/cdn/img/%s"
+	runeIconBucketName = "myrunes-assets-runeicons"
+	runeIconMimeType   = "image/png"
+	statShardUIDPrefix = "statshard-"
+)
+
+// statShardIconPaths maps the perk names of
+// the stat shards to the ddragon image paths
+// of their icons.
+var statShardIconPaths = map[string]string{
+	"diamond": "perk-images/StatMods/StatModsAdaptiveForceIcon.png",
+	"axe":     "perk-images/StatMods/StatModsAttackSpeedIcon.png",
+	"time":    "perk-images/StatMods/StatModsCDRScalingIcon.png",
+	"shield":  "perk-images/StatMods/StatModsArmorIcon.png",
+	"circle":  "perk-images/StatMods/StatModsMagicResIcon.png",
+	"heart":   "perk-images/StatMods/StatModsHealthScalingIcon.png",
+}
+
+// RuneIcon wraps the UID of a rune, rune tree
+// or stat shard and the ddragon image path of
+// its icon.
+type RuneIcon struct {
+	UID  string
+	Path string
+}
+
+type RuneIconHandler struct {
+	storage storage.Middleware
+}
+
+func NewRuneIconHandler(st storage.Middleware) *RuneIconHandler {
+	return &RuneIconHandler{st}
+}
+
+// RuneIcons returns the icons of all rune trees,
+// runes and stat shards of the passed DDragon
+// instance.
+func RuneIcons(d *ddragon.DDragon) []*RuneIcon {
+	icons := make([]*RuneIcon, 0)
+
+	for _, t := range d.Runes {
+		icons = append(icons, &RuneIcon{t.UID, t.Icon})
+		for _, s := range t.Slots {
+			for _, r := range s.Runes {
+				icons = append(icons, &RuneIcon{r.UID, r.Icon})
+			}
+		}
+	}
+
+	for perk, path := range statShardIconPaths {
+		icons = append(icons, &RuneIcon{StatShardUID(perk), path})
+	}
+
+	return icons
+}
+
+// StatShardUID returns the icon UID of the
+// stat shard with the passed perk name.
+func StatShardUID(perk string) string {
+	return statShardUIDPrefix + perk
+}
+
+func (rh *RuneIconHandler) Get(ctx context.Context, uid string) (io.ReadCloser, int64, error) {
+	return rh.storage.GetObject(ctx, runeIconBucketName, getObjectName(uid))
+}
+
+func (rh *RuneIconHandler) FetchAll(ctx context.Context, cIcons chan *RuneIcon, cError chan error) {
+	wp := workerpool.New(5)
+
+	// The results must be received completely
+	// before cError is closed.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for res := range wp.Results() {
+			if err, _ := res.(error); err != nil {
+				metrics.AssetFetchFailures.WithLabelValues("runeicon").Inc()
+				cError <- err
+			}
+		}
+	}()
+
+	for icon := range cIcons {
+		wp.Push(rh.jobFetchSingle, ctx, icon)
+	}
+	wp.Close()
+
+	wp.WaitBlocking()
+	<-done
+	close(cError)
+}
+
+func (rh *RuneIconHandler) jobFetchSingle(workerId int, params ...interface{}) interface{} {
+	ctx := params[0].(context.Context)
+	icon := params[1].(*RuneIcon)
+
+	logger.Info("ASSETSHANDLER :: [%d] fetch rune icon asset of '%s'...", workerId, icon.UID)
+
+	url := fmt.Sprintf(runeIconCDNURL, icon.Path)
+	resp, err := httpGet(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("request failed with code %d", resp.StatusCode)
+	}
+
+	return rh.put(ctx, icon.UID, resp.Body, resp.ContentLength)
+}
+
+func (rh *RuneIconHandler) put(ctx context.Context, uid string, reader io.Reader, size int64) error {
+	return rh.storage.PutObject(ctx, runeIconBucketName, getObjectName(uid), reader, size, runeIconMimeType)
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"context"
+
 	"github.com/bwmarrin/snowflake"
 	routing "github.com/qiangxue/fasthttp-routing"
 )
@@ -15,8 +17,9 @@ type AuthMiddleware interface {
 	// for the given password string and returns the
 	// hash as string containing the hashing algorithm,
 	// the parameters used to create the hash and the
-	// hash itself as base64 vlaue.
-	CreateHash(pass string) (string, error)
+	// hash itself as base64 vlaue. The hashing is
+	// traced as span of the passed context.
+	CreateHash(ctx context.Context, pass string) (string, error)
 
 	// CheckHash checks if the given hash matches a
 	// given password. The result is returned as
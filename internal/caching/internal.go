@@ -1,10 +1,12 @@
 package caching
 
 import (
+	"context"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/myrunes/backend/internal/database"
+	"github.com/myrunes/backend/internal/metrics"
 	"github.com/myrunes/backend/internal/objects"
 	"github.com/zekroTJA/timedmap"
 )
@@ -12,6 +14,7 @@ import (
 const (
 	secUsers = iota
 	secPages
+	secChampionStats
 )
 
 // Internal provides a caching module which uses
@@ -20,9 +23,10 @@ const (
 type Internal struct {
 	db database.Middleware
 
-	m     *timedmap.TimedMap
-	users timedmap.Section
-	pages timedmap.Section
+	m             *timedmap.TimedMap
+	users         timedmap.Section
+	pages         timedmap.Section
+	championStats timedmap.Section
 }
 
 // NewInternal creates a new instance of
@@ -30,31 +34,38 @@ type Internal struct {
 func NewInternal() *Internal {
 	tm := timedmap.New(15 * time.Minute)
 	return &Internal{
-		m:     tm,
-		users: tm.Section(secUsers),
-		pages: tm.Section(secPages),
+		m:             tm,
+		users:         tm.Section(secUsers),
+		pages:         tm.Section(secPages),
+		championStats: tm.Section(secChampionStats),
 	}
 }
 
+func (c *Internal) Close() error {
+	c.m.StopCleaner()
+	return nil
+}
+
 func (c *Internal) SetDatabase(db database.Middleware) {
 	c.db = db
 }
 
-func (c *Internal) GetUserByID(id snowflake.ID) (*objects.User, error) {
+func (c *Internal) GetUserByID(ctx context.Context, id snowflake.ID) (*objects.User, error) {
 	var err error
 	user, ok := c.users.GetValue(id).(*objects.User)
+	metrics.ObserveCache(metricUsers, ok && user != nil)
 	if !ok || user == nil {
-		user, err = c.db.GetUser(id, "")
+		user, err = c.db.GetUser(ctx, id, "")
 		if err != nil {
 			return nil, err
 		}
-		c.SetUserByID(id, user)
+		c.SetUserByID(ctx, id, user)
 	}
 
 	return user, nil
 }
 
-func (c *Internal) SetUserByID(id snowflake.ID, user *objects.User) error {
+func (c *Internal) SetUserByID(ctx context.Context, id snowflake.ID, user *objects.User) error {
 	if user == nil {
 		c.users.Remove(id)
 	} else {
@@ -63,12 +74,13 @@ func (c *Internal) SetUserByID(id snowflake.ID, user *objects.User) error {
 	return nil
 }
 
-func (c *Internal) GetUserByToken(token string) (*objects.User, bool) {
+func (c *Internal) GetUserByToken(ctx context.Context, token string) (*objects.User, bool) {
 	val, ok := c.users.GetValue(token).(*objects.User)
+	metrics.ObserveCache(metricUserTokens, ok && val != nil)
 	return val, ok && val != nil
 }
 
-func (c *Internal) SetUserByToken(token string, user *objects.User) error {
+func (c *Internal) SetUserByToken(ctx context.Context, token string, user *objects.User) error {
 	if user == nil {
 		c.users.Remove(token)
 	} else {
@@ -77,21 +89,22 @@ func (c *Internal) SetUserByToken(token string, user *objects.User) error {
 	return nil
 }
 
-func (c *Internal) GetPageByID(id snowflake.ID) (*objects.Page, error) {
+func (c *Internal) GetPageByID(ctx context.Context, id snowflake.ID) (*objects.Page, error) {
 	var err error
 	page, ok := c.pages.GetValue(id).(*objects.Page)
+	metrics.ObserveCache(metricPages, ok && page != nil)
 	if !ok || page == nil {
-		page, err = c.db.GetPage(id)
+		page, err = c.db.GetPage(ctx, id)
 		if err != nil {
 			return nil, err
 		}
-		c.SetPageByID(id, page)
+		c.SetPageByID(ctx, id, page)
 	}
 
 	return page, nil
 }
 
-func (c *Internal) SetPageByID(id snowflake.ID, page *objects.Page) error {
+func (c *Internal) SetPageByID(ctx context.Context, id snowflake.ID, page *objects.Page) error {
 	if page == nil {
 		c.pages.Remove(id)
 	} else {
@@ -99,3 +112,27 @@ func (c *Internal) SetPageByID(id snowflake.ID, page *objects.Page) error {
 	}
 	return nil
 }
+
+func (c *Internal) GetChampionStats(ctx context.Context, champion string) (*objects.ChampionStats, error) {
+	var err error
+	stats, ok := c.championStats.GetValue(champion).(*objects.ChampionStats)
+	metrics.ObserveCache(metricChampionStats, ok && stats != nil)
+	if !ok || stats == nil {
+		stats, err = c.db.GetChampionStats(ctx, champion)
+		if err != nil {
+			return nil, err
+		}
+		c.SetChampionStats(ctx, champion, stats)
+	}
+
+	return stats, nil
+}
+
+func (c *Internal) SetChampionStats(ctx context.Context, champion string, stats *objects.ChampionStats) error {
+	if stats == nil {
+		c.championStats.Remove(champion)
+	} else {
+		c.championStats.Set(champion, stats, expireDef)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package caching
 
 import (
+	"context"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
@@ -14,6 +15,15 @@ var (
 	expireDef = 1 * time.Hour
 )
 
+// Names of the cache sections recorded
+// in the cache requests metric.
+const (
+	metricUsers         = "users"
+	metricUserTokens    = "usertokens"
+	metricPages         = "pages"
+	metricChampionStats = "championstats"
+)
+
 // CacheMiddleware describes a caching module providing
 // functionality to store and fetch data to/from
 // a cache storage.
@@ -28,17 +38,28 @@ type CacheMiddleware interface {
 	SetDatabase(db database.Middleware)
 
 	// GetUserByID returns a User object by ID
-	GetUserByID(id snowflake.ID) (*objects.User, error)
+	GetUserByID(ctx context.Context, id snowflake.ID) (*objects.User, error)
 	// SetUserByID sets a User object to the passed ID
-	SetUserByID(id snowflake.ID, user *objects.User) error
+	SetUserByID(ctx context.Context, id snowflake.ID, user *objects.User) error
 	// GetUserByToken returns a User object by token string
-	GetUserByToken(token string) (*objects.User, bool)
+	GetUserByToken(ctx context.Context, token string) (*objects.User, bool)
 	// SetUserByToken sets a User object to the passed
 	// token string
-	SetUserByToken(token string, user *objects.User) error
+	SetUserByToken(ctx context.Context, token string, user *objects.User) error
 
 	// GetPageByID returns a Page object by ID
-	GetPageByID(id snowflake.ID) (*objects.Page, error)
+	GetPageByID(ctx context.Context, id snowflake.ID) (*objects.Page, error)
 	// SetPageByID sets a Page object to the passed ID
-	SetPageByID(id snowflake.ID, page *objects.Page) error
+	SetPageByID(ctx context.Context, id snowflake.ID, page *objects.Page) error
+
+	// GetChampionStats returns the ChampionStats
+	// object of the passed champion
+	GetChampionStats(ctx context.Context, champion string) (*objects.ChampionStats, error)
+	// SetChampionStats sets a ChampionStats object
+	// to the passed champion
+	SetChampionStats(ctx context.Context, champion string, stats *objects.ChampionStats) error
+
+	// Close releases the resources and
+	// connections of the cache.
+	Close() error
 }
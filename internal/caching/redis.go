@@ -1,6 +1,7 @@
 package caching
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -8,15 +9,24 @@ import (
 	"github.com/bwmarrin/snowflake"
 	"github.com/go-redis/redis"
 	"github.com/myrunes/backend/internal/database"
+	"github.com/myrunes/backend/internal/metrics"
 	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	keyUserByID    = "USER:ID"
 	keyUserByToken = "USER:TK"
 	keyPageByID    = "PAGE:ID"
+	keyChampStats  = "CHAMP:STATS"
 )
 
+// attribute of lookup spans recording
+// whether the key was found
+const attrCacheHit = "cache.hit"
+
 // RedisConfig contains configuration
 // values for the Redis Database
 // connection.
@@ -54,81 +64,132 @@ func (c *Redis) SetDatabase(db database.Middleware) {
 	c.db = db
 }
 
-func (c *Redis) GetUserByID(id snowflake.ID) (*objects.User, error) {
+func (c *Redis) GetUserByID(ctx context.Context, id snowflake.ID) (*objects.User, error) {
 	key := fmt.Sprintf("%s:%d", keyUserByID, id)
 
 	var user *objects.User
-	err := c.get(key, user)
+	err := c.get(ctx, key, user)
+	metrics.ObserveCache(metricUsers, err == nil && user != nil)
 	if err != nil || user == nil {
-		user, err = c.db.GetUser(id, "")
+		user, err = c.db.GetUser(ctx, id, "")
 		if err != nil {
 			return nil, err
 		}
-		c.SetUserByID(id, user)
+		c.SetUserByID(ctx, id, user)
 	}
 
 	return user, nil
 }
 
-func (c *Redis) SetUserByID(id snowflake.ID, user *objects.User) error {
+func (c *Redis) SetUserByID(ctx context.Context, id snowflake.ID, user *objects.User) error {
 	key := fmt.Sprintf("%s:%d", keyUserByID, id)
 
 	if user == nil {
-		return c.set(key, nil, expireDef)
+		return c.set(ctx, key, nil, expireDef)
 	}
-	return c.set(key, user, expireDef)
+	return c.set(ctx, key, user, expireDef)
 }
 
-func (c *Redis) GetUserByToken(token string) (*objects.User, bool) {
+func (c *Redis) GetUserByToken(ctx context.Context, token string) (*objects.User, bool) {
 	key := fmt.Sprintf("%s:%s", keyUserByToken, token)
 
 	var user *objects.User
-	err := c.get(key, user)
+	err := c.get(ctx, key, user)
+	metrics.ObserveCache(metricUserTokens, err == nil && user != nil)
 
 	return user, err == nil && user != nil
 }
 
-func (c *Redis) SetUserByToken(token string, user *objects.User) error {
+func (c *Redis) SetUserByToken(ctx context.Context, token string, user *objects.User) error {
 	key := fmt.Sprintf("%s:%s", keyUserByToken, token)
 
 	if user == nil {
-		return c.set(key, nil, expireDef)
+		return c.set(ctx, key, nil, expireDef)
 	}
-	return c.set(key, user, expireDef)
+	return c.set(ctx, key, user, expireDef)
 }
 
-func (c *Redis) GetPageByID(id snowflake.ID) (*objects.Page, error) {
+func (c *Redis) GetPageByID(ctx context.Context, id snowflake.ID) (*objects.Page, error) {
 	key := fmt.Sprintf("%s:%d", keyPageByID, id)
 
 	var page *objects.Page
-	err := c.get(key, page)
+	err := c.get(ctx, key, page)
+	metrics.ObserveCache(metricPages, err == nil && page != nil)
 	if err != nil || page == nil {
-		page, err = c.db.GetPage(id)
+		page, err = c.db.GetPage(ctx, id)
 		if err != nil {
 			return nil, err
 		}
-		c.SetPageByID(id, page)
+		c.SetPageByID(ctx, id, page)
 	}
 
 	return page, nil
 }
 
-func (c *Redis) SetPageByID(id snowflake.ID, page *objects.Page) error {
+func (c *Redis) SetPageByID(ctx context.Context, id snowflake.ID, page *objects.Page) error {
 	key := fmt.Sprintf("%s:%d", keyPageByID, id)
 
 	if page == nil {
-		return c.set(key, nil, expireDef)
+		return c.set(ctx, key, nil, expireDef)
+	}
+	return c.set(ctx, key, page, expireDef)
+}
+
+func (c *Redis) GetChampionStats(ctx context.Context, champion string) (*objects.ChampionStats, error) {
+	key := fmt.Sprintf("%s:%s", keyChampStats, champion)
+
+	stats := new(objects.ChampionStats)
+	err := c.get(ctx, key, stats)
+	metrics.ObserveCache(metricChampionStats, err == nil)
+	if err != nil {
+		stats, err = c.db.GetChampionStats(ctx, champion)
+		if err != nil {
+			return nil, err
+		}
+		c.SetChampionStats(ctx, champion, stats)
+	}
+
+	return stats, nil
+}
+
+func (c *Redis) SetChampionStats(ctx context.Context, champion string, stats *objects.ChampionStats) error {
+	key := fmt.Sprintf("%s:%s", keyChampStats, champion)
+
+	if stats == nil {
+		return c.set(ctx, key, nil, expireDef)
 	}
-	return c.set(key, page, expireDef)
+	return c.set(ctx, key, stats, expireDef)
+}
+
+func (c *Redis) Close() error {
+	return c.client.Close()
+}
+
+// Ping checks if the Redis server
+// is reachable.
+func (c *Redis) Ping(ctx context.Context) error {
+	return c.client.WithContext(ctx).Ping().Err()
 }
 
 // set sets a value in the database to the given key with the
 // defined expiration duration.
 // The value v must be a reference to a JSON serializable
 // object instance.
-func (c *Redis) set(key string, v interface{}, expiration time.Duration) error {
+func (c *Redis) set(ctx context.Context, key string, v interface{}, expiration time.Duration) (err error) {
+	op := "SET"
 	if v == nil {
-		return c.client.Del(key).Err()
+		op = "DEL"
+	}
+
+	ctx, span := startSpan(ctx, op)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	client := c.client.WithContext(ctx)
+	if v == nil {
+		return client.Del(key).Err()
 	}
 
 	d, err := json.Marshal(v)
@@ -136,18 +197,37 @@ func (c *Redis) set(key string, v interface{}, expiration time.Duration) error {
 		return err
 	}
 
-	return c.client.Set(key, d, expiration).Err()
+	return client.Set(key, d, expiration).Err()
 }
 
 // get fetches a value from the database by key and writes
 // the result to v.
 // The value v must be a reference to a JSON serializable
 // object instance.
-func (c *Redis) get(key string, v interface{}) error {
-	b, err := c.client.Get(key).Bytes()
+func (c *Redis) get(ctx context.Context, key string, v interface{}) error {
+	ctx, span := startSpan(ctx, "GET")
+	defer span.End()
+
+	b, err := c.client.WithContext(ctx).Get(key).Bytes()
+	if err == redis.Nil {
+		span.SetAttributes(attribute.Bool(attrCacheHit, false))
+		return err
+	}
 	if err != nil {
+		tracing.RecordError(span, err)
 		return err
 	}
+	span.SetAttributes(attribute.Bool(attrCacheHit, true))
 
 	return json.Unmarshal(b, v)
 }
+
+// startSpan starts the client span of
+// a call of the passed Redis command.
+func startSpan(ctx context.Context, command string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "redis."+command,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", command)))
+}
@@ -0,0 +1,74 @@
+// Package communitystats aggregates anonymized
+// rune statistics per champion from the rune
+// pages stored in the database.
+package communitystats
+
+import (
+	"context"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/myrunes/backend/internal/caching"
+	"github.com/myrunes/backend/internal/database"
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/pkg/ddragon"
+)
+
+// minContributors is the minimum number of distinct
+// page owners required for a champion or an entry
+// to be listed in the community stats.
+const minContributors = 5
+
+// Aggregate computes the ChampionStats of all champions
+// from the pages in the database and replaces the stored
+// stats in the database and the cache by them.
+//
+// Pages of users who opted out of the community stats,
+// as well as pages owned by teams, are only counted if
+// they are published.
+//
+// The number of champions with stats is returned.
+func Aggregate(ctx context.Context, db database.Middleware, cache caching.CacheMiddleware) (int, error) {
+	agg := objects.NewChampionStatsAggregator(minContributors)
+	excluded := make(map[snowflake.ID]bool)
+
+	err := db.IteratePages(ctx, func(page *objects.Page) error {
+		if !page.Published {
+			isExcluded, ok := excluded[page.Owner]
+			if !ok {
+				owner, err := db.GetUser(ctx, page.Owner, "")
+				if err != nil {
+					return err
+				}
+				isExcluded = owner == nil || owner.IsExcludedFromStats()
+				excluded[page.Owner] = isExcluded
+			}
+
+			if isExcluded {
+				return nil
+			}
+		}
+
+		agg.Add(page)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	stats := agg.Results()
+	if err = db.SetChampionStats(ctx, stats); err != nil {
+		return 0, err
+	}
+
+	byChamp := make(map[string]*objects.ChampionStats)
+	for _, s := range stats {
+		byChamp[s.Champion] = s
+	}
+
+	for _, c := range ddragon.DDragonInstance.Champions {
+		cache.SetChampionStats(ctx, c.UID, byChamp[c.UID])
+	}
+
+	return len(stats), nil
+}
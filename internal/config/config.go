@@ -8,8 +8,11 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/myrunes/backend/internal/caching"
 	"github.com/myrunes/backend/internal/database"
+	"github.com/myrunes/backend/internal/logger"
 	"github.com/myrunes/backend/internal/mailserver"
+	"github.com/myrunes/backend/internal/metrics"
 	"github.com/myrunes/backend/internal/storage"
+	"github.com/myrunes/backend/internal/tracing"
 	"github.com/myrunes/backend/internal/webserver"
 )
 
@@ -19,6 +22,9 @@ type Main struct {
 	Redis      *caching.RedisConfig  `json:"redis"`
 	WebServer  *webserver.Config     `json:"webserver"`
 	MailServer *mailserver.Config    `json:"mailserver"`
+	Metrics    *metrics.Config       `json:"metrics"`
+	Logging    *logger.Config        `json:"logging"`
+	Tracing    *tracing.Config       `json:"tracing"`
 
 	Storage struct {
 		Typ   string               `json:"type"`
@@ -75,6 +81,14 @@ func createDefault(loc string) error {
 		MailServer: &mailserver.Config{
 			Port: 465,
 		},
+		Metrics: &metrics.Config{},
+		Logging: &logger.Config{
+			Level:  "info",
+			Format: logger.FormatText,
+		},
+		Tracing: &tracing.Config{
+			SampleRatio: 1,
+		},
 	}
 
 	data, err := yaml.Marshal(def)
@@ -0,0 +1,342 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/myrunes/backend/internal/metrics"
+	"github.com/myrunes/backend/internal/objects"
+)
+
+// Metrics wraps a database Middleware and
+// records the duration of each database call
+// by method in the database call duration
+// metric.
+type Metrics struct {
+	Middleware
+}
+
+// NewMetrics creates a new instance of
+// Metrics wrapping the passed database
+// Middleware.
+func NewMetrics(db Middleware) *Metrics {
+	return &Metrics{db}
+}
+
+// observe records the duration of the call
+// of the passed method started at the
+// passed time.
+func (m *Metrics) observe(method string, start time.Time) {
+	metrics.DatabaseCallDuration.WithLabelValues(method).Observe(metrics.Since(start))
+}
+
+func (m *Metrics) Ping(ctx context.Context) error {
+	defer m.observe("Ping", time.Now())
+	return m.Middleware.Ping(ctx)
+}
+
+func (m *Metrics) CreateUser(ctx context.Context, user *objects.User) error {
+	defer m.observe("CreateUser", time.Now())
+	return m.Middleware.CreateUser(ctx, user)
+}
+
+func (m *Metrics) GetUser(ctx context.Context, uid snowflake.ID, username string) (*objects.User, error) {
+	defer m.observe("GetUser", time.Now())
+	return m.Middleware.GetUser(ctx, uid, username)
+}
+
+func (m *Metrics) EditUser(ctx context.Context, user *objects.User) error {
+	defer m.observe("EditUser", time.Now())
+	return m.Middleware.EditUser(ctx, user)
+}
+
+func (m *Metrics) DeleteUser(ctx context.Context, uid snowflake.ID) error {
+	defer m.observe("DeleteUser", time.Now())
+	return m.Middleware.DeleteUser(ctx, uid)
+}
+
+func (m *Metrics) CreatePage(ctx context.Context, page *objects.Page) error {
+	defer m.observe("CreatePage", time.Now())
+	return m.Middleware.CreatePage(ctx, page)
+}
+
+func (m *Metrics) GetPages(ctx context.Context, uid snowflake.ID, champion, filter string, sortLess func(i, j *objects.Page) bool) ([]*objects.Page, error) {
+	defer m.observe("GetPages", time.Now())
+	return m.Middleware.GetPages(ctx, uid, champion, filter, sortLess)
+}
+
+func (m *Metrics) GetPage(ctx context.Context, uid snowflake.ID) (*objects.Page, error) {
+	defer m.observe("GetPage", time.Now())
+	return m.Middleware.GetPage(ctx, uid)
+}
+
+func (m *Metrics) EditPage(ctx context.Context, page *objects.Page) error {
+	defer m.observe("EditPage", time.Now())
+	return m.Middleware.EditPage(ctx, page)
+}
+
+func (m *Metrics) EditPageIfUnmodified(ctx context.Context, page *objects.Page, edited time.Time) (bool, error) {
+	defer m.observe("EditPageIfUnmodified", time.Now())
+	return m.Middleware.EditPageIfUnmodified(ctx, page, edited)
+}
+
+func (m *Metrics) DeletePage(ctx context.Context, uid snowflake.ID) error {
+	defer m.observe("DeletePage", time.Now())
+	return m.Middleware.DeletePage(ctx, uid)
+}
+
+func (m *Metrics) DeleteUserPages(ctx context.Context, uid snowflake.ID) error {
+	defer m.observe("DeleteUserPages", time.Now())
+	return m.Middleware.DeleteUserPages(ctx, uid)
+}
+
+func (m *Metrics) IteratePages(ctx context.Context, f func(page *objects.Page) error) error {
+	defer m.observe("IteratePages", time.Now())
+	return m.Middleware.IteratePages(ctx, f)
+}
+
+func (m *Metrics) GetPagesEditedSince(ctx context.Context, owner snowflake.ID, since time.Time) ([]*objects.Page, error) {
+	defer m.observe("GetPagesEditedSince", time.Now())
+	return m.Middleware.GetPagesEditedSince(ctx, owner, since)
+}
+
+func (m *Metrics) AddPageTombstone(ctx context.Context, tombstone *objects.PageTombstone) error {
+	defer m.observe("AddPageTombstone", time.Now())
+	return m.Middleware.AddPageTombstone(ctx, tombstone)
+}
+
+func (m *Metrics) GetPageTombstones(ctx context.Context, owner snowflake.ID, since time.Time) ([]*objects.PageTombstone, error) {
+	defer m.observe("GetPageTombstones", time.Now())
+	return m.Middleware.GetPageTombstones(ctx, owner, since)
+}
+
+func (m *Metrics) CleanupPageTombstones(ctx context.Context, before time.Time) (int, error) {
+	defer m.observe("CleanupPageTombstones", time.Now())
+	return m.Middleware.CleanupPageTombstones(ctx, before)
+}
+
+func (m *Metrics) SetChampionStats(ctx context.Context, stats []*objects.ChampionStats) error {
+	defer m.observe("SetChampionStats", time.Now())
+	return m.Middleware.SetChampionStats(ctx, stats)
+}
+
+func (m *Metrics) GetChampionStats(ctx context.Context, champion string) (*objects.ChampionStats, error) {
+	defer m.observe("GetChampionStats", time.Now())
+	return m.Middleware.GetChampionStats(ctx, champion)
+}
+
+func (m *Metrics) GetRefreshToken(ctx context.Context, token string) (*objects.RefreshToken, error) {
+	defer m.observe("GetRefreshToken", time.Now())
+	return m.Middleware.GetRefreshToken(ctx, token)
+}
+
+func (m *Metrics) GetRefreshTokens(ctx context.Context, userID snowflake.ID) ([]*objects.RefreshToken, error) {
+	defer m.observe("GetRefreshTokens", time.Now())
+	return m.Middleware.GetRefreshTokens(ctx, userID)
+}
+
+func (m *Metrics) SetRefreshToken(ctx context.Context, t *objects.RefreshToken) error {
+	defer m.observe("SetRefreshToken", time.Now())
+	return m.Middleware.SetRefreshToken(ctx, t)
+}
+
+func (m *Metrics) RemoveRefreshToken(ctx context.Context, id snowflake.ID) error {
+	defer m.observe("RemoveRefreshToken", time.Now())
+	return m.Middleware.RemoveRefreshToken(ctx, id)
+}
+
+func (m *Metrics) CleanupExpiredTokens(ctx context.Context) (int, error) {
+	defer m.observe("CleanupExpiredTokens", time.Now())
+	return m.Middleware.CleanupExpiredTokens(ctx)
+}
+
+func (m *Metrics) CountRefreshTokens(ctx context.Context) (int, error) {
+	defer m.observe("CountRefreshTokens", time.Now())
+	return m.Middleware.CountRefreshTokens(ctx)
+}
+
+func (m *Metrics) SetAPIToken(ctx context.Context, token *objects.APIToken) error {
+	defer m.observe("SetAPIToken", time.Now())
+	return m.Middleware.SetAPIToken(ctx, token)
+}
+
+func (m *Metrics) GetAPIToken(ctx context.Context, uid snowflake.ID) (*objects.APIToken, error) {
+	defer m.observe("GetAPIToken", time.Now())
+	return m.Middleware.GetAPIToken(ctx, uid)
+}
+
+func (m *Metrics) ResetAPIToken(ctx context.Context, uid snowflake.ID) error {
+	defer m.observe("ResetAPIToken", time.Now())
+	return m.Middleware.ResetAPIToken(ctx, uid)
+}
+
+func (m *Metrics) VerifyAPIToken(ctx context.Context, tokenStr string) (*objects.User, error) {
+	defer m.observe("VerifyAPIToken", time.Now())
+	return m.Middleware.VerifyAPIToken(ctx, tokenStr)
+}
+
+func (m *Metrics) SetShare(ctx context.Context, share *objects.SharePage) error {
+	defer m.observe("SetShare", time.Now())
+	return m.Middleware.SetShare(ctx, share)
+}
+
+func (m *Metrics) GetShare(ctx context.Context, ident string, uid, pageID snowflake.ID) (*objects.SharePage, error) {
+	defer m.observe("GetShare", time.Now())
+	return m.Middleware.GetShare(ctx, ident, uid, pageID)
+}
+
+func (m *Metrics) DeleteShare(ctx context.Context, ident string, uid, pageID snowflake.ID) error {
+	defer m.observe("DeleteShare", time.Now())
+	return m.Middleware.DeleteShare(ctx, ident, uid, pageID)
+}
+
+func (m *Metrics) DeleteUserShares(ctx context.Context, owner snowflake.ID) error {
+	defer m.observe("DeleteUserShares", time.Now())
+	return m.Middleware.DeleteUserShares(ctx, owner)
+}
+
+func (m *Metrics) IncrementShareAccess(ctx context.Context, uid snowflake.ID, lastAccess time.Time) (*objects.SharePage, error) {
+	defer m.observe("IncrementShareAccess", time.Now())
+	return m.Middleware.IncrementShareAccess(ctx, uid, lastAccess)
+}
+
+func (m *Metrics) SetShareLastAccess(ctx context.Context, uid snowflake.ID, lastAccess time.Time) error {
+	defer m.observe("SetShareLastAccess", time.Now())
+	return m.Middleware.SetShareLastAccess(ctx, uid, lastAccess)
+}
+
+func (m *Metrics) IncrementShareForks(ctx context.Context, uid snowflake.ID) error {
+	defer m.observe("IncrementShareForks", time.Now())
+	return m.Middleware.IncrementShareForks(ctx, uid)
+}
+
+func (m *Metrics) AddShareAccess(ctx context.Context, access *objects.ShareAccess) error {
+	defer m.observe("AddShareAccess", time.Now())
+	return m.Middleware.AddShareAccess(ctx, access)
+}
+
+func (m *Metrics) HasShareAccess(ctx context.Context, shareID snowflake.ID, ipHash string) (bool, error) {
+	defer m.observe("HasShareAccess", time.Now())
+	return m.Middleware.HasShareAccess(ctx, shareID, ipHash)
+}
+
+func (m *Metrics) GetShareAccesses(ctx context.Context, shareID snowflake.ID, since time.Time) ([]*objects.ShareAccess, error) {
+	defer m.observe("GetShareAccesses", time.Now())
+	return m.Middleware.GetShareAccesses(ctx, shareID, since)
+}
+
+func (m *Metrics) DeleteShareAccesses(ctx context.Context, shareID snowflake.ID) error {
+	defer m.observe("DeleteShareAccesses", time.Now())
+	return m.Middleware.DeleteShareAccesses(ctx, shareID)
+}
+
+func (m *Metrics) CleanupShareAccesses(ctx context.Context, before time.Time) (int, error) {
+	defer m.observe("CleanupShareAccesses", time.Now())
+	return m.Middleware.CleanupShareAccesses(ctx, before)
+}
+
+func (m *Metrics) SetTeam(ctx context.Context, team *objects.Team) error {
+	defer m.observe("SetTeam", time.Now())
+	return m.Middleware.SetTeam(ctx, team)
+}
+
+func (m *Metrics) GetTeam(ctx context.Context, uid snowflake.ID) (*objects.Team, error) {
+	defer m.observe("GetTeam", time.Now())
+	return m.Middleware.GetTeam(ctx, uid)
+}
+
+func (m *Metrics) DeleteTeam(ctx context.Context, uid snowflake.ID) error {
+	defer m.observe("DeleteTeam", time.Now())
+	return m.Middleware.DeleteTeam(ctx, uid)
+}
+
+func (m *Metrics) SetTeamMember(ctx context.Context, member *objects.TeamMember) error {
+	defer m.observe("SetTeamMember", time.Now())
+	return m.Middleware.SetTeamMember(ctx, member)
+}
+
+func (m *Metrics) GetTeamMember(ctx context.Context, teamID, userID snowflake.ID) (*objects.TeamMember, error) {
+	defer m.observe("GetTeamMember", time.Now())
+	return m.Middleware.GetTeamMember(ctx, teamID, userID)
+}
+
+func (m *Metrics) GetTeamMembers(ctx context.Context, teamID snowflake.ID) ([]*objects.TeamMember, error) {
+	defer m.observe("GetTeamMembers", time.Now())
+	return m.Middleware.GetTeamMembers(ctx, teamID)
+}
+
+func (m *Metrics) GetUserTeamMembers(ctx context.Context, userID snowflake.ID) ([]*objects.TeamMember, error) {
+	defer m.observe("GetUserTeamMembers", time.Now())
+	return m.Middleware.GetUserTeamMembers(ctx, userID)
+}
+
+func (m *Metrics) DeleteTeamMember(ctx context.Context, teamID, userID snowflake.ID) error {
+	defer m.observe("DeleteTeamMember", time.Now())
+	return m.Middleware.DeleteTeamMember(ctx, teamID, userID)
+}
+
+func (m *Metrics) SetFolder(ctx context.Context, folder *objects.Folder) error {
+	defer m.observe("SetFolder", time.Now())
+	return m.Middleware.SetFolder(ctx, folder)
+}
+
+func (m *Metrics) GetFolder(ctx context.Context, uid snowflake.ID) (*objects.Folder, error) {
+	defer m.observe("GetFolder", time.Now())
+	return m.Middleware.GetFolder(ctx, uid)
+}
+
+func (m *Metrics) GetFolders(ctx context.Context, owner snowflake.ID) ([]*objects.Folder, error) {
+	defer m.observe("GetFolders", time.Now())
+	return m.Middleware.GetFolders(ctx, owner)
+}
+
+func (m *Metrics) DeleteFolder(ctx context.Context, uid snowflake.ID) error {
+	defer m.observe("DeleteFolder", time.Now())
+	return m.Middleware.DeleteFolder(ctx, uid)
+}
+
+func (m *Metrics) DeleteUserFolders(ctx context.Context, owner snowflake.ID) error {
+	defer m.observe("DeleteUserFolders", time.Now())
+	return m.Middleware.DeleteUserFolders(ctx, owner)
+}
+
+func (m *Metrics) SetWebhook(ctx context.Context, webhook *objects.Webhook) error {
+	defer m.observe("SetWebhook", time.Now())
+	return m.Middleware.SetWebhook(ctx, webhook)
+}
+
+func (m *Metrics) GetWebhook(ctx context.Context, uid snowflake.ID) (*objects.Webhook, error) {
+	defer m.observe("GetWebhook", time.Now())
+	return m.Middleware.GetWebhook(ctx, uid)
+}
+
+func (m *Metrics) GetWebhooks(ctx context.Context, owner snowflake.ID) ([]*objects.Webhook, error) {
+	defer m.observe("GetWebhooks", time.Now())
+	return m.Middleware.GetWebhooks(ctx, owner)
+}
+
+func (m *Metrics) DeleteWebhook(ctx context.Context, uid snowflake.ID) error {
+	defer m.observe("DeleteWebhook", time.Now())
+	return m.Middleware.DeleteWebhook(ctx, uid)
+}
+
+func (m *Metrics) DeleteUserWebhooks(ctx context.Context, owner snowflake.ID) error {
+	defer m.observe("DeleteUserWebhooks", time.Now())
+	return m.Middleware.DeleteUserWebhooks(ctx, owner)
+}
+
+func (m *Metrics) SetWebhookDelivery(ctx context.Context, delivery *objects.WebhookDelivery) error {
+	defer m.observe("SetWebhookDelivery", time.Now())
+	return m.Middleware.SetWebhookDelivery(ctx, delivery)
+}
+
+func (m *Metrics) GetWebhookDeliveries(ctx context.Context, webhookID snowflake.ID, limit int) ([]*objects.WebhookDelivery, error) {
+	defer m.observe("GetWebhookDeliveries", time.Now())
+	return m.Middleware.GetWebhookDeliveries(ctx, webhookID, limit)
+}
+
+func (m *Metrics) CleanupWebhookDeliveries(ctx context.Context, before time.Time) (int, error) {
+	defer m.observe("CleanupWebhookDeliveries", time.Now())
+	return m.Middleware.CleanupWebhookDeliveries(ctx, before)
+}
@@ -1,6 +1,9 @@
 package database
 
 import (
+	"context"
+	"time"
+
 	"github.com/bwmarrin/snowflake"
 	"github.com/myrunes/backend/internal/objects"
 )
@@ -18,35 +21,42 @@ import (
 // object was fetched, only return nil
 // (or the default value for the type)
 // for both the value and the error.
+//
+// All methods accessing the database get passed
+// the context of the operation, which carries
+// its deadline and tracing span.
 type Middleware interface {
 	// Connect  to the database using the
 	// defined parameters.
 	Connect(params interface{}) error
 	// Close the connection to the database.
 	Close()
+	// Ping checks if the database is
+	// reachable.
+	Ping(ctx context.Context) error
 
 	// CreateUser creates a new user object
 	// in the database from the given user
 	// object.
-	CreateUser(user *objects.User) error
+	CreateUser(ctx context.Context, user *objects.User) error
 	// GetUser returns a user object by the
 	// passed uid or username or e-mail, which
 	// is passed as username parameter.
 	// Therefore, the priority of matching is:
 	// 1. UID, 2. username, 3. e-mail
-	GetUser(uid snowflake.ID, username string) (*objects.User, error)
+	GetUser(ctx context.Context, uid snowflake.ID, username string) (*objects.User, error)
 	// EditUser updates a user object in the
 	// database to the object passed by its
 	// UID.
-	EditUser(user *objects.User) error
+	EditUser(ctx context.Context, user *objects.User) error
 	// DeleteUser removes a user from the database
 	// or marks it as removed so that the object
 	// can not be fetched anymore.
-	DeleteUser(uid snowflake.ID) error
+	DeleteUser(ctx context.Context, uid snowflake.ID) error
 
 	// CreatePage creates a page object in the
 	// database from the passed page object.
-	CreatePage(page *objects.Page) error
+	CreatePage(ctx context.Context, page *objects.Page) error
 	// GetPages returns a collection of pages
 	// owned by the given users uid.
 	// If champion is not empty or "general",
@@ -62,6 +72,7 @@ type Middleware interface {
 	// collection must be lesss-sorted by
 	// the given sortLess function.
 	GetPages(
+		ctx context.Context,
 		uid snowflake.ID,
 		champion,
 		filter string,
@@ -69,65 +80,217 @@ type Middleware interface {
 	) ([]*objects.Page, error)
 	// GetPage returns a page object by the
 	// given pages uid.
-	GetPage(uid snowflake.ID) (*objects.Page, error)
+	GetPage(ctx context.Context, uid snowflake.ID) (*objects.Page, error)
 	// EditPage replaces the page object in
 	// the database by the passed page
 	// object by its UID.
-	EditPage(page *objects.Page) error
+	EditPage(ctx context.Context, page *objects.Page) error
+	// EditPageIfUnmodified replaces the page object
+	// in the database by the passed page object by
+	// its UID, but only if the stored page was last
+	// edited at the passed time. The returned bool
+	// is false if the stored page was modified in
+	// the meantime or does not exist.
+	EditPageIfUnmodified(ctx context.Context, page *objects.Page, edited time.Time) (bool, error)
 	// DeletePage removes a page object from
 	// the database or marks it as removed
 	// so it's not accessable anymore.
-	DeletePage(uid snowflake.ID) error
+	DeletePage(ctx context.Context, uid snowflake.ID) error
 	// DeleteUserPages deletes all pages
 	// of the users UID passed.
-	DeleteUserPages(uid snowflake.ID) error
+	DeleteUserPages(ctx context.Context, uid snowflake.ID) error
+	// IteratePages calls the passed function
+	// for each page in the database. If the
+	// function returns an error, the iteration
+	// is aborted and the error is returned.
+	IteratePages(ctx context.Context, f func(page *objects.Page) error) error
+	// GetPagesEditedSince returns all pages of the
+	// passed owner which were created or edited
+	// after the passed time.
+	GetPagesEditedSince(ctx context.Context, owner snowflake.ID, since time.Time) ([]*objects.Page, error)
+
+	// AddPageTombstone stores the passed page
+	// tombstone in the database.
+	AddPageTombstone(ctx context.Context, tombstone *objects.PageTombstone) error
+	// GetPageTombstones returns all tombstones of
+	// pages of the passed owner which were deleted
+	// after the passed time.
+	GetPageTombstones(ctx context.Context, owner snowflake.ID, since time.Time) ([]*objects.PageTombstone, error)
+	// CleanupPageTombstones removes all tombstones
+	// of pages deleted before the passed time from
+	// the database.
+	CleanupPageTombstones(ctx context.Context, before time.Time) (int, error)
+
+	// SetChampionStats replaces all stored
+	// champion stats by the passed ones.
+	SetChampionStats(ctx context.Context, stats []*objects.ChampionStats) error
+	// GetChampionStats returns the stored
+	// stats of the passed champion.
+	GetChampionStats(ctx context.Context, champion string) (*objects.ChampionStats, error)
 
 	// GetRefreshToken returns a refresh token object
 	// from the database matching the given refresh
 	// token string.
-	GetRefreshToken(token string) (*objects.RefreshToken, error)
+	GetRefreshToken(ctx context.Context, token string) (*objects.RefreshToken, error)
 	// GetRefreshTokens returns a list of refresh tokens
 	// belonging to the given userID.
-	GetRefreshTokens(userID snowflake.ID) ([]*objects.RefreshToken, error)
+	GetRefreshTokens(ctx context.Context, userID snowflake.ID) ([]*objects.RefreshToken, error)
 	// SetRefreshToken sets a given refresh token
 	// object to the database or updates one.
-	SetRefreshToken(t *objects.RefreshToken) error
+	SetRefreshToken(ctx context.Context, t *objects.RefreshToken) error
 	// RemoveRefreshToken removes a refresh token from
 	// database if existent by the given token.
-	RemoveRefreshToken(id snowflake.ID) error
+	RemoveRefreshToken(ctx context.Context, id snowflake.ID) error
 	// CleanupExpiredTokens removes all expired tokens
 	// from the database.
-	CleanupExpiredTokens() (int, error)
+	CleanupExpiredTokens(ctx context.Context) (int, error)
+	// CountRefreshTokens returns the number of
+	// refresh tokens which are not expired.
+	CountRefreshTokens(ctx context.Context) (int, error)
 
 	// SetAPIToken sets the passed API token
 	// to the user defined in the APIToken
 	// object.
-	SetAPIToken(token *objects.APIToken) error
+	SetAPIToken(ctx context.Context, token *objects.APIToken) error
 	// GetAPIToken returns the APIToken object,
 	// if available, of the passed users uid.
-	GetAPIToken(uid snowflake.ID) (*objects.APIToken, error)
+	GetAPIToken(ctx context.Context, uid snowflake.ID) (*objects.APIToken, error)
 	// ResetAPIToken deletes the APIToken
 	// object of the passed users uid so
 	// that it is no more accessable.
-	ResetAPIToken(uid snowflake.ID) error
+	ResetAPIToken(ctx context.Context, uid snowflake.ID) error
 	// VerifyAPIToken returns a User object
 	// which the passed API token string
 	// belongs to.
-	VerifyAPIToken(tokenStr string) (*objects.User, error)
+	VerifyAPIToken(ctx context.Context, tokenStr string) (*objects.User, error)
 
 	// SetShare creates a nnew share entry
 	// in the database from the passed SharePage
-	// object.
-	SetShare(share *objects.SharePage) error
+	// object. A share of a single page replaces
+	// an existing share of the same page.
+	SetShare(ctx context.Context, share *objects.SharePage) error
 	// GetShare returns the SharePage object by
 	// the shares ident, uid or pageID of the
 	// RunePage the share is assigned to.
 	// (Priority in this order)
-	GetShare(ident string, uid, pageID snowflake.ID) (*objects.SharePage, error)
+	GetShare(ctx context.Context, ident string, uid, pageID snowflake.ID) (*objects.SharePage, error)
 	// DeleteShare removes a SharePage object
 	// from the database or makes it inaccessable
 	// by the shares ident, uid oder the pageID
 	// of the RunePage the share is belonging to.
 	// (Priority in this order)
-	DeleteShare(ident string, uid, pageID snowflake.ID) error
+	DeleteShare(ctx context.Context, ident string, uid, pageID snowflake.ID) error
+	// DeleteUserShares removes all shares of the
+	// passed owner, which is either a user or a
+	// team, and their access events.
+	DeleteUserShares(ctx context.Context, owner snowflake.ID) error
+	// IncrementShareAccess atomically increases the
+	// access count of the share with the given uid by
+	// one and sets its last access time. If the share
+	// has a maximum count of accesses, the count is only
+	// increased if it is below that maximum. The updated
+	// share is returned, or nil if the share does not
+	// exist or its maximum count of accesses is reached.
+	IncrementShareAccess(ctx context.Context, uid snowflake.ID, lastAccess time.Time) (*objects.SharePage, error)
+	// SetShareLastAccess sets the last access time
+	// of the share with the given uid.
+	SetShareLastAccess(ctx context.Context, uid snowflake.ID, lastAccess time.Time) error
+	// IncrementShareForks atomically increases the
+	// fork count of the share with the given uid by one.
+	IncrementShareForks(ctx context.Context, uid snowflake.ID) error
+
+	// AddShareAccess stores the passed share
+	// access event in the database.
+	AddShareAccess(ctx context.Context, access *objects.ShareAccess) error
+	// HasShareAccess returns true if an access
+	// event of the given share with the given
+	// IP hash exists in the database.
+	HasShareAccess(ctx context.Context, shareID snowflake.ID, ipHash string) (bool, error)
+	// GetShareAccesses returns all access events
+	// of the given share which occured after the
+	// passed time.
+	GetShareAccesses(ctx context.Context, shareID snowflake.ID, since time.Time) ([]*objects.ShareAccess, error)
+	// DeleteShareAccesses removes all access
+	// events of the given share from the database.
+	DeleteShareAccesses(ctx context.Context, shareID snowflake.ID) error
+	// CleanupShareAccesses removes all share
+	// access events which occured before the
+	// passed time and returns the number of
+	// removed events.
+	CleanupShareAccesses(ctx context.Context, before time.Time) (int, error)
+
+	// SetTeam creates a new team in the database
+	// from the passed Team object or updates an
+	// existing one by its UID.
+	SetTeam(ctx context.Context, team *objects.Team) error
+	// GetTeam returns a team object by the
+	// passed teams uid.
+	GetTeam(ctx context.Context, uid snowflake.ID) (*objects.Team, error)
+	// DeleteTeam removes a team and all its
+	// memberships from the database.
+	DeleteTeam(ctx context.Context, uid snowflake.ID) error
+
+	// SetTeamMember creates a new team membership
+	// from the passed TeamMember object or updates
+	// an existing one by its team and user ID.
+	SetTeamMember(ctx context.Context, member *objects.TeamMember) error
+	// GetTeamMember returns the membership of the
+	// passed user in the passed team.
+	GetTeamMember(ctx context.Context, teamID, userID snowflake.ID) (*objects.TeamMember, error)
+	// GetTeamMembers returns all memberships,
+	// including pending ones, of the passed team.
+	GetTeamMembers(ctx context.Context, teamID snowflake.ID) ([]*objects.TeamMember, error)
+	// GetUserTeamMembers returns all memberships,
+	// including pending ones, of the passed user.
+	GetUserTeamMembers(ctx context.Context, userID snowflake.ID) ([]*objects.TeamMember, error)
+	// DeleteTeamMember removes the membership of
+	// the passed user in the passed team.
+	DeleteTeamMember(ctx context.Context, teamID, userID snowflake.ID) error
+
+	// SetFolder creates a new folder in the database
+	// from the passed Folder object or updates an
+	// existing one by its UID.
+	SetFolder(ctx context.Context, folder *objects.Folder) error
+	// GetFolder returns a folder object by the
+	// passed folders uid.
+	GetFolder(ctx context.Context, uid snowflake.ID) (*objects.Folder, error)
+	// GetFolders returns all folders of the
+	// passed owner.
+	GetFolders(ctx context.Context, owner snowflake.ID) ([]*objects.Folder, error)
+	// DeleteFolder removes a folder from
+	// the database.
+	DeleteFolder(ctx context.Context, uid snowflake.ID) error
+	// DeleteUserFolders removes all folders
+	// of the passed owner from the database.
+	DeleteUserFolders(ctx context.Context, owner snowflake.ID) error
+
+	// SetWebhook creates a new webhook in the database
+	// from the passed Webhook object or updates an
+	// existing one by its UID.
+	SetWebhook(ctx context.Context, webhook *objects.Webhook) error
+	// GetWebhook returns a webhook object by
+	// the passed webhooks uid.
+	GetWebhook(ctx context.Context, uid snowflake.ID) (*objects.Webhook, error)
+	// GetWebhooks returns all webhooks of
+	// the passed owner.
+	GetWebhooks(ctx context.Context, owner snowflake.ID) ([]*objects.Webhook, error)
+	// DeleteWebhook removes a webhook and its
+	// deliveries from the database.
+	DeleteWebhook(ctx context.Context, uid snowflake.ID) error
+	// DeleteUserWebhooks removes all webhooks of
+	// the passed owner and their deliveries from
+	// the database.
+	DeleteUserWebhooks(ctx context.Context, owner snowflake.ID) error
+	// SetWebhookDelivery creates a new webhook delivery
+	// in the database from the passed WebhookDelivery
+	// object or updates an existing one by its UID.
+	SetWebhookDelivery(ctx context.Context, delivery *objects.WebhookDelivery) error
+	// GetWebhookDeliveries returns the latest deliveries
+	// of the passed webhook, ordered by creation time
+	// descending and limited to the passed number.
+	GetWebhookDeliveries(ctx context.Context, webhookID snowflake.ID, limit int) ([]*objects.WebhookDelivery, error)
+	// CleanupWebhookDeliveries removes all webhook
+	// deliveries created before the passed time and
+	// returns the number of removed deliveries.
+	CleanupWebhookDeliveries(ctx context.Context, before time.Time) (int, error)
 }
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/myrunes/backend/internal/logger"
+)
+
+// mongoMigration describes a change of the stored
+// data which is applied once on connect. Applied
+// migrations are recorded by name in the migrations
+// collection.
+type mongoMigration struct {
+	name  string
+	apply func(ctx context.Context, c *collections) error
+}
+
+// mongoMigrations contains all migrations in the
+// order they are applied.
+var mongoMigrations = []*mongoMigration{
+	{"shares-total-max-accesses", migrateShareMaxAccesses},
+}
+
+// appliedMigration is the record of an
+// applied migration.
+type appliedMigration struct {
+	Name    string    `bson:"name"`
+	Applied time.Time `bson:"applied"`
+}
+
+// ensureIndexes creates the indexes of the
+// collections, if they do not exist.
+func (m *MongoDB) ensureIndexes(ctx context.Context) error {
+	_, err := m.collections.shareaccesses.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "shareid", Value: 1}, {Key: "iphash", Value: 1}}},
+		{Keys: bson.D{{Key: "timestamp", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Champion stats are upserted by champion.
+	_, err = m.collections.championstats.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "champion", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = m.collections.migrations.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	return err
+}
+
+// migrate applies all migrations which
+// were not applied before.
+func (m *MongoDB) migrate(ctx context.Context) error {
+	for _, mig := range mongoMigrations {
+		n, err := m.count(ctx, m.collections.migrations, bson.M{"name": mig.name})
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+
+		logger.Info("DATABASE :: applying migration '%s'", mig.name)
+		if err = mig.apply(ctx, m.collections); err != nil {
+			return err
+		}
+
+		err = m.insert(ctx, m.collections.migrations, &appliedMigration{mig.name, time.Now()})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateShareMaxAccesses converts the maximum
+// access counts of shares from the remaining number
+// of accesses, which was decremented on each access,
+// to the total number of accesses, which is compared
+// against the access count. The list of accessing
+// IP addresses, which is replaced by the share
+// access events, is removed.
+//
+// The list of IP addresses, which was stored with
+// every share in the old format, marks shares which
+// are not migrated yet. It is removed in the same
+// update which converts the maximum access count, so
+// that the migration can be applied again after it
+// was interrupted without converting shares twice.
+func migrateShareMaxAccesses(ctx context.Context, c *collections) error {
+	cursor, err := c.shares.Find(ctx, bson.M{
+		"accessips":   bson.M{"$exists": true},
+		"maxaccesses": bson.M{"$gt": 0},
+		"accesses":    bson.M{"$gt": 0},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var share struct {
+			UID      snowflake.ID `bson:"uid"`
+			Accesses int          `bson:"accesses"`
+		}
+		if err = cursor.Decode(&share); err != nil {
+			return err
+		}
+
+		_, err = c.shares.UpdateOne(ctx,
+			bson.M{"uid": share.UID, "accessips": bson.M{"$exists": true}},
+			bson.M{
+				"$inc":   bson.M{"maxaccesses": share.Accesses},
+				"$unset": bson.M{"accessips": ""},
+			})
+		if err != nil {
+			return err
+		}
+	}
+	if err = cursor.Err(); err != nil {
+		return err
+	}
+
+	_, err = c.shares.UpdateMany(ctx,
+		bson.M{"accessips": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"accessips": ""}})
+
+	return err
+}
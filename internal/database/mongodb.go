@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"sort"
 	"time"
 
@@ -37,7 +38,16 @@ type collections struct {
 	pages,
 	apitokens,
 	refreshtokens,
-	shares *mongo.Collection
+	shares,
+	shareaccesses,
+	teams,
+	teammembers,
+	folders,
+	pagetombstones,
+	webhooks,
+	webhookdeliveries,
+	championstats,
+	migrations *mongo.Collection
 }
 
 func (m *MongoDB) Connect(params interface{}) (err error) {
@@ -52,14 +62,14 @@ func (m *MongoDB) Connect(params interface{}) (err error) {
 		return
 	}
 
-	ctxConnect, cancelConnect := ctxTimeout(5 * time.Second)
+	ctxConnect, cancelConnect := ctxTimeout(context.Background(), 5*time.Second)
 	defer cancelConnect()
 
 	if err = m.client.Connect(ctxConnect); err != nil {
 		return
 	}
 
-	ctxPing, cancelPing := ctxTimeout(5 * time.Second)
+	ctxPing, cancelPing := ctxTimeout(context.Background(), 5*time.Second)
 	defer cancelPing()
 
 	if err = m.client.Ping(ctxPing, readpref.Primary()); err != nil {
@@ -69,31 +79,56 @@ func (m *MongoDB) Connect(params interface{}) (err error) {
 	m.db = m.client.Database(cfg.DataDB)
 
 	m.collections = &collections{
-		users:         m.db.Collection("users"),
-		pages:         m.db.Collection("pages"),
-		shares:        m.db.Collection("shares"),
-		apitokens:     m.db.Collection("apitokens"),
-		refreshtokens: m.db.Collection("refreshtokens"),
+		users:             m.db.Collection("users"),
+		pages:             m.db.Collection("pages"),
+		shares:            m.db.Collection("shares"),
+		shareaccesses:     m.db.Collection("shareaccesses"),
+		teams:             m.db.Collection("teams"),
+		teammembers:       m.db.Collection("teammembers"),
+		folders:           m.db.Collection("folders"),
+		pagetombstones:    m.db.Collection("pagetombstones"),
+		webhooks:          m.db.Collection("webhooks"),
+		webhookdeliveries: m.db.Collection("webhookdeliveries"),
+		championstats:     m.db.Collection("championstats"),
+		apitokens:         m.db.Collection("apitokens"),
+		refreshtokens:     m.db.Collection("refreshtokens"),
+		migrations:        m.db.Collection("migrations"),
 	}
 
-	return err
+	ctxSetup, cancelSetup := ctxTimeout(context.Background(), 30*time.Second)
+	defer cancelSetup()
+
+	if err = m.ensureIndexes(ctxSetup); err != nil {
+		return
+	}
+
+	err = m.migrate(ctxSetup)
+
+	return
 }
 
 func (m *MongoDB) Close() {
-	ctx, cancel := ctxTimeout(5 * time.Second)
+	ctx, cancel := ctxTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	m.client.Disconnect(ctx)
 }
 
-func (m *MongoDB) CreateUser(user *objects.User) error {
-	return m.insert(m.collections.users, user)
+func (m *MongoDB) Ping(ctx context.Context) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return m.client.Ping(ctx, readpref.Primary())
+}
+
+func (m *MongoDB) CreateUser(ctx context.Context, user *objects.User) error {
+	return m.insert(ctx, m.collections.users, user)
 }
 
-func (m *MongoDB) GetUser(uid snowflake.ID, username string) (*objects.User, error) {
+func (m *MongoDB) GetUser(ctx context.Context, uid snowflake.ID, username string) (*objects.User, error) {
 	user := new(objects.User)
 
-	ok, err := m.get(m.collections.users, bson.M{"$or": bson.A{
+	ok, err := m.get(ctx, m.collections.users, bson.M{"$or": bson.A{
 		bson.M{"username": equalsAndNotEmpty(username)},
 		bson.M{"mailaddress": equalsAndNotEmpty(username)},
 		bson.M{"uid": uid},
@@ -106,13 +141,13 @@ func (m *MongoDB) GetUser(uid snowflake.ID, username string) (*objects.User, err
 	return user, err
 }
 
-func (m *MongoDB) EditUser(user *objects.User) error {
-	return m.insertOrUpdate(m.collections.users,
+func (m *MongoDB) EditUser(ctx context.Context, user *objects.User) error {
+	return m.insertOrUpdate(ctx, m.collections.users,
 		bson.M{"uid": user.UID}, user)
 }
 
-func (m *MongoDB) DeleteUser(uid snowflake.ID) error {
-	ctxDelOne, cancelDelOne := ctxTimeout(5 * time.Second)
+func (m *MongoDB) DeleteUser(ctx context.Context, uid snowflake.ID) error {
+	ctxDelOne, cancelDelOne := ctxTimeout(ctx, 5*time.Second)
 	defer cancelDelOne()
 
 	_, err := m.collections.users.DeleteOne(ctxDelOne, bson.M{"uid": uid})
@@ -120,11 +155,11 @@ func (m *MongoDB) DeleteUser(uid snowflake.ID) error {
 	return err
 }
 
-func (m *MongoDB) CreatePage(page *objects.Page) error {
-	return m.insert(m.collections.pages, page)
+func (m *MongoDB) CreatePage(ctx context.Context, page *objects.Page) error {
+	return m.insert(ctx, m.collections.pages, page)
 }
 
-func (m *MongoDB) GetPages(uid snowflake.ID, champion, filter string, sortLess func(i, j *objects.Page) bool) ([]*objects.Page, error) {
+func (m *MongoDB) GetPages(ctx context.Context, uid snowflake.ID, champion, filter string, sortLess func(i, j *objects.Page) bool) ([]*objects.Page, error) {
 	var query bson.M
 	if champion != "" && champion != "general" {
 		query = bson.M{"owner": uid, "champions": champion}
@@ -133,21 +168,22 @@ func (m *MongoDB) GetPages(uid snowflake.ID, champion, filter string, sortLess f
 	}
 
 	if filter != "" {
+		pattern := "(?i)" + regexp.QuoteMeta(filter)
 		query["$or"] = bson.A{
 			bson.M{
 				"title": bson.M{
-					"$regex": fmt.Sprintf("(?i).*%s.*", filter),
+					"$regex": pattern,
 				},
 			},
 			bson.M{
 				"champions": bson.M{
-					"$regex": fmt.Sprintf("(?i).*%s.*", filter),
+					"$regex": pattern,
 				},
 			},
 		}
 	}
 
-	count, err := m.count(m.collections.pages, query)
+	count, err := m.count(ctx, m.collections.pages, query)
 	if err != nil {
 		return nil, err
 	}
@@ -158,7 +194,7 @@ func (m *MongoDB) GetPages(uid snowflake.ID, champion, filter string, sortLess f
 		return pages, nil
 	}
 
-	ctxFind, cancelFind := ctxTimeout(5 * time.Second)
+	ctxFind, cancelFind := ctxTimeout(ctx, 5*time.Second)
 	defer cancelFind()
 
 	res, err := m.collections.pages.Find(ctxFind, query)
@@ -166,7 +202,7 @@ func (m *MongoDB) GetPages(uid snowflake.ID, champion, filter string, sortLess f
 		return nil, err
 	}
 
-	ctxNext, cancelNext := ctxTimeout(5 * time.Second)
+	ctxNext, cancelNext := ctxTimeout(ctx, 5*time.Second)
 	defer cancelNext()
 
 	i := 0
@@ -189,29 +225,46 @@ func (m *MongoDB) GetPages(uid snowflake.ID, champion, filter string, sortLess f
 	return pages, nil
 }
 
-func (m *MongoDB) GetPage(uid snowflake.ID) (*objects.Page, error) {
+func (m *MongoDB) GetPage(ctx context.Context, uid snowflake.ID) (*objects.Page, error) {
 	page := new(objects.Page)
-	ok, err := m.get(m.collections.pages, bson.M{"uid": uid}, page)
+	ok, err := m.get(ctx, m.collections.pages, bson.M{"uid": uid}, page)
 	if err != nil || !ok {
 		return nil, err
 	}
 	return page, nil
 }
 
-func (m *MongoDB) EditPage(page *objects.Page) error {
-	return m.insertOrUpdate(m.collections.pages, bson.M{"uid": page.UID}, page)
+func (m *MongoDB) EditPage(ctx context.Context, page *objects.Page) error {
+	return m.insertOrUpdate(ctx, m.collections.pages, bson.M{"uid": page.UID}, page)
 }
 
-func (m *MongoDB) DeletePage(uid snowflake.ID) error {
-	ctx, cancel := ctxTimeout(5 * time.Second)
+func (m *MongoDB) EditPageIfUnmodified(ctx context.Context, page *objects.Page, edited time.Time) (bool, error) {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := m.collections.pages.UpdateOne(ctx, bson.M{
+		"uid":    page.UID,
+		"edited": edited,
+	}, bson.M{
+		"$set": page,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return res.MatchedCount > 0, nil
+}
+
+func (m *MongoDB) DeletePage(ctx context.Context, uid snowflake.ID) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	_, err := m.collections.pages.DeleteOne(ctx, bson.M{"uid": uid})
 	return err
 }
 
-func (m *MongoDB) DeleteUserPages(uid snowflake.ID) error {
-	ctxDelMany, cancelDelMany := ctxTimeout(5 * time.Second)
+func (m *MongoDB) DeleteUserPages(ctx context.Context, uid snowflake.ID) error {
+	ctxDelMany, cancelDelMany := ctxTimeout(ctx, 5*time.Second)
 	defer cancelDelMany()
 
 	_, err := m.collections.pages.DeleteMany(ctxDelMany,
@@ -220,39 +273,176 @@ func (m *MongoDB) DeleteUserPages(uid snowflake.ID) error {
 	return err
 }
 
-func (m *MongoDB) SetAPIToken(token *objects.APIToken) error {
-	return m.insertOrUpdate(m.collections.apitokens, &bson.M{"userid": token.UserID}, token)
+func (m *MongoDB) IteratePages(ctx context.Context, f func(page *objects.Page) error) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	cursor, err := m.collections.pages.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		page := new(objects.Page)
+		if err = cursor.Decode(page); err != nil {
+			return err
+		}
+		if err = f(page); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+func (m *MongoDB) GetPagesEditedSince(ctx context.Context, owner snowflake.ID, since time.Time) (res []*objects.Page, err error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res = make([]*objects.Page, 0)
+	cursor, err := m.collections.pages.Find(ctx, bson.M{
+		"owner": owner,
+		"edited": bson.M{
+			"$gt": since,
+		},
+	})
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		v := new(objects.Page)
+		if err = cursor.Decode(v); err != nil {
+			return
+		}
+		res = append(res, v)
+	}
+
+	err = cursor.Err()
+	return
+}
+
+func (m *MongoDB) AddPageTombstone(ctx context.Context, tombstone *objects.PageTombstone) error {
+	return m.insert(ctx, m.collections.pagetombstones, tombstone)
+}
+
+func (m *MongoDB) GetPageTombstones(ctx context.Context, owner snowflake.ID, since time.Time) (res []*objects.PageTombstone, err error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res = make([]*objects.PageTombstone, 0)
+	cursor, err := m.collections.pagetombstones.Find(ctx, bson.M{
+		"owner": owner,
+		"deleted": bson.M{
+			"$gt": since,
+		},
+	})
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		v := new(objects.PageTombstone)
+		if err = cursor.Decode(v); err != nil {
+			return
+		}
+		res = append(res, v)
+	}
+
+	err = cursor.Err()
+	return
+}
+
+func (m *MongoDB) CleanupPageTombstones(ctx context.Context, before time.Time) (n int, err error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res, err := m.collections.pagetombstones.DeleteMany(ctx, bson.M{
+		"deleted": bson.M{
+			"$lt": before,
+		},
+	})
+	if res != nil {
+		n = int(res.DeletedCount)
+	}
+
+	return
+}
+
+func (m *MongoDB) SetChampionStats(ctx context.Context, stats []*objects.ChampionStats) error {
+	ctx, cancel := ctxTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// The stats are replaced champion by champion
+	// and stale champions are removed afterwards, so
+	// that readers never miss the stats of a champion
+	// while they are updated.
+	champions := make(bson.A, len(stats))
+	models := make([]mongo.WriteModel, 0, len(stats)+1)
+	for i, s := range stats {
+		champions[i] = s.Champion
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"champion": s.Champion}).
+			SetReplacement(s).
+			SetUpsert(true))
+	}
+	models = append(models, mongo.NewDeleteManyModel().
+		SetFilter(bson.M{"champion": bson.M{"$nin": champions}}))
+
+	_, err := m.collections.championstats.BulkWrite(ctx, models,
+		options.BulkWrite().SetOrdered(true))
+	return err
+}
+
+func (m *MongoDB) GetChampionStats(ctx context.Context, champion string) (*objects.ChampionStats, error) {
+	stats := new(objects.ChampionStats)
+	ok, err := m.get(ctx, m.collections.championstats, bson.M{"champion": champion}, stats)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return stats, nil
 }
 
-func (m *MongoDB) GetAPIToken(uID snowflake.ID) (*objects.APIToken, error) {
+func (m *MongoDB) SetAPIToken(ctx context.Context, token *objects.APIToken) error {
+	return m.insertOrUpdate(ctx, m.collections.apitokens, &bson.M{"userid": token.UserID}, token)
+}
+
+func (m *MongoDB) GetAPIToken(ctx context.Context, uID snowflake.ID) (*objects.APIToken, error) {
 	token := new(objects.APIToken)
-	ok, err := m.get(m.collections.apitokens, bson.M{"userid": uID}, token)
+	ok, err := m.get(ctx, m.collections.apitokens, bson.M{"userid": uID}, token)
 	if err != nil || !ok {
 		return nil, err
 	}
 	return token, nil
 }
 
-func (m *MongoDB) ResetAPIToken(uID snowflake.ID) error {
-	ctx, cancel := ctxTimeout(5 * time.Second)
+func (m *MongoDB) ResetAPIToken(ctx context.Context, uID snowflake.ID) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	_, err := m.collections.apitokens.DeleteOne(ctx, bson.M{"userid": uID})
 	return err
 }
 
-func (m *MongoDB) VerifyAPIToken(tokenStr string) (*objects.User, error) {
+func (m *MongoDB) VerifyAPIToken(ctx context.Context, tokenStr string) (*objects.User, error) {
 	token := new(objects.APIToken)
-	ok, err := m.get(m.collections.apitokens, bson.M{"token": tokenStr}, token)
+	ok, err := m.get(ctx, m.collections.apitokens, bson.M{"token": tokenStr}, token)
 	if err != nil || !ok {
 		return nil, err
 	}
 
-	return m.GetUser(token.UserID, "")
+	return m.GetUser(ctx, token.UserID, "")
 }
 
-func (m *MongoDB) SetShare(share *objects.SharePage) error {
-	return m.insertOrUpdate(m.collections.shares, bson.M{
+func (m *MongoDB) SetShare(ctx context.Context, share *objects.SharePage) error {
+	if share.IsCollection() {
+		return m.insertOrUpdate(ctx, m.collections.shares, bson.M{"uid": share.UID}, share)
+	}
+
+	return m.insertOrUpdate(ctx, m.collections.shares, bson.M{
 		"$or": bson.A{
 			bson.M{"uid": share.UID},
 			bson.M{"pageid": share.PageID},
@@ -260,10 +450,10 @@ func (m *MongoDB) SetShare(share *objects.SharePage) error {
 	}, share)
 }
 
-func (m *MongoDB) GetShare(ident string, uid, pageID snowflake.ID) (*objects.SharePage, error) {
+func (m *MongoDB) GetShare(ctx context.Context, ident string, uid, pageID snowflake.ID) (*objects.SharePage, error) {
 	share := new(objects.SharePage)
 
-	ok, err := m.get(m.collections.shares, bson.M{
+	ok, err := m.get(ctx, m.collections.shares, bson.M{
 		"$or": bson.A{
 			bson.M{"ident": equalsAndNotEmpty(ident)},
 			bson.M{"uid": uid},
@@ -282,8 +472,8 @@ func (m *MongoDB) GetShare(ident string, uid, pageID snowflake.ID) (*objects.Sha
 	return share, nil
 }
 
-func (m *MongoDB) DeleteShare(ident string, uid, pageID snowflake.ID) error {
-	ctx, cancel := ctxTimeout(5 * time.Second)
+func (m *MongoDB) DeleteShare(ctx context.Context, ident string, uid, pageID snowflake.ID) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	_, err := m.collections.shares.DeleteOne(ctx, bson.M{
@@ -297,17 +487,395 @@ func (m *MongoDB) DeleteShare(ident string, uid, pageID snowflake.ID) error {
 	return err
 }
 
-func (m *MongoDB) GetRefreshToken(token string) (t *objects.RefreshToken, err error) {
+func (m *MongoDB) DeleteUserShares(ctx context.Context, owner snowflake.ID) error {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := m.collections.shares.Find(ctx, bson.M{"ownerid": owner},
+		options.Find().SetProjection(bson.M{"uid": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	shareIDs := make(bson.A, 0)
+	for cursor.Next(ctx) {
+		share := new(objects.SharePage)
+		if err = cursor.Decode(share); err != nil {
+			return err
+		}
+		shareIDs = append(shareIDs, share.UID)
+	}
+	if err = cursor.Err(); err != nil {
+		return err
+	}
+
+	if _, err = m.collections.shareaccesses.DeleteMany(ctx,
+		bson.M{"shareid": bson.M{"$in": shareIDs}}); err != nil {
+		return err
+	}
+
+	_, err = m.collections.shares.DeleteMany(ctx, bson.M{"ownerid": owner})
+	return err
+}
+
+func (m *MongoDB) IncrementShareAccess(ctx context.Context, uid snowflake.ID, lastAccess time.Time) (*objects.SharePage, error) {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Shares with a negative maximum count
+	// of accesses are not limited.
+	share := new(objects.SharePage)
+	err := m.collections.shares.FindOneAndUpdate(ctx,
+		bson.M{
+			"uid": uid,
+			"$or": bson.A{
+				bson.M{"maxaccesses": bson.M{"$lt": 0}},
+				bson.M{"$expr": bson.M{"$lt": bson.A{"$accesses", "$maxaccesses"}}},
+			},
+		},
+		bson.M{
+			"$inc": bson.M{"accesses": 1},
+			"$set": bson.M{"lastaccess": lastAccess},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).
+		Decode(share)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return share, nil
+}
+
+func (m *MongoDB) SetShareLastAccess(ctx context.Context, uid snowflake.ID, lastAccess time.Time) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := m.collections.shares.UpdateOne(ctx,
+		bson.M{"uid": uid},
+		bson.M{"$set": bson.M{"lastaccess": lastAccess}})
+
+	return err
+}
+
+func (m *MongoDB) IncrementShareForks(ctx context.Context, uid snowflake.ID) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := m.collections.shares.UpdateOne(ctx,
+		bson.M{"uid": uid},
+		bson.M{"$inc": bson.M{"forks": 1}})
+
+	return err
+}
+
+func (m *MongoDB) AddShareAccess(ctx context.Context, access *objects.ShareAccess) error {
+	return m.insert(ctx, m.collections.shareaccesses, access)
+}
+
+func (m *MongoDB) HasShareAccess(ctx context.Context, shareID snowflake.ID, ipHash string) (bool, error) {
+	n, err := m.count(ctx, m.collections.shareaccesses, bson.M{
+		"shareid": shareID,
+		"iphash":  ipHash,
+	})
+	return n > 0, err
+}
+
+func (m *MongoDB) GetShareAccesses(ctx context.Context, shareID snowflake.ID, since time.Time) (res []*objects.ShareAccess, err error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res = make([]*objects.ShareAccess, 0)
+	cursor, err := m.collections.shareaccesses.Find(ctx, bson.M{
+		"shareid": shareID,
+		"timestamp": bson.M{
+			"$gte": since,
+		},
+	})
+	if err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+
+	for cursor.Next(ctx) {
+		v := new(objects.ShareAccess)
+		if err = cursor.Decode(v); err != nil {
+			return
+		}
+		res = append(res, v)
+	}
+
+	return
+}
+
+func (m *MongoDB) DeleteShareAccesses(ctx context.Context, shareID snowflake.ID) error {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := m.collections.shareaccesses.DeleteMany(ctx, bson.M{"shareid": shareID})
+	return err
+}
+
+func (m *MongoDB) CleanupShareAccesses(ctx context.Context, before time.Time) (n int, err error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res, err := m.collections.shareaccesses.DeleteMany(ctx, bson.M{
+		"timestamp": bson.M{
+			"$lt": before,
+		},
+	})
+	if res != nil {
+		n = int(res.DeletedCount)
+	}
+
+	return
+}
+
+func (m *MongoDB) SetTeam(ctx context.Context, team *objects.Team) error {
+	return m.insertOrUpdate(ctx, m.collections.teams, bson.M{"uid": team.UID}, team)
+}
+
+func (m *MongoDB) GetTeam(ctx context.Context, uid snowflake.ID) (*objects.Team, error) {
+	team := new(objects.Team)
+	ok, err := m.get(ctx, m.collections.teams, bson.M{"uid": uid}, team)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return team, nil
+}
+
+func (m *MongoDB) DeleteTeam(ctx context.Context, uid snowflake.ID) error {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if _, err := m.collections.teammembers.DeleteMany(ctx, bson.M{"teamid": uid}); err != nil {
+		return err
+	}
+
+	_, err := m.collections.teams.DeleteOne(ctx, bson.M{"uid": uid})
+	return err
+}
+
+func (m *MongoDB) SetTeamMember(ctx context.Context, member *objects.TeamMember) error {
+	return m.insertOrUpdate(ctx, m.collections.teammembers, bson.M{
+		"teamid": member.TeamID,
+		"userid": member.UserID,
+	}, member)
+}
+
+func (m *MongoDB) GetTeamMember(ctx context.Context, teamID, userID snowflake.ID) (*objects.TeamMember, error) {
+	member := new(objects.TeamMember)
+	ok, err := m.get(ctx, m.collections.teammembers, bson.M{
+		"teamid": teamID,
+		"userid": userID,
+	}, member)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return member, nil
+}
+
+func (m *MongoDB) GetTeamMembers(ctx context.Context, teamID snowflake.ID) ([]*objects.TeamMember, error) {
+	return m.getTeamMembers(ctx, bson.M{"teamid": teamID})
+}
+
+func (m *MongoDB) GetUserTeamMembers(ctx context.Context, userID snowflake.ID) ([]*objects.TeamMember, error) {
+	return m.getTeamMembers(ctx, bson.M{"userid": userID})
+}
+
+func (m *MongoDB) DeleteTeamMember(ctx context.Context, teamID, userID snowflake.ID) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := m.collections.teammembers.DeleteOne(ctx, bson.M{
+		"teamid": teamID,
+		"userid": userID,
+	})
+	return err
+}
+
+func (m *MongoDB) SetFolder(ctx context.Context, folder *objects.Folder) error {
+	return m.insertOrUpdate(ctx, m.collections.folders, bson.M{"uid": folder.UID}, folder)
+}
+
+func (m *MongoDB) GetFolder(ctx context.Context, uid snowflake.ID) (*objects.Folder, error) {
+	folder := new(objects.Folder)
+	ok, err := m.get(ctx, m.collections.folders, bson.M{"uid": uid}, folder)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return folder, nil
+}
+
+func (m *MongoDB) GetFolders(ctx context.Context, owner snowflake.ID) (res []*objects.Folder, err error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res = make([]*objects.Folder, 0)
+	cursor, err := m.collections.folders.Find(ctx, bson.M{"owner": owner})
+	if err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+
+	for cursor.Next(ctx) {
+		v := new(objects.Folder)
+		if err = cursor.Decode(v); err != nil {
+			return
+		}
+		res = append(res, v)
+	}
+
+	return
+}
+
+func (m *MongoDB) DeleteFolder(ctx context.Context, uid snowflake.ID) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := m.collections.folders.DeleteOne(ctx, bson.M{"uid": uid})
+	return err
+}
+
+func (m *MongoDB) DeleteUserFolders(ctx context.Context, owner snowflake.ID) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := m.collections.folders.DeleteMany(ctx, bson.M{"owner": owner})
+	return err
+}
+
+func (m *MongoDB) SetWebhook(ctx context.Context, webhook *objects.Webhook) error {
+	return m.insertOrUpdate(ctx, m.collections.webhooks, bson.M{"uid": webhook.UID}, webhook)
+}
+
+func (m *MongoDB) GetWebhook(ctx context.Context, uid snowflake.ID) (*objects.Webhook, error) {
+	webhook := new(objects.Webhook)
+	ok, err := m.get(ctx, m.collections.webhooks, bson.M{"uid": uid}, webhook)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (m *MongoDB) GetWebhooks(ctx context.Context, owner snowflake.ID) (res []*objects.Webhook, err error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res = make([]*objects.Webhook, 0)
+	cursor, err := m.collections.webhooks.Find(ctx, bson.M{"owner": owner})
+	if err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+
+	for cursor.Next(ctx) {
+		v := new(objects.Webhook)
+		if err = cursor.Decode(v); err != nil {
+			return
+		}
+		res = append(res, v)
+	}
+
+	return
+}
+
+func (m *MongoDB) DeleteWebhook(ctx context.Context, uid snowflake.ID) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := m.collections.webhookdeliveries.DeleteMany(ctx, bson.M{"webhookid": uid}); err != nil {
+		return err
+	}
+
+	_, err := m.collections.webhooks.DeleteOne(ctx, bson.M{"uid": uid})
+	return err
+}
+
+func (m *MongoDB) DeleteUserWebhooks(ctx context.Context, owner snowflake.ID) error {
+	webhooks, err := m.GetWebhooks(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range webhooks {
+		if err = m.DeleteWebhook(ctx, w.UID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MongoDB) SetWebhookDelivery(ctx context.Context, delivery *objects.WebhookDelivery) error {
+	return m.insertOrUpdate(ctx, m.collections.webhookdeliveries, bson.M{"uid": delivery.UID}, delivery)
+}
+
+func (m *MongoDB) GetWebhookDeliveries(ctx context.Context, webhookID snowflake.ID, limit int) (res []*objects.WebhookDelivery, err error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.M{"created": -1}).
+		SetLimit(int64(limit))
+
+	res = make([]*objects.WebhookDelivery, 0)
+	cursor, err := m.collections.webhookdeliveries.Find(ctx, bson.M{"webhookid": webhookID}, opts)
+	if err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+
+	for cursor.Next(ctx) {
+		v := new(objects.WebhookDelivery)
+		if err = cursor.Decode(v); err != nil {
+			return
+		}
+		res = append(res, v)
+	}
+
+	return
+}
+
+func (m *MongoDB) CleanupWebhookDeliveries(ctx context.Context, before time.Time) (n int, err error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res, err := m.collections.webhookdeliveries.DeleteMany(ctx, bson.M{
+		"created": bson.M{
+			"$lt": before,
+		},
+	})
+	if res != nil {
+		n = int(res.DeletedCount)
+	}
+
+	return
+}
+
+func (m *MongoDB) GetRefreshToken(ctx context.Context, token string) (t *objects.RefreshToken, err error) {
 	t = new(objects.RefreshToken)
-	ok, err := m.get(m.collections.refreshtokens, bson.M{"token": token}, t)
+	ok, err := m.get(ctx, m.collections.refreshtokens, bson.M{"token": token}, t)
 	if !ok {
 		t = nil
 	}
 	return
 }
 
-func (m *MongoDB) GetRefreshTokens(userID snowflake.ID) (res []*objects.RefreshToken, err error) {
-	ctx, cancel := ctxTimeout(10 * time.Second)
+func (m *MongoDB) GetRefreshTokens(ctx context.Context, userID snowflake.ID) (res []*objects.RefreshToken, err error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	res = make([]*objects.RefreshToken, 0)
@@ -333,12 +901,12 @@ func (m *MongoDB) GetRefreshTokens(userID snowflake.ID) (res []*objects.RefreshT
 	return
 }
 
-func (m *MongoDB) SetRefreshToken(t *objects.RefreshToken) error {
-	return m.insertOrUpdate(m.collections.refreshtokens, bson.M{"id": t.ID}, t)
+func (m *MongoDB) SetRefreshToken(ctx context.Context, t *objects.RefreshToken) error {
+	return m.insertOrUpdate(ctx, m.collections.refreshtokens, bson.M{"id": t.ID}, t)
 }
 
-func (m *MongoDB) RemoveRefreshToken(id snowflake.ID) error {
-	ctx, cancel := ctxTimeout(5 * time.Second)
+func (m *MongoDB) RemoveRefreshToken(ctx context.Context, id snowflake.ID) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	_, err := m.collections.refreshtokens.DeleteOne(ctx, bson.M{"id": id})
@@ -349,8 +917,8 @@ func (m *MongoDB) RemoveRefreshToken(id snowflake.ID) error {
 	return err
 }
 
-func (m *MongoDB) CleanupExpiredTokens() (n int, err error) {
-	ctx, cancel := ctxTimeout(10 * time.Second)
+func (m *MongoDB) CleanupExpiredTokens(ctx context.Context) (n int, err error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	now := time.Now()
@@ -366,11 +934,24 @@ func (m *MongoDB) CleanupExpiredTokens() (n int, err error) {
 	return
 }
 
+func (m *MongoDB) CountRefreshTokens(ctx context.Context) (int, error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	n, err := m.collections.refreshtokens.CountDocuments(ctx, bson.M{
+		"deadline": bson.M{
+			"$gt": time.Now(),
+		},
+	})
+
+	return int(n), err
+}
+
 // --- HELPERS ------------------------------------------------------------------
 
 // insert adds the given vaalue v to the passed collection.
-func (m *MongoDB) insert(collection *mongo.Collection, v interface{}) error {
-	ctx, cancel := ctxTimeout(5 * time.Second)
+func (m *MongoDB) insert(ctx context.Context, collection *mongo.Collection, v interface{}) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	_, err := collection.InsertOne(ctx, v)
@@ -381,8 +962,8 @@ func (m *MongoDB) insert(collection *mongo.Collection, v interface{}) error {
 // in the passed collection by using the passed filter BSON
 // command.
 // If the value does not exist, the value winn be inserted.
-func (m *MongoDB) insertOrUpdate(collection *mongo.Collection, filter, v interface{}) error {
-	ctx, cancel := ctxTimeout(5 * time.Second)
+func (m *MongoDB) insertOrUpdate(ctx context.Context, collection *mongo.Collection, filter, v interface{}) error {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	res, err := collection.UpdateOne(
@@ -396,7 +977,7 @@ func (m *MongoDB) insertOrUpdate(collection *mongo.Collection, filter, v interfa
 	}
 
 	if res.MatchedCount == 0 {
-		return m.insert(collection, v)
+		return m.insert(ctx, collection, v)
 	}
 
 	return err
@@ -409,8 +990,8 @@ func (m *MongoDB) insertOrUpdate(collection *mongo.Collection, filter, v interfa
 // If the value could not be found, false will be returned.
 // An error is only returned if the database access failed,
 // not if the value was not found.
-func (m *MongoDB) get(collection *mongo.Collection, filter interface{}, v interface{}) (bool, error) {
-	ctx, cancel := ctxTimeout(5 * time.Second)
+func (m *MongoDB) get(ctx context.Context, collection *mongo.Collection, filter interface{}, v interface{}) (bool, error) {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	res := collection.FindOne(ctx, filter)
@@ -432,18 +1013,44 @@ func (m *MongoDB) get(collection *mongo.Collection, filter interface{}, v interf
 
 // count returns the number of values in the passed
 // collection matching the passed filter BSON command.
-func (M *MongoDB) count(collection *mongo.Collection, filter interface{}) (int64, error) {
-	ctx, cancel := ctxTimeout(5 * time.Second)
+func (M *MongoDB) count(ctx context.Context, collection *mongo.Collection, filter interface{}) (int64, error) {
+	ctx, cancel := ctxTimeout(ctx, 5*time.Second)
 	defer cancel()
 	return collection.CountDocuments(ctx, filter)
 }
 
-// ctxTimeout creates a timeout context with the
-// passed timeout duration and returns the context
-// object and a cancelation function.
-func ctxTimeout(d time.Duration) (context.Context, context.CancelFunc) {
-	ctx, cancel := context.WithTimeout(context.Background(), d)
-	return ctx, cancel
+// getTeamMembers returns all team memberships
+// matching the passed filter BSON command.
+func (m *MongoDB) getTeamMembers(ctx context.Context, filter interface{}) (res []*objects.TeamMember, err error) {
+	ctx, cancel := ctxTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res = make([]*objects.TeamMember, 0)
+	cursor, err := m.collections.teammembers.Find(ctx, filter)
+	if err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+
+	for cursor.Next(ctx) {
+		v := new(objects.TeamMember)
+		if err = cursor.Decode(v); err != nil {
+			return
+		}
+		res = append(res, v)
+	}
+
+	return
+}
+
+// ctxTimeout creates a timeout context derived from
+// the passed context with the passed timeout duration
+// and returns the context object and a cancelation
+// function.
+func ctxTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, d)
 }
 
 // equalsAndNotEmpty creates a BSON filter to
@@ -0,0 +1,481 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/internal/tracing"
+)
+
+// Tracing wraps a database Middleware and
+// records each database call as client span
+// of the span of the passed context.
+type Tracing struct {
+	Middleware
+
+	system string
+}
+
+// NewTracing creates a new instance of
+// Tracing wrapping the passed database
+// Middleware of the passed database
+// system, like "mongodb".
+func NewTracing(db Middleware, system string) *Tracing {
+	return &Tracing{db, system}
+}
+
+// start starts the span of a call of
+// the passed method.
+func (tr *Tracing) start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "database."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", tr.system),
+			attribute.String("db.operation", method)))
+}
+
+// end records the passed error of the
+// call in the passed span and ends it.
+func (tr *Tracing) end(span trace.Span, err error) {
+	tracing.RecordError(span, err)
+	span.End()
+}
+
+func (tr *Tracing) Ping(ctx context.Context) error {
+	ctx, span := tr.start(ctx, "Ping")
+	err := tr.Middleware.Ping(ctx)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) CreateUser(ctx context.Context, user *objects.User) error {
+	ctx, span := tr.start(ctx, "CreateUser")
+	err := tr.Middleware.CreateUser(ctx, user)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetUser(ctx context.Context, uid snowflake.ID, username string) (*objects.User, error) {
+	ctx, span := tr.start(ctx, "GetUser")
+	res, err := tr.Middleware.GetUser(ctx, uid, username)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) EditUser(ctx context.Context, user *objects.User) error {
+	ctx, span := tr.start(ctx, "EditUser")
+	err := tr.Middleware.EditUser(ctx, user)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) DeleteUser(ctx context.Context, uid snowflake.ID) error {
+	ctx, span := tr.start(ctx, "DeleteUser")
+	err := tr.Middleware.DeleteUser(ctx, uid)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) CreatePage(ctx context.Context, page *objects.Page) error {
+	ctx, span := tr.start(ctx, "CreatePage")
+	err := tr.Middleware.CreatePage(ctx, page)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetPages(ctx context.Context, uid snowflake.ID, champion, filter string, sortLess func(i, j *objects.Page) bool) ([]*objects.Page, error) {
+	ctx, span := tr.start(ctx, "GetPages")
+	res, err := tr.Middleware.GetPages(ctx, uid, champion, filter, sortLess)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) GetPage(ctx context.Context, uid snowflake.ID) (*objects.Page, error) {
+	ctx, span := tr.start(ctx, "GetPage")
+	res, err := tr.Middleware.GetPage(ctx, uid)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) EditPage(ctx context.Context, page *objects.Page) error {
+	ctx, span := tr.start(ctx, "EditPage")
+	err := tr.Middleware.EditPage(ctx, page)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) EditPageIfUnmodified(ctx context.Context, page *objects.Page, edited time.Time) (bool, error) {
+	ctx, span := tr.start(ctx, "EditPageIfUnmodified")
+	res, err := tr.Middleware.EditPageIfUnmodified(ctx, page, edited)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) DeletePage(ctx context.Context, uid snowflake.ID) error {
+	ctx, span := tr.start(ctx, "DeletePage")
+	err := tr.Middleware.DeletePage(ctx, uid)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) DeleteUserPages(ctx context.Context, uid snowflake.ID) error {
+	ctx, span := tr.start(ctx, "DeleteUserPages")
+	err := tr.Middleware.DeleteUserPages(ctx, uid)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) IteratePages(ctx context.Context, f func(page *objects.Page) error) error {
+	ctx, span := tr.start(ctx, "IteratePages")
+	err := tr.Middleware.IteratePages(ctx, f)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetPagesEditedSince(ctx context.Context, owner snowflake.ID, since time.Time) ([]*objects.Page, error) {
+	ctx, span := tr.start(ctx, "GetPagesEditedSince")
+	res, err := tr.Middleware.GetPagesEditedSince(ctx, owner, since)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) AddPageTombstone(ctx context.Context, tombstone *objects.PageTombstone) error {
+	ctx, span := tr.start(ctx, "AddPageTombstone")
+	err := tr.Middleware.AddPageTombstone(ctx, tombstone)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetPageTombstones(ctx context.Context, owner snowflake.ID, since time.Time) ([]*objects.PageTombstone, error) {
+	ctx, span := tr.start(ctx, "GetPageTombstones")
+	res, err := tr.Middleware.GetPageTombstones(ctx, owner, since)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) CleanupPageTombstones(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tr.start(ctx, "CleanupPageTombstones")
+	res, err := tr.Middleware.CleanupPageTombstones(ctx, before)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) SetChampionStats(ctx context.Context, stats []*objects.ChampionStats) error {
+	ctx, span := tr.start(ctx, "SetChampionStats")
+	err := tr.Middleware.SetChampionStats(ctx, stats)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetChampionStats(ctx context.Context, champion string) (*objects.ChampionStats, error) {
+	ctx, span := tr.start(ctx, "GetChampionStats")
+	res, err := tr.Middleware.GetChampionStats(ctx, champion)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) GetRefreshToken(ctx context.Context, token string) (*objects.RefreshToken, error) {
+	ctx, span := tr.start(ctx, "GetRefreshToken")
+	res, err := tr.Middleware.GetRefreshToken(ctx, token)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) GetRefreshTokens(ctx context.Context, userID snowflake.ID) ([]*objects.RefreshToken, error) {
+	ctx, span := tr.start(ctx, "GetRefreshTokens")
+	res, err := tr.Middleware.GetRefreshTokens(ctx, userID)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) SetRefreshToken(ctx context.Context, t *objects.RefreshToken) error {
+	ctx, span := tr.start(ctx, "SetRefreshToken")
+	err := tr.Middleware.SetRefreshToken(ctx, t)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) RemoveRefreshToken(ctx context.Context, id snowflake.ID) error {
+	ctx, span := tr.start(ctx, "RemoveRefreshToken")
+	err := tr.Middleware.RemoveRefreshToken(ctx, id)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) CleanupExpiredTokens(ctx context.Context) (int, error) {
+	ctx, span := tr.start(ctx, "CleanupExpiredTokens")
+	res, err := tr.Middleware.CleanupExpiredTokens(ctx)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) CountRefreshTokens(ctx context.Context) (int, error) {
+	ctx, span := tr.start(ctx, "CountRefreshTokens")
+	res, err := tr.Middleware.CountRefreshTokens(ctx)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) SetAPIToken(ctx context.Context, token *objects.APIToken) error {
+	ctx, span := tr.start(ctx, "SetAPIToken")
+	err := tr.Middleware.SetAPIToken(ctx, token)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetAPIToken(ctx context.Context, uid snowflake.ID) (*objects.APIToken, error) {
+	ctx, span := tr.start(ctx, "GetAPIToken")
+	res, err := tr.Middleware.GetAPIToken(ctx, uid)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) ResetAPIToken(ctx context.Context, uid snowflake.ID) error {
+	ctx, span := tr.start(ctx, "ResetAPIToken")
+	err := tr.Middleware.ResetAPIToken(ctx, uid)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) VerifyAPIToken(ctx context.Context, tokenStr string) (*objects.User, error) {
+	ctx, span := tr.start(ctx, "VerifyAPIToken")
+	res, err := tr.Middleware.VerifyAPIToken(ctx, tokenStr)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) SetShare(ctx context.Context, share *objects.SharePage) error {
+	ctx, span := tr.start(ctx, "SetShare")
+	err := tr.Middleware.SetShare(ctx, share)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetShare(ctx context.Context, ident string, uid, pageID snowflake.ID) (*objects.SharePage, error) {
+	ctx, span := tr.start(ctx, "GetShare")
+	res, err := tr.Middleware.GetShare(ctx, ident, uid, pageID)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) DeleteShare(ctx context.Context, ident string, uid, pageID snowflake.ID) error {
+	ctx, span := tr.start(ctx, "DeleteShare")
+	err := tr.Middleware.DeleteShare(ctx, ident, uid, pageID)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) DeleteUserShares(ctx context.Context, owner snowflake.ID) error {
+	ctx, span := tr.start(ctx, "DeleteUserShares")
+	err := tr.Middleware.DeleteUserShares(ctx, owner)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) IncrementShareAccess(ctx context.Context, uid snowflake.ID, lastAccess time.Time) (*objects.SharePage, error) {
+	ctx, span := tr.start(ctx, "IncrementShareAccess")
+	res, err := tr.Middleware.IncrementShareAccess(ctx, uid, lastAccess)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) SetShareLastAccess(ctx context.Context, uid snowflake.ID, lastAccess time.Time) error {
+	ctx, span := tr.start(ctx, "SetShareLastAccess")
+	err := tr.Middleware.SetShareLastAccess(ctx, uid, lastAccess)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) IncrementShareForks(ctx context.Context, uid snowflake.ID) error {
+	ctx, span := tr.start(ctx, "IncrementShareForks")
+	err := tr.Middleware.IncrementShareForks(ctx, uid)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) AddShareAccess(ctx context.Context, access *objects.ShareAccess) error {
+	ctx, span := tr.start(ctx, "AddShareAccess")
+	err := tr.Middleware.AddShareAccess(ctx, access)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) HasShareAccess(ctx context.Context, shareID snowflake.ID, ipHash string) (bool, error) {
+	ctx, span := tr.start(ctx, "HasShareAccess")
+	res, err := tr.Middleware.HasShareAccess(ctx, shareID, ipHash)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) GetShareAccesses(ctx context.Context, shareID snowflake.ID, since time.Time) ([]*objects.ShareAccess, error) {
+	ctx, span := tr.start(ctx, "GetShareAccesses")
+	res, err := tr.Middleware.GetShareAccesses(ctx, shareID, since)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) DeleteShareAccesses(ctx context.Context, shareID snowflake.ID) error {
+	ctx, span := tr.start(ctx, "DeleteShareAccesses")
+	err := tr.Middleware.DeleteShareAccesses(ctx, shareID)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) CleanupShareAccesses(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tr.start(ctx, "CleanupShareAccesses")
+	res, err := tr.Middleware.CleanupShareAccesses(ctx, before)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) SetTeam(ctx context.Context, team *objects.Team) error {
+	ctx, span := tr.start(ctx, "SetTeam")
+	err := tr.Middleware.SetTeam(ctx, team)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetTeam(ctx context.Context, uid snowflake.ID) (*objects.Team, error) {
+	ctx, span := tr.start(ctx, "GetTeam")
+	res, err := tr.Middleware.GetTeam(ctx, uid)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) DeleteTeam(ctx context.Context, uid snowflake.ID) error {
+	ctx, span := tr.start(ctx, "DeleteTeam")
+	err := tr.Middleware.DeleteTeam(ctx, uid)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) SetTeamMember(ctx context.Context, member *objects.TeamMember) error {
+	ctx, span := tr.start(ctx, "SetTeamMember")
+	err := tr.Middleware.SetTeamMember(ctx, member)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetTeamMember(ctx context.Context, teamID, userID snowflake.ID) (*objects.TeamMember, error) {
+	ctx, span := tr.start(ctx, "GetTeamMember")
+	res, err := tr.Middleware.GetTeamMember(ctx, teamID, userID)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) GetTeamMembers(ctx context.Context, teamID snowflake.ID) ([]*objects.TeamMember, error) {
+	ctx, span := tr.start(ctx, "GetTeamMembers")
+	res, err := tr.Middleware.GetTeamMembers(ctx, teamID)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) GetUserTeamMembers(ctx context.Context, userID snowflake.ID) ([]*objects.TeamMember, error) {
+	ctx, span := tr.start(ctx, "GetUserTeamMembers")
+	res, err := tr.Middleware.GetUserTeamMembers(ctx, userID)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) DeleteTeamMember(ctx context.Context, teamID, userID snowflake.ID) error {
+	ctx, span := tr.start(ctx, "DeleteTeamMember")
+	err := tr.Middleware.DeleteTeamMember(ctx, teamID, userID)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) SetFolder(ctx context.Context, folder *objects.Folder) error {
+	ctx, span := tr.start(ctx, "SetFolder")
+	err := tr.Middleware.SetFolder(ctx, folder)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetFolder(ctx context.Context, uid snowflake.ID) (*objects.Folder, error) {
+	ctx, span := tr.start(ctx, "GetFolder")
+	res, err := tr.Middleware.GetFolder(ctx, uid)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) GetFolders(ctx context.Context, owner snowflake.ID) ([]*objects.Folder, error) {
+	ctx, span := tr.start(ctx, "GetFolders")
+	res, err := tr.Middleware.GetFolders(ctx, owner)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) DeleteFolder(ctx context.Context, uid snowflake.ID) error {
+	ctx, span := tr.start(ctx, "DeleteFolder")
+	err := tr.Middleware.DeleteFolder(ctx, uid)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) DeleteUserFolders(ctx context.Context, owner snowflake.ID) error {
+	ctx, span := tr.start(ctx, "DeleteUserFolders")
+	err := tr.Middleware.DeleteUserFolders(ctx, owner)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) SetWebhook(ctx context.Context, webhook *objects.Webhook) error {
+	ctx, span := tr.start(ctx, "SetWebhook")
+	err := tr.Middleware.SetWebhook(ctx, webhook)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetWebhook(ctx context.Context, uid snowflake.ID) (*objects.Webhook, error) {
+	ctx, span := tr.start(ctx, "GetWebhook")
+	res, err := tr.Middleware.GetWebhook(ctx, uid)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) GetWebhooks(ctx context.Context, owner snowflake.ID) ([]*objects.Webhook, error) {
+	ctx, span := tr.start(ctx, "GetWebhooks")
+	res, err := tr.Middleware.GetWebhooks(ctx, owner)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) DeleteWebhook(ctx context.Context, uid snowflake.ID) error {
+	ctx, span := tr.start(ctx, "DeleteWebhook")
+	err := tr.Middleware.DeleteWebhook(ctx, uid)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) DeleteUserWebhooks(ctx context.Context, owner snowflake.ID) error {
+	ctx, span := tr.start(ctx, "DeleteUserWebhooks")
+	err := tr.Middleware.DeleteUserWebhooks(ctx, owner)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) SetWebhookDelivery(ctx context.Context, delivery *objects.WebhookDelivery) error {
+	ctx, span := tr.start(ctx, "SetWebhookDelivery")
+	err := tr.Middleware.SetWebhookDelivery(ctx, delivery)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetWebhookDeliveries(ctx context.Context, webhookID snowflake.ID, limit int) ([]*objects.WebhookDelivery, error) {
+	ctx, span := tr.start(ctx, "GetWebhookDeliveries")
+	res, err := tr.Middleware.GetWebhookDeliveries(ctx, webhookID, limit)
+	tr.end(span, err)
+	return res, err
+}
+
+func (tr *Tracing) CleanupWebhookDeliveries(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tr.start(ctx, "CleanupWebhookDeliveries")
+	res, err := tr.Middleware.CleanupWebhookDeliveries(ctx, before)
+	tr.end(span, err)
+	return res, err
+}
@@ -0,0 +1,88 @@
+// Package events provides a publish and subscribe
+// mechanism to notify connected clients about
+// changes of resources like pages, shares and
+// favorites.
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// Types of published events.
+const (
+	TypePageCreated      = "page.created"
+	TypePageUpdated      = "page.updated"
+	TypePageDeleted      = "page.deleted"
+	TypeShareAccessed    = "share.accessed"
+	TypeFavoritesChanged = "favorites.changed"
+)
+
+// Types contains all types of
+// published events.
+var Types = []string{
+	TypePageCreated,
+	TypePageUpdated,
+	TypePageDeleted,
+	TypeShareAccessed,
+	TypeFavoritesChanged,
+}
+
+// IsValidType returns true if the passed
+// string is a type of published events.
+func IsValidType(typ string) bool {
+	for _, t := range Types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Event describes a change of a resource owned
+// by the user or team Owner. Data contains the
+// JSON encoded event payload.
+type Event struct {
+	Type  string          `json:"type"`
+	Owner snowflake.ID    `json:"owner"`
+	Time  time.Time       `json:"time"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// NewEvent creates a new Event of the passed type
+// for the passed owner with the JSON encoded data
+// as payload.
+func NewEvent(typ string, owner snowflake.ID, data interface{}) (*Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		Type:  typ,
+		Owner: owner,
+		Time:  time.Now(),
+		Data:  raw,
+	}, nil
+}
+
+// Broker describes a service which distributes
+// published events to all subscribers of the
+// owner of the events.
+type Broker interface {
+	// Publish distributes the passed event
+	// to all subscribers of its owner.
+	Publish(e *Event) error
+	// Subscribe returns a channel receiving all
+	// events of the passed owners and a function
+	// to cancel the subscription, which closes
+	// the channel. Events are dropped for
+	// subscribers which do not keep up with
+	// receiving.
+	Subscribe(owners []snowflake.ID) (<-chan *Event, func())
+	// Close cancels all subscriptions and
+	// releases the resources of the broker.
+	Close() error
+}
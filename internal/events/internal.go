@@ -0,0 +1,94 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// size of the event buffer of
+// each subscription
+const subscriptionBufferSize = 32
+
+// subscription wraps the event channel and
+// the owners subscribed to.
+type subscription struct {
+	owners map[snowflake.ID]struct{}
+	c      chan *Event
+}
+
+// Internal provides a Broker which distributes
+// events to subscribers of the same process.
+type Internal struct {
+	mtx  sync.RWMutex
+	subs map[*subscription]struct{}
+}
+
+// NewInternal creates a new instance of Internal.
+func NewInternal() *Internal {
+	return &Internal{
+		subs: make(map[*subscription]struct{}),
+	}
+}
+
+func (b *Internal) Publish(e *Event) error {
+	b.dispatch(e)
+	return nil
+}
+
+func (b *Internal) Subscribe(owners []snowflake.ID) (<-chan *Event, func()) {
+	s := &subscription{
+		owners: make(map[snowflake.ID]struct{}, len(owners)),
+		c:      make(chan *Event, subscriptionBufferSize),
+	}
+	for _, o := range owners {
+		s.owners[o] = struct{}{}
+	}
+
+	b.mtx.Lock()
+	b.subs[s] = struct{}{}
+	b.mtx.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mtx.Lock()
+			if _, ok := b.subs[s]; ok {
+				delete(b.subs, s)
+				close(s.c)
+			}
+			b.mtx.Unlock()
+		})
+	}
+
+	return s.c, cancel
+}
+
+func (b *Internal) Close() error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	for s := range b.subs {
+		delete(b.subs, s)
+		close(s.c)
+	}
+
+	return nil
+}
+
+// dispatch sends the passed event to all
+// subscriptions of the owner of the event.
+func (b *Internal) dispatch(e *Event) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	for s := range b.subs {
+		if _, ok := s.owners[e.Owner]; !ok {
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// receive returns the next event of the passed channel
+// or nil, if no event is received within a second.
+func receive(c <-chan *Event) *Event {
+	select {
+	case e := <-c:
+		return e
+	case <-time.After(time.Second):
+		return nil
+	}
+}
+
+// expectNoEvent fails the test if an event is
+// received from the passed channel.
+func expectNoEvent(t *testing.T, c <-chan *Event) {
+	t.Helper()
+
+	select {
+	case e := <-c:
+		t.Errorf("unexpected event %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func newTestEvent(t *testing.T, typ string, owner snowflake.ID) *Event {
+	t.Helper()
+
+	e, err := NewEvent(typ, owner, map[string]string{"uid": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestInternalFanOut(t *testing.T) {
+	b := NewInternal()
+	defer b.Close()
+
+	cUser, cancelUser := b.Subscribe([]snowflake.ID{1, 10})
+	defer cancelUser()
+	cMember, cancelMember := b.Subscribe([]snowflake.ID{2, 10})
+	defer cancelMember()
+
+	b.Publish(newTestEvent(t, TypePageCreated, 10))
+
+	for _, c := range []<-chan *Event{cUser, cMember} {
+		if e := receive(c); e == nil || e.Owner != 10 || e.Type != TypePageCreated {
+			t.Errorf("unexpected team event %+v", e)
+		}
+	}
+
+	b.Publish(newTestEvent(t, TypePageUpdated, 1))
+
+	if e := receive(cUser); e == nil || e.Owner != 1 {
+		t.Errorf("unexpected user event %+v", e)
+	}
+	expectNoEvent(t, cMember)
+}
+
+func TestInternalCancel(t *testing.T) {
+	b := NewInternal()
+	defer b.Close()
+
+	c, cancel := b.Subscribe([]snowflake.ID{1})
+	cancel()
+	cancel()
+
+	if _, ok := <-c; ok {
+		t.Error("channel is not closed after cancel")
+	}
+
+	// Publishing to canceled subscriptions
+	// must not panic.
+	b.Publish(newTestEvent(t, TypePageCreated, 1))
+}
+
+func TestInternalDropsEventsOfSlowSubscribers(t *testing.T) {
+	b := NewInternal()
+	defer b.Close()
+
+	c, cancel := b.Subscribe([]snowflake.ID{1})
+	defer cancel()
+
+	// Publish must not block if the buffer
+	// of a subscriber is full.
+	for i := 0; i < subscriptionBufferSize*2; i++ {
+		b.Publish(newTestEvent(t, TypePageUpdated, 1))
+	}
+
+	if n := len(c); n != subscriptionBufferSize {
+		t.Errorf("expected %d buffered events, got %d", subscriptionBufferSize, n)
+	}
+}
+
+func TestInternalClose(t *testing.T) {
+	b := NewInternal()
+
+	c, cancel := b.Subscribe([]snowflake.ID{1})
+	b.Close()
+
+	if _, ok := <-c; ok {
+		t.Error("channel is not closed after close")
+	}
+
+	// Canceling after close must not
+	// close the channel again.
+	cancel()
+}
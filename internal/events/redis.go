@@ -0,0 +1,76 @@
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/go-redis/redis"
+
+	"github.com/myrunes/backend/internal/logger"
+)
+
+// name of the Redis pub/sub channel
+// events are published to
+const redisChannel = "MYRUNES:EVENTS"
+
+// Redis provides a Broker which publishes events
+// via Redis pub/sub, so that events are distributed
+// to the subscribers of all instances connected
+// to the same Redis server.
+type Redis struct {
+	local  *Internal
+	client *redis.Client
+	pubsub *redis.PubSub
+}
+
+// NewRedis creates a new instance of Redis
+// connecting to the Redis server with the passed
+// options and starts receiving events published
+// by all instances.
+func NewRedis(opts *redis.Options) *Redis {
+	b := &Redis{
+		local:  NewInternal(),
+		client: redis.NewClient(opts),
+	}
+
+	b.pubsub = b.client.Subscribe(redisChannel)
+	go b.receive()
+
+	return b
+}
+
+func (b *Redis) Publish(e *Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return b.client.Publish(redisChannel, data).Err()
+}
+
+func (b *Redis) Subscribe(owners []snowflake.ID) (<-chan *Event, func()) {
+	return b.local.Subscribe(owners)
+}
+
+func (b *Redis) Close() error {
+	err := b.pubsub.Close()
+	b.local.Close()
+	if cErr := b.client.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+// receive dispatches all events received from
+// the Redis channel to the local subscribers
+// until the pub/sub connection is closed.
+func (b *Redis) receive() {
+	for msg := range b.pubsub.Channel() {
+		e := new(Event)
+		if err := json.Unmarshal([]byte(msg.Payload), e); err != nil {
+			logger.Error("EVENTS :: failed decoding event: %s", err.Error())
+			continue
+		}
+		b.local.dispatch(e)
+	}
+}
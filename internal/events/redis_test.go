@@ -0,0 +1,92 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/bwmarrin/snowflake"
+	"github.com/go-redis/redis"
+)
+
+// publishUntilReceived publishes the passed event
+// until it is received from the passed channel,
+// because subscriptions to the Redis channel are
+// established asynchronously.
+func publishUntilReceived(t *testing.T, b Broker, e *Event, c <-chan *Event) *Event {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if err := b.Publish(e); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case r := <-c:
+			return r
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	t.Fatal("event was not received")
+	return nil
+}
+
+func TestRedisFanOut(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// Two brokers simulate two instances
+	// connected to the same Redis server.
+	b1 := NewRedis(&redis.Options{Addr: s.Addr()})
+	defer b1.Close()
+	b2 := NewRedis(&redis.Options{Addr: s.Addr()})
+	defer b2.Close()
+
+	c1, cancel1 := b1.Subscribe([]snowflake.ID{1})
+	defer cancel1()
+	c2, cancel2 := b2.Subscribe([]snowflake.ID{1})
+	defer cancel2()
+	cOther, cancelOther := b2.Subscribe([]snowflake.ID{2})
+	defer cancelOther()
+
+	sent := newTestEvent(t, TypePageDeleted, 1)
+
+	e := publishUntilReceived(t, b1, sent, c2)
+	if e.Type != sent.Type || e.Owner != sent.Owner || string(e.Data) != string(sent.Data) {
+		t.Errorf("received event %+v differs from sent event %+v", e, sent)
+	}
+	if !e.Time.Equal(sent.Time) {
+		t.Errorf("received time %s differs from sent time %s", e.Time, sent.Time)
+	}
+
+	// The publishing instance receives its own
+	// events via Redis like all other instances.
+	if e := receive(c1); e == nil || e.Owner != 1 {
+		t.Errorf("publishing instance received %+v", e)
+	}
+
+	expectNoEvent(t, cOther)
+}
+
+func TestRedisClose(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	b := NewRedis(&redis.Options{Addr: s.Addr()})
+	c, _ := b.Subscribe([]snowflake.ID{1})
+
+	if err = b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := <-c; ok {
+		t.Error("channel is not closed after close")
+	}
+}
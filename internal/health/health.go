@@ -0,0 +1,182 @@
+// Package health checks the availability of the
+// components the backend depends on.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Status values of components and reports.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFailed   = "failed"
+)
+
+// ErrTimeout is reported for checks which did
+// not finish within the check timeout.
+var ErrTimeout = errors.New("check timed out")
+
+// Check checks the health of a component and
+// returns optional details about the component.
+// If the component is not available, an error is
+// returned. If the component is available but
+// degraded, an error wrapped by Degraded is
+// returned.
+type Check func(ctx context.Context) (details interface{}, err error)
+
+// degradedError marks the wrapped error
+// as reason of a degraded component.
+type degradedError struct {
+	err error
+}
+
+func (e *degradedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *degradedError) Unwrap() error {
+	return e.err
+}
+
+// Degraded wraps the passed error so that a
+// check returning it reports its component
+// as degraded instead of failed.
+func Degraded(err error) error {
+	return &degradedError{err}
+}
+
+// ComponentStatus describes the result of
+// the check of a single component.
+type ComponentStatus struct {
+	Status   string      `json:"status"`
+	Error    string      `json:"error,omitempty"`
+	Duration int64       `json:"duration"`
+	Details  interface{} `json:"details,omitempty"`
+}
+
+// Report describes the results of the checks of
+// all components. Status is failed if any component
+// failed, degraded if any component is degraded
+// and ok otherwise.
+type Report struct {
+	Status     string                      `json:"status"`
+	Components map[string]*ComponentStatus `json:"components"`
+}
+
+// Health holds the checks of all components
+// and executes them with a timeout.
+type Health struct {
+	timeout time.Duration
+
+	mtx    sync.RWMutex
+	checks map[string]Check
+}
+
+// New creates a new instance of Health which
+// cancels checks after the passed timeout.
+func New(timeout time.Duration) *Health {
+	return &Health{
+		timeout: timeout,
+		checks:  make(map[string]Check),
+	}
+}
+
+// Register adds the passed check of the
+// component with the passed name. A check
+// registered with the same name before
+// is replaced.
+func (h *Health) Register(name string, check Check) *Health {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.checks[name] = check
+	return h
+}
+
+// Check executes all registered checks
+// concurrently with contexts derived from the
+// passed context and returns their results.
+func (h *Health) Check(ctx context.Context) *Report {
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+
+	report := &Report{
+		Status:     StatusOK,
+		Components: make(map[string]*ComponentStatus, len(h.checks)),
+	}
+
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(len(h.checks))
+	for name, check := range h.checks {
+		go func(name string, check Check) {
+			defer wg.Done()
+			res := h.run(ctx, check)
+
+			mtx.Lock()
+			report.Components[name] = res
+			mtx.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	for _, res := range report.Components {
+		switch {
+		case res.Status == StatusFailed:
+			report.Status = StatusFailed
+		case res.Status == StatusDegraded && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+// run executes the passed check and returns its
+// result. If the check does not return within
+// the timeout, it is reported as failed.
+func (h *Health) run(ctx context.Context, check Check) *ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	type result struct {
+		details interface{}
+		err     error
+	}
+
+	start := time.Now()
+	cRes := make(chan result, 1)
+	go func() {
+		details, err := check(ctx)
+		cRes <- result{details, err}
+	}()
+
+	var res result
+	select {
+	case res = <-cRes:
+	case <-ctx.Done():
+		res.err = ErrTimeout
+	}
+
+	status := &ComponentStatus{
+		Status:   StatusOK,
+		Duration: time.Since(start).Milliseconds(),
+		Details:  res.details,
+	}
+
+	var degraded *degradedError
+	switch {
+	case errors.As(res.err, &degraded):
+		status.Status = StatusDegraded
+		status.Error = res.err.Error()
+	case res.err != nil:
+		status.Status = StatusFailed
+		status.Error = res.err.Error()
+	}
+
+	return status
+}
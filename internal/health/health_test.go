@@ -0,0 +1,120 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func checkOK(ctx context.Context) (interface{}, error) {
+	return "details", nil
+}
+
+func checkDegraded(ctx context.Context) (interface{}, error) {
+	return nil, Degraded(errors.New("slow"))
+}
+
+func checkFailed(ctx context.Context) (interface{}, error) {
+	return nil, errors.New("unavailable")
+}
+
+// checkBlocking returns shortly after its
+// context is cancelled.
+func checkBlocking(ctx context.Context) (interface{}, error) {
+	<-ctx.Done()
+	time.Sleep(10 * time.Millisecond)
+	return nil, ctx.Err()
+}
+
+// checkHanging ignores its context.
+func checkHanging(ctx context.Context) (interface{}, error) {
+	time.Sleep(200 * time.Millisecond)
+	return nil, nil
+}
+
+func TestCheckAggregation(t *testing.T) {
+	cases := []struct {
+		name   string
+		checks map[string]Check
+		exp    string
+	}{
+		{"no checks", map[string]Check{}, StatusOK},
+		{"ok", map[string]Check{"a": checkOK, "b": checkOK}, StatusOK},
+		{"degraded", map[string]Check{"a": checkOK, "b": checkDegraded}, StatusDegraded},
+		{"failed", map[string]Check{"a": checkOK, "b": checkFailed}, StatusFailed},
+		{"failed and degraded", map[string]Check{"a": checkDegraded, "b": checkFailed, "c": checkDegraded}, StatusFailed},
+	}
+
+	for _, c := range cases {
+		h := New(time.Second)
+		for name, check := range c.checks {
+			h.Register(name, check)
+		}
+
+		report := h.Check(context.Background())
+		if report.Status != c.exp {
+			t.Errorf("%s: expected status %s, got %s", c.name, c.exp, report.Status)
+		}
+		if len(report.Components) != len(c.checks) {
+			t.Errorf("%s: expected %d components, got %d", c.name, len(c.checks), len(report.Components))
+		}
+	}
+}
+
+func TestCheckComponentStatus(t *testing.T) {
+	h := New(time.Second).
+		Register("ok", checkOK).
+		Register("degraded", checkDegraded).
+		Register("failed", checkFailed)
+
+	report := h.Check(context.Background())
+
+	if res := report.Components["ok"]; res.Status != StatusOK || res.Error != "" || res.Details != "details" {
+		t.Errorf("unexpected status of ok component %+v", res)
+	}
+	if res := report.Components["degraded"]; res.Status != StatusDegraded || res.Error != "slow" {
+		t.Errorf("unexpected status of degraded component %+v", res)
+	}
+	if res := report.Components["failed"]; res.Status != StatusFailed || res.Error != "unavailable" {
+		t.Errorf("unexpected status of failed component %+v", res)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+
+	h := New(timeout).
+		Register("ok", checkOK).
+		Register("blocking", checkBlocking).
+		Register("hanging", checkHanging)
+
+	start := time.Now()
+	report := h.Check(context.Background())
+	if d := time.Since(start); d > 10*timeout {
+		t.Errorf("expected checks to be cancelled after %s, took %s", timeout, d)
+	}
+
+	if report.Status != StatusFailed {
+		t.Errorf("expected status %s, got %s", StatusFailed, report.Status)
+	}
+	for _, name := range []string{"blocking", "hanging"} {
+		if res := report.Components[name]; res.Status != StatusFailed || res.Error != ErrTimeout.Error() {
+			t.Errorf("%s: expected timed out component, got %+v", name, res)
+		}
+	}
+	if res := report.Components["ok"]; res.Status != StatusOK {
+		t.Errorf("expected ok component, got %+v", res)
+	}
+}
+
+func TestRegisterReplaces(t *testing.T) {
+	h := New(time.Second).
+		Register("db", checkFailed).
+		Register("db", checkOK)
+
+	report := h.Check(context.Background())
+	if report.Status != StatusOK || len(report.Components) != 1 {
+		t.Errorf("expected replaced check, got %+v", report)
+	}
+}
@@ -1,13 +1,52 @@
 package logger
 
 import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
 	"github.com/op/go-logging"
 )
 
 const mainLoggerName = "main"
 
+// Output formats of log records.
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
 var log = logging.MustGetLogger(mainLoggerName)
 
+var (
+	// std is the entry without fields used
+	// by the package level log functions
+	std = new(Entry)
+
+	// formatMtx guards the output format
+	formatMtx    sync.RWMutex
+	outputFormat = FormatText
+)
+
+// Config wraps the configuration values
+// for the logger.
+type Config struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+}
+
+// Fields contains key-value pairs which are
+// attached to log records of an Entry.
+type Fields map[string]interface{}
+
+// Entry holds fields which are attached
+// to all records logged via the entry.
+type Entry struct {
+	fields Fields
+}
+
 // Setup sets configuration for logger
 func Setup(format string, level int) {
 	formatter := logging.MustStringFormatter(format)
@@ -15,33 +54,111 @@ func Setup(format string, level int) {
 	logging.SetLevel(logging.Level(level), mainLoggerName)
 }
 
+// Configure sets the log level and the output
+// format from the passed config. Empty values
+// keep the current settings. The text format
+// uses the format string passed to Setup,
+// the json and logfmt formats write one
+// structured record per line to stderr.
+func Configure(cfg *Config) error {
+	if cfg == nil {
+		return nil
+	}
+
+	if cfg.Level != "" {
+		level, err := logging.LogLevel(cfg.Level)
+		if err != nil {
+			return err
+		}
+		logging.SetLevel(level, mainLoggerName)
+	}
+
+	if cfg.Format != "" {
+		f := strings.ToLower(cfg.Format)
+		if f != FormatText && f != FormatJSON && f != FormatLogfmt {
+			return errors.New("invalid log format")
+		}
+		formatMtx.Lock()
+		outputFormat = f
+		formatMtx.Unlock()
+	}
+
+	return nil
+}
+
 // SetLogLevel sets the log level for the current logger
 func SetLogLevel(logLevel int) {
 	logging.SetLevel(logging.Level(logLevel), mainLoggerName)
 }
 
+// WithFields returns an Entry which attaches
+// the passed fields to all logged records.
+func WithFields(fields Fields) *Entry {
+	return &Entry{fields}
+}
+
+// WithFields returns a new Entry containing the
+// fields of the entry and the passed fields.
+func (e *Entry) WithFields(fields Fields) *Entry {
+	merged := make(Fields, len(e.fields)+len(fields))
+	for k, v := range e.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Entry{merged}
+}
+
+// Debug prints a (formatted) debug message
+func (e *Entry) Debug(format string, args ...interface{}) {
+	e.log(logging.DEBUG, format, args)
+}
+
+// Info prints a (formatted) info message
+func (e *Entry) Info(format string, args ...interface{}) {
+	e.log(logging.INFO, format, args)
+}
+
+// Warning prints a (formatted) warning message
+func (e *Entry) Warning(format string, args ...interface{}) {
+	e.log(logging.WARNING, format, args)
+}
+
+// Error prints a (formatted) error message
+func (e *Entry) Error(format string, args ...interface{}) {
+	e.log(logging.ERROR, format, args)
+}
+
+// Fatal prints a (formatted) fatal message
+// followed by an exit call with code 1.
+func (e *Entry) Fatal(format string, args ...interface{}) {
+	e.log(logging.CRITICAL, format, args)
+	os.Exit(1)
+}
+
 // Debug prints a (formatted) debug message
 func Debug(format string, args ...interface{}) {
-	log.Debugf(format, args...)
+	std.log(logging.DEBUG, format, args)
 }
 
 // Info prints a (formatted) info message
 func Info(format string, args ...interface{}) {
-	log.Infof(format, args...)
+	std.log(logging.INFO, format, args)
 }
 
 // Warning prints a (formatted) warning message
 func Warning(format string, args ...interface{}) {
-	log.Warningf(format, args...)
+	std.log(logging.WARNING, format, args)
 }
 
 // Error prints a (formatted) error message
 func Error(format string, args ...interface{}) {
-	log.Errorf(format, args...)
+	std.log(logging.ERROR, format, args)
 }
 
 // Fatal prints a (formatted) fatal message
 // followed by an exit call with code 1.
 func Fatal(format string, args ...interface{}) {
-	log.Fatalf(format, args...)
+	std.Fatal(format, args...)
 }
@@ -0,0 +1,212 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/op/go-logging"
+)
+
+const (
+	// separator between the component and
+	// the message of log messages like
+	// "DATABASE :: initialization"
+	componentSeparator = " :: "
+	// format of record timestamps
+	timeFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+var (
+	// output of structured records
+	out io.Writer = os.Stderr
+	// outMtx guards writes to out so that
+	// records are not interleaved
+	outMtx sync.Mutex
+)
+
+// record describes a structured log record.
+type record struct {
+	time      time.Time
+	level     string
+	component string
+	message   string
+	fields    Fields
+}
+
+// log formats the passed message and writes it
+// with the fields of the entry in the configured
+// output format, if the passed level is enabled.
+func (e *Entry) log(level logging.Level, format string, args []interface{}) {
+	if !log.IsEnabledFor(level) {
+		return
+	}
+
+	msg := fmt.Sprintf(format, args...)
+
+	formatMtx.RLock()
+	f := outputFormat
+	formatMtx.RUnlock()
+
+	if f == FormatText {
+		logText(level, msg, e.fields)
+		return
+	}
+
+	r := &record{
+		time:    time.Now(),
+		level:   strings.ToLower(level.String()),
+		message: msg,
+		fields:  e.fields,
+	}
+	if i := strings.Index(msg, componentSeparator); i > 0 && !strings.Contains(msg[:i], " ") {
+		r.component = msg[:i]
+		r.message = msg[i+len(componentSeparator):]
+	}
+
+	var buf bytes.Buffer
+	if f == FormatJSON {
+		r.encodeJSON(&buf)
+	} else {
+		r.encodeLogfmt(&buf)
+	}
+	buf.WriteByte('\n')
+
+	outMtx.Lock()
+	out.Write(buf.Bytes())
+	outMtx.Unlock()
+}
+
+// logText logs the passed message with the fields
+// appended as logfmt pairs via the go-logging
+// logger using the format set with Setup.
+func logText(level logging.Level, msg string, fields Fields) {
+	if len(fields) > 0 {
+		var buf bytes.Buffer
+		buf.WriteString(msg)
+		for _, k := range sortedKeys(fields) {
+			buf.WriteByte(' ')
+			writeLogfmtPair(&buf, k, fields[k])
+		}
+		msg = buf.String()
+	}
+
+	switch level {
+	case logging.DEBUG:
+		log.Debug(msg)
+	case logging.INFO:
+		log.Info(msg)
+	case logging.WARNING:
+		log.Warning(msg)
+	case logging.ERROR:
+		log.Error(msg)
+	default:
+		log.Critical(msg)
+	}
+}
+
+// encodeJSON writes the record as JSON object to
+// buf. The fields are written after the time,
+// level, component and message in sorted order.
+func (r *record) encodeJSON(buf *bytes.Buffer) {
+	buf.WriteString(`{"time":`)
+	writeJSONValue(buf, r.time.Format(timeFormat))
+	buf.WriteString(`,"level":`)
+	writeJSONValue(buf, r.level)
+	if r.component != "" {
+		buf.WriteString(`,"component":`)
+		writeJSONValue(buf, r.component)
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSONValue(buf, r.message)
+
+	for _, k := range sortedKeys(r.fields) {
+		buf.WriteByte(',')
+		writeJSONValue(buf, k)
+		buf.WriteByte(':')
+		writeJSONValue(buf, fieldValue(r.fields[k]))
+	}
+
+	buf.WriteByte('}')
+}
+
+// encodeLogfmt writes the record as logfmt line
+// to buf in the same order as encodeJSON.
+func (r *record) encodeLogfmt(buf *bytes.Buffer) {
+	writeLogfmtPair(buf, "time", r.time.Format(timeFormat))
+	buf.WriteByte(' ')
+	writeLogfmtPair(buf, "level", r.level)
+	if r.component != "" {
+		buf.WriteByte(' ')
+		writeLogfmtPair(buf, "component", r.component)
+	}
+	buf.WriteByte(' ')
+	writeLogfmtPair(buf, "msg", r.message)
+
+	for _, k := range sortedKeys(r.fields) {
+		buf.WriteByte(' ')
+		writeLogfmtPair(buf, k, r.fields[k])
+	}
+}
+
+// writeJSONValue writes the JSON encoding of v
+// to buf. If v can not be encoded, its string
+// representation is written instead.
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(data)
+}
+
+// writeLogfmtPair writes the passed key and value
+// as key=value pair to buf. The value is quoted
+// if it is empty or contains spaces, quotes,
+// equal signs or non-printable characters.
+func writeLogfmtPair(buf *bytes.Buffer, key string, v interface{}) {
+	s := fmt.Sprint(fieldValue(v))
+
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	if s == "" || strings.IndexFunc(s, needsQuoting) >= 0 {
+		buf.WriteString(strconv.Quote(s))
+	} else {
+		buf.WriteString(s)
+	}
+}
+
+func needsQuoting(r rune) bool {
+	return r == ' ' || r == '"' || r == '=' || !unicode.IsPrint(r)
+}
+
+// fieldValue returns the message of errors and
+// the string representation of Stringers, which
+// are otherwise encoded as empty JSON objects
+// or as their underlying value.
+func fieldValue(v interface{}) interface{} {
+	switch vt := v.(type) {
+	case error:
+		return vt.Error()
+	case fmt.Stringer:
+		return vt.String()
+	}
+	return v
+}
+
+func sortedKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,6 +1,13 @@
 package mailserver
 
 import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/myrunes/backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/gomail.v2"
 )
 
@@ -13,6 +20,10 @@ type Config struct {
 	Password string `json:"password"`
 }
 
+// ErrClosed is returned when sending a mail
+// after the mail server was closed.
+var ErrClosed = errors.New("mail server is closed")
+
 // MailServer provides a connection
 // to a mail-server to send e-mails.
 type MailServer struct {
@@ -20,6 +31,10 @@ type MailServer struct {
 
 	defFrom     string
 	defFromName string
+
+	mtx     sync.RWMutex
+	closed  bool
+	sending sync.WaitGroup
 }
 
 // NewMailServer initializes a new mail server with
@@ -43,11 +58,62 @@ func NewMailServer(config *Config, defFrom, defFromName string) (*MailServer, er
 	return ms, nil
 }
 
+// Ping checks if the mail server is reachable
+// by dialing and closing a connection.
+func (ms *MailServer) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	closer, err := ms.dialer.Dial()
+	if err != nil {
+		return err
+	}
+	return closer.Close()
+}
+
 // SendMailRaw dials the connection to the
 // mail server and sends the passed Message
-// object.
-func (ms *MailServer) SendMailRaw(msg *gomail.Message) error {
-	return ms.dialer.DialAndSend(msg)
+// object. Sending is traced as span of the
+// passed context.
+func (ms *MailServer) SendMailRaw(ctx context.Context, msg *gomail.Message) error {
+	ms.mtx.RLock()
+	if ms.closed {
+		ms.mtx.RUnlock()
+		return ErrClosed
+	}
+	ms.sending.Add(1)
+	ms.mtx.RUnlock()
+	defer ms.sending.Done()
+
+	_, span := tracing.Tracer().Start(ctx, "mailserver.SendMail",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("net.peer.name", ms.dialer.Host),
+			attribute.Int("net.peer.port", ms.dialer.Port)))
+	defer span.End()
+
+	err := ctx.Err()
+	if err == nil {
+		err = ms.dialer.DialAndSend(msg)
+	}
+	tracing.RecordError(span, err)
+
+	return err
+}
+
+// Close stops accepting new mails, so that
+// following sends fail with ErrClosed, and
+// blocks until all mails which are currently
+// sent are finished.
+func (ms *MailServer) Close() error {
+	ms.mtx.Lock()
+	ms.closed = true
+	ms.mtx.Unlock()
+
+	ms.sending.Wait()
+
+	return nil
 }
 
 // SendMail wraps the given data from, fromName, to,
@@ -55,7 +121,7 @@ func (ms *MailServer) SendMailRaw(msg *gomail.Message) error {
 // which is then sent via SendMailRaw.
 // If from and fromName is empty, the defFrom and
 // defFromName will be used instead.
-func (ms *MailServer) SendMail(from, fromName, to, subject, body, bodyType string) error {
+func (ms *MailServer) SendMail(ctx context.Context, from, fromName, to, subject, body, bodyType string) error {
 	if from == "" {
 		from = ms.defFrom
 	}
@@ -69,12 +135,12 @@ func (ms *MailServer) SendMail(from, fromName, to, subject, body, bodyType strin
 	msg.SetAddressHeader("To", to, "")
 	msg.SetHeader("Subject", subject)
 	msg.SetBody(bodyType, body)
-	return ms.SendMailRaw(msg)
+	return ms.SendMailRaw(ctx, msg)
 }
 
 // SendMailFromDef is shorthand for SendMail
 // with defFrom and defFromName as sender
 // specifications.
-func (ms *MailServer) SendMailFromDef(to, subject, body, bodyType string) error {
-	return ms.SendMail("", "", to, subject, body, bodyType)
+func (ms *MailServer) SendMailFromDef(ctx context.Context, to, subject, body, bodyType string) error {
+	return ms.SendMail(ctx, "", "", to, subject, body, bodyType)
 }
@@ -0,0 +1,109 @@
+package mailserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// newTestMailServer returns a mail server sending
+// to a listener which accepts connections but holds
+// back the greeting until release is closed, so
+// that sends are in flight until then.
+func newTestMailServer(t *testing.T) (ms *MailServer, accepted <-chan struct{}, release chan<- struct{}) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	cAccepted := make(chan struct{}, 1)
+	cRelease := make(chan struct{})
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		cAccepted <- struct{}{}
+		<-cRelease
+		conn.Write([]byte("421 service not available\r\n"))
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	ms = &MailServer{
+		dialer:  gomail.NewDialer(addr.IP.String(), addr.Port, "", ""),
+		defFrom: "noreply@myrunes.com",
+	}
+
+	return ms, cAccepted, cRelease
+}
+
+func TestCloseWaitsForSentMails(t *testing.T) {
+	ms, accepted, release := newTestMailServer(t)
+
+	cSent := make(chan error, 1)
+	go func() {
+		cSent <- ms.SendMailFromDef(context.Background(), "user@example.com", "subject", "body", "text/plain")
+	}()
+
+	select {
+	case <-accepted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("mail server was not dialed")
+	}
+
+	cClosed := make(chan error, 1)
+	go func() {
+		cClosed <- ms.Close()
+	}()
+
+	select {
+	case <-cClosed:
+		t.Fatal("close returned before the mail was sent")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case err := <-cClosed:
+		if err != nil {
+			t.Errorf("close failed: %s", err.Error())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("close did not return after the mail was sent")
+	}
+
+	if err := <-cSent; err == nil {
+		t.Error("expected sending to fail with the rejecting server")
+	}
+}
+
+func TestSendAfterClose(t *testing.T) {
+	ms, _, _ := newTestMailServer(t)
+
+	if err := ms.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ms.SendMailFromDef(context.Background(), "user@example.com", "subject", "body", "text/plain")
+	if err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestSendCancelledContext(t *testing.T) {
+	ms := &MailServer{dialer: gomail.NewDialer("127.0.0.1", 1, "", "")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := ms.SendMailFromDef(ctx, "user@example.com", "subject", "body", "text/plain"); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+// Package metrics defines the metrics collected
+// by the backend and serves them in the Prometheus
+// text exposition format.
+package metrics
+
+import (
+	"math"
+	"time"
+
+	"github.com/myrunes/backend/internal/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Label values of results.
+const (
+	ResultHit     = "hit"
+	ResultMiss    = "miss"
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+var (
+	// HTTPRequests counts the handled HTTP requests
+	// by route, method and response status code.
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "myrunes_http_requests_total",
+		Help: "Number of handled HTTP requests.",
+	}, []string{"route", "method", "status"})
+	// HTTPRequestDuration observes the handling duration
+	// of HTTP requests by route, method and response
+	// status code.
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "myrunes_http_request_duration_seconds",
+		Help:    "Duration of handling HTTP requests in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+	// RateLimitRejections counts the requests
+	// rejected by rate limiters by bucket.
+	RateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "myrunes_ratelimit_rejections_total",
+		Help: "Number of requests rejected by rate limiters.",
+	}, []string{"bucket"})
+	// CacheRequests counts the cache lookups
+	// by cache section and result.
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "myrunes_cache_requests_total",
+		Help: "Number of cache lookups by result.",
+	}, []string{"cache", "result"})
+	// DatabaseCallDuration observes the duration
+	// of database calls by method.
+	DatabaseCallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "myrunes_database_call_duration_seconds",
+		Help:    "Duration of database calls in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+	// DDragonRefreshes counts the refreshes of
+	// the ddragon data by result.
+	DDragonRefreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "myrunes_ddragon_refreshes_total",
+		Help: "Number of ddragon data refreshes by result.",
+	}, []string{"result"})
+	// AssetFetchFailures counts the failed
+	// fetches of assets by asset type.
+	AssetFetchFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "myrunes_asset_fetch_failures_total",
+		Help: "Number of failed asset fetches.",
+	}, []string{"type"})
+)
+
+// ObserveCache records a lookup in the passed
+// cache section which was a hit if hit is true.
+func ObserveCache(cache string, hit bool) {
+	if hit {
+		CacheRequests.WithLabelValues(cache, ResultHit).Inc()
+	} else {
+		CacheRequests.WithLabelValues(cache, ResultMiss).Inc()
+	}
+}
+
+// Result returns ResultSuccess if the
+// passed error is nil, otherwise
+// ResultFailure.
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
+
+// Since returns the time elapsed since
+// the passed time in seconds.
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
+
+// RegisterActiveRefreshTokens registers the gauge of
+// refresh tokens which are not expired. Its value is
+// obtained by calling the passed function on each
+// scrape.
+func RegisterActiveRefreshTokens(count func() (int, error)) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "myrunes_refresh_tokens_active",
+		Help: "Number of refresh tokens which are not expired.",
+	}, func() float64 {
+		n, err := count()
+		if err != nil {
+			logger.Error("METRICS :: failed counting refresh tokens: %s", err.Error())
+			return math.NaN()
+		}
+		return float64(n)
+	})
+}
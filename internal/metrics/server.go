@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// path metrics are served on
+const metricsPath = "/metrics"
+
+// maximum duration keep alive connections
+// of scrapers are kept open waiting for
+// the next request
+const idleTimeout = 15 * time.Second
+
+const bearerPrefix = "Bearer "
+
+// Config wraps properties for
+// the metrics server.
+type Config struct {
+	Addr  string `json:"addr"`
+	Token string `json:"token"`
+}
+
+// Server serves the metrics of the default
+// Prometheus registry on /metrics on a separate
+// listener, so that access can be restricted by
+// the bind address. If a token is configured, it
+// must be passed as bearer token in the
+// Authorization header.
+type Server struct {
+	server *http.Server
+	config *Config
+}
+
+// NewServer creates a new instance of
+// Server using the passed config.
+func NewServer(config *Config) *Server {
+	s := &Server{config: config}
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, s.authorize(promhttp.Handler()))
+
+	s.server = &http.Server{
+		Addr:        config.Addr,
+		Handler:     mux,
+		IdleTimeout: idleTimeout,
+	}
+
+	return s
+}
+
+// ListenAndServeBlocking starts listening on the
+// configured address and serving the metrics,
+// which blocks the current goroutine.
+func (s *Server) ListenAndServeBlocking() error {
+	err := s.server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting new connections and
+// blocks until all in-flight requests are finished
+// or the passed context is done. In the latter
+// case, the error of the context is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// authorize wraps the passed handler, responding
+// with 405 to requests using other methods than GET
+// and with 401 to requests which are not authorized.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.authorized(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized returns true if no token is configured
+// or if the passed request contains the configured
+// token as bearer token.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.config.Token == "" {
+		return true
+	}
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, bearerPrefix) {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(auth[len(bearerPrefix):]), []byte(s.config.Token)) == 1
+}
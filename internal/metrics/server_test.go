@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(s *Server, method, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, metricsPath, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	s.server.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServerAuthorization(t *testing.T) {
+	cases := []struct {
+		name          string
+		token         string
+		method        string
+		authorization string
+		status        int
+	}{
+		{"no token configured", "", "GET", "", http.StatusOK},
+		{"valid token", "secret", "GET", "Bearer secret", http.StatusOK},
+		{"missing token", "secret", "GET", "", http.StatusUnauthorized},
+		{"wrong token", "secret", "GET", "Bearer wrong", http.StatusUnauthorized},
+		{"no bearer token", "secret", "GET", "Basic secret", http.StatusUnauthorized},
+		{"wrong method", "", "POST", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, c := range cases {
+		s := NewServer(&Config{Token: c.token})
+		if rec := scrape(s, c.method, c.authorization); rec.Code != c.status {
+			t.Errorf("%s: expected status %d, got %d", c.name, c.status, rec.Code)
+		}
+	}
+}
+
+func TestServerExposesMetrics(t *testing.T) {
+	HTTPRequests.WithLabelValues("/test", "GET", "200").Inc()
+	ObserveCache("test", true)
+
+	rec := scrape(NewServer(&Config{}), "GET", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, sample := range []string{
+		`myrunes_http_requests_total{method="GET",route="/test",status="200"} 1`,
+		`myrunes_cache_requests_total{cache="test",result="hit"} 1`,
+		"# TYPE go_goroutines gauge",
+	} {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected exposition to contain %q", sample)
+		}
+	}
+}
+
+func TestServerNotFound(t *testing.T) {
+	s := NewServer(&Config{})
+	rec := httptest.NewRecorder()
+	s.server.Handler.ServeHTTP(rec, httptest.NewRequest("GET", "/other", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,222 @@
+package objects
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// maximum number of entries listed
+// per category of champion stats
+const championStatsMaxEntries = 10
+
+// ChampionStats wraps the aggregated rune
+// statistics of all pages of a champion.
+type ChampionStats struct {
+	Champion       string                  `json:"champion"`
+	Pages          int                     `json:"pages"`
+	Updated        time.Time               `json:"updated"`
+	Keystones      []*RuneStatsEntry       `json:"keystones"`
+	SecondaryTrees []*RuneStatsEntry       `json:"secondarytrees"`
+	StatShards     []*StatShardsStatsEntry `json:"statshards"`
+}
+
+// RuneStatsEntry wraps the number of pages
+// using a rune or rune tree and the ratio
+// to the total number of pages.
+type RuneStatsEntry struct {
+	UID   string  `json:"uid"`
+	Pages int     `json:"pages"`
+	Ratio float64 `json:"ratio"`
+}
+
+// StatShardsStatsEntry wraps the number of
+// pages using a stat shard combination and
+// the ratio to the total number of pages.
+type StatShardsStatsEntry struct {
+	Rows  [3]string `json:"rows"`
+	Pages int       `json:"pages"`
+	Ratio float64   `json:"ratio"`
+}
+
+// ChampionStatsAggregator accumulates rune pages
+// to anonymized per champion statistics.
+//
+// Champions and entries are only included in the
+// results if the pages counted for them belong to
+// at least minContributors different owners, so
+// that the selection of single users can not be
+// derived from the results.
+type ChampionStatsAggregator struct {
+	minContributors int
+	champions       map[string]*championStatsCounter
+}
+
+// championStatsCounter counts the pages and
+// the entries of a single champion.
+type championStatsCounter struct {
+	statsCounter
+	keystones      map[string]*statsCounter
+	secondaryTrees map[string]*statsCounter
+	statShards     map[string]*statsCounter
+}
+
+// statsCounter counts pages and the
+// distinct owners of the pages.
+type statsCounter struct {
+	pages  int
+	owners map[snowflake.ID]struct{}
+}
+
+// NewEmptyChampionStats returns a ChampionStats
+// object of the passed champion without any
+// pages counted.
+func NewEmptyChampionStats(champion string) *ChampionStats {
+	return &ChampionStats{
+		Champion:       champion,
+		Keystones:      make([]*RuneStatsEntry, 0),
+		SecondaryTrees: make([]*RuneStatsEntry, 0),
+		StatShards:     make([]*StatShardsStatsEntry, 0),
+	}
+}
+
+// NewChampionStatsAggregator creates a new
+// ChampionStatsAggregator with the passed
+// minimum number of distinct page owners
+// per champion and entry.
+func NewChampionStatsAggregator(minContributors int) *ChampionStatsAggregator {
+	return &ChampionStatsAggregator{
+		minContributors: minContributors,
+		champions:       make(map[string]*championStatsCounter),
+	}
+}
+
+// Add counts the passed page for all
+// champions assigned to the page.
+// Incomplete pages are skipped.
+func (a *ChampionStatsAggregator) Add(page *Page) {
+	if page.Primary == nil || page.Secondary == nil || page.Perks == nil {
+		return
+	}
+
+	seen := make(map[string]struct{})
+	for _, champ := range page.Champions {
+		if _, ok := seen[champ]; ok {
+			continue
+		}
+		seen[champ] = struct{}{}
+
+		c, ok := a.champions[champ]
+		if !ok {
+			c = &championStatsCounter{
+				keystones:      make(map[string]*statsCounter),
+				secondaryTrees: make(map[string]*statsCounter),
+				statShards:     make(map[string]*statsCounter),
+			}
+			a.champions[champ] = c
+		}
+
+		c.add(page.Owner)
+		countStats(c.keystones, page.Primary.Rows[0], page.Owner)
+		countStats(c.secondaryTrees, page.Secondary.Tree, page.Owner)
+		countStats(c.statShards, strings.Join(page.Perks.Rows[:], ","), page.Owner)
+	}
+}
+
+// Results returns the ChampionStats of all
+// champions with enough distinct page owners.
+func (a *ChampionStatsAggregator) Results() []*ChampionStats {
+	now := time.Now()
+	res := make([]*ChampionStats, 0, len(a.champions))
+
+	for champ, c := range a.champions {
+		if len(c.owners) < a.minContributors {
+			continue
+		}
+
+		stats := NewEmptyChampionStats(champ)
+		stats.Pages = c.pages
+		stats.Updated = now
+
+		for _, k := range a.topKeys(c.keystones) {
+			stats.Keystones = append(stats.Keystones, &RuneStatsEntry{
+				UID:   k,
+				Pages: c.keystones[k].pages,
+				Ratio: float64(c.keystones[k].pages) / float64(c.pages),
+			})
+		}
+
+		for _, k := range a.topKeys(c.secondaryTrees) {
+			stats.SecondaryTrees = append(stats.SecondaryTrees, &RuneStatsEntry{
+				UID:   k,
+				Pages: c.secondaryTrees[k].pages,
+				Ratio: float64(c.secondaryTrees[k].pages) / float64(c.pages),
+			})
+		}
+
+		for _, k := range a.topKeys(c.statShards) {
+			entry := &StatShardsStatsEntry{
+				Pages: c.statShards[k].pages,
+				Ratio: float64(c.statShards[k].pages) / float64(c.pages),
+			}
+			copy(entry.Rows[:], strings.Split(k, ","))
+			stats.StatShards = append(stats.StatShards, entry)
+		}
+
+		res = append(res, stats)
+	}
+
+	return res
+}
+
+// topKeys returns the keys of the passed counters
+// with enough distinct page owners sorted by their
+// page counts, limited to championStatsMaxEntries.
+func (a *ChampionStatsAggregator) topKeys(counters map[string]*statsCounter) []string {
+	keys := make([]string, 0, len(counters))
+	for k, c := range counters {
+		if len(c.owners) >= a.minContributors {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		ci, cj := counters[keys[i]], counters[keys[j]]
+		if ci.pages == cj.pages {
+			return keys[i] < keys[j]
+		}
+		return ci.pages > cj.pages
+	})
+
+	if len(keys) > championStatsMaxEntries {
+		keys = keys[:championStatsMaxEntries]
+	}
+
+	return keys
+}
+
+// add counts a page of the passed owner.
+func (c *statsCounter) add(owner snowflake.ID) {
+	if c.owners == nil {
+		c.owners = make(map[snowflake.ID]struct{})
+	}
+	c.pages++
+	c.owners[owner] = struct{}{}
+}
+
+// countStats counts a page of the passed owner
+// to the counter of the passed key in counters.
+func countStats(counters map[string]*statsCounter, key string, owner snowflake.ID) {
+	if key == "" {
+		return
+	}
+
+	c, ok := counters[key]
+	if !ok {
+		c = new(statsCounter)
+		counters[key] = c
+	}
+	c.add(owner)
+}
@@ -0,0 +1,69 @@
+package objects
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/myrunes/backend/internal/static"
+)
+
+// folderIDNode is the node to generate folder snowflake IDs.
+var folderIDNode, _ = snowflake.NewNode(static.NodeIDFolders)
+
+// maximum length of folder names
+const folderNameMaxLen = 64
+
+var (
+	ErrInvalidFolderName = errors.New("invalid folder name")
+)
+
+// Folder describes a folder which pages of
+// a user or a team can be organized in.
+// Folders can be nested by setting the
+// UID of another folder as Parent. Root
+// folders have no parent.
+type Folder struct {
+	UID     snowflake.ID `json:"uid"`
+	Owner   snowflake.ID `json:"owner"`
+	Parent  snowflake.ID `json:"parent,omitempty"`
+	Name    string       `json:"name"`
+	Created time.Time    `json:"created"`
+}
+
+// NewFolder creates a new Folder object owned
+// by the passed owner with the passed name and
+// parent folder and a generated UID.
+func NewFolder(owner snowflake.ID, name string, parent snowflake.ID) (*Folder, error) {
+	folder := &Folder{
+		UID:     folderIDNode.Generate(),
+		Owner:   owner,
+		Parent:  parent,
+		Created: time.Now(),
+	}
+
+	if err := folder.SetName(name); err != nil {
+		return nil, err
+	}
+
+	return folder, nil
+}
+
+// SetName sets the trimmed passed name as
+// folder name, if it is valid.
+func (f *Folder) SetName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > folderNameMaxLen {
+		return ErrInvalidFolderName
+	}
+
+	f.Name = name
+	return nil
+}
+
+// IsRoot returns true if the folder
+// has no parent folder.
+func (f *Folder) IsRoot() bool {
+	return f.Parent == 0
+}
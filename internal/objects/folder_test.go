@@ -0,0 +1,33 @@
+package objects
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewFolder(t *testing.T) {
+	folder, err := NewFolder(1, "  builds  ", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if folder.Name != "builds" || folder.Owner != 1 || folder.UID == 0 {
+		t.Errorf("unexpected folder %+v", folder)
+	}
+	if !folder.IsRoot() {
+		t.Error("expected folder without parent to be a root folder")
+	}
+
+	child, err := NewFolder(1, "ranked", folder.UID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if child.IsRoot() || child.Parent != folder.UID {
+		t.Errorf("unexpected child folder %+v", child)
+	}
+
+	for _, name := range []string{"", "   ", strings.Repeat("a", folderNameMaxLen+1)} {
+		if _, err = NewFolder(1, name, 0); err != ErrInvalidFolderName {
+			t.Errorf("%q: expected ErrInvalidFolderName, got %v", name, err)
+		}
+	}
+}
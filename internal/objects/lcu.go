@@ -0,0 +1,67 @@
+package objects
+
+import "github.com/myrunes/backend/pkg/ddragon"
+
+// PerkIDs maps the perks of the PerksPool
+// to their perk IDs in the League client.
+var PerkIDs = map[string]int{
+	"heart":   5001,
+	"shield":  5002,
+	"circle":  5003,
+	"axe":     5005,
+	"time":    5007,
+	"diamond": 5008,
+}
+
+// LCUPage describes a rune page in the format
+// used by the perks API of the League client,
+// where trees are referenced as styles and
+// runes and perks by their numeric IDs.
+type LCUPage struct {
+	Name            string `json:"name"`
+	PrimaryStyleID  int    `json:"primaryStyleId"`
+	SubStyleID      int    `json:"subStyleId"`
+	SelectedPerkIDs []int  `json:"selectedPerkIds"`
+}
+
+// LCU returns the page in the format of the
+// League client. Selected perk IDs are ordered
+// by the rows of the primary tree, the secondary
+// tree and the perks. If any tree, rune or perk
+// of the page is unknown, nil is returned.
+func (p *Page) LCU() *LCUPage {
+	if p.Primary == nil || p.Secondary == nil || p.Perks == nil {
+		return nil
+	}
+
+	dd := ddragon.DDragonInstance
+	primary := dd.GetRuneTree(p.Primary.Tree)
+	secondary := dd.GetRuneTree(p.Secondary.Tree)
+	if primary == nil || secondary == nil {
+		return nil
+	}
+
+	runes := append(p.Primary.Rows[:], p.Secondary.Rows[:]...)
+	ids := make([]int, 0, len(runes)+len(p.Perks.Rows))
+	for _, uid := range runes {
+		r := dd.GetRune(uid)
+		if r == nil {
+			return nil
+		}
+		ids = append(ids, r.ID)
+	}
+	for _, perk := range p.Perks.Rows {
+		id, ok := PerkIDs[perk]
+		if !ok {
+			return nil
+		}
+		ids = append(ids, id)
+	}
+
+	return &LCUPage{
+		Name:            p.Title,
+		PrimaryStyleID:  primary.ID,
+		SubStyleID:      secondary.ID,
+		SelectedPerkIDs: ids,
+	}
+}
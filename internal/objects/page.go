@@ -2,9 +2,11 @@ package objects
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/myrunes/backend/pkg/ddragon"
+	"github.com/myrunes/backend/pkg/markdown"
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/myrunes/backend/internal/static"
@@ -13,14 +15,60 @@ import (
 // pageIDNode is the node to generate page snowflake IDs.
 var pageIDNode, _ = snowflake.NewNode(static.NodeIDPages)
 
+const (
+	// maximum number of tags per page
+	pageTagsMax = 16
+	// maximum length of a single tag
+	pageTagMaxLen = 32
+	// maximum number of opponent
+	// champions per page
+	pageAgainstMax = 10
+	// maximum length of page notes
+	pageNotesMaxLen = 10000
+	// maximum length of a single
+	// rune annotation
+	pageAnnotationMaxLen = 500
+)
+
+// Roles a page can be assigned to.
+const (
+	RoleTop     = "top"
+	RoleJungle  = "jungle"
+	RoleMid     = "mid"
+	RoleBot     = "bot"
+	RoleSupport = "support"
+)
+
+// Game modes a page can be assigned to.
+const (
+	ModeClassic    = "classic"
+	ModeARAM       = "aram"
+	ModeURF        = "urf"
+	ModeOneForAll  = "oneforall"
+	ModeNexusBlitz = "nexusblitz"
+)
+
+// PageRoles contains all valid page roles.
+var PageRoles = []string{RoleTop, RoleJungle, RoleMid, RoleBot, RoleSupport}
+
+// PageModes contains all valid page game modes.
+var PageModes = []string{ModeClassic, ModeARAM, ModeURF, ModeOneForAll, ModeNexusBlitz}
+
 var (
 	ErrInvalidChamp = errors.New("invalid champion")
+	ErrInvalidTag   = errors.New("invalid tag")
+	ErrInvalidRole  = errors.New("invalid role")
+	ErrInvalidMode  = errors.New("invalid game mode")
 
-	errInvalidTree    = errors.New("invalid tree")
-	errInvalidPriRune = errors.New("invalid primary rune")
-	errInvalidSecRune = errors.New("invalid secondary rune")
-	errInvalidPerk    = errors.New("invalid perk")
-	errInvalidTitle   = errors.New("invalid title")
+	errInvalidTree       = errors.New("invalid tree")
+	errInvalidPriRune    = errors.New("invalid primary rune")
+	errInvalidSecRune    = errors.New("invalid secondary rune")
+	errInvalidPerk       = errors.New("invalid perk")
+	errInvalidTitle      = errors.New("invalid title")
+	errTooManyTags       = errors.New("too many tags")
+	errTooManyAgainst    = errors.New("too many opponent champions")
+	errNotesTooLong      = errors.New("notes too long")
+	errInvalidAnnotation = errors.New("invalid rune annotation")
 )
 
 // PerksPool describes the matrix of
@@ -35,15 +83,31 @@ var PerksPool = [][]string{
 // and the selection of runes and
 // perks for this page.
 type Page struct {
-	UID       snowflake.ID   `json:"uid"`
-	Owner     snowflake.ID   `json:"owner"`
-	Title     string         `json:"title"`
-	Created   time.Time      `json:"created"`
-	Edited    time.Time      `json:"edited"`
-	Champions []string       `json:"champions"`
-	Primary   *PrimaryTree   `json:"primary"`
-	Secondary *SecondaryTree `json:"secondary"`
-	Perks     *Perks         `json:"perks"`
+	UID         snowflake.ID      `json:"uid"`
+	Owner       snowflake.ID      `json:"owner"`
+	Title       string            `json:"title"`
+	Created     time.Time         `json:"created"`
+	Edited      time.Time         `json:"edited"`
+	Champions   []string          `json:"champions"`
+	Primary     *PrimaryTree      `json:"primary"`
+	Secondary   *SecondaryTree    `json:"secondary"`
+	Perks       *Perks            `json:"perks"`
+	Published   bool              `json:"published"`
+	ForkedFrom  *PageOrigin       `json:"forkedfrom,omitempty"`
+	Tags        []string          `json:"tags"`
+	Folder      snowflake.ID      `json:"folder,omitempty"`
+	Role        string            `json:"role,omitempty"`
+	Against     []string          `json:"against,omitempty"`
+	Mode        string            `json:"mode,omitempty"`
+	Notes       string            `json:"notes,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// PageOrigin references the original page
+// and its author a page was forked from.
+type PageOrigin struct {
+	PageID snowflake.ID `json:"page"`
+	Author snowflake.ID `json:"author"`
 }
 
 // PrimaryTree holds the tree type
@@ -75,6 +139,7 @@ type Perks struct {
 func NewEmptyPage() *Page {
 	return &Page{
 		Champions: make([]string, 0),
+		Tags:      make([]string, 0),
 		Primary: &PrimaryTree{
 			Rows: [4]string{},
 		},
@@ -98,6 +163,25 @@ func (p *Page) Validate() error {
 		return errInvalidTitle
 	}
 
+	// Sanitize and check notes
+	p.Notes = markdown.Sanitize(p.Notes)
+	if len(p.Notes) > pageNotesMaxLen {
+		return errNotesTooLong
+	}
+
+	// Check and normalize tags
+	if err := p.normalizeTags(); err != nil {
+		return err
+	}
+
+	// Check role and game mode
+	if p.Role != "" && !IsValidRole(p.Role) {
+		return ErrInvalidRole
+	}
+	if p.Mode != "" && !IsValidMode(p.Mode) {
+		return ErrInvalidMode
+	}
+
 	// Check if primary and secondary tree are the same,
 	// which is not allowed
 	if p.Secondary.Tree == p.Primary.Tree {
@@ -162,6 +246,23 @@ func (p *Page) Validate() error {
 		return errInvalidSecRune
 	}
 
+	// Check if annotations are only set for
+	// selected runes and sanitize them
+	for uid, annotation := range p.Annotations {
+		if !p.HasRune(uid) {
+			return errInvalidAnnotation
+		}
+		annotation = markdown.Sanitize(annotation)
+		if len(annotation) > pageAnnotationMaxLen {
+			return errInvalidAnnotation
+		}
+		if annotation == "" {
+			delete(p.Annotations, uid)
+		} else {
+			p.Annotations[uid] = annotation
+		}
+	}
+
 	// Check perks
 	for i, row := range p.Perks.Rows {
 		var exists bool
@@ -201,18 +302,93 @@ func (p *Page) Validate() error {
 
 	p.Champions = champs
 
+	// Check if opponent champions exist by
+	// their champion UIDs
+	against := make([]string, 0, len(p.Against))
+	seen := make(map[string]struct{})
+	for _, champ := range p.Against {
+		if ddragon.DDragonInstance.GetChampion(champ) == nil {
+			return ErrInvalidChamp
+		}
+		if _, ok := seen[champ]; !ok {
+			seen[champ] = struct{}{}
+			against = append(against, champ)
+		}
+	}
+
+	if len(against) > pageAgainstMax {
+		return errTooManyAgainst
+	}
+
+	p.Against = against
+
 	return nil
 }
 
 // FinalizeCreate sets final values of
 // the page like the UID, the owner ID,
 // creation date and last edit date.
+// A fork origin set by the client is
+// discarded.
 func (p *Page) FinalizeCreate(owner snowflake.ID) {
 	now := time.Now()
 	p.UID = pageIDNode.Generate()
 	p.Owner = owner
 	p.Created = now
 	p.Edited = now
+	p.ForkedFrom = nil
+}
+
+// Copy returns a copy of the rune selection,
+// the title and the champions of the page
+// without any identifying values.
+func (p *Page) Copy() *Page {
+	c := &Page{
+		Title:     p.Title,
+		Champions: append([]string{}, p.Champions...),
+		Tags:      append([]string{}, p.Tags...),
+		Role:      p.Role,
+		Against:   append([]string{}, p.Against...),
+		Mode:      p.Mode,
+		Notes:     p.Notes,
+	}
+
+	if p.Annotations != nil {
+		c.Annotations = make(map[string]string, len(p.Annotations))
+		for uid, annotation := range p.Annotations {
+			c.Annotations[uid] = annotation
+		}
+	}
+
+	if p.Primary != nil {
+		primary := *p.Primary
+		c.Primary = &primary
+	}
+	if p.Secondary != nil {
+		secondary := *p.Secondary
+		c.Secondary = &secondary
+	}
+	if p.Perks != nil {
+		perks := *p.Perks
+		c.Perks = &perks
+	}
+
+	return c
+}
+
+// Fork creates a copy of the page owned
+// by the passed owner which references
+// the page and its owner as origin.
+func (p *Page) Fork(owner snowflake.ID) *Page {
+	fork := p.Copy()
+
+	fork.FinalizeCreate(owner)
+	fork.ForkedFrom = &PageOrigin{
+		PageID: p.UID,
+		Author: p.Owner,
+	}
+
+	return fork
 }
 
 // Update sets mutable data to the
@@ -228,4 +404,134 @@ func (p *Page) Update(newPage *Page) {
 	p.Perks = newPage.Perks
 	p.Primary = newPage.Primary
 	p.Secondary = newPage.Secondary
+	p.Published = newPage.Published
+	p.Tags = newPage.Tags
+	p.Folder = newPage.Folder
+	p.Role = newPage.Role
+	p.Against = newPage.Against
+	p.Mode = newPage.Mode
+	p.Notes = newPage.Notes
+	p.Annotations = newPage.Annotations
+}
+
+// HasRune returns true if the rune with the
+// passed UID is selected in the primary or
+// secondary tree of the page.
+func (p *Page) HasRune(uid string) bool {
+	return (p.Primary != nil && contains(p.Primary.Rows[:], uid)) ||
+		(p.Secondary != nil && contains(p.Secondary.Rows[:], uid))
+}
+
+// HasChampion returns true if the page
+// is linked to the passed champion.
+func (p *Page) HasChampion(champion string) bool {
+	return contains(p.Champions, champion)
+}
+
+// IsAgainst returns true if the page is
+// set up against the passed champion.
+func (p *Page) IsAgainst(champion string) bool {
+	return contains(p.Against, champion)
+}
+
+// MoveToFolder moves the page into the
+// folder with the passed UID. A UID of 0
+// moves the page to the root.
+func (p *Page) MoveToFolder(folder snowflake.ID) {
+	p.Folder = folder
+	p.Edited = time.Now()
+}
+
+// HasTag returns true if the page is
+// tagged with the passed tag.
+func (p *Page) HasTag(tag string) bool {
+	return contains(p.Tags, tag)
+}
+
+// RenameTag replaces the tag from with the tag
+// to. If the page is already tagged with to,
+// both tags are merged. The returned bool is
+// true if the page was tagged with from.
+func (p *Page) RenameTag(from, to string) bool {
+	if !p.HasTag(from) {
+		return false
+	}
+
+	tags := make([]string, 0, len(p.Tags))
+	for _, t := range p.Tags {
+		if t != from && t != to {
+			tags = append(tags, t)
+		}
+	}
+
+	p.Tags = append(tags, to)
+	p.Edited = time.Now()
+
+	return true
+}
+
+// normalizeTags normalizes all tags of
+// the page and removes duplicates. If
+// a tag is invalid or the page has too
+// many tags, an error is returned.
+func (p *Page) normalizeTags() error {
+	tags := make([]string, 0, len(p.Tags))
+	seen := make(map[string]struct{})
+
+	for _, t := range p.Tags {
+		t = NormalizeTag(t)
+		if !IsValidTag(t) {
+			return ErrInvalidTag
+		}
+		if _, ok := seen[t]; !ok {
+			seen[t] = struct{}{}
+			tags = append(tags, t)
+		}
+	}
+
+	if len(tags) > pageTagsMax {
+		return errTooManyTags
+	}
+
+	p.Tags = tags
+
+	return nil
+}
+
+// IsValidRole returns true if the
+// passed role is a valid page role.
+func IsValidRole(role string) bool {
+	return contains(PageRoles, role)
+}
+
+// IsValidMode returns true if the passed
+// mode is a valid page game mode.
+func IsValidMode(mode string) bool {
+	return contains(PageModes, mode)
+}
+
+// IsValidTag returns true if the passed
+// normalized tag is not empty and does
+// not exceed the maximum tag length.
+func IsValidTag(tag string) bool {
+	return tag != "" && len([]rune(tag)) <= pageTagMaxLen
+}
+
+// NormalizeTag returns the passed tag in
+// lower case with leading and trailing
+// spaces removed and inner white spaces
+// collapsed into single spaces.
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
+// contains returns true if the passed
+// slice contains v.
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
 }
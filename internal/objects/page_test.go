@@ -0,0 +1,145 @@
+package objects
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/myrunes/backend/pkg/ddragon"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	cases := []struct {
+		tag string
+		exp string
+	}{
+		{"mid", "mid"},
+		{"  Mid ", "mid"},
+		{"Solo   Queue", "solo queue"},
+		{"\tone\n two ", "one two"},
+		{"   ", ""},
+	}
+
+	for _, c := range cases {
+		if res := NormalizeTag(c.tag); res != c.exp {
+			t.Errorf("%q: expected %q, got %q", c.tag, c.exp, res)
+		}
+	}
+}
+
+func TestPageValidateTags(t *testing.T) {
+	setupTestDDragon()
+
+	page := newTestPage()
+	page.Tags = []string{"Mid", " mid ", "Solo  Queue", "solo queue", "ranked"}
+	if err := page.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"mid", "solo queue", "ranked"}; !reflect.DeepEqual(page.Tags, exp) {
+		t.Errorf("expected tags %v, got %v", exp, page.Tags)
+	}
+
+	page = newTestPage()
+	page.Tags = []string{"mid", "  "}
+	if err := page.Validate(); err != ErrInvalidTag {
+		t.Errorf("expected ErrInvalidTag for empty tag, got %v", err)
+	}
+
+	page = newTestPage()
+	page.Tags = []string{strings.Repeat("a", pageTagMaxLen+1)}
+	if err := page.Validate(); err != ErrInvalidTag {
+		t.Errorf("expected ErrInvalidTag for long tag, got %v", err)
+	}
+
+	page = newTestPage()
+	page.Tags = make([]string, 0, pageTagsMax+1)
+	for i := 0; i <= pageTagsMax; i++ {
+		page.Tags = append(page.Tags, fmt.Sprintf("tag %d", i))
+	}
+	if err := page.Validate(); err != errTooManyTags {
+		t.Errorf("expected errTooManyTags, got %v", err)
+	}
+
+	// Duplicates do not count against the limit.
+	page = newTestPage()
+	page.Tags = make([]string, 0, pageTagsMax*2)
+	for i := 0; i < pageTagsMax; i++ {
+		page.Tags = append(page.Tags, fmt.Sprintf("tag %d", i), fmt.Sprintf("TAG %d", i))
+	}
+	if err := page.Validate(); err != nil {
+		t.Errorf("expected duplicates to be merged, got %v", err)
+	}
+}
+
+func TestPageRenameTag(t *testing.T) {
+	page := newTestPage()
+	page.Tags = []string{"mid", "ranked"}
+
+	if page.RenameTag("top", "bot") {
+		t.Error("expected no rename of missing tag")
+	}
+	if exp := []string{"mid", "ranked"}; !reflect.DeepEqual(page.Tags, exp) {
+		t.Errorf("expected tags %v, got %v", exp, page.Tags)
+	}
+
+	if !page.RenameTag("mid", "middle") {
+		t.Error("expected tag to be renamed")
+	}
+	if exp := []string{"ranked", "middle"}; !reflect.DeepEqual(page.Tags, exp) {
+		t.Errorf("expected tags %v, got %v", exp, page.Tags)
+	}
+
+	// Renaming into an existing tag merges both.
+	if !page.RenameTag("middle", "ranked") {
+		t.Error("expected tag to be merged")
+	}
+	if exp := []string{"ranked"}; !reflect.DeepEqual(page.Tags, exp) {
+		t.Errorf("expected tags %v, got %v", exp, page.Tags)
+	}
+}
+
+func TestPageValidateMetadata(t *testing.T) {
+	setupTestDDragon()
+
+	page := newTestPage()
+	page.Role = RoleMid
+	page.Mode = ModeARAM
+	page.Against = []string{"jinx", "lux", "jinx"}
+	if err := page.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"jinx", "lux"}; !reflect.DeepEqual(page.Against, exp) {
+		t.Errorf("expected opponents %v, got %v", exp, page.Against)
+	}
+
+	cases := []struct {
+		name string
+		edit func(p *Page)
+		exp  error
+	}{
+		{"invalid role", func(p *Page) { p.Role = "middle" }, ErrInvalidRole},
+		{"upper case role", func(p *Page) { p.Role = "MID" }, ErrInvalidRole},
+		{"invalid mode", func(p *Page) { p.Mode = "ranked" }, ErrInvalidMode},
+		{"invalid opponent", func(p *Page) { p.Against = []string{"jinx", "unknown"} }, ErrInvalidChamp},
+	}
+
+	for _, c := range cases {
+		page := newTestPage()
+		c.edit(page)
+		if err := page.Validate(); err != c.exp {
+			t.Errorf("%s: expected %v, got %v", c.name, c.exp, err)
+		}
+	}
+
+	page = newTestPage()
+	for i := 0; i <= pageAgainstMax; i++ {
+		uid := fmt.Sprintf("champ%d", i)
+		ddragon.DDragonInstance.Champions = append(ddragon.DDragonInstance.Champions,
+			&ddragon.Champion{UID: uid, Name: uid})
+		page.Against = append(page.Against, uid)
+	}
+	if err := page.Validate(); err != errTooManyAgainst {
+		t.Errorf("expected errTooManyAgainst, got %v", err)
+	}
+}
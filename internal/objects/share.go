@@ -1,8 +1,11 @@
 package objects
 
 import (
+	"context"
 	"time"
 
+	"github.com/myrunes/backend/internal/auth"
+	"github.com/myrunes/backend/pkg/ddragon"
 	"github.com/myrunes/backend/pkg/random"
 
 	"github.com/bwmarrin/snowflake"
@@ -14,16 +17,38 @@ var shareIDNode, _ = snowflake.NewNode(static.NodeIDShares)
 
 // SharePage wraps a RunePage public share.
 type SharePage struct {
-	UID         snowflake.ID `json:"uid"`
-	Ident       string       `json:"ident"`
-	OwnerID     snowflake.ID `json:"owner"`
-	PageID      snowflake.ID `json:"page"`
-	Created     time.Time    `json:"created"`
-	MaxAccesses int          `json:"maxaccesses"`
-	Expires     time.Time    `json:"expires"`
-	Accesses    int          `json:"accesses"`
-	LastAccess  time.Time    `json:"lastaccess"`
-	AccessIPs   []string     `json:"accessips,omitempty"`
+	UID         snowflake.ID   `json:"uid"`
+	Ident       string         `json:"ident"`
+	OwnerID     snowflake.ID   `json:"owner"`
+	PageID      snowflake.ID   `json:"page"`
+	PageIDs     []snowflake.ID `json:"pages,omitempty"`
+	Filter      *ShareFilter   `json:"filter,omitempty"`
+	Created     time.Time      `json:"created"`
+	MaxAccesses int            `json:"maxaccesses"`
+	Expires     time.Time      `json:"expires"`
+	Accesses    int            `json:"accesses"`
+	Forks       int            `json:"forks"`
+	LastAccess  time.Time      `json:"lastaccess"`
+	Protected   bool           `json:"protected"`
+	Audience    []snowflake.ID `json:"audience,omitempty"`
+
+	PassHash []byte `json:"-"`
+}
+
+// ShareFilter describes a dynamic selection
+// of pages of the owner of a share collection.
+type ShareFilter struct {
+	Champion string `json:"champion"`
+}
+
+// Validate checks if the champion of
+// the filter exists.
+func (f *ShareFilter) Validate() error {
+	if ddragon.DDragonInstance.GetChampion(f.Champion) == nil {
+		return ErrInvalidChamp
+	}
+
+	return nil
 }
 
 // NEwSharePage creates a new SharePage instance with
@@ -37,6 +62,37 @@ type SharePage struct {
 // should be enough to count as unlimited access
 // by time.
 func NewSharePage(ownerID, pageID snowflake.ID, maxAccesses int, expires time.Time) (*SharePage, error) {
+	share, err := newShare(ownerID, maxAccesses, expires)
+	if err != nil {
+		return nil, err
+	}
+
+	share.PageID = pageID
+
+	return share, nil
+}
+
+// NewShareCollection creates a new SharePage instance
+// sharing either the passed list of pageIDs or all
+// pages of the owner matching the passed filter.
+// maxAccesses and expires are handled like in
+// NewSharePage.
+func NewShareCollection(ownerID snowflake.ID, pageIDs []snowflake.ID, filter *ShareFilter, maxAccesses int, expires time.Time) (*SharePage, error) {
+	share, err := newShare(ownerID, maxAccesses, expires)
+	if err != nil {
+		return nil, err
+	}
+
+	share.PageIDs = pageIDs
+	share.Filter = filter
+
+	return share, nil
+}
+
+// newShare creates a new SharePage instance with
+// the passed ownerID, maxAccess count and expiration
+// time and generates the shares UID and ident.
+func newShare(ownerID snowflake.ID, maxAccesses int, expires time.Time) (*SharePage, error) {
 	now := time.Now()
 	var err error
 
@@ -55,9 +111,7 @@ func NewSharePage(ownerID, pageID snowflake.ID, maxAccesses int, expires time.Ti
 		LastAccess:  now,
 		MaxAccesses: maxAccesses,
 		OwnerID:     ownerID,
-		PageID:      pageID,
 		UID:         shareIDNode.Generate(),
-		AccessIPs:   make([]string, 0),
 	}
 
 	const identSubset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
@@ -65,3 +119,79 @@ func NewSharePage(ownerID, pageID snowflake.ID, maxAccesses int, expires time.Ti
 
 	return share, err
 }
+
+// IsCollection returns true if the share
+// shares multiple pages either by a list
+// of page IDs or by a filter.
+func (s *SharePage) IsCollection() bool {
+	return len(s.PageIDs) > 0 || s.Filter != nil
+}
+
+// IsAccessible returns true if the share is
+// neither expired nor has reached its maximum
+// count of accesses.
+func (s *SharePage) IsAccessible() bool {
+	if s.MaxAccesses == 0 || (s.MaxAccesses > 0 && s.Accesses >= s.MaxAccesses) {
+		return false
+	}
+
+	return (s.Expires == time.Time{}) || s.Expires.After(time.Now())
+}
+
+// SetPassword hashes the passed password using the
+// passed authMiddleware and sets it as the shares
+// access password.
+// If password is empty, the password protection
+// of the share will be removed.
+func (s *SharePage) SetPassword(ctx context.Context, password string, authMiddleware auth.AuthMiddleware) error {
+	if password == "" {
+		s.PassHash = nil
+		s.Protected = false
+		return nil
+	}
+
+	passHash, err := authMiddleware.CreateHash(ctx, password)
+	if err != nil {
+		return err
+	}
+
+	s.PassHash = []byte(passHash)
+	s.Protected = true
+
+	return nil
+}
+
+// CheckPassword returns true if the share is not
+// password protected or if the passed password
+// matches the shares password hash.
+func (s *SharePage) CheckPassword(password string, authMiddleware auth.AuthMiddleware) bool {
+	if !s.Protected {
+		return true
+	}
+
+	return authMiddleware.CheckHash(string(s.PassHash), password)
+}
+
+// IsRestricted returns true if the share is only
+// accessable by a defined audience of users.
+func (s *SharePage) IsRestricted() bool {
+	return len(s.Audience) > 0
+}
+
+// InAudience returns true if the share is not
+// restricted to an audience or if the passed
+// user ID is either the owner of the share or
+// part of the shares audience.
+func (s *SharePage) InAudience(uid snowflake.ID) bool {
+	if !s.IsRestricted() || uid == s.OwnerID {
+		return true
+	}
+
+	for _, id := range s.Audience {
+		if id == uid {
+			return true
+		}
+	}
+
+	return false
+}
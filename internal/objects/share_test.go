@@ -0,0 +1,30 @@
+package objects
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSharePageIsAccessible(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		name  string
+		share SharePage
+		exp   bool
+	}{
+		{"unlimited", SharePage{MaxAccesses: -1, Accesses: 100, Expires: future}, true},
+		{"below limit", SharePage{MaxAccesses: 3, Accesses: 2, Expires: future}, true},
+		{"limit reached", SharePage{MaxAccesses: 3, Accesses: 3, Expires: future}, false},
+		{"exhausted", SharePage{MaxAccesses: 0, Expires: future}, false},
+		{"expired", SharePage{MaxAccesses: -1, Expires: past}, false},
+		{"no expiration", SharePage{MaxAccesses: -1}, true},
+	}
+
+	for _, c := range cases {
+		if res := c.share.IsAccessible(); res != c.exp {
+			t.Errorf("%s: expected %t, got %t", c.name, c.exp, res)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package objects
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// User agent classes of share accesses.
+const (
+	UserAgentBot     = "bot"
+	UserAgentMobile  = "mobile"
+	UserAgentDesktop = "desktop"
+	UserAgentUnknown = "unknown"
+)
+
+// shareStatsDateFormat is the format of the
+// dates of the share stats timeline.
+const shareStatsDateFormat = "2006-01-02"
+
+// ShareAccessLifetime is the duration after which
+// share access events are removed. It covers the
+// longest period of the share stats. Visitors
+// returning after this duration are counted as
+// new visitors of the share.
+const ShareAccessLifetime = 365 * 24 * time.Hour
+
+// botUserAgentKeywords contains lowercased
+// keywords of user agent strings identifying
+// crawlers and link preview bots.
+var botUserAgentKeywords = []string{
+	"bot", "crawler", "spider", "preview", "curl", "wget", "http-client",
+}
+
+// mobileUserAgentKeywords contains lowercased
+// keywords of user agent strings identifying
+// mobile devices.
+var mobileUserAgentKeywords = []string{
+	"mobile", "android", "iphone", "ipad", "ipod",
+}
+
+// ShareAccess describes a single access
+// event of a page share.
+// The IP address of the accessing client
+// is only stored as salted hash.
+type ShareAccess struct {
+	ShareID   snowflake.ID `json:"share"`
+	Timestamp time.Time    `json:"timestamp"`
+	IPHash    string       `json:"iphash"`
+	UserAgent string       `json:"useragent"`
+	Referrer  string       `json:"referrer"`
+}
+
+// ShareStats wraps aggregated access
+// statistics of a page share.
+type ShareStats struct {
+	Accesses       int                   `json:"accesses"`
+	UniqueVisitors int                   `json:"uniquevisitors"`
+	Forks          int                   `json:"forks"`
+	Timeline       []*ShareStatsDay      `json:"timeline"`
+	UserAgents     map[string]int        `json:"useragents"`
+	TopReferrers   []*ShareStatsReferrer `json:"topreferrers"`
+}
+
+// ShareStatsDay wraps the access counts
+// of a share on a single day.
+type ShareStatsDay struct {
+	Date           string `json:"date"`
+	Accesses       int    `json:"accesses"`
+	UniqueVisitors int    `json:"uniquevisitors"`
+}
+
+// ShareStatsReferrer wraps the access
+// count of a share by a referrer host.
+type ShareStatsReferrer struct {
+	Referrer string `json:"referrer"`
+	Accesses int    `json:"accesses"`
+}
+
+// NewShareAccess creates a new ShareAccess event
+// for the passed share ID at the current time.
+// The passed IP address is hashed using the passed
+// server side key, the user agent is reduced to its
+// class and the referrer is reduced to its host name.
+func NewShareAccess(shareID snowflake.ID, key []byte, ipAddr, userAgent, referrer string) *ShareAccess {
+	return &ShareAccess{
+		ShareID:   shareID,
+		Timestamp: time.Now(),
+		IPHash:    hashShareAccessIP(key, shareID, ipAddr),
+		UserAgent: userAgentClass(userAgent),
+		Referrer:  referrerHost(referrer),
+	}
+}
+
+// NewShareStats aggregates the passed share
+// accesses to a ShareStats object containing
+// the access counts per (UTC) day since the
+// passed time and the top referrers limited to
+// the passed count.
+func NewShareStats(accesses []*ShareAccess, since time.Time, topReferrers int) *ShareStats {
+	stats := &ShareStats{
+		Timeline:     make([]*ShareStatsDay, 0),
+		UserAgents:   make(map[string]int),
+		TopReferrers: make([]*ShareStatsReferrer, 0),
+	}
+
+	visitors := make(map[string]struct{})
+	days := make(map[string]*ShareStatsDay)
+	dayVisitors := make(map[string]map[string]struct{})
+	referrers := make(map[string]int)
+
+	now := time.Now().UTC()
+	for d := since.UTC().Truncate(24 * time.Hour); !d.After(now); d = d.Add(24 * time.Hour) {
+		day := &ShareStatsDay{Date: d.Format(shareStatsDateFormat)}
+		days[day.Date] = day
+		dayVisitors[day.Date] = make(map[string]struct{})
+		stats.Timeline = append(stats.Timeline, day)
+	}
+
+	for _, a := range accesses {
+		stats.Accesses++
+		stats.UserAgents[a.UserAgent]++
+		visitors[a.IPHash] = struct{}{}
+
+		if a.Referrer != "" {
+			referrers[a.Referrer]++
+		}
+
+		date := a.Timestamp.UTC().Format(shareStatsDateFormat)
+		if day, ok := days[date]; ok {
+			day.Accesses++
+			dayVisitors[date][a.IPHash] = struct{}{}
+			day.UniqueVisitors = len(dayVisitors[date])
+		}
+	}
+
+	stats.UniqueVisitors = len(visitors)
+
+	for ref, n := range referrers {
+		stats.TopReferrers = append(stats.TopReferrers, &ShareStatsReferrer{
+			Referrer: ref,
+			Accesses: n,
+		})
+	}
+
+	sort.Slice(stats.TopReferrers, func(i, j int) bool {
+		ri, rj := stats.TopReferrers[i], stats.TopReferrers[j]
+		if ri.Accesses == rj.Accesses {
+			return ri.Referrer < rj.Referrer
+		}
+		return ri.Accesses > rj.Accesses
+	})
+
+	if len(stats.TopReferrers) > topReferrers {
+		stats.TopReferrers = stats.TopReferrers[:topReferrers]
+	}
+
+	return stats
+}
+
+// hashShareAccessIP returns the hex encoded
+// HMAC-SHA256 of the passed share ID and IP address
+// using the passed key. The key must be kept secret,
+// because the hashes of the small IPv4 address space
+// could otherwise be reversed by brute force. The
+// share ID is included so that visitors can not be
+// correlated across multiple shares.
+func hashShareAccessIP(key []byte, shareID snowflake.ID, ipAddr string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strconv.FormatInt(int64(shareID), 10)))
+	mac.Write([]byte{':'})
+	mac.Write([]byte(ipAddr))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// userAgentClass returns the class of client
+// identified by the passed user agent string.
+func userAgentClass(userAgent string) string {
+	if userAgent == "" {
+		return UserAgentUnknown
+	}
+
+	userAgent = strings.ToLower(userAgent)
+
+	for _, kw := range botUserAgentKeywords {
+		if strings.Contains(userAgent, kw) {
+			return UserAgentBot
+		}
+	}
+
+	for _, kw := range mobileUserAgentKeywords {
+		if strings.Contains(userAgent, kw) {
+			return UserAgentMobile
+		}
+	}
+
+	if strings.HasPrefix(userAgent, "mozilla/") || strings.HasPrefix(userAgent, "opera/") {
+		return UserAgentDesktop
+	}
+
+	return UserAgentUnknown
+}
+
+// referrerHost returns the lowercased host name
+// of the passed referrer URL. If the referrer is
+// empty or invalid, an empty string is returned.
+func referrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(u.Hostname())
+}
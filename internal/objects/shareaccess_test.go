@@ -0,0 +1,72 @@
+package objects
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHashShareAccessIP(t *testing.T) {
+	key := []byte("secret")
+
+	h := hashShareAccessIP(key, 1, "203.0.113.7")
+	if len(h) != 64 {
+		t.Fatalf("expected hex encoded SHA256 length, got %d", len(h))
+	}
+	if h != hashShareAccessIP(key, 1, "203.0.113.7") {
+		t.Error("hash is not deterministic")
+	}
+	if h == hashShareAccessIP(key, 2, "203.0.113.7") {
+		t.Error("hash does not depend on the share ID")
+	}
+	if h == hashShareAccessIP([]byte("other"), 1, "203.0.113.7") {
+		t.Error("hash does not depend on the key")
+	}
+	if h == hashShareAccessIP(key, 1, "203.0.113.8") {
+		t.Error("hash does not depend on the IP address")
+	}
+}
+
+func TestNewShareStats(t *testing.T) {
+	now := time.Now()
+	since := now.AddDate(0, 0, -1)
+	accesses := []*ShareAccess{
+		{Timestamp: now, IPHash: "a", UserAgent: UserAgentDesktop, Referrer: "example.com"},
+		{Timestamp: now, IPHash: "a", UserAgent: UserAgentDesktop, Referrer: "example.com"},
+		{Timestamp: now, IPHash: "b", UserAgent: UserAgentMobile, Referrer: "other.com"},
+		{Timestamp: since, IPHash: "c", UserAgent: UserAgentBot},
+	}
+
+	stats := NewShareStats(accesses, since, 1)
+
+	if stats.Accesses != 4 || stats.UniqueVisitors != 3 {
+		t.Errorf("unexpected totals: %d accesses, %d visitors", stats.Accesses, stats.UniqueVisitors)
+	}
+	if len(stats.Timeline) != 2 {
+		t.Fatalf("expected 2 days, got %d", len(stats.Timeline))
+	}
+	if day := stats.Timeline[1]; day.Accesses != 3 || day.UniqueVisitors != 2 {
+		t.Errorf("unexpected counts of today: %+v", day)
+	}
+	if len(stats.TopReferrers) != 1 || stats.TopReferrers[0].Referrer != "example.com" {
+		t.Errorf("unexpected top referrers: %+v", stats.TopReferrers)
+	}
+	if stats.UserAgents[UserAgentDesktop] != 2 || stats.UserAgents[UserAgentBot] != 1 {
+		t.Errorf("unexpected user agents: %+v", stats.UserAgents)
+	}
+}
+
+func TestUserAgentClass(t *testing.T) {
+	cases := map[string]string{
+		"": UserAgentUnknown,
+		"Mozilla/5.0 (compatible; Googlebot/2.1)":   UserAgentBot,
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 14_0)":  UserAgentMobile,
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64)": UserAgentDesktop,
+		"some-client/1.0":                           UserAgentUnknown,
+	}
+
+	for ua, exp := range cases {
+		if res := userAgentClass(ua); res != exp {
+			t.Errorf("%q: expected %s, got %s", ua, exp, res)
+		}
+	}
+}
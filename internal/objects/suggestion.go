@@ -0,0 +1,105 @@
+package objects
+
+import (
+	"fmt"
+
+	"github.com/myrunes/backend/pkg/ddragon"
+)
+
+// Sources of page suggestions.
+const (
+	SuggestionSourceOwn       = "own"
+	SuggestionSourceCommunity = "community"
+)
+
+// PageSuggestion wraps a page skeleton proposed
+// as template when creating a page for a
+// champion and the source of the suggestion.
+// If the suggestion is derived from a page of
+// the user, BasedOn contains the pages UID.
+type PageSuggestion struct {
+	Source  string `json:"source"`
+	BasedOn string `json:"basedon,omitempty"`
+	Page    *Page  `json:"page"`
+}
+
+// NewPageSuggestion creates a PageSuggestion from
+// a copy of the passed page assigned to the passed
+// champion. If the copy does not pass Page.Validate,
+// an error is returned.
+func NewPageSuggestion(page *Page, champion string) (*PageSuggestion, error) {
+	if page.Primary == nil || page.Secondary == nil || page.Perks == nil {
+		return nil, errInvalidTree
+	}
+
+	skeleton := page.Copy()
+	skeleton.Champions = []string{champion}
+
+	if err := skeleton.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &PageSuggestion{
+		Source:  SuggestionSourceOwn,
+		BasedOn: page.UID.String(),
+		Page:    skeleton,
+	}, nil
+}
+
+// NewCommunityPageSuggestion creates a PageSuggestion
+// for the passed champion from the passed keystone,
+// secondary tree and stat shards. The remaining
+// runes are set to the first rune of their slot.
+// If the resulting page does not pass Page.Validate,
+// an error is returned.
+func NewCommunityPageSuggestion(champion, keystone, secondaryTree string, statShards [3]string) (*PageSuggestion, error) {
+	dd := ddragon.DDragonInstance
+
+	primary := dd.GetKeystoneTree(keystone)
+	secondary := dd.GetRuneTree(secondaryTree)
+	if primary == nil || secondary == nil || len(primary.Slots) < 4 || len(secondary.Slots) < 3 {
+		return nil, errInvalidTree
+	}
+
+	page := NewEmptyPage()
+	page.Champions = []string{champion}
+	page.Perks.Rows = statShards
+
+	page.Primary.Tree = primary.UID
+	page.Primary.Rows[0] = keystone
+	for i := 1; i < len(page.Primary.Rows); i++ {
+		page.Primary.Rows[i] = firstRune(primary.Slots[i])
+	}
+
+	page.Secondary.Tree = secondary.UID
+	for i := range page.Secondary.Rows {
+		page.Secondary.Rows[i] = firstRune(secondary.Slots[i+1])
+	}
+
+	title := keystone
+	if r := dd.GetRune(keystone); r != nil {
+		title = r.Name
+	}
+	if c := dd.GetChampion(champion); c != nil {
+		title = fmt.Sprintf("%s - %s", c.Name, title)
+	}
+	page.Title = title
+
+	if err := page.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &PageSuggestion{
+		Source: SuggestionSourceCommunity,
+		Page:   page,
+	}, nil
+}
+
+// firstRune returns the UID of the first
+// rune of the passed slot.
+func firstRune(slot *ddragon.RuneSlot) string {
+	if len(slot.Runes) == 0 {
+		return ""
+	}
+	return slot.Runes[0].UID
+}
@@ -0,0 +1,150 @@
+package objects
+
+import (
+	"testing"
+
+	"github.com/myrunes/backend/pkg/ddragon"
+)
+
+// setupTestDDragon sets a DDragon instance containing
+// the champions and rune trees used by test pages.
+func setupTestDDragon() {
+	tree := func(uid string, slots ...[]string) *ddragon.RuneTree {
+		t := &ddragon.RuneTree{UID: uid}
+		for _, runes := range slots {
+			slot := new(ddragon.RuneSlot)
+			for _, r := range runes {
+				slot.Runes = append(slot.Runes, &ddragon.Rune{UID: r, Name: r})
+			}
+			t.Slots = append(t.Slots, slot)
+		}
+		return t
+	}
+
+	ddragon.DDragonInstance = &ddragon.DDragon{
+		Champions: []*ddragon.Champion{{UID: "jinx", Name: "Jinx"}, {UID: "lux", Name: "Lux"}},
+		Runes: []*ddragon.RuneTree{
+			tree("precision",
+				[]string{"presstheattack", "lethaltempo"},
+				[]string{"overheal", "triumph"},
+				[]string{"legendalacrity"},
+				[]string{"coupdegrace"}),
+			tree("sorcery",
+				[]string{"arcanecomet"},
+				[]string{"manaflowband", "nullifyingorb"},
+				[]string{"transcendence"},
+				[]string{"gatheringstorm"}),
+			tree("short", []string{"keystone"}),
+		},
+	}
+}
+
+func newTestPage() *Page {
+	page := NewEmptyPage()
+	page.UID = 42
+	page.Title = "test page"
+	page.Champions = []string{"lux"}
+	page.Tags = []string{"mid"}
+	page.Primary.Tree = "precision"
+	page.Primary.Rows = [4]string{"lethaltempo", "triumph", "legendalacrity", "coupdegrace"}
+	page.Secondary.Tree = "sorcery"
+	page.Secondary.Rows = [2]string{"manaflowband", "gatheringstorm"}
+	page.Perks.Rows = [3]string{"diamond", "shield", "heart"}
+	return page
+}
+
+func TestNewPageSuggestion(t *testing.T) {
+	setupTestDDragon()
+
+	page := newTestPage()
+	s, err := NewPageSuggestion(page, "jinx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Source != SuggestionSourceOwn || s.BasedOn != "42" {
+		t.Errorf("unexpected suggestion %+v", s)
+	}
+	if len(s.Page.Champions) != 1 || s.Page.Champions[0] != "jinx" {
+		t.Errorf("expected suggestion for jinx, got %v", s.Page.Champions)
+	}
+	if *s.Page.Primary != *page.Primary || *s.Page.Secondary != *page.Secondary || *s.Page.Perks != *page.Perks {
+		t.Error("expected suggestion to use the runes of the page")
+	}
+	if s.Page.UID != 0 {
+		t.Error("expected suggestion not to have a UID")
+	}
+
+	// The suggestion is a copy of the page.
+	s.Page.Primary.Rows[0] = "presstheattack"
+	if len(page.Champions) != 1 || page.Champions[0] != "lux" || page.Primary.Rows[0] != "lethaltempo" {
+		t.Error("expected page not to be changed")
+	}
+}
+
+func TestNewPageSuggestionInvalid(t *testing.T) {
+	setupTestDDragon()
+
+	incomplete := newTestPage()
+	incomplete.Secondary = nil
+	if _, err := NewPageSuggestion(incomplete, "jinx"); err == nil {
+		t.Error("expected error for incomplete page")
+	}
+
+	if _, err := NewPageSuggestion(newTestPage(), "unknown"); err != ErrInvalidChamp {
+		t.Errorf("expected ErrInvalidChamp, got %v", err)
+	}
+
+	invalid := newTestPage()
+	invalid.Primary.Rows[1] = "unknown"
+	if _, err := NewPageSuggestion(invalid, "jinx"); err == nil {
+		t.Error("expected error for page with invalid runes")
+	}
+}
+
+func TestNewCommunityPageSuggestion(t *testing.T) {
+	setupTestDDragon()
+
+	shards := [3]string{"time", "circle", "shield"}
+	s, err := NewCommunityPageSuggestion("jinx", "lethaltempo", "sorcery", shards)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := s.Page
+	if s.Source != SuggestionSourceCommunity || s.BasedOn != "" {
+		t.Errorf("unexpected suggestion %+v", s)
+	}
+	if p.Title != "Jinx - lethaltempo" {
+		t.Errorf("unexpected title %q", p.Title)
+	}
+	if p.Primary.Tree != "precision" || p.Primary.Rows != [4]string{"lethaltempo", "overheal", "legendalacrity", "coupdegrace"} {
+		t.Errorf("unexpected primary tree %+v", p.Primary)
+	}
+	if p.Secondary.Tree != "sorcery" || p.Secondary.Rows != [2]string{"manaflowband", "transcendence"} {
+		t.Errorf("unexpected secondary tree %+v", p.Secondary)
+	}
+	if p.Perks.Rows != shards {
+		t.Errorf("unexpected perks %+v", p.Perks)
+	}
+
+	cases := []struct {
+		name      string
+		keystone  string
+		secondary string
+		shards    [3]string
+	}{
+		{"unknown keystone", "unknown", "sorcery", shards},
+		{"rune which is no keystone", "triumph", "sorcery", shards},
+		{"unknown secondary tree", "lethaltempo", "unknown", shards},
+		{"same trees", "lethaltempo", "precision", shards},
+		{"tree with too few slots", "keystone", "sorcery", shards},
+		{"invalid stat shards", "lethaltempo", "sorcery", [3]string{"heart", "heart", "heart"}},
+	}
+
+	for _, c := range cases {
+		if _, err := NewCommunityPageSuggestion("jinx", c.keystone, c.secondary, c.shards); err == nil {
+			t.Errorf("%s: expected error", c.name)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package objects
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/myrunes/backend/internal/static"
+)
+
+// teamIDNode is the node to generate team snowflake IDs.
+var teamIDNode, _ = snowflake.NewNode(static.NodeIDTeams)
+
+// maximum length of team names
+const teamNameMaxLen = 64
+
+// Roles of team members.
+const (
+	TeamRoleViewer = "viewer"
+	TeamRoleEditor = "editor"
+	TeamRoleAdmin  = "admin"
+)
+
+// Permission describes the level of access
+// to resources like pages and shares which
+// are owned by a user or a team.
+// Higher permissions include all lower
+// permissions.
+type Permission int
+
+const (
+	// PermissionRead allows viewing resources.
+	PermissionRead Permission = iota
+	// PermissionWrite allows creating, editing,
+	// deleting and sharing resources.
+	PermissionWrite
+	// PermissionManage allows managing the team
+	// and its members.
+	PermissionManage
+)
+
+var (
+	ErrInvalidTeamName = errors.New("invalid team name")
+	ErrInvalidTeamRole = errors.New("invalid team role")
+)
+
+// teamRolePermissions maps team roles to
+// the highest permission granted by them.
+var teamRolePermissions = map[string]Permission{
+	TeamRoleViewer: PermissionRead,
+	TeamRoleEditor: PermissionWrite,
+	TeamRoleAdmin:  PermissionManage,
+}
+
+// Team describes a workspace which owns
+// pages shared between its members.
+type Team struct {
+	UID     snowflake.ID `json:"uid"`
+	Name    string       `json:"name"`
+	Created time.Time    `json:"created"`
+}
+
+// TeamMember describes the membership of
+// a user in a team with the members role.
+// Members are pending until they accepted
+// the invitation to the team.
+type TeamMember struct {
+	TeamID    snowflake.ID `json:"team"`
+	UserID    snowflake.ID `json:"user"`
+	Role      string       `json:"role"`
+	Pending   bool         `json:"pending"`
+	InvitedBy snowflake.ID `json:"invitedby"`
+	Joined    time.Time    `json:"joined"`
+}
+
+// NewTeam creates a new Team object with
+// the passed name and a generated UID.
+func NewTeam(name string) (*Team, error) {
+	team := &Team{
+		UID:     teamIDNode.Generate(),
+		Created: time.Now(),
+	}
+
+	if err := team.SetName(name); err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+// SetName sets the trimmed passed name as
+// team name, if it is valid.
+func (t *Team) SetName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > teamNameMaxLen {
+		return ErrInvalidTeamName
+	}
+
+	t.Name = name
+	return nil
+}
+
+// NewTeamMember creates a pending TeamMember
+// of the passed team and user with the passed
+// role, invited by the passed user.
+func NewTeamMember(teamID, userID snowflake.ID, role string, invitedBy snowflake.ID) (*TeamMember, error) {
+	if !IsValidTeamRole(role) {
+		return nil, ErrInvalidTeamRole
+	}
+
+	return &TeamMember{
+		TeamID:    teamID,
+		UserID:    userID,
+		Role:      role,
+		Pending:   true,
+		InvitedBy: invitedBy,
+	}, nil
+}
+
+// Accept accepts the invitation of
+// the member to the team.
+func (m *TeamMember) Accept() {
+	m.Pending = false
+	m.Joined = time.Now()
+}
+
+// Can returns true if the member is not
+// pending and its role grants the passed
+// permission.
+func (m *TeamMember) Can(perm Permission) bool {
+	if m.Pending {
+		return false
+	}
+
+	granted, ok := teamRolePermissions[m.Role]
+	return ok && granted >= perm
+}
+
+// IsValidTeamRole returns true if the
+// passed role is a valid team role.
+func IsValidTeamRole(role string) bool {
+	_, ok := teamRolePermissions[role]
+	return ok
+}
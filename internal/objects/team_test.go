@@ -0,0 +1,86 @@
+package objects
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTeamMemberCan(t *testing.T) {
+	cases := []struct {
+		name   string
+		member TeamMember
+		read   bool
+		write  bool
+		manage bool
+	}{
+		{"admin", TeamMember{Role: TeamRoleAdmin}, true, true, true},
+		{"editor", TeamMember{Role: TeamRoleEditor}, true, true, false},
+		{"viewer", TeamMember{Role: TeamRoleViewer}, true, false, false},
+		{"pending admin", TeamMember{Role: TeamRoleAdmin, Pending: true}, false, false, false},
+		{"pending viewer", TeamMember{Role: TeamRoleViewer, Pending: true}, false, false, false},
+		{"invalid role", TeamMember{Role: "owner"}, false, false, false},
+		{"no role", TeamMember{}, false, false, false},
+	}
+
+	for _, c := range cases {
+		for _, p := range []struct {
+			perm Permission
+			exp  bool
+		}{
+			{PermissionRead, c.read},
+			{PermissionWrite, c.write},
+			{PermissionManage, c.manage},
+		} {
+			if res := c.member.Can(p.perm); res != p.exp {
+				t.Errorf("%s: permission %d: expected %t, got %t", c.name, p.perm, p.exp, res)
+			}
+		}
+	}
+}
+
+func TestNewTeamMember(t *testing.T) {
+	if _, err := NewTeamMember(1, 2, "owner", 3); err != ErrInvalidTeamRole {
+		t.Errorf("expected ErrInvalidTeamRole, got %v", err)
+	}
+
+	member, err := NewTeamMember(1, 2, TeamRoleEditor, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !member.Pending || member.Can(PermissionRead) {
+		t.Error("expected invited member to be pending without permissions")
+	}
+
+	member.Accept()
+	if member.Pending || member.Joined.IsZero() {
+		t.Error("expected accepted member not to be pending")
+	}
+	if !member.Can(PermissionWrite) || member.Can(PermissionManage) {
+		t.Error("expected accepted editor to have write permission only")
+	}
+}
+
+func TestTeamSetName(t *testing.T) {
+	cases := []struct {
+		name string
+		exp  string
+		err  error
+	}{
+		{"team", "team", nil},
+		{"  team  ", "team", nil},
+		{"", "", ErrInvalidTeamName},
+		{"   ", "", ErrInvalidTeamName},
+		{strings.Repeat("a", teamNameMaxLen), strings.Repeat("a", teamNameMaxLen), nil},
+		{strings.Repeat("a", teamNameMaxLen+1), "", ErrInvalidTeamName},
+	}
+
+	for _, c := range cases {
+		team := new(Team)
+		if err := team.SetName(c.name); err != c.err {
+			t.Errorf("%q: expected error %v, got %v", c.name, c.err, err)
+		}
+		if team.Name != c.exp {
+			t.Errorf("%q: expected name %q, got %q", c.name, c.exp, team.Name)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package objects
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// PageTombstoneLifetime is the duration page
+// tombstones are kept after the deletion of
+// the page.
+const PageTombstoneLifetime = 30 * 24 * time.Hour
+
+// PageTombstone records the deletion of a page
+// so that clients synchronizing pages can be
+// informed about deleted pages.
+type PageTombstone struct {
+	PageID  snowflake.ID `json:"page"`
+	Owner   snowflake.ID `json:"owner"`
+	Deleted time.Time    `json:"deleted"`
+}
+
+// NewPageTombstone creates a new PageTombstone
+// recording the deletion of the passed page
+// at the current time.
+func NewPageTombstone(page *Page) *PageTombstone {
+	return &PageTombstone{
+		PageID:  page.UID,
+		Owner:   page.Owner,
+		Deleted: time.Now(),
+	}
+}
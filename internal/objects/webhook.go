@@ -0,0 +1,186 @@
+package objects
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/myrunes/backend/internal/events"
+	"github.com/myrunes/backend/internal/static"
+	"github.com/myrunes/backend/pkg/netguard"
+	"github.com/myrunes/backend/pkg/random"
+)
+
+// webhookIDNode is the node to generate webhook snowflake IDs.
+var webhookIDNode, _ = snowflake.NewNode(static.NodeIDWebhooks)
+
+// webhookDeliveryIDNode is the node to generate
+// webhook delivery snowflake IDs.
+var webhookDeliveryIDNode, _ = snowflake.NewNode(static.NodeIDWebhookDeliveries)
+
+const (
+	// maximum length of webhook URLs
+	webhookURLMaxLen = 2048
+	// minimum and maximum length of
+	// webhook signing secrets
+	webhookSecretMinLen = 16
+	webhookSecretMaxLen = 256
+	// number of random bytes of
+	// generated signing secrets
+	webhookSecretGenLen = 32
+	// timeout of resolving the host
+	// of webhook URLs
+	webhookURLLookupTimeout = 5 * time.Second
+)
+
+// WebhookDeliveryLifetime is the duration
+// webhook deliveries are kept in the
+// delivery log.
+const WebhookDeliveryLifetime = 7 * 24 * time.Hour
+
+// States of webhook deliveries.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+var (
+	ErrInvalidWebhookURL    = errors.New("invalid webhook URL")
+	ErrNonPublicWebhookURL  = errors.New("webhook URL does not resolve to a public address")
+	ErrInvalidWebhookSecret = errors.New("invalid webhook secret")
+	ErrInvalidWebhookEvent  = errors.New("invalid webhook event")
+)
+
+// Webhook describes an URL events of the user or
+// team Owner are delivered to. Deliveries are
+// signed with the Secret, so that receivers can
+// verify their origin. Only events of the types
+// listed in Events are delivered.
+type Webhook struct {
+	UID     snowflake.ID `json:"uid"`
+	Owner   snowflake.ID `json:"owner"`
+	URL     string       `json:"url"`
+	Secret  string       `json:"secret,omitempty"`
+	Events  []string     `json:"events"`
+	Enabled bool         `json:"enabled"`
+	Created time.Time    `json:"created"`
+}
+
+// WebhookDelivery records the delivery of an
+// event to a webhook. Attempts contains the
+// number of delivery attempts. Code and Error
+// describe the result of the last attempt.
+type WebhookDelivery struct {
+	UID         snowflake.ID `json:"uid"`
+	WebhookID   snowflake.ID `json:"webhook"`
+	Event       string       `json:"event"`
+	Status      string       `json:"status"`
+	Attempts    int          `json:"attempts"`
+	Code        int          `json:"code,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	Created     time.Time    `json:"created"`
+	LastAttempt time.Time    `json:"lastattempt"`
+}
+
+// NewWebhook creates a new enabled Webhook object
+// owned by the passed owner delivering the passed
+// event types to the passed URL. If secret is empty,
+// a random signing secret is generated.
+func NewWebhook(owner snowflake.ID, url, secret string, evts []string) (*Webhook, error) {
+	var err error
+	if secret == "" {
+		if secret, err = random.Base64(webhookSecretGenLen); err != nil {
+			return nil, err
+		}
+	}
+
+	webhook := &Webhook{
+		UID:     webhookIDNode.Generate(),
+		Owner:   owner,
+		URL:     url,
+		Secret:  secret,
+		Events:  evts,
+		Enabled: true,
+		Created: time.Now(),
+	}
+
+	if err = webhook.Validate(); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// Validate checks the URL, the secret and the
+// subscribed event types of the webhook. The URL
+// must resolve to public addresses only.
+// Duplicate event types are removed.
+func (w *Webhook) Validate() error {
+	if len(w.URL) > webhookURLMaxLen {
+		return ErrInvalidWebhookURL
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+
+	// Deliveries are checked again on connecting,
+	// because the host may resolve to another
+	// address later on.
+	ctx, cancel := context.WithTimeout(context.Background(), webhookURLLookupTimeout)
+	defer cancel()
+	if netguard.CheckHost(ctx, u.Hostname()) != nil {
+		return ErrNonPublicWebhookURL
+	}
+
+	if len(w.Secret) < webhookSecretMinLen || len(w.Secret) > webhookSecretMaxLen {
+		return ErrInvalidWebhookSecret
+	}
+
+	if len(w.Events) == 0 {
+		return ErrInvalidWebhookEvent
+	}
+	evts := make([]string, 0, len(w.Events))
+	for _, e := range w.Events {
+		if !events.IsValidType(e) {
+			return ErrInvalidWebhookEvent
+		}
+		if !contains(evts, e) {
+			evts = append(evts, e)
+		}
+	}
+	w.Events = evts
+
+	return nil
+}
+
+// Subscribes returns true if the webhook
+// is enabled and subscribed to events of
+// the passed type.
+func (w *Webhook) Subscribes(typ string) bool {
+	return w.Enabled && contains(w.Events, typ)
+}
+
+// Sanitize returns a copy of the webhook
+// without the signing secret.
+func (w *Webhook) Sanitize() *Webhook {
+	c := *w
+	c.Secret = ""
+	return &c
+}
+
+// NewWebhookDelivery creates a new pending
+// WebhookDelivery of an event of the passed
+// type to the passed webhook.
+func NewWebhookDelivery(webhook *Webhook, event string) *WebhookDelivery {
+	return &WebhookDelivery{
+		UID:       webhookDeliveryIDNode.Generate(),
+		WebhookID: webhook.UID,
+		Event:     event,
+		Status:    WebhookDeliveryPending,
+		Created:   time.Now(),
+	}
+}
@@ -0,0 +1,68 @@
+package objects
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/myrunes/backend/internal/events"
+)
+
+func TestWebhookValidate(t *testing.T) {
+	secret := strings.Repeat("s", webhookSecretMinLen)
+
+	cases := []struct {
+		name   string
+		url    string
+		secret string
+		events []string
+		err    error
+	}{
+		{"valid", "https://93.184.216.34/hook", secret, []string{events.TypePageCreated}, nil},
+		{"valid ipv6", "http://[2606:2800:220:1:248:1893:25c8:1946]:8080/hook", secret, []string{events.TypePageCreated}, nil},
+		{"invalid scheme", "ftp://93.184.216.34/hook", secret, []string{events.TypePageCreated}, ErrInvalidWebhookURL},
+		{"missing host", "https:///hook", secret, []string{events.TypePageCreated}, ErrInvalidWebhookURL},
+		{"too long", "https://93.184.216.34/" + strings.Repeat("a", webhookURLMaxLen), secret, []string{events.TypePageCreated}, ErrInvalidWebhookURL},
+		{"loopback", "http://127.0.0.1:8080/hook", secret, []string{events.TypePageCreated}, ErrNonPublicWebhookURL},
+		{"loopback ipv6", "http://[::1]/hook", secret, []string{events.TypePageCreated}, ErrNonPublicWebhookURL},
+		{"localhost", "http://localhost/hook", secret, []string{events.TypePageCreated}, ErrNonPublicWebhookURL},
+		{"private", "http://192.168.0.10/hook", secret, []string{events.TypePageCreated}, ErrNonPublicWebhookURL},
+		{"link-local", "http://169.254.169.254/latest/meta-data", secret, []string{events.TypePageCreated}, ErrNonPublicWebhookURL},
+		{"short secret", "https://93.184.216.34/hook", "secret", []string{events.TypePageCreated}, ErrInvalidWebhookSecret},
+		{"no events", "https://93.184.216.34/hook", secret, nil, ErrInvalidWebhookEvent},
+		{"invalid event", "https://93.184.216.34/hook", secret, []string{"page.renamed"}, ErrInvalidWebhookEvent},
+	}
+
+	for _, c := range cases {
+		w := &Webhook{URL: c.url, Secret: c.secret, Events: c.events}
+		if err := w.Validate(); err != c.err {
+			t.Errorf("%s: expected error %v, got %v", c.name, c.err, err)
+		}
+	}
+}
+
+func TestNewWebhook(t *testing.T) {
+	w, err := NewWebhook(1, "https://93.184.216.34/hook", "",
+		[]string{events.TypePageCreated, events.TypePageDeleted, events.TypePageCreated})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(w.Secret) < webhookSecretMinLen {
+		t.Errorf("generated secret %q is too short", w.Secret)
+	}
+	if len(w.Events) != 2 {
+		t.Errorf("duplicate events were not removed: %v", w.Events)
+	}
+	if !w.Subscribes(events.TypePageDeleted) || w.Subscribes(events.TypePageUpdated) {
+		t.Errorf("unexpected subscriptions of %v", w.Events)
+	}
+
+	w.Enabled = false
+	if w.Subscribes(events.TypePageDeleted) {
+		t.Error("disabled webhook subscribes to events")
+	}
+
+	if w.Sanitize().Secret != "" || w.Secret == "" {
+		t.Error("sanitizing did not remove the secret of the copy only")
+	}
+}
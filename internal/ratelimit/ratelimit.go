@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/myrunes/backend/internal/metrics"
 	"github.com/myrunes/backend/internal/shared"
 	routing "github.com/qiangxue/fasthttp-routing"
 	"github.com/zekroTJA/ratelimit"
@@ -24,6 +25,7 @@ type RateLimitManager struct {
 
 type rateLimitHandler struct {
 	id      int
+	name    string
 	handler routing.Handler
 }
 
@@ -36,9 +38,19 @@ func New() *RateLimitManager {
 	}
 }
 
+// A Reserver consumes n tokens of a rate limiter
+// bucket for the connection of the passed request.
+// If not enough tokens are available, the execution
+// of following handlers is aborted, a json error body
+// is written in combination with a 429 status code
+// and false is returned.
+type Reserver func(ctx *routing.Context, n int) bool
+
 // GetHandler returns a new afsthttp-routing
 // handler which manages per-route and connection-
-// based rate limiting.
+// based rate limiting. Rejected requests are
+// recorded by the passed bucket name in the
+// rate limit rejections metric.
 // Rate limit information is added as 'X-RateLimit-Limit',
 // 'X-RateLimit-Remaining' and 'X-RateLimit-Reset'
 // headers.
@@ -46,21 +58,35 @@ func New() *RateLimitManager {
 // handlers when rate limit is exceed and throws
 // a json error body in combination with a 429
 // status code.
-func (rlm *RateLimitManager) GetHandler(limit time.Duration, burst int) routing.Handler {
+func (rlm *RateLimitManager) GetHandler(name string, limit time.Duration, burst int) routing.Handler {
+	return rlm.GetReserver(name, limit, burst).Handler()
+}
+
+// GetReserver returns a new Reserver of a rate limiter
+// bucket like the bucket of GetHandler, which can be
+// used by handlers consuming a number of tokens
+// depending on the request.
+func (rlm *RateLimitManager) GetReserver(name string, limit time.Duration, burst int) Reserver {
 	rlh := &rateLimitHandler{
-		id: len(rlm.handler),
+		id:   len(rlm.handler),
+		name: name,
 	}
 
-	rlh.handler = func(ctx *routing.Context) error {
+	reserve := func(ctx *routing.Context, n int) bool {
+		if n <= 0 {
+			return true
+		}
+
 		limiterID := fmt.Sprintf("%d#%s",
 			rlh.id, shared.GetIPAddr(ctx))
-		ok, res := rlm.GetLimiter(limiterID, limit, burst).Reserve()
+		ok, res := rlm.GetLimiter(limiterID, limit, burst).ReserveN(n)
 
 		ctx.Response.Header.Set("X-RateLimit-Limit", fmt.Sprintf("%d", res.Burst))
 		ctx.Response.Header.Set("X-RateLimit-Remaining", fmt.Sprintf("%d", res.Remaining))
 		ctx.Response.Header.Set("X-RateLimit-Reset", fmt.Sprintf("%d", res.Reset.Unix()))
 
 		if !ok {
+			metrics.RateLimitRejections.WithLabelValues(rlh.name).Inc()
 			ctx.Abort()
 			ctx.Response.Header.SetContentType("application/json")
 			ctx.SetStatusCode(429)
@@ -68,12 +94,22 @@ func (rlm *RateLimitManager) GetHandler(limit time.Duration, burst int) routing.
 				"{\n  \"code\": 429,\n  \"message\": \"you are being rate limited\"\n}")
 		}
 
-		return nil
+		return ok
 	}
 
+	rlh.handler = Reserver(reserve).Handler()
 	rlm.handler = append(rlm.handler, rlh)
 
-	return rlh.handler
+	return reserve
+}
+
+// Handler returns a handler consuming
+// one token of the Reserver per request.
+func (r Reserver) Handler() routing.Handler {
+	return func(ctx *routing.Context) error {
+		r(ctx, 1)
+		return nil
+	}
 }
 
 // GetLimiter tries to get an existent limiter
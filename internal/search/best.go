@@ -0,0 +1,74 @@
+package search
+
+import (
+	"github.com/myrunes/backend/internal/objects"
+)
+
+// Weights of matching criteria used
+// to rank pages for a game situation.
+const (
+	weightBestRole    = 4
+	weightBestAgainst = 3
+	weightBestMode    = 2
+)
+
+// Criteria describes a game situation pages
+// are looked up for. Champion is required,
+// all other values are optional.
+type Criteria struct {
+	Champion string
+	Against  string
+	Role     string
+	Mode     string
+}
+
+// Best returns all of the passed pages linked to
+// the champion of the passed criteria, ranked by
+// how well they match the other criteria. Pages
+// assigned to another game mode than requested
+// are excluded, because their setup usually does
+// not apply to other modes. Pages with equal
+// scores are sorted by their last modification,
+// most recent first.
+func Best(pages []*objects.Page, c *Criteria) []*Result {
+	results := make([]*Result, 0)
+
+	for _, p := range pages {
+		if score, ok := c.match(p); ok {
+			results = append(results, &Result{
+				Page:  p,
+				Score: score,
+			})
+		}
+	}
+
+	sortResults(results)
+
+	return results
+}
+
+// match returns true and the score of the
+// match if the passed page is applicable
+// for the criteria.
+func (c *Criteria) match(p *objects.Page) (score int, ok bool) {
+	if !p.HasChampion(c.Champion) {
+		return
+	}
+
+	if c.Mode != "" && p.Mode != "" {
+		if p.Mode != c.Mode {
+			return
+		}
+		score += weightBestMode
+	}
+
+	if c.Role != "" && p.Role == c.Role {
+		score += weightBestRole
+	}
+
+	if c.Against != "" && p.IsAgainst(c.Against) {
+		score += weightBestAgainst
+	}
+
+	return score, true
+}
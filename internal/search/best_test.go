@@ -0,0 +1,90 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/myrunes/backend/internal/objects"
+)
+
+func TestBest(t *testing.T) {
+	setupTestDDragon()
+
+	now := time.Now()
+	newPage := func(title, champion, role, mode string, against []string, edited time.Duration) *objects.Page {
+		page := newSearchPage(title, champion, "lethaltempo", "precision", "sorcery", now.Add(-edited))
+		page.Role = role
+		page.Mode = mode
+		page.Against = against
+		return page
+	}
+
+	general := newPage("general", "jinx", "", "", nil, 0)
+	bot := newPage("bot", "jinx", objects.RoleBot, "", nil, time.Hour)
+	botVsLux := newPage("bot vs lux", "jinx", objects.RoleBot, objects.ModeClassic, []string{"lux"}, 2*time.Hour)
+	mid := newPage("mid", "jinx", objects.RoleMid, "", []string{"lux"}, 3*time.Hour)
+	aram := newPage("aram", "jinx", "", objects.ModeARAM, nil, 4*time.Hour)
+	lux := newPage("lux", "lux", objects.RoleBot, "", nil, 0)
+
+	pages := []*objects.Page{general, bot, botVsLux, mid, aram, lux}
+
+	cases := []struct {
+		name     string
+		criteria Criteria
+		exp      []*objects.Page
+		scores   []int
+	}{
+		{
+			"champion only",
+			Criteria{Champion: "jinx"},
+			[]*objects.Page{general, bot, botVsLux, mid, aram},
+			[]int{0, 0, 0, 0, 0},
+		},
+		{
+			"role",
+			Criteria{Champion: "jinx", Role: objects.RoleBot},
+			[]*objects.Page{bot, botVsLux, general, mid, aram},
+			[]int{weightBestRole, weightBestRole, 0, 0, 0},
+		},
+		{
+			"role before opponent",
+			Criteria{Champion: "jinx", Role: objects.RoleBot, Against: "lux"},
+			[]*objects.Page{botVsLux, bot, mid, general, aram},
+			[]int{weightBestRole + weightBestAgainst, weightBestRole, weightBestAgainst, 0, 0},
+		},
+		{
+			// Pages assigned to other modes are excluded,
+			// pages without mode are kept.
+			"mode",
+			Criteria{Champion: "jinx", Mode: objects.ModeClassic},
+			[]*objects.Page{botVsLux, general, bot, mid},
+			[]int{weightBestMode, 0, 0, 0},
+		},
+		{
+			"aram",
+			Criteria{Champion: "jinx", Mode: objects.ModeARAM, Role: objects.RoleBot},
+			[]*objects.Page{bot, aram, general, mid},
+			[]int{weightBestRole, weightBestMode, 0, 0},
+		},
+		{
+			"unknown champion",
+			Criteria{Champion: "missfortune"},
+			[]*objects.Page{},
+			[]int{},
+		},
+	}
+
+	for _, c := range cases {
+		results := Best(pages, &c.criteria)
+		if len(results) != len(c.exp) {
+			t.Errorf("%s: expected %d results, got %d", c.name, len(c.exp), len(results))
+			continue
+		}
+		for i, r := range results {
+			if r.Page != c.exp[i] || r.Score != c.scores[i] {
+				t.Errorf("%s: expected result %d to be %q with score %d, got %q with score %d",
+					c.name, i, c.exp[i].Title, c.scores[i], r.Page.Title, r.Score)
+			}
+		}
+	}
+}
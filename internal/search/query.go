@@ -0,0 +1,181 @@
+// Package search provides a tokenized, fielded
+// search over rune pages including the names of
+// champions, runes and rune trees resolved
+// through ddragon.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// maximum number of terms parsed
+	// from a query string
+	maxTerms = 16
+	// maximum length of a single term
+	maxTermLen = 64
+)
+
+// Fields which can be used in fielded
+// query terms like 'champion:jinx'.
+const (
+	FieldChampion  = "champion"
+	FieldKeystone  = "keystone"
+	FieldRune      = "rune"
+	FieldTree      = "tree"
+	FieldPrimary   = "primary"
+	FieldSecondary = "secondary"
+	FieldTitle     = "title"
+	FieldRole      = "role"
+	FieldMode      = "mode"
+	FieldAgainst   = "against"
+	FieldNotes     = "notes"
+)
+
+// fieldAliases maps alternative field
+// names to their field.
+var fieldAliases = map[string]string{
+	"champ": FieldChampion,
+	"ks":    FieldKeystone,
+	"vs":    FieldAgainst,
+}
+
+// validFields contains all fields which
+// can be used in fielded terms.
+var validFields = map[string]struct{}{
+	FieldChampion:  {},
+	FieldKeystone:  {},
+	FieldRune:      {},
+	FieldTree:      {},
+	FieldPrimary:   {},
+	FieldSecondary: {},
+	FieldTitle:     {},
+	FieldRole:      {},
+	FieldMode:      {},
+	FieldAgainst:   {},
+	FieldNotes:     {},
+}
+
+// Term is a single normalized term
+// of a query. If Field is empty, the
+// term is matched against all fields.
+type Term struct {
+	Field string
+	Value string
+}
+
+// Query is a parsed search query.
+type Query struct {
+	Terms []*Term
+}
+
+// ParseQuery parses the passed query string into
+// a Query. Terms are separated by white spaces.
+// Double quotes can be used to group words into
+// a single term. Terms in the form 'field:value'
+// are parsed as fielded terms if the field is
+// known. Otherwise, the term is treated as free
+// text. Term values are normalized so that they
+// do not contain any special characters.
+func ParseQuery(q string) *Query {
+	query := &Query{
+		Terms: make([]*Term, 0),
+	}
+
+	for _, token := range tokenize(q) {
+		if len(query.Terms) >= maxTerms {
+			break
+		}
+
+		term := new(Term)
+		value := token
+
+		if i := strings.Index(token, ":"); i > 0 {
+			field := strings.ToLower(token[:i])
+			if alias, ok := fieldAliases[field]; ok {
+				field = alias
+			}
+			if _, ok := validFields[field]; ok {
+				term.Field = field
+				value = token[i+1:]
+			}
+		}
+
+		if term.Value = normalize(value); term.Value == "" {
+			continue
+		}
+
+		if len(term.Value) > maxTermLen {
+			term.Value = term.Value[:maxTermLen]
+		}
+
+		query.Terms = append(query.Terms, term)
+	}
+
+	return query
+}
+
+// IsEmpty returns true if the query
+// does not contain any terms.
+func (q *Query) IsEmpty() bool {
+	return len(q.Terms) == 0
+}
+
+// FieldValues returns the values of all
+// terms of the passed field.
+func (q *Query) FieldValues(field string) []string {
+	values := make([]string, 0)
+	for _, t := range q.Terms {
+		if t.Field == field {
+			values = append(values, t.Value)
+		}
+	}
+	return values
+}
+
+// tokenize splits the passed string by white
+// spaces, keeping text enclosed in double
+// quotes together.
+func tokenize(s string) []string {
+	tokens := make([]string, 0)
+
+	var sb strings.Builder
+	var quoted bool
+
+	flush := func() {
+		if sb.Len() > 0 {
+			tokens = append(tokens, sb.String())
+			sb.Reset()
+		}
+	}
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			flush()
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// normalize lowercases the passed string and
+// removes all characters which are neither
+// letters nor digits, so that for example
+// 'Lethal Tempo', 'lethal-tempo' and
+// 'lethaltempo' are equal.
+func normalize(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
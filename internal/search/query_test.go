@@ -0,0 +1,69 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	cases := []struct {
+		query string
+		exp   []Term
+	}{
+		{"", []Term{}},
+		{"   ", []Term{}},
+		{"jinx", []Term{{"", "jinx"}}},
+		{"Lethal-Tempo", []Term{{"", "lethaltempo"}}},
+		{`"lethal tempo" jinx`, []Term{{"", "lethaltempo"}, {"", "jinx"}}},
+		{"champion:Jinx", []Term{{FieldChampion, "jinx"}}},
+		{"CHAMP:jinx ks:lethal vs:lux", []Term{{FieldChampion, "jinx"}, {FieldKeystone, "lethal"}, {FieldAgainst, "lux"}}},
+		{`title:"my page"`, []Term{{FieldTitle, "mypage"}}},
+		{"unknown:value", []Term{{"", "unknownvalue"}}},
+		{":jinx", []Term{{"", "jinx"}}},
+		{"champion:", []Term{}},
+		{"!!! ??? jinx", []Term{{"", "jinx"}}},
+		{".* $where", []Term{{"", "where"}}},
+	}
+
+	for _, c := range cases {
+		q := ParseQuery(c.query)
+		if len(q.Terms) != len(c.exp) {
+			t.Errorf("%q: expected %d terms, got %d", c.query, len(c.exp), len(q.Terms))
+			continue
+		}
+		for i, term := range q.Terms {
+			if *term != c.exp[i] {
+				t.Errorf("%q: expected term %d to be %+v, got %+v", c.query, i, c.exp[i], *term)
+			}
+		}
+		if q.IsEmpty() != (len(c.exp) == 0) {
+			t.Errorf("%q: unexpected IsEmpty result", c.query)
+		}
+	}
+}
+
+func TestParseQueryLimits(t *testing.T) {
+	q := ParseQuery(strings.Repeat("a ", maxTerms+5))
+	if len(q.Terms) != maxTerms {
+		t.Errorf("expected %d terms, got %d", maxTerms, len(q.Terms))
+	}
+
+	q = ParseQuery(strings.Repeat("a", maxTermLen*2))
+	if len(q.Terms) != 1 || len(q.Terms[0].Value) != maxTermLen {
+		t.Errorf("expected a single term of length %d, got %+v", maxTermLen, q.Terms)
+	}
+}
+
+func TestQueryFieldValues(t *testing.T) {
+	q := ParseQuery("champion:jinx free champ:lux role:bot")
+
+	if v := q.FieldValues(FieldChampion); len(v) != 2 || v[0] != "jinx" || v[1] != "lux" {
+		t.Errorf("unexpected champion values %v", v)
+	}
+	if v := q.FieldValues(""); len(v) != 1 || v[0] != "free" {
+		t.Errorf("unexpected free text values %v", v)
+	}
+	if v := q.FieldValues(FieldMode); len(v) != 0 {
+		t.Errorf("unexpected mode values %v", v)
+	}
+}
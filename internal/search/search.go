@@ -0,0 +1,238 @@
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/pkg/ddragon"
+)
+
+// Weights of matches of free text terms
+// used to rank the search results.
+const (
+	weightTitleWord = 4
+	weightTitle     = 2
+	weightChampion  = 3
+	weightKeystone  = 2
+	weightRune      = 1
+	weightTree      = 1
+	weightNotes     = 1
+	weightField     = 1
+)
+
+// Result wraps a page matching a
+// query and the rank of the match.
+type Result struct {
+	Page  *objects.Page `json:"page"`
+	Score int           `json:"score"`
+}
+
+// document contains the normalized
+// searchable values of a page.
+type document struct {
+	titleWords []string
+	title      string
+	champions  []string
+	keystone   []string
+	runes      []string
+	primary    []string
+	secondary  []string
+	role       []string
+	mode       []string
+	against    []string
+	notes      []string
+}
+
+// Pages returns all of the passed pages which match
+// all terms of the passed query, ranked by the score
+// of their match. Pages with equal scores are sorted
+// by their last modification, most recent first.
+func Pages(pages []*objects.Page, q *Query) []*Result {
+	results := make([]*Result, 0)
+
+	for _, p := range pages {
+		if score, ok := q.match(newDocument(p)); ok {
+			results = append(results, &Result{
+				Page:  p,
+				Score: score,
+			})
+		}
+	}
+
+	sortResults(results)
+
+	return results
+}
+
+// sortResults sorts the passed results by their
+// score. Results with equal scores are sorted by
+// the last modification of their pages, most
+// recent first.
+func sortResults(results []*Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Page.Edited.After(results[j].Page.Edited)
+		}
+		return results[i].Score > results[j].Score
+	})
+}
+
+// match returns true and the score of the match
+// if all terms of the query match the passed
+// document.
+func (q *Query) match(d *document) (int, bool) {
+	var score int
+
+	for _, t := range q.Terms {
+		var s int
+
+		switch t.Field {
+		case FieldChampion:
+			s = weightField * matchAny(d.champions, t.Value)
+		case FieldKeystone:
+			s = weightField * matchAny(d.keystone, t.Value)
+		case FieldRune:
+			s = weightField * matchAny(d.runes, t.Value)
+		case FieldTree:
+			s = weightField * (matchAny(d.primary, t.Value) + matchAny(d.secondary, t.Value))
+		case FieldPrimary:
+			s = weightField * matchAny(d.primary, t.Value)
+		case FieldSecondary:
+			s = weightField * matchAny(d.secondary, t.Value)
+		case FieldRole:
+			s = weightField * matchAny(d.role, t.Value)
+		case FieldMode:
+			s = weightField * matchAny(d.mode, t.Value)
+		case FieldAgainst:
+			s = weightField * matchAny(d.against, t.Value)
+		case FieldNotes:
+			s = weightField * matchAny(d.notes, t.Value)
+		case FieldTitle:
+			if strings.Contains(d.title, t.Value) {
+				s = weightField
+			}
+		default:
+			s = d.score(t.Value)
+		}
+
+		if s == 0 {
+			return 0, false
+		}
+		score += s
+	}
+
+	return score, true
+}
+
+// score returns the weighted score of the
+// free text term v matching the document.
+func (d *document) score(v string) (s int) {
+	if matchAny(d.titleWords, v) > 0 {
+		s += weightTitleWord
+	} else if strings.Contains(d.title, v) {
+		s += weightTitle
+	}
+
+	s += weightChampion * matchAny(d.champions, v)
+	s += weightKeystone * matchAny(d.keystone, v)
+	s += weightRune * matchAny(d.runes, v)
+	s += weightTree * (matchAny(d.primary, v) + matchAny(d.secondary, v))
+	s += weightNotes * matchAny(d.notes, v)
+
+	return
+}
+
+// newDocument creates a document from the passed
+// page resolving the names of the champions, runes
+// and trees of the page through ddragon.
+func newDocument(p *objects.Page) *document {
+	d := &document{
+		title: normalize(p.Title),
+	}
+
+	d.titleWords = words(p.Title)
+	d.notes = words(p.Notes)
+	for _, annotation := range p.Annotations {
+		d.notes = append(d.notes, words(annotation)...)
+	}
+
+	d.champions = championNames(p.Champions)
+	d.against = championNames(p.Against)
+	d.role = []string{normalize(p.Role)}
+	d.mode = []string{normalize(p.Mode)}
+
+	if p.Primary != nil {
+		d.primary = treeNames(p.Primary.Tree)
+		d.keystone = runeNames(p.Primary.Rows[0])
+		for _, uid := range p.Primary.Rows {
+			d.runes = append(d.runes, runeNames(uid)...)
+		}
+	}
+
+	if p.Secondary != nil {
+		d.secondary = treeNames(p.Secondary.Tree)
+		for _, uid := range p.Secondary.Rows {
+			d.runes = append(d.runes, runeNames(uid)...)
+		}
+	}
+
+	return d
+}
+
+// words returns the normalized words
+// of the passed text.
+func words(text string) []string {
+	res := make([]string, 0)
+	for _, w := range strings.Fields(text) {
+		if w = normalize(w); w != "" {
+			res = append(res, w)
+		}
+	}
+	return res
+}
+
+// championNames returns the normalized UIDs and
+// names of the champions with the passed UIDs.
+func championNames(uids []string) []string {
+	names := make([]string, 0, len(uids)*2)
+	for _, uid := range uids {
+		names = append(names, normalize(uid))
+		if c := ddragon.DDragonInstance.GetChampion(uid); c != nil {
+			names = append(names, normalize(c.Name))
+		}
+	}
+	return names
+}
+
+// runeNames returns the normalized UID and
+// name of the rune with the passed UID.
+func runeNames(uid string) []string {
+	names := []string{normalize(uid)}
+	if r := ddragon.DDragonInstance.GetRune(uid); r != nil {
+		names = append(names, normalize(r.Name))
+	}
+	return names
+}
+
+// treeNames returns the normalized UID and
+// name of the rune tree with the passed UID.
+func treeNames(uid string) []string {
+	names := []string{normalize(uid)}
+	if t := ddragon.DDragonInstance.GetRuneTree(uid); t != nil {
+		names = append(names, normalize(t.Name))
+	}
+	return names
+}
+
+// matchAny returns 1 if any of the passed
+// values starts with v. Otherwise, 0 is
+// returned.
+func matchAny(values []string, v string) int {
+	for _, val := range values {
+		if strings.HasPrefix(val, v) {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,148 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/pkg/ddragon"
+)
+
+// setupTestDDragon sets a DDragon instance containing
+// the champions, runes and trees used by test pages.
+func setupTestDDragon() {
+	ddragon.DDragonInstance = &ddragon.DDragon{
+		Champions: []*ddragon.Champion{
+			{UID: "jinx", Name: "Jinx"},
+			{UID: "lux", Name: "Lux"},
+			{UID: "missfortune", Name: "Miss Fortune"},
+		},
+		Runes: []*ddragon.RuneTree{
+			{UID: "precision", Name: "Precision", Slots: []*ddragon.RuneSlot{
+				{Runes: []*ddragon.Rune{{UID: "lethaltempo", Name: "Lethal Tempo"}, {UID: "presstheattack", Name: "Press the Attack"}}},
+			}},
+			{UID: "sorcery", Name: "Sorcery", Slots: []*ddragon.RuneSlot{
+				{Runes: []*ddragon.Rune{{UID: "arcanecomet", Name: "Arcane Comet"}}},
+			}},
+		},
+	}
+}
+
+// newSearchPage creates a page with the passed title,
+// champion, keystone and rune trees edited at the
+// passed time.
+func newSearchPage(title, champion, keystone, primary, secondary string, edited time.Time) *objects.Page {
+	page := objects.NewEmptyPage()
+	page.Title = title
+	page.Champions = []string{champion}
+	page.Primary.Tree = primary
+	page.Primary.Rows[0] = keystone
+	page.Secondary.Tree = secondary
+	page.Edited = edited
+	return page
+}
+
+func TestPages(t *testing.T) {
+	setupTestDDragon()
+
+	now := time.Now()
+
+	bot := newSearchPage("Jinx bot lane", "jinx", "lethaltempo", "precision", "sorcery", now.Add(-time.Hour))
+	bot.Notes = "Poke with **rockets**"
+	bot.Role = objects.RoleBot
+	bot.Against = []string{"lux"}
+
+	mid := newSearchPage("Lux mid", "lux", "arcanecomet", "sorcery", "precision", now.Add(-2*time.Hour))
+	mid.Notes = "Good against jinx"
+	mid.Role = objects.RoleMid
+	mid.Mode = objects.ModeARAM
+	mid.Against = []string{"jinx", "missfortune"}
+
+	aggressive := newSearchPage("Aggressive", "jinx", "presstheattack", "precision", "sorcery", now)
+
+	mf := newSearchPage("Bottom", "missfortune", "presstheattack", "precision", "sorcery", now.Add(-3*time.Hour))
+
+	pages := []*objects.Page{bot, mid, aggressive, mf}
+
+	cases := []struct {
+		query string
+		exp   []*objects.Page
+	}{
+		// Title word and champion before champion
+		// only before notes.
+		{"jinx", []*objects.Page{bot, aggressive, mid}},
+		// Equal scores are sorted by modification.
+		{"champion:jinx", []*objects.Page{aggressive, bot}},
+		{"champ:JINX", []*objects.Page{aggressive, bot}},
+		{`"Lethal Tempo"`, []*objects.Page{bot}},
+		{"ks:lethal", []*objects.Page{bot}},
+		{"ks:press", []*objects.Page{aggressive, mf}},
+		{"rune:arcane", []*objects.Page{mid}},
+		{"primary:sorcery", []*objects.Page{mid}},
+		{"secondary:sorcery", []*objects.Page{aggressive, bot, mf}},
+		{"tree:precision", []*objects.Page{aggressive, bot, mid, mf}},
+		{"title:lane", []*objects.Page{bot}},
+		{"notes:rockets", []*objects.Page{bot}},
+		{"miss fortune", []*objects.Page{}},
+		{`"miss fortune"`, []*objects.Page{mf}},
+		{"fort", []*objects.Page{}},
+		{"role:bot", []*objects.Page{bot}},
+		{"role:MID", []*objects.Page{mid}},
+		{"role:support", []*objects.Page{}},
+		{"mode:aram", []*objects.Page{mid}},
+		{"vs:lux", []*objects.Page{bot}},
+		{"vs:jinx", []*objects.Page{mid}},
+		{`vs:"miss fortune"`, []*objects.Page{mid}},
+		{"against:lux champion:jinx", []*objects.Page{bot}},
+		// All terms must match.
+		{"jinx champion:lux", []*objects.Page{mid}},
+		{"jinx rune:arcane champion:jinx", []*objects.Page{}},
+		{"unknown", []*objects.Page{}},
+	}
+
+	for _, c := range cases {
+		results := Pages(pages, ParseQuery(c.query))
+		if len(results) != len(c.exp) {
+			titles := make([]string, len(results))
+			for i, r := range results {
+				titles[i] = r.Page.Title
+			}
+			t.Errorf("%q: expected %d results, got %v", c.query, len(c.exp), titles)
+			continue
+		}
+		for i, r := range results {
+			if r.Page != c.exp[i] {
+				t.Errorf("%q: expected result %d to be %q, got %q", c.query, i, c.exp[i].Title, r.Page.Title)
+			}
+		}
+	}
+}
+
+func TestPagesScore(t *testing.T) {
+	setupTestDDragon()
+
+	page := newSearchPage("Jinx", "jinx", "lethaltempo", "precision", "sorcery", time.Now())
+
+	cases := []struct {
+		query string
+		exp   int
+	}{
+		{"jinx", weightTitleWord + weightChampion},
+		{"jin", weightTitleWord + weightChampion},
+		{"lethal", weightKeystone + weightRune},
+		{"precision", weightTree},
+		{"champion:jinx", weightField},
+		{"jinx precision", weightTitleWord + weightChampion + weightTree},
+	}
+
+	for _, c := range cases {
+		results := Pages([]*objects.Page{page}, ParseQuery(c.query))
+		if len(results) != 1 {
+			t.Errorf("%q: expected a result", c.query)
+			continue
+		}
+		if results[0].Score != c.exp {
+			t.Errorf("%q: expected score %d, got %d", c.query, c.exp, results[0].Score)
+		}
+	}
+}
@@ -6,4 +6,8 @@ const (
 	NodeIDPages
 	NodeIDRefreshTokens
 	NodeIDShares
+	NodeIDTeams
+	NodeIDFolders
+	NodeIDWebhooks
+	NodeIDWebhookDeliveries
 )
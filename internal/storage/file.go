@@ -1,8 +1,10 @@
 package storage
 
 import (
+	"context"
 	"errors"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
 )
@@ -32,7 +34,7 @@ func (f *File) Init(param ...interface{}) (err error) {
 	return nil
 }
 
-func (f *File) BucketExists(name string) (bool, error) {
+func (f *File) BucketExists(ctx context.Context, name string) (bool, error) {
 	stat, err := os.Stat(path.Join(f.location, name))
 	if os.IsNotExist(err) {
 		return false, nil
@@ -46,21 +48,21 @@ func (f *File) BucketExists(name string) (bool, error) {
 	return true, nil
 }
 
-func (f *File) CreateBucket(name string, location ...string) error {
+func (f *File) CreateBucket(ctx context.Context, name string, location ...string) error {
 	return os.MkdirAll(path.Join(f.location, name), os.ModeDir)
 }
 
-func (f *File) CreateBucketIfNotExists(name string, location ...string) (err error) {
-	ok, err := f.BucketExists(name)
+func (f *File) CreateBucketIfNotExists(ctx context.Context, name string, location ...string) (err error) {
+	ok, err := f.BucketExists(ctx, name)
 	if err == nil && !ok {
-		err = f.CreateBucket(name, location...)
+		err = f.CreateBucket(ctx, name, location...)
 	}
 
 	return
 }
 
-func (f *File) PutObject(bucketName string, objectName string, reader io.Reader, objectSize int64, mimeType string) (err error) {
-	if err = f.CreateBucketIfNotExists(bucketName); err != nil {
+func (f *File) PutObject(ctx context.Context, bucketName string, objectName string, reader io.Reader, objectSize int64, mimeType string) (err error) {
+	if err = f.CreateBucketIfNotExists(ctx, bucketName); err != nil {
 		return
 	}
 
@@ -89,7 +91,7 @@ func (f *File) PutObject(bucketName string, objectName string, reader io.Reader,
 	return
 }
 
-func (f *File) GetObject(bucketName string, objectName string) (io.ReadCloser, int64, error) {
+func (f *File) GetObject(ctx context.Context, bucketName string, objectName string) (io.ReadCloser, int64, error) {
 	fd := path.Join(f.location, bucketName, objectName)
 	stat, err := os.Stat(fd)
 	var fh *os.File
@@ -107,7 +109,26 @@ func (f *File) GetObject(bucketName string, objectName string) (io.ReadCloser, i
 	return fh, stat.Size(), err
 }
 
-func (f *File) DeleteObject(bucketName, objectName string) error {
+func (f *File) DeleteObject(ctx context.Context, bucketName, objectName string) error {
 	fd := path.Join(f.location, bucketName, objectName)
 	return os.Remove(fd)
 }
+
+func (f *File) ListObjects(ctx context.Context, bucketName string) ([]string, error) {
+	infos, err := ioutil.ReadDir(path.Join(f.location, bucketName))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if !info.IsDir() {
+			names = append(names, info.Name())
+		}
+	}
+
+	return names, nil
+}
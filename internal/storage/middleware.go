@@ -1,19 +1,27 @@
 package storage
 
 import (
+	"context"
 	"io"
 )
 
 // Middleware interface provides functionalities to
 // access an object storage driver.
+// Bucket and object operations are passed the
+// context of the caller, which is used to trace
+// and, if supported by the driver, cancel them.
 type Middleware interface {
 	Init(param ...interface{}) error
 
-	BucketExists(name string) (bool, error)
-	CreateBucket(name string, location ...string) error
-	CreateBucketIfNotExists(name string, location ...string) error
+	BucketExists(ctx context.Context, name string) (bool, error)
+	CreateBucket(ctx context.Context, name string, location ...string) error
+	CreateBucketIfNotExists(ctx context.Context, name string, location ...string) error
 
-	PutObject(bucketName, objectName string, reader io.Reader, objectSize int64, mimeType string) error
-	GetObject(bucketName, objectName string) (io.ReadCloser, int64, error)
-	DeleteObject(bucketName, objectName string) error
+	PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, objectSize int64, mimeType string) error
+	GetObject(ctx context.Context, bucketName, objectName string) (io.ReadCloser, int64, error)
+	DeleteObject(ctx context.Context, bucketName, objectName string) error
+	// ListObjects returns the names of all objects
+	// of the passed bucket. If the bucket does not
+	// exist, an empty list is returned.
+	ListObjects(ctx context.Context, bucketName string) ([]string, error)
 }
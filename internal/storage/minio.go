@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"errors"
 	"io"
 
@@ -39,35 +40,35 @@ func (m *Minio) Init(params ...interface{}) (err error) {
 	return
 }
 
-func (m *Minio) BucketExists(name string) (bool, error) {
+func (m *Minio) BucketExists(ctx context.Context, name string) (bool, error) {
 	return m.client.BucketExists(name)
 }
 
-func (m *Minio) CreateBucket(name string, location ...string) error {
+func (m *Minio) CreateBucket(ctx context.Context, name string, location ...string) error {
 	return m.client.MakeBucket(name, m.getLocation(location))
 }
 
-func (m *Minio) CreateBucketIfNotExists(name string, location ...string) (err error) {
-	ok, err := m.BucketExists(name)
+func (m *Minio) CreateBucketIfNotExists(ctx context.Context, name string, location ...string) (err error) {
+	ok, err := m.BucketExists(ctx, name)
 	if err == nil && !ok {
-		err = m.CreateBucket(name, location...)
+		err = m.CreateBucket(ctx, name, location...)
 	}
 
 	return
 }
 
-func (m *Minio) PutObject(bucketName, objectName string, reader io.Reader, objectSize int64, mimeType string) (err error) {
-	if err = m.CreateBucketIfNotExists(bucketName, m.location); err != nil {
+func (m *Minio) PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, objectSize int64, mimeType string) (err error) {
+	if err = m.CreateBucketIfNotExists(ctx, bucketName, m.location); err != nil {
 		return
 	}
-	_, err = m.client.PutObject(bucketName, objectName, reader, objectSize, minio.PutObjectOptions{
+	_, err = m.client.PutObjectWithContext(ctx, bucketName, objectName, reader, objectSize, minio.PutObjectOptions{
 		ContentType: mimeType,
 	})
 	return
 }
 
-func (m *Minio) GetObject(bucketName, objectName string) (io.ReadCloser, int64, error) {
-	obj, err := m.client.GetObject(bucketName, objectName, minio.GetObjectOptions{})
+func (m *Minio) GetObject(ctx context.Context, bucketName, objectName string) (io.ReadCloser, int64, error) {
+	obj, err := m.client.GetObjectWithContext(ctx, bucketName, objectName, minio.GetObjectOptions{})
 	if err != nil {
 		return nil, 0, err
 	}
@@ -80,7 +81,7 @@ func (m *Minio) GetObject(bucketName, objectName string) (io.ReadCloser, int64,
 	return obj, stat.Size, err
 }
 
-func (m *Minio) DeleteObject(bucketName, objectName string) error {
+func (m *Minio) DeleteObject(ctx context.Context, bucketName, objectName string) error {
 	return m.client.RemoveObject(bucketName, objectName)
 }
 
@@ -90,3 +91,23 @@ func (m *Minio) getLocation(loc []string) string {
 	}
 	return m.location
 }
+
+func (m *Minio) ListObjects(ctx context.Context, bucketName string) ([]string, error) {
+	ok, err := m.BucketExists(ctx, bucketName)
+	if err != nil || !ok {
+		return []string{}, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	names := make([]string, 0)
+	for obj := range m.client.ListObjectsV2(bucketName, "", true, done) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		names = append(names, obj.Key)
+	}
+
+	return names, nil
+}
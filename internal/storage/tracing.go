@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/myrunes/backend/internal/tracing"
+)
+
+// Tracing wraps a storage Middleware and
+// records each storage call as client span
+// of the span of the passed context.
+type Tracing struct {
+	Middleware
+}
+
+// NewTracing creates a new instance of
+// Tracing wrapping the passed storage
+// Middleware.
+func NewTracing(st Middleware) *Tracing {
+	return &Tracing{st}
+}
+
+// start starts the span of a call of the
+// passed method on the passed bucket.
+func (tr *Tracing) start(ctx context.Context, method, bucketName string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "storage."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("storage.operation", method),
+			attribute.String("storage.bucket", bucketName)))
+}
+
+// end records the passed error of the
+// call in the passed span and ends it.
+func (tr *Tracing) end(span trace.Span, err error) {
+	tracing.RecordError(span, err)
+	span.End()
+}
+
+func (tr *Tracing) BucketExists(ctx context.Context, name string) (bool, error) {
+	ctx, span := tr.start(ctx, "BucketExists", name)
+	ok, err := tr.Middleware.BucketExists(ctx, name)
+	tr.end(span, err)
+	return ok, err
+}
+
+func (tr *Tracing) CreateBucket(ctx context.Context, name string, location ...string) error {
+	ctx, span := tr.start(ctx, "CreateBucket", name)
+	err := tr.Middleware.CreateBucket(ctx, name, location...)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) CreateBucketIfNotExists(ctx context.Context, name string, location ...string) error {
+	ctx, span := tr.start(ctx, "CreateBucketIfNotExists", name)
+	err := tr.Middleware.CreateBucketIfNotExists(ctx, name, location...)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, objectSize int64, mimeType string) error {
+	ctx, span := tr.start(ctx, "PutObject", bucketName)
+	span.SetAttributes(attribute.String("storage.object", objectName))
+	err := tr.Middleware.PutObject(ctx, bucketName, objectName, reader, objectSize, mimeType)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) GetObject(ctx context.Context, bucketName, objectName string) (io.ReadCloser, int64, error) {
+	ctx, span := tr.start(ctx, "GetObject", bucketName)
+	span.SetAttributes(attribute.String("storage.object", objectName))
+	r, size, err := tr.Middleware.GetObject(ctx, bucketName, objectName)
+	tr.end(span, err)
+	return r, size, err
+}
+
+func (tr *Tracing) DeleteObject(ctx context.Context, bucketName, objectName string) error {
+	ctx, span := tr.start(ctx, "DeleteObject", bucketName)
+	span.SetAttributes(attribute.String("storage.object", objectName))
+	err := tr.Middleware.DeleteObject(ctx, bucketName, objectName)
+	tr.end(span, err)
+	return err
+}
+
+func (tr *Tracing) ListObjects(ctx context.Context, bucketName string) ([]string, error) {
+	ctx, span := tr.start(ctx, "ListObjects", bucketName)
+	names, err := tr.Middleware.ListObjects(ctx, bucketName)
+	tr.end(span, err)
+	return names, err
+}
@@ -0,0 +1,130 @@
+// Package tracing sets up the export of the
+// spans recorded by the backend to an
+// OpenTelemetry collector.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/myrunes/backend/internal/logger"
+	"github.com/myrunes/backend/internal/static"
+)
+
+const (
+	// service name reported if no
+	// service name is configured
+	defServiceName = "myrunes-backend"
+	// name of the instrumentation scope
+	// of the exported spans
+	scopeName = "github.com/myrunes/backend"
+)
+
+// ErrInvalidEndpoint is returned by Setup if
+// the configured endpoint is no HTTP URL.
+var ErrInvalidEndpoint = errors.New("endpoint must be a http or https URL")
+
+// Config wraps properties for the
+// export of spans.
+type Config struct {
+	Endpoint    string            `json:"endpoint"`
+	Headers     map[string]string `json:"headers"`
+	ServiceName string            `json:"servicename"`
+	SampleRatio float64           `json:"sampleratio"`
+}
+
+// Setup creates a tracer provider exporting spans
+// via OTLP over HTTP to the traces endpoint of the
+// collector configured in the passed config and sets
+// it as global tracer provider together with the
+// W3C trace context and baggage propagators.
+// A sample ratio of 0 or less samples all traces.
+func Setup(cfg *Config) (*sdktrace.TracerProvider, error) {
+	opts, err := exporterOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = defServiceName
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+			semconv.ServiceVersionKey.String(static.AppVersion))))
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Error("TRACING :: %s", err.Error())
+	}))
+
+	return tp, nil
+}
+
+// Tracer returns the tracer spans of the
+// backend are started with.
+func Tracer() trace.Tracer {
+	return otel.Tracer(scopeName)
+}
+
+// RecordError records the passed error in the
+// passed span and sets the status of the span
+// to error. If the error is nil, the span is
+// not changed.
+func RecordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// exporterOptions returns the options of the
+// OTLP exporter for the passed config.
+func exporterOptions(cfg *Config) ([]otlptracehttp.Option, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidEndpoint
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithHeaders(cfg.Headers),
+	}
+	if u.Path != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+	}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,252 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// testCollector is an OTLP/HTTP traces receiver
+// recording the received export requests.
+type testCollector struct {
+	server   *httptest.Server
+	requests chan *collectortrace.ExportTraceServiceRequest
+	headers  chan http.Header
+}
+
+func newTestCollector(t *testing.T) *testCollector {
+	c := &testCollector{
+		requests: make(chan *collectortrace.ExportTraceServiceRequest, 16),
+		headers:  make(chan http.Header, 16),
+	}
+
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		req := new(collectortrace.ExportTraceServiceRequest)
+		if err = proto.Unmarshal(body, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		c.requests <- req
+		c.headers <- r.Header
+
+		res, _ := proto.Marshal(new(collectortrace.ExportTraceServiceResponse))
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write(res)
+	}))
+	t.Cleanup(c.server.Close)
+
+	return c
+}
+
+// spans returns all spans received by
+// the collector by their names.
+func (c *testCollector) spans(t *testing.T) (map[string]*tracepb.Span, *collectortrace.ExportTraceServiceRequest) {
+	t.Helper()
+
+	select {
+	case req := <-c.requests:
+		spans := make(map[string]*tracepb.Span)
+		for _, rs := range req.GetResourceSpans() {
+			for _, ils := range rs.GetInstrumentationLibrarySpans() {
+				for _, s := range ils.GetSpans() {
+					spans[s.GetName()] = s
+				}
+			}
+		}
+		return spans, req
+	default:
+		t.Fatal("collector received no spans")
+		return nil, nil
+	}
+}
+
+// setupTest sets up tracing exporting to the
+// passed collector and resets the global tracer
+// provider and propagator on cleanup.
+func setupTest(t *testing.T, c *testCollector, cfg *Config) {
+	cfg.Endpoint = c.server.URL + "/v1/traces"
+
+	tp, err := Setup(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	// Shutdown is called by the tests to flush
+	// the spans, so that a second call on cleanup
+	// has no effect.
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+}
+
+// shutdown flushes the recorded spans to
+// the collector.
+func shutdown(t *testing.T) {
+	t.Helper()
+
+	tp, ok := otel.GetTracerProvider().(interface {
+		Shutdown(ctx context.Context) error
+	})
+	if !ok {
+		t.Fatal("global tracer provider is no SDK tracer provider")
+	}
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %s", err.Error())
+	}
+}
+
+func TestSetupExportsSpans(t *testing.T) {
+	c := newTestCollector(t)
+	setupTest(t, c, &Config{
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		ServiceName: "test-service",
+	})
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	_, child := Tracer().Start(ctx, "child", trace.WithSpanKind(trace.SpanKindClient))
+	RecordError(child, context.DeadlineExceeded)
+	child.End()
+	parent.End()
+
+	shutdown(t)
+
+	if auth := (<-c.headers).Get("Authorization"); auth != "Bearer token" {
+		t.Errorf("expected configured header to be sent, got %q", auth)
+	}
+
+	spans, req := c.spans(t)
+
+	p, ok := spans["parent"]
+	if !ok {
+		t.Fatal("parent span was not exported")
+	}
+	ch, ok := spans["child"]
+	if !ok {
+		t.Fatal("child span was not exported")
+	}
+
+	if hex.EncodeToString(ch.GetTraceId()) != hex.EncodeToString(p.GetTraceId()) {
+		t.Error("expected child span to be part of the trace of the parent span")
+	}
+	if hex.EncodeToString(ch.GetParentSpanId()) != hex.EncodeToString(p.GetSpanId()) {
+		t.Error("expected child span to be child of the parent span")
+	}
+	if ch.GetKind() != tracepb.Span_SPAN_KIND_CLIENT {
+		t.Errorf("expected child span to be client span, got %s", ch.GetKind())
+	}
+	if ch.GetStatus().GetCode() != tracepb.Status_STATUS_CODE_ERROR {
+		t.Errorf("expected status of child span to be error, got %s", ch.GetStatus().GetCode())
+	}
+	if len(ch.GetEvents()) != 1 || ch.GetEvents()[0].GetName() != "exception" {
+		t.Error("expected error to be recorded as exception event")
+	}
+	if p.GetStatus().GetCode() == tracepb.Status_STATUS_CODE_ERROR {
+		t.Error("expected status of parent span not to be error")
+	}
+
+	rs := req.GetResourceSpans()[0]
+	if scope := rs.GetInstrumentationLibrarySpans()[0].GetInstrumentationLibrary().GetName(); scope != scopeName {
+		t.Errorf("expected scope %s, got %s", scopeName, scope)
+	}
+	serviceName := ""
+	for _, attr := range rs.GetResource().GetAttributes() {
+		if attr.GetKey() == "service.name" {
+			serviceName = attr.GetValue().GetStringValue()
+		}
+	}
+	if serviceName != "test-service" {
+		t.Errorf("expected service name test-service, got %q", serviceName)
+	}
+}
+
+func TestSetupContinuesRemoteTraces(t *testing.T) {
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+
+	c := newTestCollector(t)
+	setupTest(t, c, &Config{})
+
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier{
+		"Traceparent": {"00-" + traceID + "-" + parentID + "-01"},
+	})
+	_, span := Tracer().Start(ctx, "server", trace.WithSpanKind(trace.SpanKindServer))
+	span.End()
+
+	shutdown(t)
+
+	spans, _ := c.spans(t)
+	s, ok := spans["server"]
+	if !ok {
+		t.Fatal("span was not exported")
+	}
+
+	if id := hex.EncodeToString(s.GetTraceId()); id != traceID {
+		t.Errorf("expected trace ID %s, got %s", traceID, id)
+	}
+	if id := hex.EncodeToString(s.GetParentSpanId()); id != parentID {
+		t.Errorf("expected parent span ID %s, got %s", parentID, id)
+	}
+}
+
+func TestSetupRespectsRemoteSamplingDecision(t *testing.T) {
+	c := newTestCollector(t)
+	setupTest(t, c, &Config{})
+
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier{
+		"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+	})
+	_, span := Tracer().Start(ctx, "server")
+	if span.SpanContext().IsSampled() {
+		t.Error("expected span of unsampled remote trace not to be sampled")
+	}
+	span.End()
+
+	shutdown(t)
+
+	select {
+	case <-c.requests:
+		t.Error("expected no spans to be exported")
+	default:
+	}
+}
+
+func TestSetupInvalidEndpoint(t *testing.T) {
+	for _, endpoint := range []string{
+		"",
+		"localhost:4318",
+		"grpc://localhost:4317",
+		"http://",
+		"http://%zz",
+	} {
+		if _, err := Setup(&Config{Endpoint: endpoint}); err == nil {
+			t.Errorf("expected error for endpoint %q", endpoint)
+		}
+	}
+}
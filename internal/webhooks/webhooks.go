@@ -0,0 +1,364 @@
+// Package webhooks delivers published events to
+// the webhook URLs registered by users and teams.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/myrunes/backend/internal/database"
+	"github.com/myrunes/backend/internal/events"
+	"github.com/myrunes/backend/internal/logger"
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/internal/static"
+	"github.com/myrunes/backend/pkg/netguard"
+	"github.com/myrunes/backend/pkg/workerpool"
+)
+
+// Headers set on webhook deliveries.
+const (
+	HeaderEvent     = "X-Myrunes-Event"
+	HeaderDelivery  = "X-Myrunes-Delivery"
+	HeaderSignature = "X-Myrunes-Signature"
+)
+
+const (
+	// number of workers delivering
+	// webhooks concurrently
+	workers = 5
+	// maximum number of published events
+	// waiting to be dispatched
+	queueSize = 256
+	// timeout of a single delivery
+	// request
+	requestTimeout = 10 * time.Second
+	// maximum number of bytes read
+	// from delivery responses
+	responseReadMax = 64 * 1024
+	// prefix of delivery signatures
+	signaturePrefix = "sha256="
+)
+
+// retryDelays contains the delays before each
+// retry of a failed delivery. The number of
+// delivery attempts is limited to the number
+// of retry delays plus the initial attempt.
+var retryDelays = []time.Duration{
+	10 * time.Second,
+	1 * time.Minute,
+	10 * time.Minute,
+}
+
+// Dispatcher wraps an events.Broker and delivers
+// all events published via the broker to the
+// webhooks of the owner of the events which are
+// subscribed to the type of the event.
+// Published events are queued and dispatched one
+// after another. Deliveries are executed in a
+// worker pool and failed deliveries are retried.
+// Deliveries are only sent to public addresses.
+// Deliveries which are waiting for a retry when the
+// dispatcher is closed are recorded as failed.
+type Dispatcher struct {
+	events.Broker
+
+	db     database.Middleware
+	client *http.Client
+	wp     *workerpool.WorkerPool
+
+	queue      chan *events.Event
+	dispatched chan struct{}
+
+	mtx    sync.RWMutex
+	closed bool
+
+	retriesMtx sync.Mutex
+	retries    map[*delivery]*time.Timer
+}
+
+// delivery wraps a webhook, the record of
+// the delivery to the webhook and the JSON
+// encoded event delivered.
+type delivery struct {
+	webhook *objects.Webhook
+	record  *objects.WebhookDelivery
+	payload []byte
+}
+
+// New creates a new instance of Dispatcher
+// wrapping the passed broker and using the
+// passed database to get the webhooks and to
+// record the deliveries.
+func New(broker events.Broker, db database.Middleware) *Dispatcher {
+	d := &Dispatcher{
+		Broker: broker,
+		db:     db,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			// No proxy is used, because the addresses
+			// dialed are checked by netguard, which
+			// would only check the proxy address.
+			Transport: &http.Transport{
+				DialContext: netguard.DialContext(requestTimeout),
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		wp:         workerpool.New(workers),
+		queue:      make(chan *events.Event, queueSize),
+		dispatched: make(chan struct{}),
+		retries:    make(map[*delivery]*time.Timer),
+	}
+
+	go func() {
+		for res := range d.wp.Results() {
+			if err, _ := res.(error); err != nil {
+				logger.Error("WEBHOOKS :: failed recording delivery: %s", err.Error())
+			}
+		}
+	}()
+
+	go func() {
+		for e := range d.queue {
+			d.dispatch(e)
+		}
+		close(d.dispatched)
+	}()
+
+	return d
+}
+
+// Publish distributes the passed event via the
+// wrapped broker and enqueues it for delivery
+// to the webhooks subscribed to the event. If
+// the queue is full, the event is not delivered
+// to webhooks.
+func (d *Dispatcher) Publish(e *events.Event) error {
+	d.mtx.RLock()
+	if !d.closed {
+		select {
+		case d.queue <- e:
+		default:
+			logger.Warning("WEBHOOKS :: dropped %s event: queue full", e.Type)
+		}
+	}
+	d.mtx.RUnlock()
+
+	return d.Broker.Publish(e)
+}
+
+// Close stops accepting events, waits until all
+// queued events are dispatched and all enqueued
+// deliveries are executed and closes the wrapped
+// broker. Scheduled retries are cancelled and
+// their deliveries are recorded as failed.
+func (d *Dispatcher) Close() error {
+	d.mtx.Lock()
+	closed := d.closed
+	if !closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mtx.Unlock()
+
+	if !closed {
+		<-d.dispatched
+		d.wp.Close()
+		d.wp.WaitBlocking()
+
+		d.retriesMtx.Lock()
+		for dl, timer := range d.retries {
+			// Retries which are already due are
+			// cancelled by retry itself.
+			if timer.Stop() {
+				d.cancel(dl)
+			}
+			delete(d.retries, dl)
+		}
+		d.retriesMtx.Unlock()
+	}
+
+	return d.Broker.Close()
+}
+
+// Sign returns the signature of the passed
+// payload, which is the hex encoded HMAC-SHA256
+// of the payload using the passed secret as key
+// prefixed with "sha256=".
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// dispatch enqueues a delivery of the passed
+// event in the worker pool for each webhook of
+// the owner of the event subscribed to the
+// event type. It blocks while all workers
+// are busy.
+func (d *Dispatcher) dispatch(e *events.Event) {
+	webhooks, err := d.db.GetWebhooks(context.Background(), e.Owner)
+	if err != nil {
+		logger.Error("WEBHOOKS :: failed getting webhooks: %s", err.Error())
+		return
+	}
+
+	var payload []byte
+	for _, w := range webhooks {
+		if !w.Subscribes(e.Type) {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = json.Marshal(e); err != nil {
+				logger.Error("WEBHOOKS :: failed encoding event: %s", err.Error())
+				return
+			}
+		}
+
+		record := objects.NewWebhookDelivery(w, e.Type)
+		if err = d.db.SetWebhookDelivery(context.Background(), record); err != nil {
+			logger.Error("WEBHOOKS :: failed recording delivery: %s", err.Error())
+			continue
+		}
+
+		d.wp.Push(d.jobDeliver, &delivery{w, record, payload})
+	}
+}
+
+// scheduleRetry schedules a retry of the passed
+// delivery after the passed delay. If the dispatcher
+// is closed, the delivery is cancelled instead.
+func (d *Dispatcher) scheduleRetry(dl *delivery, delay time.Duration) {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+
+	if d.closed {
+		d.cancel(dl)
+		return
+	}
+
+	d.retriesMtx.Lock()
+	d.retries[dl] = time.AfterFunc(delay, func() { d.retry(dl) })
+	d.retriesMtx.Unlock()
+}
+
+// retry enqueues the passed delivery in the
+// worker pool, if the dispatcher is not
+// closed. Otherwise, the delivery is
+// cancelled.
+func (d *Dispatcher) retry(dl *delivery) {
+	d.retriesMtx.Lock()
+	delete(d.retries, dl)
+	d.retriesMtx.Unlock()
+
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+
+	if d.closed {
+		d.cancel(dl)
+		return
+	}
+
+	d.wp.Push(d.jobDeliver, dl)
+}
+
+// cancel records the passed delivery, which is
+// waiting for a retry, as failed, because it can
+// not be retried after the dispatcher is closed.
+func (d *Dispatcher) cancel(dl *delivery) {
+	logger.Warning("WEBHOOKS :: cancelled delivery %d: dispatcher closed", dl.record.UID)
+
+	dl.record.Status = objects.WebhookDeliveryFailed
+	dl.record.Error = fmt.Sprintf("%s (retry cancelled on shutdown)", dl.record.Error)
+
+	if err := d.db.SetWebhookDelivery(context.Background(), dl.record); err != nil {
+		logger.Error("WEBHOOKS :: failed recording delivery: %s", err.Error())
+	}
+}
+
+// jobDeliver executes a delivery attempt of the
+// delivery passed as first parameter and records
+// its result. If the attempt failed, a retry is
+// scheduled until the maximum number of attempts
+// is reached.
+func (d *Dispatcher) jobDeliver(workerID int, params ...interface{}) interface{} {
+	dl := params[0].(*delivery)
+	rec := dl.record
+
+	rec.Attempts++
+	rec.LastAttempt = time.Now()
+
+	code, err := d.send(dl)
+	rec.Code = code
+
+	switch {
+	case err == nil:
+		rec.Status = objects.WebhookDeliverySucceeded
+		rec.Error = ""
+	case rec.Attempts <= len(retryDelays):
+		rec.Status = objects.WebhookDeliveryPending
+		rec.Error = err.Error()
+	default:
+		rec.Status = objects.WebhookDeliveryFailed
+		rec.Error = err.Error()
+	}
+
+	if err != nil {
+		logger.Debug("WEBHOOKS :: [%d] delivery %d attempt %d failed: %s",
+			workerID, rec.UID, rec.Attempts, err.Error())
+	}
+
+	err = d.db.SetWebhookDelivery(context.Background(), rec)
+
+	// The retry is scheduled after recording the
+	// attempt, because the record is modified by
+	// the retry.
+	if rec.Status == objects.WebhookDeliveryPending {
+		d.scheduleRetry(dl, retryDelays[rec.Attempts-1])
+	}
+
+	return err
+}
+
+// send sends the payload of the passed delivery
+// to the URL of the webhook and returns the
+// response status code. An error is returned if
+// the request failed or the response status code
+// is not in the 2xx range.
+func (d *Dispatcher) send(dl *delivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, dl.webhook.URL, bytes.NewReader(dl.payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("MYRUNES-Webhooks/%s", static.AppVersion))
+	req.Header.Set(HeaderEvent, dl.record.Event)
+	req.Header.Set(HeaderDelivery, dl.record.UID.String())
+	req.Header.Set(HeaderSignature, Sign(dl.webhook.Secret, dl.payload))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, responseReadMax))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
@@ -0,0 +1,317 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/myrunes/backend/internal/database"
+	"github.com/myrunes/backend/internal/events"
+	"github.com/myrunes/backend/internal/objects"
+)
+
+const testSecret = "test-webhook-secret"
+
+// testDatabase stores webhooks and deliveries in
+// memory. Methods which are not implemented panic
+// by calling the embedded nil middleware.
+type testDatabase struct {
+	database.Middleware
+
+	mtx        sync.Mutex
+	webhooks   []*objects.Webhook
+	deliveries map[snowflake.ID]objects.WebhookDelivery
+}
+
+func newTestDatabase(webhooks ...*objects.Webhook) *testDatabase {
+	return &testDatabase{
+		webhooks:   webhooks,
+		deliveries: make(map[snowflake.ID]objects.WebhookDelivery),
+	}
+}
+
+func (db *testDatabase) GetWebhooks(ctx context.Context, owner snowflake.ID) ([]*objects.Webhook, error) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	webhooks := make([]*objects.Webhook, 0)
+	for _, w := range db.webhooks {
+		if w.Owner == owner {
+			webhooks = append(webhooks, w)
+		}
+	}
+	return webhooks, nil
+}
+
+func (db *testDatabase) SetWebhookDelivery(ctx context.Context, delivery *objects.WebhookDelivery) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	db.deliveries[delivery.UID] = *delivery
+	return nil
+}
+
+// waitForDeliveries waits until the passed number of
+// deliveries is finished and returns them.
+func (db *testDatabase) waitForDeliveries(t *testing.T, n int) []objects.WebhookDelivery {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		db.mtx.Lock()
+		finished := make([]objects.WebhookDelivery, 0, len(db.deliveries))
+		for _, d := range db.deliveries {
+			if d.Status != objects.WebhookDeliveryPending {
+				finished = append(finished, d)
+			}
+		}
+		db.mtx.Unlock()
+
+		if len(finished) >= n {
+			return finished
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("%d deliveries were not finished", n)
+	return nil
+}
+
+// testReceiver is a webhook receiver which verifies
+// the signature of deliveries and fails the passed
+// number of attempts of each delivery.
+type testReceiver struct {
+	*httptest.Server
+
+	mtx      sync.Mutex
+	fails    int
+	attempts map[string]int
+	received []*events.Event
+	invalid  int
+}
+
+func newTestReceiver(t *testing.T, fails int) *testReceiver {
+	r := &testReceiver{
+		fails:    fails,
+		attempts: make(map[string]int),
+	}
+
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		r.mtx.Lock()
+		defer r.mtx.Unlock()
+
+		if req.Header.Get(HeaderSignature) != Sign(testSecret, body) {
+			r.invalid++
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		id := req.Header.Get(HeaderDelivery)
+		r.attempts[id]++
+		if r.attempts[id] <= r.fails {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		e := new(events.Event)
+		if json.Unmarshal(body, e) != nil || e.Type != req.Header.Get(HeaderEvent) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.received = append(r.received, e)
+	}))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+// newTestDispatcher creates a dispatcher with short
+// retry delays which is allowed to deliver to the
+// local test receivers.
+func newTestDispatcher(t *testing.T, db database.Middleware) *Dispatcher {
+	delays := retryDelays
+	retryDelays = []time.Duration{10 * time.Millisecond, 10 * time.Millisecond}
+	t.Cleanup(func() { retryDelays = delays })
+
+	d := New(events.NewInternal(), db)
+	d.client = &http.Client{Timeout: time.Second}
+
+	return d
+}
+
+func newTestWebhook(owner snowflake.ID, url string, evts ...string) *objects.Webhook {
+	return &objects.Webhook{
+		UID:     snowflake.ID(time.Now().UnixNano()),
+		Owner:   owner,
+		URL:     url,
+		Secret:  testSecret,
+		Events:  evts,
+		Enabled: true,
+	}
+}
+
+func newTestEvent(t *testing.T, typ string, owner snowflake.ID) *events.Event {
+	e, err := events.NewEvent(typ, owner, map[string]string{"uid": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestSign(t *testing.T) {
+	sig := Sign("secret", []byte(`{"type":"page.created"}`))
+	if !strings.HasPrefix(sig, signaturePrefix) || len(sig) != len(signaturePrefix)+64 {
+		t.Errorf("unexpected signature format %s", sig)
+	}
+	if sig == Sign("other secret", []byte(`{"type":"page.created"}`)) {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestDeliveryWithRetries(t *testing.T) {
+	receiver := newTestReceiver(t, 2)
+	db := newTestDatabase(
+		newTestWebhook(1, receiver.URL, events.TypePageCreated),
+		newTestWebhook(2, receiver.URL, events.TypePageCreated))
+	d := newTestDispatcher(t, db)
+	defer d.Close()
+
+	// The event type and the owner of the first
+	// event do not match any webhook.
+	d.Publish(newTestEvent(t, events.TypePageDeleted, 1))
+	d.Publish(newTestEvent(t, events.TypePageCreated, 3))
+	d.Publish(newTestEvent(t, events.TypePageCreated, 1))
+
+	deliveries := db.waitForDeliveries(t, 1)
+	if len(deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(deliveries))
+	}
+
+	rec := deliveries[0]
+	if rec.Status != objects.WebhookDeliverySucceeded || rec.Attempts != 3 || rec.Code != http.StatusOK {
+		t.Errorf("unexpected delivery record %+v", rec)
+	}
+
+	receiver.mtx.Lock()
+	defer receiver.mtx.Unlock()
+	if receiver.invalid != 0 {
+		t.Errorf("%d deliveries had invalid signatures", receiver.invalid)
+	}
+	if len(receiver.received) != 1 || receiver.received[0].Owner != 1 {
+		t.Errorf("unexpected received events %+v", receiver.received)
+	}
+}
+
+func TestDeliveryFailsAfterRetries(t *testing.T) {
+	receiver := newTestReceiver(t, 100)
+	db := newTestDatabase(newTestWebhook(1, receiver.URL, events.TypePageCreated))
+	d := newTestDispatcher(t, db)
+	defer d.Close()
+
+	d.Publish(newTestEvent(t, events.TypePageCreated, 1))
+
+	rec := db.waitForDeliveries(t, 1)[0]
+	if rec.Status != objects.WebhookDeliveryFailed || rec.Attempts != len(retryDelays)+1 ||
+		rec.Code != http.StatusServiceUnavailable || rec.Error == "" {
+		t.Errorf("unexpected delivery record %+v", rec)
+	}
+}
+
+func TestDeliveryToNonPublicAddress(t *testing.T) {
+	receiver := newTestReceiver(t, 0)
+	db := newTestDatabase(newTestWebhook(1, receiver.URL, events.TypePageCreated))
+
+	delays := retryDelays
+	retryDelays = nil
+	defer func() { retryDelays = delays }()
+
+	// The default client of the dispatcher
+	// refuses to connect to the local receiver.
+	d := New(events.NewInternal(), db)
+	defer d.Close()
+
+	d.Publish(newTestEvent(t, events.TypePageCreated, 1))
+
+	rec := db.waitForDeliveries(t, 1)[0]
+	if rec.Status != objects.WebhookDeliveryFailed || !strings.Contains(rec.Error, "not public") {
+		t.Errorf("unexpected delivery record %+v", rec)
+	}
+
+	receiver.mtx.Lock()
+	defer receiver.mtx.Unlock()
+	if len(receiver.attempts) != 0 {
+		t.Error("delivery reached the local receiver")
+	}
+}
+
+func TestCloseDeliversQueuedEvents(t *testing.T) {
+	receiver := newTestReceiver(t, 0)
+	db := newTestDatabase(newTestWebhook(1, receiver.URL, events.TypePageCreated))
+	d := newTestDispatcher(t, db)
+
+	for i := 0; i < 10; i++ {
+		d.Publish(newTestEvent(t, events.TypePageCreated, 1))
+	}
+	d.Close()
+
+	receiver.mtx.Lock()
+	n := len(receiver.received)
+	receiver.mtx.Unlock()
+	if n != 10 {
+		t.Errorf("expected 10 delivered events, got %d", n)
+	}
+
+	// Events published after closing
+	// are not delivered anymore.
+	d.Publish(newTestEvent(t, events.TypePageCreated, 1))
+}
+
+func TestCloseCancelsScheduledRetries(t *testing.T) {
+	receiver := newTestReceiver(t, len(retryDelays)+1)
+	db := newTestDatabase(newTestWebhook(1, receiver.URL, events.TypePageCreated))
+	d := newTestDispatcher(t, db)
+	retryDelays = []time.Duration{time.Hour}
+
+	d.Publish(newTestEvent(t, events.TypePageCreated, 1))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		d.retriesMtx.Lock()
+		n := len(d.retries)
+		d.retriesMtx.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("retry was not scheduled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	d.Close()
+
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	if len(db.deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(db.deliveries))
+	}
+	for _, rec := range db.deliveries {
+		if rec.Status != objects.WebhookDeliveryFailed || rec.Attempts != 1 ||
+			!strings.Contains(rec.Error, "cancelled on shutdown") {
+			t.Errorf("unexpected delivery record %+v", rec)
+		}
+	}
+	if len(d.retries) != 0 {
+		t.Error("expected scheduled retries to be removed")
+	}
+}
@@ -0,0 +1,136 @@
+package webserver
+
+import (
+	"context"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/myrunes/backend/internal/caching"
+	"github.com/myrunes/backend/internal/database"
+	"github.com/myrunes/backend/internal/objects"
+)
+
+// AccessControl checks the permissions of users
+// on resources like pages and shares, which are
+// either owned by a user or by a team.
+//
+// Users have all permissions on resources they
+// own themselves. On resources owned by a team,
+// the permissions are granted by the role of
+// the users membership in the team.
+type AccessControl struct {
+	db    database.Middleware
+	cache caching.CacheMiddleware
+}
+
+// NewAccessControl initializes a new AccessControl
+// instance using the passed database and cache
+// middlewares.
+func NewAccessControl(db database.Middleware, cache caching.CacheMiddleware) *AccessControl {
+	return &AccessControl{db, cache}
+}
+
+// Can returns true if the passed user has the
+// passed permission on resources owned by the
+// passed owner, which is either a user or a team.
+func (ac *AccessControl) Can(ctx context.Context, userID, ownerID snowflake.ID, perm objects.Permission) (bool, error) {
+	if userID == ownerID {
+		return true, nil
+	}
+
+	member, err := ac.db.GetTeamMember(ctx, ownerID, userID)
+	if err != nil || member == nil {
+		return false, err
+	}
+
+	return member.Can(perm), nil
+}
+
+// Page returns the page by the passed uid if the
+// passed user has the passed permission on the page.
+// If the page does not exist or the permission is
+// not granted, nil is returned.
+func (ac *AccessControl) Page(ctx context.Context, userID, uid snowflake.ID, perm objects.Permission) (*objects.Page, error) {
+	page, err := ac.cache.GetPageByID(ctx, uid)
+	if err != nil || page == nil {
+		return nil, err
+	}
+
+	if ok, err := ac.Can(ctx, userID, page.Owner, perm); !ok {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// Share returns the share by the passed uid if the
+// passed user has the passed permission on the
+// resources of the owner of the share. If the share
+// does not exist or the permission is not granted,
+// nil is returned.
+func (ac *AccessControl) Share(ctx context.Context, userID, uid snowflake.ID, perm objects.Permission) (*objects.SharePage, error) {
+	share, err := ac.db.GetShare(ctx, "", uid, -1)
+	if err != nil || share == nil {
+		return nil, err
+	}
+
+	if ok, err := ac.Can(ctx, userID, share.OwnerID, perm); !ok {
+		return nil, err
+	}
+
+	return share, nil
+}
+
+// Folder returns the folder by the passed uid if
+// the passed user has the passed permission on the
+// resources of the owner of the folder. If the
+// folder does not exist or the permission is not
+// granted, nil is returned.
+func (ac *AccessControl) Folder(ctx context.Context, userID, uid snowflake.ID, perm objects.Permission) (*objects.Folder, error) {
+	folder, err := ac.db.GetFolder(ctx, uid)
+	if err != nil || folder == nil {
+		return nil, err
+	}
+
+	if ok, err := ac.Can(ctx, userID, folder.Owner, perm); !ok {
+		return nil, err
+	}
+
+	return folder, nil
+}
+
+// Webhook returns the webhook by the passed uid if
+// the passed user has the passed permission on the
+// resources of the owner of the webhook. If the
+// webhook does not exist or the permission is not
+// granted, nil is returned.
+func (ac *AccessControl) Webhook(ctx context.Context, userID, uid snowflake.ID, perm objects.Permission) (*objects.Webhook, error) {
+	webhook, err := ac.db.GetWebhook(ctx, uid)
+	if err != nil || webhook == nil {
+		return nil, err
+	}
+
+	if ok, err := ac.Can(ctx, userID, webhook.Owner, perm); !ok {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// Owners returns the IDs of the passed user and
+// of all teams the user is an accepted member of.
+func (ac *AccessControl) Owners(ctx context.Context, userID snowflake.ID) ([]snowflake.ID, error) {
+	members, err := ac.db.GetUserTeamMembers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := []snowflake.ID{userID}
+	for _, m := range members {
+		if m.Can(objects.PermissionRead) {
+			owners = append(owners, m.TeamID)
+		}
+	}
+
+	return owners, nil
+}
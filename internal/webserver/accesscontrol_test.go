@@ -0,0 +1,125 @@
+package webserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/myrunes/backend/internal/objects"
+)
+
+func TestAccessControl(t *testing.T) {
+	const (
+		teamID = 10
+
+		owner    = 1
+		admin    = 2
+		editor   = 3
+		viewer   = 4
+		pending  = 5
+		stranger = 6
+	)
+
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+
+	for _, m := range []*objects.TeamMember{
+		{TeamID: teamID, UserID: admin, Role: objects.TeamRoleAdmin},
+		{TeamID: teamID, UserID: editor, Role: objects.TeamRoleEditor},
+		{TeamID: teamID, UserID: viewer, Role: objects.TeamRoleViewer},
+		{TeamID: teamID, UserID: pending, Role: objects.TeamRoleAdmin, Pending: true},
+	} {
+		db.SetTeamMember(context.Background(), m)
+	}
+
+	userShare := newTestShare(t, db, owner)
+	teamShare := newTestShare(t, db, teamID)
+
+	cases := []struct {
+		name   string
+		user   snowflake.ID
+		owner  snowflake.ID
+		page   snowflake.ID
+		share  snowflake.ID
+		read   bool
+		write  bool
+		manage bool
+	}{
+		{"owner on own resources", owner, owner, userShare.PageID, userShare.UID, true, true, true},
+		{"team member on foreign user resources", admin, owner, userShare.PageID, userShare.UID, false, false, false},
+		{"stranger on user resources", stranger, owner, userShare.PageID, userShare.UID, false, false, false},
+		{"admin on team resources", admin, teamID, teamShare.PageID, teamShare.UID, true, true, true},
+		{"editor on team resources", editor, teamID, teamShare.PageID, teamShare.UID, true, true, false},
+		{"viewer on team resources", viewer, teamID, teamShare.PageID, teamShare.UID, true, false, false},
+		{"pending member on team resources", pending, teamID, teamShare.PageID, teamShare.UID, false, false, false},
+		{"non-member on team resources", stranger, teamID, teamShare.PageID, teamShare.UID, false, false, false},
+	}
+
+	ctx := context.Background()
+
+	for _, c := range cases {
+		for _, p := range []struct {
+			perm objects.Permission
+			exp  bool
+		}{
+			{objects.PermissionRead, c.read},
+			{objects.PermissionWrite, c.write},
+			{objects.PermissionManage, c.manage},
+		} {
+			ok, err := ws.access.Can(ctx, c.user, c.owner, p.perm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != p.exp {
+				t.Errorf("%s: Can permission %d: expected %t, got %t", c.name, p.perm, p.exp, ok)
+			}
+
+			page, err := ws.access.Page(ctx, c.user, c.page, p.perm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (page != nil) != p.exp {
+				t.Errorf("%s: Page permission %d: expected %t, got %t", c.name, p.perm, p.exp, page != nil)
+			}
+			if page != nil && page.UID != c.page {
+				t.Errorf("%s: Page: expected page %s, got %s", c.name, c.page, page.UID)
+			}
+
+			share, err := ws.access.Share(ctx, c.user, c.share, p.perm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (share != nil) != p.exp {
+				t.Errorf("%s: Share permission %d: expected %t, got %t", c.name, p.perm, p.exp, share != nil)
+			}
+			if share != nil && share.UID != c.share {
+				t.Errorf("%s: Share: expected share %s, got %s", c.name, c.share, share.UID)
+			}
+		}
+	}
+
+	if page, err := ws.access.Page(ctx, owner, 12345, objects.PermissionRead); page != nil || err != nil {
+		t.Errorf("expected unknown page to be nil, got %v, %v", page, err)
+	}
+	if share, err := ws.access.Share(ctx, owner, 12345, objects.PermissionRead); share != nil || err != nil {
+		t.Errorf("expected unknown share to be nil, got %v, %v", share, err)
+	}
+}
+
+func TestAccessControlOwners(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+
+	db.SetTeamMember(context.Background(), &objects.TeamMember{TeamID: 10, UserID: 1, Role: objects.TeamRoleViewer})
+	db.SetTeamMember(context.Background(), &objects.TeamMember{TeamID: 11, UserID: 1, Role: objects.TeamRoleAdmin, Pending: true})
+	db.SetTeamMember(context.Background(), &objects.TeamMember{TeamID: 12, UserID: 2, Role: objects.TeamRoleAdmin})
+
+	owners, err := ws.access.Owners(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owners) != 2 || owners[0] != 1 || owners[1] != 10 {
+		t.Errorf("expected owners [1 10], got %v", owners)
+	}
+}
@@ -1,6 +1,7 @@
 package webserver
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"runtime"
@@ -14,12 +15,16 @@ import (
 
 	"github.com/myrunes/backend/internal/caching"
 	"github.com/myrunes/backend/internal/database"
+	"github.com/myrunes/backend/internal/metrics"
 	"github.com/myrunes/backend/internal/objects"
 	"github.com/myrunes/backend/internal/ratelimit"
 	"github.com/myrunes/backend/internal/shared"
 	"github.com/myrunes/backend/internal/static"
+	"github.com/myrunes/backend/internal/tracing"
 	"github.com/myrunes/backend/pkg/random"
 	routing "github.com/qiangxue/fasthttp-routing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -119,8 +124,15 @@ func NewAuthorization(signingKey []byte, db database.Middleware, cache caching.C
 // together with the actual hash data.
 //
 // This implementation uses Argon2id hash generation.
-func (auth *Authorization) CreateHash(pass string) (string, error) {
-	return argon2id.CreateHash(pass, argon2Params)
+func (auth *Authorization) CreateHash(ctx context.Context, pass string) (string, error) {
+	_, span := tracing.Tracer().Start(ctx, "auth.CreateHash",
+		trace.WithAttributes(attribute.String("hash.algorithm", "argon2id")))
+	defer span.End()
+
+	hash, err := argon2id.CreateHash(pass, argon2Params)
+	tracing.RecordError(span, err)
+
+	return hash, err
 }
 
 // CheckHash tries to compare the passed hash string
@@ -155,10 +167,11 @@ func (auth *Authorization) Login(ctx *routing.Context) bool {
 	limiter := auth.rlm.GetLimiter(fmt.Sprintf("loginAttempt#%s", shared.GetIPAddr(ctx)), attemptLimit, attemptBurst)
 
 	if limiter.Tokens() <= 0 {
+		metrics.RateLimitRejections.WithLabelValues("login_attempt").Inc()
 		return jsonError(ctx, errRateLimited, fasthttp.StatusTooManyRequests) != nil
 	}
 
-	user, err := auth.db.GetUser(snowflake.ID(-1), strings.ToLower(login.UserName))
+	user, err := auth.db.GetUser(requestContext(ctx), snowflake.ID(-1), strings.ToLower(login.UserName))
 	if err != nil {
 		return jsonError(ctx, err, fasthttp.StatusInternalServerError) != nil
 	}
@@ -168,7 +181,7 @@ func (auth *Authorization) Login(ctx *routing.Context) bool {
 	}
 
 	// Querrying user in cache to set cache entry
-	auth.cache.GetUserByID(user.UID)
+	auth.cache.GetUserByID(requestContext(ctx), user.UID)
 
 	if !auth.CheckHash(string(user.PassHash), login.Password) {
 		limiter.Allow()
@@ -176,7 +189,7 @@ func (auth *Authorization) Login(ctx *routing.Context) bool {
 	}
 
 	if token, err := auth.CreateAndSetRefreshToken(ctx, user.UID, login.Remember); err != nil {
-		auth.cache.SetUserByToken(token, user)
+		auth.cache.SetUserByToken(requestContext(ctx), token, user)
 	}
 
 	return true
@@ -198,7 +211,7 @@ func (auth *Authorization) CreateAndSetRefreshToken(ctx *routing.Context, uid sn
 		return
 	}
 
-	err = auth.db.SetRefreshToken((&objects.RefreshToken{
+	err = auth.db.SetRefreshToken(requestContext(ctx), (&objects.RefreshToken{
 		Token:    token,
 		UserID:   uid,
 		Deadline: expires,
@@ -208,7 +221,7 @@ func (auth *Authorization) CreateAndSetRefreshToken(ctx *routing.Context, uid sn
 		return
 	}
 
-	user, err := auth.cache.GetUserByID(uid)
+	user, err := auth.cache.GetUserByID(requestContext(ctx), uid)
 	if err != nil {
 		return
 	}
@@ -218,7 +231,7 @@ func (auth *Authorization) CreateAndSetRefreshToken(ctx *routing.Context, uid sn
 		err = jsonError(ctx, err, fasthttp.StatusBadRequest)
 		return
 	}
-	if err = auth.db.EditUser(user); err != nil {
+	if err = auth.db.EditUser(requestContext(ctx), user); err != nil {
 		err = jsonError(ctx, err, fasthttp.StatusInternalServerError)
 		return
 	}
@@ -242,7 +255,7 @@ func (auth *Authorization) ObtainAccessToken(ctx *routing.Context) (string, erro
 	}
 
 	refreshToken := string(key)
-	token, err := auth.db.GetRefreshToken(refreshToken)
+	token, err := auth.db.GetRefreshToken(requestContext(ctx), refreshToken)
 	if err != nil {
 		return "", jsonError(ctx, err, fasthttp.StatusInternalServerError)
 	}
@@ -252,7 +265,7 @@ func (auth *Authorization) ObtainAccessToken(ctx *routing.Context) (string, erro
 
 	now := time.Now()
 	if now.After(token.Deadline) {
-		auth.db.RemoveRefreshToken(token.ID)
+		auth.db.RemoveRefreshToken(requestContext(ctx), token.ID)
 		return "", jsonError(ctx, errUnauthorized, fasthttp.StatusUnauthorized)
 	}
 
@@ -268,7 +281,7 @@ func (auth *Authorization) ObtainAccessToken(ctx *routing.Context) (string, erro
 	token.LastAccess = time.Now()
 	token.LastAccessClient = string(ctx.Request.Header.Peek("user-agent"))
 	token.LastAccessIP = shared.GetIPAddr(ctx)
-	if err = auth.db.SetRefreshToken(token); err != nil {
+	if err = auth.db.SetRefreshToken(requestContext(ctx), token); err != nil {
 		return "", jsonError(ctx, err, fasthttp.StatusInternalServerError)
 	}
 
@@ -280,55 +293,91 @@ func (auth *Authorization) ObtainAccessToken(ctx *routing.Context) (string, erro
 // session authentication or API token could be
 // identified in the request.
 func (auth *Authorization) CheckRequestAuth(ctx *routing.Context) error {
-	var user *objects.User
-	var err error
-	var authValue string
+	user, authValue, err := auth.getRequestUser(ctx)
+
+	if err == errInvalidAccess {
+		return jsonError(ctx, err, fasthttp.StatusUnauthorized)
+	}
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if user == nil {
+		return jsonError(ctx, errInvalidAccess, fasthttp.StatusUnauthorized)
+	}
 
-	authValueB := ctx.Request.Header.PeekBytes(authorizationHeader)
-	if authValueB != nil && len(authValueB) > 0 {
-		authValue = string(authValueB)
+	ctx.Set("user", user)
+	ctx.Set("apitoken", authValue)
+
+	return nil
+}
+
+// GetRequestUser tries to identify the user
+// authenticated by the passed request context
+// without aborting the handler stack.
+// If the request is not or not validly
+// authenticated, nil is returned for both the
+// user and the error.
+func (auth *Authorization) GetRequestUser(ctx *routing.Context) (*objects.User, error) {
+	user, _, err := auth.getRequestUser(ctx)
+	if err == errInvalidAccess {
+		return nil, nil
 	}
+
+	return user, err
+}
+
+// getRequestUser returns the user authenticated
+// by either an API token or an access token
+// passed in the requests Authorization header
+// together with the passed authorization value.
+// If the passed access token is invalid,
+// errInvalidAccess is returned.
+func (auth *Authorization) getRequestUser(ctx *routing.Context) (*objects.User, string, error) {
+	return auth.userByAuthorization(requestContext(ctx),
+		string(ctx.Request.Header.PeekBytes(authorizationHeader)))
+}
+
+// userByAuthorization returns the user authenticated
+// by the passed value of an Authorization header,
+// which contains either an API token or an access
+// token, together with the passed token.
+// If the passed access token is invalid,
+// errInvalidAccess is returned.
+func (auth *Authorization) userByAuthorization(ctx context.Context, authorization string) (user *objects.User, authValue string, err error) {
+	authValue = authorization
 	if strings.HasPrefix(strings.ToLower(authValue), "basic") {
 		authValue = authValue[6:]
 		var ok bool
-		if user, ok = auth.cache.GetUserByToken(authValue); !ok {
-			if user, err = auth.db.VerifyAPIToken(authValue); err == nil {
-				auth.cache.SetUserByToken(authValue, user)
+		if user, ok = auth.cache.GetUserByToken(ctx, authValue); !ok {
+			if user, err = auth.db.VerifyAPIToken(ctx, authValue); err == nil {
+				auth.cache.SetUserByToken(ctx, authValue, user)
 			}
 		}
 	} else if strings.HasPrefix(strings.ToLower(authValue), "accesstoken ") {
 		authValue = authValue[12:]
 
-		jwtToken, err := jwt.Parse(authValue, func(t *jwt.Token) (interface{}, error) {
+		jwtToken, jwtErr := jwt.Parse(authValue, func(t *jwt.Token) (interface{}, error) {
 			return auth.signingKey, nil
 		})
-		if err != nil || !jwtToken.Valid {
-			return jsonError(ctx, errInvalidAccess, fasthttp.StatusUnauthorized)
+		if jwtErr != nil || !jwtToken.Valid {
+			err = errInvalidAccess
+			return
 		}
 
 		claimsMap, ok := jwtToken.Claims.(jwt.MapClaims)
 		if !ok {
-			return jsonError(ctx, errInvalidAccess, fasthttp.StatusUnauthorized)
+			err = errInvalidAccess
+			return
 		}
 
 		claims := jwt.StandardClaims{}
 		claims.Subject, _ = claimsMap["sub"].(string)
 
 		userID, _ := snowflake.ParseString(claims.Subject)
-		user, err = auth.cache.GetUserByID(userID)
+		user, err = auth.cache.GetUserByID(ctx, userID)
 	}
 
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-	if user == nil {
-		return jsonError(ctx, errInvalidAccess, fasthttp.StatusUnauthorized)
-	}
-
-	ctx.Set("user", user)
-	ctx.Set("apitoken", authValue)
-
-	return nil
+	return
 }
 
 // Logout provides a handler which removes the
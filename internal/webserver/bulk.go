@@ -0,0 +1,213 @@
+package webserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/myrunes/backend/internal/events"
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/valyala/fasthttp"
+)
+
+// Operations which can be executed on
+// pages via the bulk endpoint.
+const (
+	bulkOpDelete         = "delete"
+	bulkOpDuplicate      = "duplicate"
+	bulkOpAddChampion    = "addchampion"
+	bulkOpRemoveChampion = "removechampion"
+	bulkOpSetTags        = "settags"
+)
+
+// maximum number of operations
+// per bulk request
+const bulkOperationsMax = 100
+
+// bulkRequest describes the request model
+// of bulk page operations.
+type bulkRequest struct {
+	Operations []*bulkOperation `json:"operations"`
+}
+
+// bulkOperation describes a single operation
+// executed on the page with the UID Page.
+// Champion is used by the champion operations
+// and Tags by the settags operation.
+type bulkOperation struct {
+	Op       string       `json:"op"`
+	Page     snowflake.ID `json:"page"`
+	Champion string       `json:"champion,omitempty"`
+	Tags     []string     `json:"tags,omitempty"`
+}
+
+// bulkResult describes the result of a single
+// bulk operation. On success, Page contains the
+// modified or created page. On failure, Error
+// contains the failure reason.
+type bulkResult struct {
+	Op    string        `json:"op"`
+	UID   snowflake.ID  `json:"uid"`
+	Code  int           `json:"code"`
+	Error string        `json:"error,omitempty"`
+	Page  *objects.Page `json:"page,omitempty"`
+}
+
+// bulkEvent wraps the type and the affected
+// page of an event published after the
+// execution of bulk operations.
+type bulkEvent struct {
+	typ  string
+	page *objects.Page
+}
+
+// countBulkCreates returns the number of
+// passed operations which create pages.
+func countBulkCreates(ops []*bulkOperation) (n int) {
+	for _, op := range ops {
+		if op.Op == bulkOpDuplicate {
+			n++
+		}
+	}
+	return
+}
+
+// executeBulkOperations executes the passed operations
+// in order as the passed user and returns a result for
+// each operation. Each operation is either applied
+// completely or not at all. A failing operation does
+// not abort the following operations.
+// Cache entries of all affected pages are updated
+// together after all operations were executed.
+// Afterwards, the page events are published.
+func (ws *WebServer) executeBulkOperations(ctx context.Context, userID snowflake.ID, ops []*bulkOperation) []*bulkResult {
+	results := make([]*bulkResult, len(ops))
+	batch := make(map[snowflake.ID]*objects.Page)
+	published := make([]*bulkEvent, 0, len(ops))
+
+	for i, op := range ops {
+		page, status, err := ws.executeBulkOperation(ctx, userID, op, batch)
+		res := &bulkResult{
+			Op:   op.Op,
+			UID:  op.Page,
+			Code: status,
+		}
+
+		switch {
+		case err != nil:
+			res.Error = err.Error()
+		case op.Op == bulkOpDelete:
+			batch[page.UID] = nil
+			published = append(published, &bulkEvent{events.TypePageDeleted, page})
+		case op.Op == bulkOpDuplicate:
+			batch[page.UID] = page
+			res.Page = page
+			published = append(published, &bulkEvent{events.TypePageCreated, page})
+		default:
+			batch[page.UID] = page
+			res.Page = page
+			published = append(published, &bulkEvent{events.TypePageUpdated, page})
+		}
+
+		results[i] = res
+	}
+
+	for uid, page := range batch {
+		ws.cache.SetPageByID(ctx, uid, page)
+	}
+
+	for _, e := range published {
+		if e.typ == events.TypePageDeleted {
+			ws.publish(e.typ, e.page.Owner, &pageDeletedEvent{e.page.UID})
+		} else {
+			ws.publish(e.typ, e.page.Owner, e.page)
+		}
+	}
+
+	return results
+}
+
+// executeBulkOperation executes a single bulk operation
+// as the passed user and returns the resulting page,
+// which is the removed page for delete operations.
+// Pages modified by previous operations of the same
+// request are taken from batch, where deleted pages
+// are nil. The operation is applied on a copy of the
+// page, so that the cached page is not modified if
+// the operation fails. On failure, the returned
+// status code describes the HTTP status of the error.
+func (ws *WebServer) executeBulkOperation(
+	ctx context.Context,
+	userID snowflake.ID,
+	op *bulkOperation,
+	batch map[snowflake.ID]*objects.Page,
+) (*objects.Page, int, error) {
+	var err error
+
+	page, ok := batch[op.Page]
+	if !ok {
+		if page, err = ws.access.Page(ctx, userID, op.Page, objects.PermissionWrite); err != nil {
+			return nil, fasthttp.StatusInternalServerError, err
+		}
+	}
+	if page == nil {
+		return nil, fasthttp.StatusNotFound, errNotFound
+	}
+
+	if op.Op == bulkOpDelete {
+		if err = ws.deletePage(ctx, page); err != nil {
+			return nil, fasthttp.StatusInternalServerError, err
+		}
+		return page, fasthttp.StatusOK, nil
+	}
+
+	if op.Op == bulkOpDuplicate {
+		dup := page.Copy()
+		dup.FinalizeCreate(page.Owner)
+		dup.Folder = page.Folder
+
+		if err = dup.Validate(); err != nil {
+			return nil, fasthttp.StatusBadRequest, err
+		}
+		if err = ws.db.CreatePage(ctx, dup); err != nil {
+			return nil, fasthttp.StatusInternalServerError, err
+		}
+		return dup, fasthttp.StatusCreated, nil
+	}
+
+	updated := *page
+	updated.Champions = append([]string{}, page.Champions...)
+	updated.Edited = time.Now()
+
+	switch op.Op {
+	case bulkOpAddChampion:
+		if !updated.HasChampion(op.Champion) {
+			updated.Champions = append(updated.Champions, op.Champion)
+		}
+	case bulkOpRemoveChampion:
+		champions := make([]string, 0, len(updated.Champions))
+		for _, c := range updated.Champions {
+			if c != op.Champion {
+				champions = append(champions, c)
+			}
+		}
+		updated.Champions = champions
+	case bulkOpSetTags:
+		updated.Tags = op.Tags
+	default:
+		return nil, fasthttp.StatusBadRequest, errInvalidBulkOperation
+	}
+
+	if err = updated.Validate(); err != nil {
+		return nil, fasthttp.StatusBadRequest, err
+	}
+	ok, err = ws.db.EditPageIfUnmodified(ctx, &updated, page.Edited)
+	if err != nil {
+		return nil, fasthttp.StatusInternalServerError, err
+	}
+	if !ok {
+		return nil, fasthttp.StatusPreconditionFailed, errPreconditionFailed
+	}
+
+	return &updated, fasthttp.StatusOK, nil
+}
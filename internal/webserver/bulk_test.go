@@ -0,0 +1,158 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/valyala/fasthttp"
+)
+
+// bulkResponse describes the response
+// model of bulk page operations.
+type bulkResponse struct {
+	N    int           `json:"n"`
+	Data []*bulkResult `json:"data"`
+}
+
+// bulkBody creates the body of a bulk request
+// executing the passed operation on the passed
+// page n times.
+func bulkBody(op string, page snowflake.ID, n int) []byte {
+	ops := make([]string, n)
+	for i := range ops {
+		ops[i] = fmt.Sprintf(`{"op": "%s", "page": "%s"}`, op, page)
+	}
+	return []byte(`{"operations": [` + strings.Join(ops, ",") + `]}`)
+}
+
+func TestBulkOperations(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	auth := addTestUser(ws, db, 1, "alice")
+
+	page := newTestPage(1)
+	db.CreatePage(context.Background(), page)
+	deleted := newTestPage(1)
+	db.CreatePage(context.Background(), deleted)
+	foreign := newTestPage(2)
+	db.CreatePage(context.Background(), foreign)
+
+	body := fmt.Sprintf(`{"operations": [
+		{"op": "addchampion", "page": "%[1]s", "champion": "lux"},
+		{"op": "settags", "page": "%[1]s", "tags": ["bot"]},
+		{"op": "addchampion", "page": "%[1]s", "champion": "unknown"},
+		{"op": "duplicate", "page": "%[1]s"},
+		{"op": "delete", "page": "%[2]s"},
+		{"op": "settags", "page": "%[2]s", "tags": ["mid"]},
+		{"op": "delete", "page": "%[3]s"},
+		{"op": "rename", "page": "%[1]s"}
+	]}`, page.UID, deleted.UID, foreign.UID)
+
+	ctx := ws.request("POST", "/pages/bulk", auth, []byte(body))
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", code, ctx.Response.Body())
+	}
+
+	res := new(bulkResponse)
+	if err := json.Unmarshal(ctx.Response.Body(), res); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int{
+		fasthttp.StatusOK,
+		fasthttp.StatusOK,
+		fasthttp.StatusBadRequest,
+		fasthttp.StatusCreated,
+		fasthttp.StatusOK,
+		fasthttp.StatusNotFound,
+		fasthttp.StatusNotFound,
+		fasthttp.StatusBadRequest,
+	}
+	if res.N != len(expected) {
+		t.Fatalf("expected %d results, got %d", len(expected), res.N)
+	}
+	for i, code := range expected {
+		if res.Data[i].Code != code {
+			t.Errorf("operation %d: expected code %d, got %d (%s)",
+				i, code, res.Data[i].Code, res.Data[i].Error)
+		}
+	}
+
+	// The failed champion operation must not
+	// leave changes on the page.
+	stored, _ := db.GetPage(context.Background(), page.UID)
+	if !stored.HasChampion("lux") || stored.HasChampion("unknown") || !stored.HasTag("bot") {
+		t.Errorf("unexpected stored page: champions %v, tags %v", stored.Champions, stored.Tags)
+	}
+
+	if p, _ := db.GetPage(context.Background(), deleted.UID); p != nil {
+		t.Error("deleted page is still stored")
+	}
+	if p, _ := db.GetPage(context.Background(), foreign.UID); p == nil {
+		t.Error("page of another user was deleted")
+	}
+	if len(db.tombstones) != 1 {
+		t.Errorf("expected 1 tombstone, got %d", len(db.tombstones))
+	}
+
+	dup := res.Data[3].Page
+	if dup == nil || dup.UID == page.UID || dup.Owner != page.Owner {
+		t.Fatalf("unexpected duplicate: %+v", dup)
+	}
+	if p, _ := db.GetPage(context.Background(), dup.UID); p == nil {
+		t.Error("duplicate is not stored")
+	}
+}
+
+func TestBulkOperationsPageCreateRateLimit(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	auth := addTestUser(ws, db, 1, "alice")
+
+	page := newTestPage(1)
+	db.CreatePage(context.Background(), page)
+
+	ctx := ws.request("POST", "/pages/bulk", auth, bulkBody(bulkOpDuplicate, page.UID, pageCreateBurst+1))
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusBadRequest {
+		t.Fatalf("expected status 400 for too many duplicates, got %d", code)
+	}
+
+	ctx = ws.request("POST", "/pages/bulk", auth, bulkBody(bulkOpDuplicate, page.UID, 3))
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", code, ctx.Response.Body())
+	}
+	if rem := string(ctx.Response.Header.Peek("X-RateLimit-Remaining")); rem != "2" {
+		t.Errorf("expected 2 remaining page creations, got %s", rem)
+	}
+
+	// Operations which do not create pages
+	// do not consume page creation tokens.
+	ctx = ws.request("POST", "/pages/bulk", auth, bulkBody(bulkOpAddChampion, page.UID, 10))
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", code, ctx.Response.Body())
+	}
+
+	ctx = ws.request("POST", "/pages/bulk", auth, bulkBody(bulkOpDuplicate, page.UID, 3))
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusTooManyRequests {
+		t.Fatalf("expected status 429, got %d", code)
+	}
+
+	if n := db.numPages(); n != 4 {
+		t.Errorf("expected 4 pages, got %d", n)
+	}
+
+	// The single page creation shares
+	// the bucket with bulk requests.
+	ctx = ws.request("POST", "/pages/bulk", auth, bulkBody(bulkOpDuplicate, page.UID, 2))
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", code, ctx.Response.Body())
+	}
+	ctx = ws.request("POST", "/pages", auth, []byte(`{}`))
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusTooManyRequests {
+		t.Errorf("expected status 429 on page creation, got %d", code)
+	}
+}
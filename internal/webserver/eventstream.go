@@ -0,0 +1,121 @@
+package webserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/myrunes/backend/internal/events"
+	"github.com/myrunes/backend/internal/logger"
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/valyala/fasthttp"
+)
+
+// publish publishes an event of the passed type
+// with the passed data for the passed owner.
+// Failures are logged but not returned, because
+// events must not fail the request which caused
+// them.
+func (ws *WebServer) publish(typ string, owner snowflake.ID, data interface{}) {
+	e, err := events.NewEvent(typ, owner, data)
+	if err == nil {
+		err = ws.events.Publish(e)
+	}
+	if err != nil {
+		logger.Error("WEBSERVER :: failed publishing %s event: %s", typ, err.Error())
+	}
+}
+
+// streamEvents sets up the response of the passed
+// request context as Server-Sent Events stream which
+// sends all events received from the passed channel
+// until the channel is closed, the client disconnects
+// or the done channel is closed. Afterwards, cancel
+// is called.
+// Each event is passed to authorize before it is sent.
+// Events for which authorize returns false are dropped.
+// If authorize returns an error, the stream is ended.
+// Keep alive comments are sent periodically to
+// detect disconnected clients.
+func streamEvents(
+	ctx *fasthttp.RequestCtx,
+	c <-chan *events.Event,
+	cancel func(),
+	done <-chan struct{},
+	authorize func(e *events.Event) (bool, error),
+) {
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	ctx.Response.Header.Set("Connection", "keep-alive")
+	ctx.Response.Header.Set("X-Accel-Buffering", "no")
+
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		keepAlive := time.NewTicker(eventStreamKeepAlive)
+		defer keepAlive.Stop()
+
+		// Flush the headers immediately so that
+		// clients know the stream is established.
+		if _, err := fmt.Fprint(w, ": connected\n\n"); err != nil || w.Flush() != nil {
+			return
+		}
+
+		for {
+			var err error
+
+			select {
+			case e, ok := <-c:
+				if !ok {
+					return
+				}
+				var send bool
+				if send, err = authorize(e); err != nil {
+					return
+				}
+				if !send {
+					continue
+				}
+				var data []byte
+				if data, err = json.Marshal(e); err == nil {
+					_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+				}
+			case <-keepAlive.C:
+				_, err = fmt.Fprint(w, ": keep-alive\n\n")
+			case <-done:
+				return
+			}
+
+			if err != nil || w.Flush() != nil {
+				return
+			}
+		}
+	})
+}
+
+// authorizeEvent returns an event authorization
+// function for streamEvents which checks for each
+// event if the passed Authorization header value
+// still authenticates the user with the passed ID
+// and if this user is still permitted to read the
+// resources of the owner of the event. So, streams
+// end once the authorization is revoked and events
+// of teams the user left are dropped.
+func (ws *WebServer) authorizeEvent(userID snowflake.ID, authorization string) func(e *events.Event) (bool, error) {
+	return func(e *events.Event) (bool, error) {
+		ctx := context.Background()
+
+		user, _, err := ws.auth.userByAuthorization(ctx, authorization)
+		if err != nil {
+			return false, err
+		}
+		if user == nil || user.UID != userID {
+			return false, errInvalidAccess
+		}
+
+		return ws.access.Can(ctx, userID, e.Owner, objects.PermissionRead)
+	}
+}
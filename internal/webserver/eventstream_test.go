@@ -0,0 +1,220 @@
+package webserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+
+	"github.com/myrunes/backend/internal/events"
+	"github.com/myrunes/backend/internal/objects"
+)
+
+// serveTestWebServer serves the web server on an
+// in-memory listener and returns a HTTP client
+// connecting to this listener. The server is shut
+// down on cleanup.
+func serveTestWebServer(t *testing.T, ws *WebServer) *http.Client {
+	ln := fasthttputil.NewInmemoryListener()
+	go ws.server.Serve(ln)
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return ln.Dial()
+		},
+	}
+
+	t.Cleanup(func() {
+		// Idle connections are only closed by the
+		// server after the idle timeout.
+		transport.CloseIdleConnections()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := ws.Shutdown(ctx); err != nil {
+			t.Errorf("shutdown failed: %s", err.Error())
+		}
+	})
+
+	return &http.Client{Transport: transport}
+}
+
+// testEventStream receives the events of
+// an event stream opened by a client.
+type testEventStream struct {
+	events chan *events.Event
+}
+
+// openTestEventStream opens an event stream
+// with the passed authorization and blocks
+// until the stream is established.
+func openTestEventStream(t *testing.T, client *http.Client, authorization string) *testEventStream {
+	t.Helper()
+
+	req, err := http.NewRequest("GET", "http://myrunes"+testPathPrefix+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", authorization)
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+
+	if res.StatusCode != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.StatusCode)
+	}
+
+	s := &testEventStream{events: make(chan *events.Event, 16)}
+	connected := make(chan struct{})
+
+	go func() {
+		defer close(s.events)
+
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == ": connected" {
+				close(connected)
+			}
+			if strings.HasPrefix(line, "data: ") {
+				e := new(events.Event)
+				if json.Unmarshal([]byte(line[6:]), e) == nil {
+					s.events <- e
+				}
+			}
+		}
+	}()
+
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event stream was not established")
+	}
+
+	return s
+}
+
+// next returns the next received event. If the
+// stream was closed, nil and false are returned.
+func (s *testEventStream) next(t *testing.T) (*events.Event, bool) {
+	t.Helper()
+
+	select {
+	case e, ok := <-s.events:
+		return e, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return nil, false
+	}
+}
+
+// expectEvent fails the test if the next event of the
+// stream is not of the passed type and owner.
+func (s *testEventStream) expectEvent(t *testing.T, typ string, owner snowflake.ID) {
+	t.Helper()
+
+	e, ok := s.next(t)
+	if !ok {
+		t.Fatal("stream was closed")
+	}
+	if e.Type != typ || e.Owner != owner {
+		t.Errorf("expected %s event of %s, got %s event of %s", typ, owner, e.Type, e.Owner)
+	}
+}
+
+func TestEventStreamFanOut(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	client := serveTestWebServer(t, ws)
+
+	authAlice := addTestUser(ws, db, 1, "alice")
+	authBob := addTestUser(ws, db, 2, "bob")
+	db.SetTeamMember(context.Background(), &objects.TeamMember{TeamID: 10, UserID: 1, Role: objects.TeamRoleEditor})
+	db.SetTeamMember(context.Background(), &objects.TeamMember{TeamID: 10, UserID: 2, Role: objects.TeamRoleViewer})
+
+	alice := openTestEventStream(t, client, authAlice)
+	bob := openTestEventStream(t, client, authBob)
+
+	ws.publish(events.TypePageCreated, 10, &pageDeletedEvent{1})
+	alice.expectEvent(t, events.TypePageCreated, 10)
+	bob.expectEvent(t, events.TypePageCreated, 10)
+
+	ws.publish(events.TypePageUpdated, 1, &pageDeletedEvent{1})
+	ws.publish(events.TypePageUpdated, 2, &pageDeletedEvent{2})
+	alice.expectEvent(t, events.TypePageUpdated, 1)
+	bob.expectEvent(t, events.TypePageUpdated, 2)
+}
+
+func TestEventStreamReauthorization(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	client := serveTestWebServer(t, ws)
+
+	authAlice := addTestUser(ws, db, 1, "alice")
+	authBob := addTestUser(ws, db, 2, "bob")
+	db.SetTeamMember(context.Background(), &objects.TeamMember{TeamID: 10, UserID: 1, Role: objects.TeamRoleAdmin})
+	db.SetTeamMember(context.Background(), &objects.TeamMember{TeamID: 10, UserID: 2, Role: objects.TeamRoleViewer})
+
+	alice := openTestEventStream(t, client, authAlice)
+	bob := openTestEventStream(t, client, authBob)
+
+	// Events of teams the user left after opening
+	// the stream are not sent anymore.
+	db.DeleteTeamMember(context.Background(), 10, 2)
+
+	ws.publish(events.TypePageDeleted, 10, &pageDeletedEvent{1})
+	ws.publish(events.TypePageDeleted, 2, &pageDeletedEvent{2})
+	alice.expectEvent(t, events.TypePageDeleted, 10)
+	bob.expectEvent(t, events.TypePageDeleted, 2)
+
+	// Streams end once the authorization
+	// of the stream is revoked.
+	ctx := ws.request("DELETE", "/apitoken", authAlice, nil)
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", code, ctx.Response.Body())
+	}
+
+	ws.publish(events.TypePageDeleted, 1, &pageDeletedEvent{1})
+	if e, ok := alice.next(t); ok {
+		t.Errorf("received %s event after the authorization was revoked", e.Type)
+	}
+
+	ws.publish(events.TypePageDeleted, 10, &pageDeletedEvent{1})
+	ws.publish(events.TypePageDeleted, 2, &pageDeletedEvent{2})
+	bob.expectEvent(t, events.TypePageDeleted, 2)
+}
+
+func TestShutdownEndsEventStreams(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	client := serveTestWebServer(t, ws)
+
+	stream := openTestEventStream(t, client, addTestUser(ws, db, 1, "alice"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cShutdown := make(chan error, 1)
+	go func() {
+		cShutdown <- ws.Shutdown(ctx)
+	}()
+
+	if e, ok := stream.next(t); ok {
+		t.Fatalf("expected stream to end, got event %s", e.Type)
+	}
+
+	if err := <-cShutdown; err != nil {
+		t.Errorf("shutdown failed: %s", err.Error())
+	}
+}
@@ -1,971 +1,2383 @@
-package webserver
-
-import (
-	"errors"
-	"fmt"
-	"io"
-	"strings"
-	"time"
-
-	"github.com/myrunes/backend/internal/shared"
-	"github.com/myrunes/backend/pkg/comparison"
-	"github.com/myrunes/backend/pkg/ddragon"
-	"github.com/myrunes/backend/pkg/etag"
-	"github.com/myrunes/backend/pkg/random"
-
-	"github.com/bwmarrin/snowflake"
-	"github.com/myrunes/backend/internal/objects"
-	"github.com/myrunes/backend/internal/static"
-	routing "github.com/qiangxue/fasthttp-routing"
-	"github.com/valyala/fasthttp"
-)
-
-// -----------------------------------------------------
-// --- ASSETS ---
-
-// GET /assets/champions/avatars/:id
-func (ws *WebServer) handlerGetAssetsChampionAvatars(ctx *routing.Context) error {
-	id := ctx.Param("id")
-
-	i := strings.LastIndex(id, ".")
-	if i >= 0 {
-		id = id[:i]
-	}
-
-	reader, size, err := ws.avatarAssetsHandler.Get(id)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-	defer reader.Close()
-
-	imgData := make([]byte, size)
-	_, err = reader.Read(imgData)
-	if err != nil && err != io.EOF {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	eTag := etag.Generate(imgData, false)
-
-	ctx.Response.Header.SetContentType("image/png")
-	// 24h browser caching
-	ctx.Response.Header.Set("Cache-Control", "public, max-age=86400, immutable")
-	ctx.Response.Header.Set("ETag", eTag)
-	ctx.SetBody(imgData)
-	return nil
-}
-
-// -----------------------------------------------------
-// --- AUTHORIZATION ---
-
-// POST /login
-func (ws *WebServer) handlerLogin(ctx *routing.Context) error {
-	if !ws.auth.Login(ctx) {
-		return nil
-	}
-
-	return jsonResponse(ctx, nil, fasthttp.StatusOK)
-}
-
-// GET /accesstoken
-func (ws *WebServer) handlerGetAccessToken(ctx *routing.Context) error {
-	accessToken, err := ws.auth.ObtainAccessToken(ctx)
-	if err != nil {
-		return err
-	}
-	if accessToken == "" {
-		return nil
-	}
-
-	return jsonResponse(ctx, &objects.AccessToken{Token: accessToken}, fasthttp.StatusOK)
-}
-
-// GET /refreshtokens
-func (ws *WebServer) handlerGetRefreshTokens(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-
-	tokens, err := ws.db.GetRefreshTokens(user.UID)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	for _, t := range tokens {
-		t.Sanitize()
-	}
-
-	return jsonResponse(ctx, &listResponse{len(tokens), tokens}, fasthttp.StatusOK)
-}
-
-// DELETE /refreshtokens/:id
-func (ws *WebServer) handlerDeleteRefreshToken(ctx *routing.Context) error {
-	id := ctx.Param("id")
-
-	sfId, err := snowflake.ParseString(id)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	err = ws.db.RemoveRefreshToken(sfId)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	return jsonResponse(ctx, nil, fasthttp.StatusOK)
-}
-
-// -----------------------------------------------------
-// --- USERS ---
-
-// POST /users
-func (ws *WebServer) handlerCreateUser(ctx *routing.Context) error {
-	data := new(loginRequest)
-	if err := parseJSONBody(ctx, data); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if ok, err := ws.validateReCaptcha(ctx, &data.reCaptchaResponse); !ok {
-		return err
-	}
-
-	if data.UserName == "" || data.Password == "" || len(data.Password) < 8 {
-		return jsonError(ctx, errInvalidArguments, fasthttp.StatusBadRequest)
-	}
-
-	user, err := ws.db.GetUser(snowflake.ID(-1), strings.ToLower(data.UserName))
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-	if user != nil {
-		return jsonError(ctx, errUNameInUse, fasthttp.StatusConflict)
-	}
-
-	newUser, err := objects.NewUser(data.UserName, data.Password, ws.auth)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	if err = newUser.Validate(false); err != nil {
-		return jsonResponse(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if err = ws.db.CreateUser(newUser); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	ws.auth.CreateAndSetRefreshToken(ctx, newUser.UID, data.Remember)
-
-	outUser := *newUser
-	outUser.PassHash = nil
-
-	return jsonResponse(ctx, outUser, fasthttp.StatusCreated)
-}
-
-// GET /users/me
-func (ws *WebServer) handlerGetMe(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-	userOut := *user
-	userOut.PassHash = nil
-	userOut.HasOldPassword = isOldPasswordHash(user.PassHash)
-	return jsonResponse(ctx, userOut, fasthttp.StatusOK)
-}
-
-// POST /users/me
-func (ws *WebServer) handlerPostMe(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-	reqUser := new(userRequest)
-	var err error
-
-	if err = parseJSONBody(ctx, reqUser); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if !ws.auth.CheckHash(string(user.PassHash), reqUser.CurrentPassword) {
-		return jsonError(ctx, errUnauthorized, fasthttp.StatusUnauthorized)
-	}
-
-	newUser := &objects.User{
-		Username:    reqUser.Username,
-		DisplayName: reqUser.DisplayName,
-	}
-
-	if err = newUser.Validate(true); err != nil {
-		return jsonResponse(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if reqUser.NewPassword != "" {
-		if len(reqUser.NewPassword) < 8 {
-			return jsonError(ctx, fmt.Errorf("invalid new password"), fasthttp.StatusBadRequest)
-		}
-		var passStr string
-		passStr, err = ws.auth.CreateHash(reqUser.NewPassword)
-		if err != nil {
-			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-		}
-		newUser.PassHash = []byte(passStr)
-	}
-
-	user.Update(newUser, false)
-
-	if err = ws.db.EditUser(user); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	ws.cache.SetUserByID(newUser.UID, user)
-
-	return jsonResponse(ctx, nil, fasthttp.StatusOK)
-}
-
-// DELETE /users/me
-func (ws *WebServer) handlerDeleteMe(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-	reqUser := new(userRequest)
-	var err error
-
-	if err = parseJSONBody(ctx, reqUser); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if !ws.auth.CheckHash(string(user.PassHash), reqUser.CurrentPassword) {
-		return jsonError(ctx, errUnauthorized, fasthttp.StatusUnauthorized)
-	}
-
-	if err = ws.db.DeleteUser(user.UID); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	if err = ws.db.DeleteUserPages(user.UID); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	ws.cache.SetUserByID(user.UID, nil)
-
-	return ws.auth.Logout(ctx)
-}
-
-// GET /users/:username
-func (ws *WebServer) handlerCheckUsername(ctx *routing.Context) error {
-	uname := ctx.Param("uname")
-
-	user, err := ws.db.GetUser(snowflake.ID(-1), strings.ToLower(uname))
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	status := fasthttp.StatusOK
-	if user == nil {
-		status = fasthttp.StatusNotFound
-	}
-
-	return jsonResponse(ctx, nil, status)
-}
-
-// POST /users/me/pageorder
-func (ws *WebServer) handlerPostPageOrder(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-
-	queryArgs := ctx.QueryArgs()
-	champion := string(queryArgs.Peek("champion"))
-
-	if champion == "" {
-		champion = "general"
-	}
-
-	pageOrder := new(pageOrderRequest)
-	if err := parseJSONBody(ctx, pageOrder); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if user.PageOrder == nil {
-		user.PageOrder = make(map[string][]snowflake.ID)
-	}
-
-	user.PageOrder[champion] = pageOrder.PageOrder
-	if err := ws.db.EditUser(user); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	return jsonResponse(ctx, nil, fasthttp.StatusOK)
-}
-
-// POST /users/me/mail
-func (ws *WebServer) handlerPostMail(ctx *routing.Context) error {
-	if ws.ms == nil {
-		return jsonError(ctx, errors.New("mail server disabled by config"), fasthttp.StatusServiceUnavailable)
-	}
-
-	user := ctx.Get("user").(*objects.User)
-
-	mail := new(setMailRequest)
-	if err := parseJSONBody(ctx, mail); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if !ws.auth.CheckHash(string(user.PassHash), mail.CurrentPassword) {
-		return jsonError(ctx, errUnauthorized, fasthttp.StatusUnauthorized)
-	}
-
-	if mail.Reset {
-		user.MailAddress = ""
-		if err := ws.db.EditUser(user); err != nil {
-			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-		}
-
-		ws.cache.SetUserByID(user.UID, user)
-
-		return jsonResponse(ctx, nil, fasthttp.StatusOK)
-	}
-
-	recUser, err := ws.db.GetUser(-1, mail.MailAddress)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-	if recUser != nil && recUser.UID != user.UID {
-		return jsonError(ctx, errEmailAlreadyTaken, fasthttp.StatusBadRequest)
-	}
-
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	token, err := random.String(16, charset)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	mailText := fmt.Sprintf(
-		"Please open the following link to confirm your E-Mail address:\n"+
-			"%s/mailConfirmation?token=%s", ws.config.PublicAddr, token)
-
-	err = ws.ms.SendMailFromDef(mail.MailAddress, "E-Mail confirmation | myrunes", mailText, "text/plain")
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	ws.mailConfirmation.Set(token, &mailConfirmationData{
-		MailAddress: mail.MailAddress,
-		UserID:      user.UID,
-	}, 12*time.Hour)
-
-	return jsonResponse(ctx, nil, fasthttp.StatusOK)
-}
-
-// POST /users/me/mail/confirm
-func (ws *WebServer) handlerPostConfirmMail(ctx *routing.Context) error {
-	token := new(confirmMail)
-	if err := parseJSONBody(ctx, token); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if !ws.mailConfirmation.Contains(token.Token) {
-		return jsonError(ctx, fmt.Errorf("invalid token"), fasthttp.StatusBadRequest)
-	}
-
-	data, ok := ws.mailConfirmation.GetValue(token.Token).(*mailConfirmationData)
-	if !ok {
-		return jsonError(ctx, fmt.Errorf("wrong data struct in timedmap"), fasthttp.StatusInternalServerError)
-	}
-
-	ws.mailConfirmation.Remove(token.Token)
-
-	if user, err := ws.cache.GetUserByID(data.UserID); err == nil && user != nil {
-		user.MailAddress = data.MailAddress
-		if err := ws.db.EditUser(user); err != nil {
-			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-		}
-		ws.cache.SetUserByID(user.UID, user)
-	}
-
-	return jsonResponse(ctx, nil, fasthttp.StatusOK)
-}
-
-// POST /users/me/passwordreset
-func (ws *WebServer) handlerPostPwReset(ctx *routing.Context) error {
-	if ws.ms == nil {
-		return jsonError(ctx, errors.New("mail server disabled by config"), fasthttp.StatusServiceUnavailable)
-	}
-
-	reset := new(passwordReset)
-	if err := parseJSONBody(ctx, reset); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	user, err := ws.db.GetUser(-1, reset.MailAddress)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	if user == nil || user.MailAddress == "" {
-		return jsonResponse(ctx, nil, fasthttp.StatusOK)
-	}
-
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	token, err := random.String(24, charset)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	mailText := fmt.Sprintf("Please follow the link below to reset your accounts password:\n"+
-		"%s/passwordReset?token=%s", ws.config.PublicAddr, token)
-	err = ws.ms.SendMailFromDef(user.MailAddress, "Password reset | myrunes", mailText, "text/plain")
-	if err == nil {
-		ws.pwReset.Set(token, user.UID, 10*time.Minute)
-	}
-
-	return jsonResponse(ctx, nil, fasthttp.StatusOK)
-}
-
-// POST /users/me/passwordreset/confirm
-func (ws *WebServer) handlerPostPwResetConfirm(ctx *routing.Context) error {
-	data := new(confirmPasswordReset)
-	if err := parseJSONBody(ctx, data); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if data.NewPassword == "" || len(data.NewPassword) < 8 {
-		return jsonError(ctx, fmt.Errorf("invalid password length"), fasthttp.StatusBadRequest)
-	}
-
-	if !ws.pwReset.Contains(data.Token) {
-		return jsonError(ctx, fmt.Errorf("invalid token"), fasthttp.StatusBadRequest)
-	}
-
-	if ok, err := ws.validateReCaptcha(ctx, &data.reCaptchaResponse); !ok {
-		return err
-	}
-
-	uID, ok := ws.pwReset.GetValue(data.Token).(snowflake.ID)
-	if !ok {
-		return jsonError(ctx, fmt.Errorf("wrong data struct in timedmap"), fasthttp.StatusInternalServerError)
-	}
-
-	user, err := ws.db.GetUser(uID, "")
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	if user == nil {
-		return jsonError(ctx, fmt.Errorf("unknown user"), fasthttp.StatusBadRequest)
-	}
-
-	ws.pwReset.Remove(data.Token)
-
-	var passStr string
-	passStr, err = ws.auth.CreateHash(data.NewPassword)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-	user.PassHash = []byte(passStr)
-
-	if err = ws.db.EditUser(user); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	return jsonResponse(ctx, nil, fasthttp.StatusOK)
-}
-
-// -----------------------------------------------------
-// --- PAGES ---
-
-// POST /pages
-func (ws *WebServer) handlerCreatePage(ctx *routing.Context) error {
-	var err error
-	page := objects.NewEmptyPage()
-
-	if err = parseJSONBody(ctx, page); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if err = page.Validate(); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	user := ctx.Get("user").(*objects.User)
-	page.FinalizeCreate(user.UID)
-
-	if err = ws.db.CreatePage(page); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-	ws.cache.SetPageByID(page.UID, page)
-
-	return jsonResponse(ctx, page, fasthttp.StatusCreated)
-}
-
-// GET /pages
-func (ws *WebServer) handlerGetPages(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-	queryArgs := ctx.QueryArgs()
-
-	sortBy := string(queryArgs.Peek("sortBy"))
-	filter := string(queryArgs.Peek("filter"))
-	champion := string(queryArgs.Peek("champion"))
-	short := string(queryArgs.Peek("short"))
-
-	if champion == "" {
-		champion = "general"
-	}
-
-	var sortFunc func(i, j *objects.Page) bool
-
-	switch sortBy {
-	case "created":
-		sortFunc = func(i, j *objects.Page) bool {
-			return i.Created.After(j.Created)
-		}
-	case "title":
-		sortFunc = func(i, j *objects.Page) bool {
-			return comparison.Alphabetically(i.Title, j.Title)
-		}
-	case "custom":
-		if user.PageOrder != nil {
-			pageOrder, ok := user.PageOrder[champion]
-			if ok {
-				sortFunc = func(i, j *objects.Page) bool {
-					var pix, jix int
-					for ix, uid := range pageOrder {
-						if uid == i.UID {
-							pix = ix
-						} else if uid == j.UID {
-							jix = ix
-						}
-					}
-					return jix > pix
-				}
-			}
-		}
-	}
-
-	pages, err := ws.db.GetPages(user.UID, champion, filter, sortFunc)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	if comparison.IsTrue(short) {
-		m := make(map[string]int)
-		for _, p := range pages {
-			for _, c := range p.Champions {
-				if _, ok := m[c]; !ok {
-					m[c] = 1
-				} else {
-					m[c]++
-				}
-			}
-		}
-
-		return jsonResponse(ctx, &listResponse{N: len(m), Data: m}, fasthttp.StatusOK)
-	}
-
-	return jsonResponse(ctx, &listResponse{N: len(pages), Data: pages}, fasthttp.StatusOK)
-}
-
-// GET /pages/:id
-func (ws *WebServer) handlerGetPage(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-	_uid := ctx.Param("uid")
-	uid, err := snowflake.ParseString(_uid)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	page, err := ws.cache.GetPageByID(uid)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	if page == nil || page.Owner != user.UID {
-		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
-	}
-
-	return jsonResponse(ctx, page, fasthttp.StatusOK)
-}
-
-// POST /pages/:id
-func (ws *WebServer) handlerEditPage(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-	_uid := ctx.Param("uid")
-	uid, err := snowflake.ParseString(_uid)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	page, err := ws.cache.GetPageByID(uid)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	if page == nil || page.Owner != user.UID {
-		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
-	}
-
-	newPage := new(objects.Page)
-	if err = parseJSONBody(ctx, newPage); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	page.Update(newPage)
-	if err = page.Validate(); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if err = ws.db.EditPage(page); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-	ws.cache.SetPageByID(page.UID, page)
-
-	return jsonResponse(ctx, newPage, fasthttp.StatusOK)
-}
-
-// DELETE /pages/:id
-func (ws *WebServer) handlerDeletePage(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-	_uid := ctx.Param("uid")
-	uid, err := snowflake.ParseString(_uid)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	page, err := ws.cache.GetPageByID(uid)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	if page == nil || page.Owner != user.UID {
-		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
-	}
-
-	if err = ws.db.DeletePage(page.UID); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-	ws.cache.SetPageByID(page.UID, nil)
-
-	return jsonResponse(ctx, nil, fasthttp.StatusOK)
-}
-
-// -----------------------------------------------------
-// --- RESOURCES & STATICS ---
-
-// GET /resources/champions
-func (ws *WebServer) handlerGetChamps(ctx *routing.Context) error {
-	return jsonCachableResponse(ctx, &listResponse{N: len(ddragon.DDragonInstance.Champions), Data: ddragon.DDragonInstance.Champions}, fasthttp.StatusOK)
-}
-
-// GET /resources/runes
-func (ws *WebServer) handlerGetRunes(ctx *routing.Context) error {
-	data := map[string]interface{}{
-		"trees": ddragon.DDragonInstance.Runes,
-		"perks": objects.PerksPool,
-	}
-	return jsonCachableResponse(ctx, data, fasthttp.StatusOK)
-}
-
-// GET /version
-func (ws *WebServer) handlerGetVersion(ctx *routing.Context) error {
-	return jsonCachableResponse(ctx, map[string]string{
-		"version":    static.AppVersion,
-		"apiversion": static.APIVersion,
-		"release":    static.Release,
-	}, fasthttp.StatusOK)
-}
-
-// GET /recaptchainfo
-func (ws *WebServer) handlerGetReCaptchaInfo(ctx *routing.Context) error {
-	if ws.config.ReCaptcha == nil || ws.config.ReCaptcha.SiteKey == "" {
-		return jsonError(ctx, errors.New("not configured"), fasthttp.StatusBadRequest)
-	}
-
-	return jsonCachableResponse(ctx, map[string]string{
-		"sitekey": ws.config.ReCaptcha.SiteKey,
-	}, fasthttp.StatusOK)
-}
-
-// -----------------------------------------------------
-// --- FAVORITES ---
-
-// POST /favorites
-func (ws *WebServer) handlerPostFavorite(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-	var err error
-
-	favReq := new(alterFavoriteRequest)
-	if err = parseJSONBody(ctx, favReq); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if favReq.Favorites == nil {
-		return jsonError(ctx, errBadRequest, fasthttp.StatusBadRequest)
-	}
-
-	champMap := make(map[string]interface{})
-	for _, c := range ddragon.DDragonInstance.Champions {
-		champMap[c.UID] = nil
-	}
-
-	for i, f := range favReq.Favorites {
-		favReq.Favorites[i] = strings.ToLower(f)
-		if _, ok := champMap[f]; !ok {
-			return jsonError(ctx, objects.ErrInvalidChamp, fasthttp.StatusBadRequest)
-		}
-	}
-
-	user.Favorites = favReq.Favorites
-
-	if err = ws.db.EditUser(user); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	return jsonResponse(ctx,
-		listResponse{N: len(user.Favorites), Data: user.Favorites},
-		fasthttp.StatusOK)
-}
-
-// GET /favorites
-func (ws *WebServer) handlerGetFavorites(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-
-	if user.Favorites == nil {
-		user.Favorites = []string{}
-	}
-
-	return jsonResponse(ctx,
-		listResponse{N: len(user.Favorites), Data: user.Favorites},
-		fasthttp.StatusOK)
-}
-
-// -----------------------------------------------------
-// --- SHARES ---
-
-// POST /shares
-func (ws *WebServer) handlerCreateShare(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-	var err error
-
-	params := new(createShareRequest)
-	if err := parseJSONBody(ctx, params); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	pageID, err := snowflake.ParseString(params.Page)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	if page, err := ws.cache.GetPageByID(pageID); err != nil {
-		return jsonResponse(ctx, err, fasthttp.StatusInternalServerError)
-	} else if page == nil || page.Owner != user.UID {
-		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
-	}
-
-	share, err := objects.NewSharePage(user.UID, pageID, params.MaxAccesses, params.Expires)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	if err = ws.db.SetShare(share); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	return jsonResponse(ctx, share, fasthttp.StatusCreated)
-}
-
-// POST /shares/:id
-func (ws *WebServer) handlerPostShare(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-
-	_uid := ctx.Param("uid")
-	uid, err := snowflake.ParseString(_uid)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	share, err := ws.db.GetShare("", uid, -1)
-	if err != nil {
-		return jsonResponse(ctx, err, fasthttp.StatusInternalServerError)
-	}
-	if share == nil {
-		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
-	}
-
-	if share.OwnerID != user.UID {
-		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
-	}
-
-	params := new(createShareRequest)
-	if err := parseJSONBody(ctx, params); err != nil {
-		return jsonError(ctx, errBadRequest, fasthttp.StatusBadRequest)
-	}
-
-	if (params.Expires != time.Time{}) {
-		share.Expires = params.Expires
-	}
-
-	if params.MaxAccesses != 0 {
-		share.MaxAccesses = params.MaxAccesses
-	}
-
-	if err = ws.db.SetShare(share); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	return jsonResponse(ctx, share, fasthttp.StatusCreated)
-}
-
-// GET /shares/:id
-func (ws *WebServer) handlerGetShare(ctx *routing.Context) error {
-	ident := ctx.Param("ident")
-	byIdent := true
-	var user *objects.User
-
-	_user := ctx.Get("user")
-	if _user != nil {
-		user = _user.(*objects.User)
-		byIdent = false
-	}
-
-	shareID, err := snowflake.ParseString(ident)
-	if err != nil || user == nil {
-		shareID = -1
-	}
-
-	share, err := ws.db.GetShare(ident, shareID, shareID)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-	if share == nil || (user != nil && share.OwnerID != user.UID) {
-		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
-	}
-
-	if byIdent && (share.MaxAccesses == 0 || (share.Expires != time.Time{} && share.Expires.Before(time.Now()))) {
-		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
-	}
-
-	page, err := ws.cache.GetPageByID(share.PageID)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-	if page == nil {
-		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
-	}
-
-	owner, err := ws.cache.GetUserByID(page.Owner)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	reqAddr := shared.GetIPAddr(ctx)
-	validReqAddr := !strings.HasPrefix(reqAddr, "192.168") &&
-		!strings.HasPrefix(reqAddr, "10.23") &&
-		!(static.Release == "TRUE" && reqAddr == "127.0.0.1") &&
-		string(ctx.Request.Header.PeekBytes(headerUserAgent)) != static.DiscordUserAgentPingHeaderVal
-
-	if byIdent && validReqAddr {
-		var contains bool
-		for _, ip := range share.AccessIPs {
-			if ip == reqAddr {
-				contains = true
-			}
-		}
-
-		if !contains {
-			share.AccessIPs = append(share.AccessIPs, reqAddr)
-
-			if share.MaxAccesses > 0 {
-				share.MaxAccesses--
-			}
-
-			share.LastAccess = time.Now()
-			share.Accesses++
-
-			if err = ws.db.SetShare(share); err != nil {
-				return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-			}
-		}
-	}
-
-	share.AccessIPs = nil
-
-	return jsonResponse(ctx, &shareResponse{
-		Page:  page,
-		Share: share,
-		User:  owner.Sanitize(),
-	}, fasthttp.StatusAccepted)
-}
-
-// DELETE /shares/:id
-func (ws *WebServer) handlerDeleteShare(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-
-	_uid := ctx.Param("uid")
-	uid, err := snowflake.ParseString(_uid)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusBadRequest)
-	}
-
-	share, err := ws.db.GetShare("", uid, -1)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-	if share == nil || share.OwnerID != user.UID {
-		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
-	}
-
-	if err = ws.db.DeleteShare("", uid, -1); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	return jsonResponse(ctx, nil, fasthttp.StatusOK)
-}
-
-// -----------------------------------------------------
-// --- API TOKEN ---
-
-// POST /apitoken
-func (ws *WebServer) handlerPostAPIToken(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-	var err error
-	token := new(objects.APIToken)
-
-	if token.Token, err = random.Base64(apiTokenLength); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	token.UserID = user.UID
-	token.Created = time.Now()
-
-	if err = ws.db.SetAPIToken(token); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-	ws.cache.SetUserByToken(token.Token, user)
-
-	return jsonResponse(ctx, token, fasthttp.StatusOK)
-}
-
-// GET /apitoken
-func (ws *WebServer) handlerGetAPIToken(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-
-	token, err := ws.db.GetAPIToken(user.UID)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	if token == nil {
-		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
-	}
-
-	return jsonResponse(ctx, token, fasthttp.StatusOK)
-}
-
-// DELETE /apitoken
-func (ws *WebServer) handlerDeleteAPIToken(ctx *routing.Context) error {
-	user := ctx.Get("user").(*objects.User)
-
-	token, err := ws.db.GetAPIToken(user.UID)
-	if err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	if token != nil {
-		ws.cache.SetUserByToken(token.Token, nil)
-	}
-
-	if err := ws.db.ResetAPIToken(user.UID); err != nil {
-		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
-	}
-
-	return jsonResponse(ctx, nil, fasthttp.StatusOK)
-}
+package webserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/myrunes/backend/pkg/comparison"
+	"github.com/myrunes/backend/pkg/ddragon"
+	"github.com/myrunes/backend/pkg/etag"
+	"github.com/myrunes/backend/pkg/random"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/myrunes/backend/internal/events"
+	"github.com/myrunes/backend/internal/health"
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/internal/search"
+	"github.com/myrunes/backend/internal/static"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+// -----------------------------------------------------
+// --- ASSETS ---
+
+// GET /assets/champions/avatars/:id
+func (ws *WebServer) handlerGetAssetsChampionAvatars(ctx *routing.Context) error {
+	id := ctx.Param("id")
+
+	i := strings.LastIndex(id, ".")
+	if i >= 0 {
+		id = id[:i]
+	}
+
+	reader, size, err := ws.avatarAssetsHandler.Get(requestContext(ctx), id)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+	defer reader.Close()
+
+	imgData := make([]byte, size)
+	_, err = reader.Read(imgData)
+	if err != nil && err != io.EOF {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	eTag := etag.Generate(imgData, false)
+
+	ctx.Response.Header.SetContentType("image/png")
+	// 24h browser caching
+	ctx.Response.Header.Set("Cache-Control", "public, max-age=86400, immutable")
+	ctx.Response.Header.Set("ETag", eTag)
+	ctx.SetBody(imgData)
+	return nil
+}
+
+// -----------------------------------------------------
+// --- AUTHORIZATION ---
+
+// POST /login
+func (ws *WebServer) handlerLogin(ctx *routing.Context) error {
+	if !ws.auth.Login(ctx) {
+		return nil
+	}
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// GET /accesstoken
+func (ws *WebServer) handlerGetAccessToken(ctx *routing.Context) error {
+	accessToken, err := ws.auth.ObtainAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	if accessToken == "" {
+		return nil
+	}
+
+	return jsonResponse(ctx, &objects.AccessToken{Token: accessToken}, fasthttp.StatusOK)
+}
+
+// GET /refreshtokens
+func (ws *WebServer) handlerGetRefreshTokens(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	tokens, err := ws.db.GetRefreshTokens(requestContext(ctx), user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	for _, t := range tokens {
+		t.Sanitize()
+	}
+
+	return jsonResponse(ctx, &listResponse{len(tokens), tokens}, fasthttp.StatusOK)
+}
+
+// DELETE /refreshtokens/:id
+func (ws *WebServer) handlerDeleteRefreshToken(ctx *routing.Context) error {
+	id := ctx.Param("id")
+
+	sfId, err := snowflake.ParseString(id)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	err = ws.db.RemoveRefreshToken(requestContext(ctx), sfId)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// -----------------------------------------------------
+// --- USERS ---
+
+// POST /users
+func (ws *WebServer) handlerCreateUser(ctx *routing.Context) error {
+	data := new(loginRequest)
+	if err := parseJSONBody(ctx, data); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if ok, err := ws.validateReCaptcha(ctx, &data.reCaptchaResponse); !ok {
+		return err
+	}
+
+	if data.UserName == "" || data.Password == "" || len(data.Password) < 8 {
+		return jsonError(ctx, errInvalidArguments, fasthttp.StatusBadRequest)
+	}
+
+	user, err := ws.db.GetUser(requestContext(ctx), snowflake.ID(-1), strings.ToLower(data.UserName))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if user != nil {
+		return jsonError(ctx, errUNameInUse, fasthttp.StatusConflict)
+	}
+
+	newUser, err := objects.NewUser(requestContext(ctx), data.UserName, data.Password, ws.auth)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if err = newUser.Validate(false); err != nil {
+		return jsonResponse(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if err = ws.db.CreateUser(requestContext(ctx), newUser); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	ws.auth.CreateAndSetRefreshToken(ctx, newUser.UID, data.Remember)
+
+	outUser := *newUser
+	outUser.PassHash = nil
+
+	return jsonResponse(ctx, outUser, fasthttp.StatusCreated)
+}
+
+// GET /users/me
+func (ws *WebServer) handlerGetMe(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	userOut := *user
+	userOut.PassHash = nil
+	userOut.HasOldPassword = isOldPasswordHash(user.PassHash)
+	return jsonResponse(ctx, userOut, fasthttp.StatusOK)
+}
+
+// POST /users/me
+func (ws *WebServer) handlerPostMe(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	reqUser := new(userRequest)
+	var err error
+
+	if err = parseJSONBody(ctx, reqUser); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if !ws.auth.CheckHash(string(user.PassHash), reqUser.CurrentPassword) {
+		return jsonError(ctx, errUnauthorized, fasthttp.StatusUnauthorized)
+	}
+
+	newUser := &objects.User{
+		Username:    reqUser.Username,
+		DisplayName: reqUser.DisplayName,
+	}
+
+	if err = newUser.Validate(true); err != nil {
+		return jsonResponse(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if reqUser.NewPassword != "" {
+		if len(reqUser.NewPassword) < 8 {
+			return jsonError(ctx, fmt.Errorf("invalid new password"), fasthttp.StatusBadRequest)
+		}
+		var passStr string
+		passStr, err = ws.auth.CreateHash(requestContext(ctx), reqUser.NewPassword)
+		if err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+		newUser.PassHash = []byte(passStr)
+	}
+
+	user.Update(newUser, false)
+
+	if err = ws.db.EditUser(requestContext(ctx), user); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	ws.cache.SetUserByID(requestContext(ctx), newUser.UID, user)
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// DELETE /users/me
+func (ws *WebServer) handlerDeleteMe(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	reqUser := new(userRequest)
+	var err error
+
+	if err = parseJSONBody(ctx, reqUser); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if !ws.auth.CheckHash(string(user.PassHash), reqUser.CurrentPassword) {
+		return jsonError(ctx, errUnauthorized, fasthttp.StatusUnauthorized)
+	}
+
+	if err = ws.db.DeleteUser(requestContext(ctx), user.UID); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if err = ws.db.DeleteUserPages(requestContext(ctx), user.UID); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if err = ws.db.DeleteUserShares(requestContext(ctx), user.UID); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if err = ws.db.DeleteUserFolders(requestContext(ctx), user.UID); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if err = ws.db.DeleteUserWebhooks(requestContext(ctx), user.UID); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	members, err := ws.db.GetUserTeamMembers(requestContext(ctx), user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	for _, m := range members {
+		if err = ws.removeTeamMember(requestContext(ctx), m, true); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+	}
+
+	ws.cache.SetUserByID(requestContext(ctx), user.UID, nil)
+
+	return ws.auth.Logout(ctx)
+}
+
+// GET /users/:username
+func (ws *WebServer) handlerCheckUsername(ctx *routing.Context) error {
+	uname := ctx.Param("uname")
+
+	user, err := ws.db.GetUser(requestContext(ctx), snowflake.ID(-1), strings.ToLower(uname))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	status := fasthttp.StatusOK
+	if user == nil {
+		status = fasthttp.StatusNotFound
+	}
+
+	return jsonResponse(ctx, nil, status)
+}
+
+// POST /users/me/pageorder
+func (ws *WebServer) handlerPostPageOrder(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	queryArgs := ctx.QueryArgs()
+	champion := string(queryArgs.Peek("champion"))
+
+	if champion == "" {
+		champion = "general"
+	}
+
+	pageOrder := new(pageOrderRequest)
+	if err := parseJSONBody(ctx, pageOrder); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if user.PageOrder == nil {
+		user.PageOrder = make(map[string][]snowflake.ID)
+	}
+
+	user.PageOrder[champion] = pageOrder.PageOrder
+	if err := ws.db.EditUser(requestContext(ctx), user); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// GET /users/:uname/profile
+func (ws *WebServer) handlerGetProfile(ctx *routing.Context) error {
+	uname := ctx.Param("uname")
+	champion := string(ctx.QueryArgs().Peek("champion"))
+
+	user, err := ws.db.GetUser(requestContext(ctx), snowflake.ID(-1), strings.ToLower(uname))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if user == nil || user.Username != strings.ToLower(uname) || user.IsProfileHidden() {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	pages, err := ws.db.GetPages(requestContext(ctx), user.UID, champion, "", func(i, j *objects.Page) bool {
+		return i.Created.After(j.Created)
+	})
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	published := make([]*objects.Page, 0)
+	for _, p := range pages {
+		if p.Published {
+			published = append(published, p)
+		}
+	}
+
+	return jsonResponse(ctx, &profileResponse{
+		User:  user.Sanitize(),
+		N:     len(published),
+		Pages: published,
+	}, fasthttp.StatusOK)
+}
+
+// POST /users/me/privacy
+func (ws *WebServer) handlerPostPrivacy(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	privacy := new(objects.UserPrivacy)
+	if err := parseJSONBody(ctx, privacy); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	user.Privacy = privacy
+	if err := ws.db.EditUser(requestContext(ctx), user); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	ws.cache.SetUserByID(requestContext(ctx), user.UID, user)
+
+	return jsonResponse(ctx, privacy, fasthttp.StatusOK)
+}
+
+// POST /users/me/mail
+func (ws *WebServer) handlerPostMail(ctx *routing.Context) error {
+	if ws.ms == nil {
+		return jsonError(ctx, errors.New("mail server disabled by config"), fasthttp.StatusServiceUnavailable)
+	}
+
+	user := ctx.Get("user").(*objects.User)
+
+	mail := new(setMailRequest)
+	if err := parseJSONBody(ctx, mail); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if !ws.auth.CheckHash(string(user.PassHash), mail.CurrentPassword) {
+		return jsonError(ctx, errUnauthorized, fasthttp.StatusUnauthorized)
+	}
+
+	if mail.Reset {
+		user.MailAddress = ""
+		if err := ws.db.EditUser(requestContext(ctx), user); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+
+		ws.cache.SetUserByID(requestContext(ctx), user.UID, user)
+
+		return jsonResponse(ctx, nil, fasthttp.StatusOK)
+	}
+
+	recUser, err := ws.db.GetUser(requestContext(ctx), -1, mail.MailAddress)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if recUser != nil && recUser.UID != user.UID {
+		return jsonError(ctx, errEmailAlreadyTaken, fasthttp.StatusBadRequest)
+	}
+
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	token, err := random.String(16, charset)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	mailText := fmt.Sprintf(
+		"Please open the following link to confirm your E-Mail address:\n"+
+			"%s/mailConfirmation?token=%s", ws.config.PublicAddr, token)
+
+	err = ws.ms.SendMailFromDef(requestContext(ctx), mail.MailAddress, "E-Mail confirmation | myrunes", mailText, "text/plain")
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	ws.mailConfirmation.Set(token, &mailConfirmationData{
+		MailAddress: mail.MailAddress,
+		UserID:      user.UID,
+	}, 12*time.Hour)
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// POST /users/me/mail/confirm
+func (ws *WebServer) handlerPostConfirmMail(ctx *routing.Context) error {
+	token := new(confirmMail)
+	if err := parseJSONBody(ctx, token); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if !ws.mailConfirmation.Contains(token.Token) {
+		return jsonError(ctx, fmt.Errorf("invalid token"), fasthttp.StatusBadRequest)
+	}
+
+	data, ok := ws.mailConfirmation.GetValue(token.Token).(*mailConfirmationData)
+	if !ok {
+		return jsonError(ctx, fmt.Errorf("wrong data struct in timedmap"), fasthttp.StatusInternalServerError)
+	}
+
+	ws.mailConfirmation.Remove(token.Token)
+
+	if user, err := ws.cache.GetUserByID(requestContext(ctx), data.UserID); err == nil && user != nil {
+		user.MailAddress = data.MailAddress
+		if err := ws.db.EditUser(requestContext(ctx), user); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+		ws.cache.SetUserByID(requestContext(ctx), user.UID, user)
+	}
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// POST /users/me/passwordreset
+func (ws *WebServer) handlerPostPwReset(ctx *routing.Context) error {
+	if ws.ms == nil {
+		return jsonError(ctx, errors.New("mail server disabled by config"), fasthttp.StatusServiceUnavailable)
+	}
+
+	reset := new(passwordReset)
+	if err := parseJSONBody(ctx, reset); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	user, err := ws.db.GetUser(requestContext(ctx), -1, reset.MailAddress)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if user == nil || user.MailAddress == "" {
+		return jsonResponse(ctx, nil, fasthttp.StatusOK)
+	}
+
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	token, err := random.String(24, charset)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	mailText := fmt.Sprintf("Please follow the link below to reset your accounts password:\n"+
+		"%s/passwordReset?token=%s", ws.config.PublicAddr, token)
+	err = ws.ms.SendMailFromDef(requestContext(ctx), user.MailAddress, "Password reset | myrunes", mailText, "text/plain")
+	if err == nil {
+		ws.pwReset.Set(token, user.UID, 10*time.Minute)
+	}
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// POST /users/me/passwordreset/confirm
+func (ws *WebServer) handlerPostPwResetConfirm(ctx *routing.Context) error {
+	data := new(confirmPasswordReset)
+	if err := parseJSONBody(ctx, data); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if data.NewPassword == "" || len(data.NewPassword) < 8 {
+		return jsonError(ctx, fmt.Errorf("invalid password length"), fasthttp.StatusBadRequest)
+	}
+
+	if !ws.pwReset.Contains(data.Token) {
+		return jsonError(ctx, fmt.Errorf("invalid token"), fasthttp.StatusBadRequest)
+	}
+
+	if ok, err := ws.validateReCaptcha(ctx, &data.reCaptchaResponse); !ok {
+		return err
+	}
+
+	uID, ok := ws.pwReset.GetValue(data.Token).(snowflake.ID)
+	if !ok {
+		return jsonError(ctx, fmt.Errorf("wrong data struct in timedmap"), fasthttp.StatusInternalServerError)
+	}
+
+	user, err := ws.db.GetUser(requestContext(ctx), uID, "")
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if user == nil {
+		return jsonError(ctx, fmt.Errorf("unknown user"), fasthttp.StatusBadRequest)
+	}
+
+	ws.pwReset.Remove(data.Token)
+
+	var passStr string
+	passStr, err = ws.auth.CreateHash(requestContext(ctx), data.NewPassword)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	user.PassHash = []byte(passStr)
+
+	if err = ws.db.EditUser(requestContext(ctx), user); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// -----------------------------------------------------
+// --- PAGES ---
+
+// POST /pages
+func (ws *WebServer) handlerCreatePage(ctx *routing.Context) error {
+	var err error
+	page := objects.NewEmptyPage()
+
+	if err = parseJSONBody(ctx, page); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if err = page.Validate(); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	user := ctx.Get("user").(*objects.User)
+	team := string(ctx.QueryArgs().Peek("team"))
+
+	owner, status, err := ws.getRequestedOwner(requestContext(ctx), user.UID, team, objects.PermissionWrite)
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	page.FinalizeCreate(owner)
+
+	if status, err = ws.checkFolderParent(requestContext(ctx), page.Owner, page.Folder, nil); err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	if err = ws.db.CreatePage(requestContext(ctx), page); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	ws.cache.SetPageByID(requestContext(ctx), page.UID, page)
+	ws.publish(events.TypePageCreated, page.Owner, page)
+
+	ctx.Response.Header.SetBytesK(headerETag, pageETag(page))
+
+	return jsonResponse(ctx, page, fasthttp.StatusCreated)
+}
+
+// GET /pages
+func (ws *WebServer) handlerGetPages(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	short := string(ctx.QueryArgs().Peek("short"))
+
+	pages, status, err := ws.queryPages(ctx, user)
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	if comparison.IsTrue(short) {
+		m := make(map[string]int)
+		for _, p := range pages {
+			for _, c := range p.Champions {
+				if _, ok := m[c]; !ok {
+					m[c] = 1
+				} else {
+					m[c]++
+				}
+			}
+		}
+
+		return jsonResponse(ctx, &listResponse{N: len(m), Data: m}, fasthttp.StatusOK)
+	}
+
+	return jsonResponse(ctx, &listResponse{N: len(pages), Data: pages}, fasthttp.StatusOK)
+}
+
+// GET /pages/suggest
+func (ws *WebServer) handlerGetPageSuggestions(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	uid := string(ctx.QueryArgs().Peek("champion"))
+
+	champ := ddragon.DDragonInstance.GetChampion(uid)
+	if champ == nil {
+		return jsonError(ctx, objects.ErrInvalidChamp, fasthttp.StatusBadRequest)
+	}
+
+	pages, err := ws.db.GetPages(requestContext(ctx), user.UID, "", "", func(i, j *objects.Page) bool {
+		return i.Edited.After(j.Edited)
+	})
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	suggestions := make([]*objects.PageSuggestion, 0)
+	seen := make(map[string]struct{})
+
+	add := func(s *objects.PageSuggestion) {
+		key := pageRunesKey(s.Page)
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			suggestions = append(suggestions, s)
+		}
+	}
+
+	for _, p := range sortPagesBySimilarity(pages, champ) {
+		if len(suggestions) >= pageSuggestionsOwnMax {
+			break
+		}
+		if s, err := objects.NewPageSuggestion(p, champ.UID); err == nil {
+			add(s)
+		}
+	}
+
+	stats, err := ws.cache.GetChampionStats(requestContext(ctx), champ.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if stats != nil && len(stats.StatShards) > 0 {
+		for i, keystone := range stats.Keystones {
+			if i >= pageSuggestionsCommunityMax {
+				break
+			}
+
+			primary := ddragon.DDragonInstance.GetKeystoneTree(keystone.UID)
+			if primary == nil {
+				continue
+			}
+
+			for _, secondary := range stats.SecondaryTrees {
+				if secondary.UID == primary.UID {
+					continue
+				}
+				s, err := objects.NewCommunityPageSuggestion(
+					champ.UID, keystone.UID, secondary.UID, stats.StatShards[0].Rows)
+				if err == nil {
+					add(s)
+				}
+				break
+			}
+		}
+	}
+
+	return jsonResponse(ctx, &listResponse{N: len(suggestions), Data: suggestions}, fasthttp.StatusOK)
+}
+
+// GET /pages/search
+func (ws *WebServer) handlerGetPagesSearch(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	queryArgs := ctx.QueryArgs()
+
+	q := string(queryArgs.Peek("q"))
+	team := string(queryArgs.Peek("team"))
+	teams := string(queryArgs.Peek("teams"))
+
+	if len(q) > searchQueryMaxLen {
+		return jsonError(ctx, errSearchQueryTooLong, fasthttp.StatusBadRequest)
+	}
+
+	query := search.ParseQuery(q)
+	if query.IsEmpty() {
+		return jsonError(ctx, errEmptySearchQuery, fasthttp.StatusBadRequest)
+	}
+
+	owners, status, err := ws.getPageOwners(requestContext(ctx), user.UID, team, comparison.IsTrue(teams))
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	pages, err := ws.getOwnersPages(requestContext(ctx), owners, "", "")
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	results := search.Pages(pages, query)
+
+	return jsonResponse(ctx, &listResponse{N: len(results), Data: results}, fasthttp.StatusOK)
+}
+
+// POST /pages/bulk
+func (ws *WebServer) handlerPostPagesBulk(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	params := new(bulkRequest)
+	if err := parseJSONBody(ctx, params); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if len(params.Operations) == 0 {
+		return jsonError(ctx, errInvalidArguments, fasthttp.StatusBadRequest)
+	}
+	if len(params.Operations) > bulkOperationsMax {
+		return jsonError(ctx, errTooManyBulkOperations, fasthttp.StatusBadRequest)
+	}
+
+	// Each created page consumes a token of the
+	// page creation rate limit like a single
+	// page creation request.
+	creates := countBulkCreates(params.Operations)
+	if creates > pageCreateBurst {
+		return jsonError(ctx, errTooManyBulkCreates, fasthttp.StatusBadRequest)
+	}
+	if !ws.reservePageCreates(ctx, creates) {
+		return nil
+	}
+
+	results := ws.executeBulkOperations(requestContext(ctx), user.UID, params.Operations)
+
+	return jsonResponse(ctx, &listResponse{N: len(results), Data: results}, fasthttp.StatusOK)
+}
+
+// GET /pages/best
+func (ws *WebServer) handlerGetBestPage(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	queryArgs := ctx.QueryArgs()
+
+	team := string(queryArgs.Peek("team"))
+	teams := string(queryArgs.Peek("teams"))
+
+	criteria := &search.Criteria{
+		Champion: string(queryArgs.Peek("champion")),
+		Against:  string(queryArgs.Peek("against")),
+		Role:     string(queryArgs.Peek("role")),
+		Mode:     string(queryArgs.Peek("mode")),
+	}
+
+	dd := ddragon.DDragonInstance
+	if dd.GetChampion(criteria.Champion) == nil ||
+		(criteria.Against != "" && dd.GetChampion(criteria.Against) == nil) {
+		return jsonError(ctx, objects.ErrInvalidChamp, fasthttp.StatusBadRequest)
+	}
+	if criteria.Role != "" && !objects.IsValidRole(criteria.Role) {
+		return jsonError(ctx, objects.ErrInvalidRole, fasthttp.StatusBadRequest)
+	}
+	if criteria.Mode != "" && !objects.IsValidMode(criteria.Mode) {
+		return jsonError(ctx, objects.ErrInvalidMode, fasthttp.StatusBadRequest)
+	}
+
+	owners, status, err := ws.getPageOwners(requestContext(ctx), user.UID, team, comparison.IsTrue(teams))
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	pages, err := ws.getOwnersPages(requestContext(ctx), owners, criteria.Champion, "")
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	results := search.Best(pages, criteria)
+	if len(results) == 0 {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	return jsonResponse(ctx, results[0], fasthttp.StatusOK)
+}
+
+// GET /pages/tags
+func (ws *WebServer) handlerGetPageTags(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	queryArgs := ctx.QueryArgs()
+
+	team := string(queryArgs.Peek("team"))
+	teams := string(queryArgs.Peek("teams"))
+
+	owners, status, err := ws.getPageOwners(requestContext(ctx), user.UID, team, comparison.IsTrue(teams))
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	pages, err := ws.getOwnersPages(requestContext(ctx), owners, "", "")
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	counts := make(map[string]int)
+	for _, p := range pages {
+		for _, t := range p.Tags {
+			counts[t]++
+		}
+	}
+
+	tags := make([]*tagResponse, 0, len(counts))
+	for t, n := range counts {
+		tags = append(tags, &tagResponse{Tag: t, Pages: n})
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Pages == tags[j].Pages {
+			return tags[i].Tag < tags[j].Tag
+		}
+		return tags[i].Pages > tags[j].Pages
+	})
+
+	return jsonResponse(ctx, &listResponse{N: len(tags), Data: tags}, fasthttp.StatusOK)
+}
+
+// POST /pages/tags/rename
+func (ws *WebServer) handlerPostPageTagsRename(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	team := string(ctx.QueryArgs().Peek("team"))
+
+	params := new(tagRenameRequest)
+	if err := parseJSONBody(ctx, params); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	from := objects.NormalizeTag(params.From)
+	to := objects.NormalizeTag(params.To)
+	if !objects.IsValidTag(from) || !objects.IsValidTag(to) {
+		return jsonError(ctx, objects.ErrInvalidTag, fasthttp.StatusBadRequest)
+	}
+
+	owner, status, err := ws.getRequestedOwner(requestContext(ctx), user.UID, team, objects.PermissionWrite)
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	pages, err := ws.db.GetPages(requestContext(ctx), owner, "", "", nil)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	updated := make([]*objects.Page, 0)
+	for _, p := range pages {
+		if from == to || !p.RenameTag(from, to) {
+			continue
+		}
+		if err = ws.db.EditPage(requestContext(ctx), p); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+		ws.cache.SetPageByID(requestContext(ctx), p.UID, p)
+		ws.publish(events.TypePageUpdated, p.Owner, p)
+		updated = append(updated, p)
+	}
+
+	return jsonResponse(ctx, &listResponse{N: len(updated), Data: updated}, fasthttp.StatusOK)
+}
+
+// GET /pages/:id
+func (ws *WebServer) handlerGetPage(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	page, status, err := ws.getRequestedPage(ctx, user)
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	ctx.Response.Header.SetBytesK(headerETag, pageETag(page))
+
+	return jsonResponse(ctx, page, fasthttp.StatusOK)
+}
+
+// POST /pages/:id
+func (ws *WebServer) handlerEditPage(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	_uid := ctx.Param("uid")
+	uid, err := snowflake.ParseString(_uid)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	page, err := ws.access.Page(requestContext(ctx), user.UID, uid, objects.PermissionWrite)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if page == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	if err = checkPagePreconditions(ctx, page); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusPreconditionFailed)
+	}
+
+	newPage := new(objects.Page)
+	if err = parseJSONBody(ctx, newPage); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	updated := *page
+	updated.Update(newPage)
+	if err = updated.Validate(); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if status, err := ws.checkFolderParent(requestContext(ctx), updated.Owner, updated.Folder, nil); err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	ok, err := ws.db.EditPageIfUnmodified(requestContext(ctx), &updated, page.Edited)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if !ok {
+		ws.cache.SetPageByID(requestContext(ctx), page.UID, nil)
+		return jsonError(ctx, errPreconditionFailed, fasthttp.StatusPreconditionFailed)
+	}
+	ws.cache.SetPageByID(requestContext(ctx), updated.UID, &updated)
+	ws.publish(events.TypePageUpdated, updated.Owner, &updated)
+
+	ctx.Response.Header.SetBytesK(headerETag, pageETag(&updated))
+
+	return jsonResponse(ctx, &updated, fasthttp.StatusOK)
+}
+
+// DELETE /pages/:id
+func (ws *WebServer) handlerDeletePage(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	_uid := ctx.Param("uid")
+	uid, err := snowflake.ParseString(_uid)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	page, err := ws.access.Page(requestContext(ctx), user.UID, uid, objects.PermissionWrite)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if page == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	if err = checkPagePreconditions(ctx, page); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusPreconditionFailed)
+	}
+
+	if err = ws.deletePage(requestContext(ctx), page); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	ws.cache.SetPageByID(requestContext(ctx), page.UID, nil)
+	ws.publish(events.TypePageDeleted, page.Owner, &pageDeletedEvent{page.UID})
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// -----------------------------------------------------
+// --- FOLDERS ---
+
+// POST /folders
+func (ws *WebServer) handlerCreateFolder(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	team := string(ctx.QueryArgs().Peek("team"))
+
+	params := new(folderRequest)
+	if err := parseJSONBody(ctx, params); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	owner, status, err := ws.getRequestedOwner(requestContext(ctx), user.UID, team, objects.PermissionWrite)
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	var parent snowflake.ID
+	if params.Parent != nil {
+		parent = *params.Parent
+	}
+
+	if status, err = ws.checkFolderParent(requestContext(ctx), owner, parent, nil); err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	folder, err := objects.NewFolder(owner, params.Name, parent)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if err = ws.db.SetFolder(requestContext(ctx), folder); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, folder, fasthttp.StatusCreated)
+}
+
+// GET /folders
+func (ws *WebServer) handlerGetFolders(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	team := string(ctx.QueryArgs().Peek("team"))
+
+	owner, status, err := ws.getRequestedOwner(requestContext(ctx), user.UID, team, objects.PermissionRead)
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	folders, err := ws.db.GetFolders(requestContext(ctx), owner)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, &listResponse{N: len(folders), Data: folders}, fasthttp.StatusOK)
+}
+
+// POST /folders/:uid
+func (ws *WebServer) handlerPostFolder(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	folder, err := ws.access.Folder(requestContext(ctx), user.UID, uid, objects.PermissionWrite)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if folder == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	params := new(folderRequest)
+	if err = parseJSONBody(ctx, params); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if params.Name != "" {
+		if err = folder.SetName(params.Name); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusBadRequest)
+		}
+	}
+
+	if params.Parent != nil {
+		if status, err := ws.checkFolderParent(requestContext(ctx), folder.Owner, *params.Parent, folder); err != nil {
+			return jsonError(ctx, err, status)
+		}
+		folder.Parent = *params.Parent
+	}
+
+	if err = ws.db.SetFolder(requestContext(ctx), folder); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, folder, fasthttp.StatusOK)
+}
+
+// DELETE /folders/:uid
+func (ws *WebServer) handlerDeleteFolder(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	folder, err := ws.access.Folder(requestContext(ctx), user.UID, uid, objects.PermissionWrite)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if folder == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	folders, err := ws.db.GetFolders(requestContext(ctx), folder.Owner)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	for _, f := range folders {
+		if f.Parent != folder.UID {
+			continue
+		}
+		f.Parent = folder.Parent
+		if err = ws.db.SetFolder(requestContext(ctx), f); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+	}
+
+	pages, err := ws.db.GetPages(requestContext(ctx), folder.Owner, "", "", nil)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	for _, p := range pages {
+		if p.Folder != folder.UID {
+			continue
+		}
+		p.MoveToFolder(folder.Parent)
+		if err = ws.db.EditPage(requestContext(ctx), p); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+		ws.cache.SetPageByID(requestContext(ctx), p.UID, p)
+		ws.publish(events.TypePageUpdated, p.Owner, p)
+	}
+
+	if err = ws.db.DeleteFolder(requestContext(ctx), folder.UID); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// -----------------------------------------------------
+// --- SYNC ---
+
+// GET /sync
+func (ws *WebServer) handlerGetSync(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	queryArgs := ctx.QueryArgs()
+
+	team := string(queryArgs.Peek("team"))
+	teams := string(queryArgs.Peek("teams"))
+
+	since, err := parseSyncCursor(string(queryArgs.Peek("since")))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	now := time.Now()
+	if !since.IsZero() && since.Before(now.Add(-objects.PageTombstoneLifetime)) {
+		return jsonError(ctx, errSyncCursorExpired, fasthttp.StatusGone)
+	}
+
+	owners, status, err := ws.getPageOwners(requestContext(ctx), user.UID, team, comparison.IsTrue(teams))
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	res := &syncResponse{
+		Cursor:  formatSyncCursor(now.Add(-syncCursorOverlap)),
+		Created: make([]*objects.Page, 0),
+		Updated: make([]*objects.Page, 0),
+		Deleted: make([]snowflake.ID, 0),
+	}
+
+	for _, owner := range owners {
+		pages, err := ws.db.GetPagesEditedSince(requestContext(ctx), owner, since)
+		if err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+
+		for _, p := range pages {
+			if p.Created.After(since) {
+				res.Created = append(res.Created, p)
+			} else {
+				res.Updated = append(res.Updated, p)
+			}
+		}
+
+		if since.IsZero() {
+			continue
+		}
+
+		tombstones, err := ws.db.GetPageTombstones(requestContext(ctx), owner, since)
+		if err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+
+		for _, t := range tombstones {
+			res.Deleted = append(res.Deleted, t.PageID)
+		}
+	}
+
+	return jsonResponse(ctx, res, fasthttp.StatusOK)
+}
+
+// -----------------------------------------------------
+// --- EVENTS ---
+
+// GET /events
+func (ws *WebServer) handlerGetEvents(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	owners, err := ws.access.Owners(requestContext(ctx), user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	// Subscriptions are created for the owners the
+	// user could read from at the time of the request.
+	// Each event is checked again, because the user
+	// may leave teams or revoke the authorization
+	// while the stream is open.
+	authorize := ws.authorizeEvent(user.UID,
+		string(ctx.Request.Header.PeekBytes(authorizationHeader)))
+
+	c, cancel := ws.events.Subscribe(owners)
+	streamEvents(ctx.RequestCtx, c, cancel, ws.cShutdown, authorize)
+
+	return nil
+}
+
+// -----------------------------------------------------
+// --- WEBHOOKS ---
+
+// POST /webhooks
+func (ws *WebServer) handlerCreateWebhook(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	team := string(ctx.QueryArgs().Peek("team"))
+
+	params := new(webhookRequest)
+	if err := parseJSONBody(ctx, params); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	owner, status, err := ws.getRequestedOwner(requestContext(ctx), user.UID, team, objects.PermissionManage)
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	webhooks, err := ws.db.GetWebhooks(requestContext(ctx), owner)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if len(webhooks) >= webhooksMax {
+		return jsonError(ctx, errTooManyWebhooks, fasthttp.StatusBadRequest)
+	}
+
+	var url, secret string
+	var evts []string
+	if params.URL != nil {
+		url = *params.URL
+	}
+	if params.Secret != nil {
+		secret = *params.Secret
+	}
+	if params.Events != nil {
+		evts = *params.Events
+	}
+
+	webhook, err := objects.NewWebhook(owner, url, secret, evts)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+	if params.Enabled != nil {
+		webhook.Enabled = *params.Enabled
+	}
+
+	if err = ws.db.SetWebhook(requestContext(ctx), webhook); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, webhook, fasthttp.StatusCreated)
+}
+
+// GET /webhooks
+func (ws *WebServer) handlerGetWebhooks(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	team := string(ctx.QueryArgs().Peek("team"))
+
+	owner, status, err := ws.getRequestedOwner(requestContext(ctx), user.UID, team, objects.PermissionManage)
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	webhooks, err := ws.db.GetWebhooks(requestContext(ctx), owner)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	for i, w := range webhooks {
+		webhooks[i] = w.Sanitize()
+	}
+
+	return jsonResponse(ctx, &listResponse{N: len(webhooks), Data: webhooks}, fasthttp.StatusOK)
+}
+
+// POST /webhooks/:uid
+func (ws *WebServer) handlerPostWebhook(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	webhook, err := ws.access.Webhook(requestContext(ctx), user.UID, uid, objects.PermissionManage)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if webhook == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	params := new(webhookRequest)
+	if err = parseJSONBody(ctx, params); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if params.URL != nil {
+		webhook.URL = *params.URL
+	}
+	if params.Secret != nil {
+		webhook.Secret = *params.Secret
+	}
+	if params.Events != nil {
+		webhook.Events = *params.Events
+	}
+	if params.Enabled != nil {
+		webhook.Enabled = *params.Enabled
+	}
+
+	if err = webhook.Validate(); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if err = ws.db.SetWebhook(requestContext(ctx), webhook); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, webhook.Sanitize(), fasthttp.StatusOK)
+}
+
+// DELETE /webhooks/:uid
+func (ws *WebServer) handlerDeleteWebhook(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	webhook, err := ws.access.Webhook(requestContext(ctx), user.UID, uid, objects.PermissionManage)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if webhook == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	if err = ws.db.DeleteWebhook(requestContext(ctx), webhook.UID); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// GET /webhooks/:uid/deliveries
+func (ws *WebServer) handlerGetWebhookDeliveries(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	webhook, err := ws.access.Webhook(requestContext(ctx), user.UID, uid, objects.PermissionManage)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if webhook == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	deliveries, err := ws.db.GetWebhookDeliveries(requestContext(ctx), webhook.UID, webhookDeliveriesMax)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, &listResponse{N: len(deliveries), Data: deliveries}, fasthttp.StatusOK)
+}
+
+// -----------------------------------------------------
+// --- RESOURCES & STATICS ---
+
+// GET /resources/champions
+func (ws *WebServer) handlerGetChamps(ctx *routing.Context) error {
+	return jsonCachableResponse(ctx, &listResponse{N: len(ddragon.DDragonInstance.Champions), Data: ddragon.DDragonInstance.Champions}, fasthttp.StatusOK)
+}
+
+// GET /resources/champions/:uid/stats
+func (ws *WebServer) handlerGetChampStats(ctx *routing.Context) error {
+	uid := ctx.Param("uid")
+
+	if ddragon.DDragonInstance.GetChampion(uid) == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	stats, err := ws.cache.GetChampionStats(requestContext(ctx), uid)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if stats == nil {
+		stats = objects.NewEmptyChampionStats(uid)
+	}
+
+	return jsonCachableResponse(ctx, stats, fasthttp.StatusOK)
+}
+
+// GET /resources/runes
+func (ws *WebServer) handlerGetRunes(ctx *routing.Context) error {
+	data := map[string]interface{}{
+		"trees": ddragon.DDragonInstance.Runes,
+		"perks": objects.PerksPool,
+	}
+	return jsonCachableResponse(ctx, data, fasthttp.StatusOK)
+}
+
+// GET /version
+func (ws *WebServer) handlerGetVersion(ctx *routing.Context) error {
+	return jsonCachableResponse(ctx, map[string]string{
+		"version":    static.AppVersion,
+		"apiversion": static.APIVersion,
+		"release":    static.Release,
+	}, fasthttp.StatusOK)
+}
+
+// GET /healthz
+func (ws *WebServer) handlerGetHealthz(ctx *routing.Context) error {
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// GET /readyz
+func (ws *WebServer) handlerGetReadyz(ctx *routing.Context) error {
+	report := ws.health.Check(requestContext(ctx))
+
+	status := fasthttp.StatusOK
+	if report.Status == health.StatusFailed {
+		status = fasthttp.StatusServiceUnavailable
+	}
+
+	return jsonResponse(ctx, report, status)
+}
+
+// GET /recaptchainfo
+func (ws *WebServer) handlerGetReCaptchaInfo(ctx *routing.Context) error {
+	if ws.config.ReCaptcha == nil || ws.config.ReCaptcha.SiteKey == "" {
+		return jsonError(ctx, errors.New("not configured"), fasthttp.StatusBadRequest)
+	}
+
+	return jsonCachableResponse(ctx, map[string]string{
+		"sitekey": ws.config.ReCaptcha.SiteKey,
+	}, fasthttp.StatusOK)
+}
+
+// GET /openapi.json
+func (ws *WebServer) handlerGetOpenAPI(ctx *routing.Context) error {
+	return jsonCachableResponse(ctx, ws.openAPISpec, fasthttp.StatusOK)
+}
+
+// -----------------------------------------------------
+// --- FAVORITES ---
+
+// POST /favorites
+func (ws *WebServer) handlerPostFavorite(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	var err error
+
+	favReq := new(alterFavoriteRequest)
+	if err = parseJSONBody(ctx, favReq); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if favReq.Favorites == nil {
+		return jsonError(ctx, errBadRequest, fasthttp.StatusBadRequest)
+	}
+
+	champMap := make(map[string]interface{})
+	for _, c := range ddragon.DDragonInstance.Champions {
+		champMap[c.UID] = nil
+	}
+
+	for i, f := range favReq.Favorites {
+		favReq.Favorites[i] = strings.ToLower(f)
+		if _, ok := champMap[f]; !ok {
+			return jsonError(ctx, objects.ErrInvalidChamp, fasthttp.StatusBadRequest)
+		}
+	}
+
+	user.Favorites = favReq.Favorites
+
+	if err = ws.db.EditUser(requestContext(ctx), user); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	ws.publish(events.TypeFavoritesChanged, user.UID, &favoritesChangedEvent{user.Favorites})
+
+	return jsonResponse(ctx,
+		listResponse{N: len(user.Favorites), Data: user.Favorites},
+		fasthttp.StatusOK)
+}
+
+// GET /favorites
+func (ws *WebServer) handlerGetFavorites(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	if user.Favorites == nil {
+		user.Favorites = []string{}
+	}
+
+	return jsonResponse(ctx,
+		listResponse{N: len(user.Favorites), Data: user.Favorites},
+		fasthttp.StatusOK)
+}
+
+// -----------------------------------------------------
+// --- SHARES ---
+
+// POST /shares
+func (ws *WebServer) handlerCreateShare(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	var err error
+
+	params := new(createShareRequest)
+	if err := parseJSONBody(ctx, params); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	var share *objects.SharePage
+
+	if params.Page != "" {
+		if len(params.Pages) > 0 || params.Filter != nil {
+			return jsonError(ctx, errInvalidArguments, fasthttp.StatusBadRequest)
+		}
+
+		pageID, err := snowflake.ParseString(params.Page)
+		if err != nil {
+			return jsonError(ctx, err, fasthttp.StatusBadRequest)
+		}
+
+		page, err := ws.access.Page(requestContext(ctx), user.UID, pageID, objects.PermissionWrite)
+		if err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+		if page == nil {
+			return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+		}
+
+		if share, err = objects.NewSharePage(page.Owner, pageID, params.MaxAccesses, params.Expires); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+	} else {
+		if (len(params.Pages) > 0) == (params.Filter != nil) {
+			return jsonError(ctx, errInvalidArguments, fasthttp.StatusBadRequest)
+		}
+
+		owner := user.UID
+
+		if params.Filter != nil {
+			if err = params.Filter.Validate(); err != nil {
+				return jsonError(ctx, err, fasthttp.StatusBadRequest)
+			}
+
+			if params.Team != "" {
+				if owner, err = snowflake.ParseString(params.Team); err != nil {
+					return jsonError(ctx, err, fasthttp.StatusBadRequest)
+				}
+
+				if ok, err := ws.access.Can(requestContext(ctx), user.UID, owner, objects.PermissionWrite); err != nil {
+					return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+				} else if !ok {
+					return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+				}
+			}
+		}
+
+		pageIDs := make([]snowflake.ID, len(params.Pages))
+		for i, p := range params.Pages {
+			if pageIDs[i], err = snowflake.ParseString(p); err != nil {
+				return jsonError(ctx, err, fasthttp.StatusBadRequest)
+			}
+
+			page, err := ws.access.Page(requestContext(ctx), user.UID, pageIDs[i], objects.PermissionWrite)
+			if err != nil {
+				return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+			}
+			if page == nil {
+				return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+			}
+
+			if i == 0 {
+				owner = page.Owner
+			} else if page.Owner != owner {
+				return jsonError(ctx, errMixedShareOwners, fasthttp.StatusBadRequest)
+			}
+		}
+
+		share, err = objects.NewShareCollection(owner, pageIDs, params.Filter, params.MaxAccesses, params.Expires)
+		if err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+	}
+
+	if ok, err := ws.setShareAccessRestrictions(ctx, share, params); !ok {
+		return err
+	}
+
+	if err = ws.db.SetShare(requestContext(ctx), share); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, share, fasthttp.StatusCreated)
+}
+
+// POST /shares/:id
+func (ws *WebServer) handlerPostShare(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	_uid := ctx.Param("uid")
+	uid, err := snowflake.ParseString(_uid)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	share, err := ws.access.Share(requestContext(ctx), user.UID, uid, objects.PermissionWrite)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if share == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	params := new(createShareRequest)
+	if err := parseJSONBody(ctx, params); err != nil {
+		return jsonError(ctx, errBadRequest, fasthttp.StatusBadRequest)
+	}
+
+	if (params.Expires != time.Time{}) {
+		share.Expires = params.Expires
+	}
+
+	if params.MaxAccesses != 0 {
+		share.MaxAccesses = params.MaxAccesses
+	}
+
+	if ok, err := ws.setShareAccessRestrictions(ctx, share, params); !ok {
+		return err
+	}
+
+	if err = ws.db.SetShare(requestContext(ctx), share); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, share, fasthttp.StatusCreated)
+}
+
+// GET /shares/:id
+func (ws *WebServer) handlerGetShare(ctx *routing.Context) error {
+	ident := ctx.Param("ident")
+	byIdent := true
+	var user *objects.User
+
+	_user := ctx.Get("user")
+	if _user != nil {
+		user = _user.(*objects.User)
+		byIdent = false
+	}
+
+	shareID, err := snowflake.ParseString(ident)
+	if err != nil || user == nil {
+		shareID = -1
+	}
+
+	share, err := ws.db.GetShare(requestContext(ctx), ident, shareID, shareID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if share == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	if user != nil {
+		if ok, err := ws.access.Can(requestContext(ctx), user.UID, share.OwnerID, objects.PermissionRead); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		} else if !ok {
+			return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+		}
+	}
+
+	if byIdent && !share.IsAccessible() {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	if byIdent {
+		if ok, err := ws.checkShareAccess(ctx, share); !ok {
+			return err
+		}
+	}
+
+	pages, err := ws.getSharePages(requestContext(ctx), share)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if !share.IsCollection() && len(pages) == 0 {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	owner, team, err := ws.getOwner(requestContext(ctx), share.OwnerID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if owner == nil && team == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	if byIdent {
+		ok, err := ws.recordShareAccess(ctx, share)
+		if err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+		if !ok {
+			return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+		}
+	}
+
+	if byIdent {
+		share.Audience = nil
+	}
+
+	res := &shareResponse{
+		Share: share,
+		Team:  team,
+	}
+
+	if owner != nil {
+		res.User = owner.Sanitize()
+	}
+
+	if share.IsCollection() {
+		res.Pages = pages
+	} else {
+		res.Page = pages[0]
+	}
+
+	return jsonResponse(ctx, res, fasthttp.StatusAccepted)
+}
+
+// GET /shares/:id/stats
+func (ws *WebServer) handlerGetShareStats(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	_uid := ctx.Param("uid")
+	uid, err := snowflake.ParseString(_uid)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	days := shareStatsDaysDefault
+	if v := ctx.QueryArgs().Peek("days"); len(v) > 0 {
+		if days, err = strconv.Atoi(string(v)); err != nil || days < 1 || days > shareStatsDaysMax {
+			return jsonError(ctx, errInvalidArguments, fasthttp.StatusBadRequest)
+		}
+	}
+
+	share, err := ws.access.Share(requestContext(ctx), user.UID, uid, objects.PermissionRead)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if share == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	since := time.Now().AddDate(0, 0, -(days - 1))
+	accesses, err := ws.db.GetShareAccesses(requestContext(ctx), share.UID, since.UTC().Truncate(24*time.Hour))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	stats := objects.NewShareStats(accesses, since, shareStatsTopReferrers)
+	stats.Forks = share.Forks
+
+	return jsonResponse(ctx, stats, fasthttp.StatusOK)
+}
+
+// POST /shares/:ident/fork
+func (ws *WebServer) handlerPostShareFork(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	ident := ctx.Param("ident")
+
+	share, err := ws.db.GetShare(requestContext(ctx), ident, -1, -1)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if share == nil || !share.IsAccessible() {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	if ok, err := ws.checkShareAccess(ctx, share); !ok {
+		return err
+	}
+
+	var params shareForkRequest
+	if len(ctx.PostBody()) > 0 {
+		if err = parseJSONBody(ctx, &params); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusBadRequest)
+		}
+	}
+
+	if share.IsCollection() && params.Page == "" {
+		return jsonError(ctx, errInvalidArguments, fasthttp.StatusBadRequest)
+	}
+
+	pages, err := ws.getSharePages(requestContext(ctx), share)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	var page *objects.Page
+	for _, p := range pages {
+		if params.Page == "" || p.UID.String() == params.Page {
+			page = p
+			break
+		}
+	}
+	if page == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	fork := page.Fork(user.UID)
+
+	if err = ws.db.CreatePage(requestContext(ctx), fork); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	ws.cache.SetPageByID(requestContext(ctx), fork.UID, fork)
+	ws.publish(events.TypePageCreated, fork.Owner, fork)
+
+	if err = ws.db.IncrementShareForks(requestContext(ctx), share.UID); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, fork, fasthttp.StatusCreated)
+}
+
+// GET /shares/:ident/image.png
+func (ws *WebServer) handlerGetShareImage(ctx *routing.Context) error {
+	ident := ctx.Param("ident")
+
+	share, err := ws.db.GetShare(requestContext(ctx), ident, -1, -1)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if share == nil || !share.IsAccessible() {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	if ok, err := ws.checkShareAccess(ctx, share); !ok {
+		return err
+	}
+
+	pages, err := ws.getSharePages(requestContext(ctx), share)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if len(pages) == 0 {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	imgData, err := ws.pageImageRenderer.Get(requestContext(ctx), pages[0])
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	// Viewing the image reveals the shared page,
+	// so it counts as access of the share.
+	ok, err := ws.recordShareAccess(ctx, share)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if !ok {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	// Images of protected shares must not be
+	// stored by shared caches, which would serve
+	// them without authentication.
+	cacheScope := "public"
+	if share.Protected || share.IsRestricted() {
+		cacheScope = "private"
+	}
+
+	ctx.Response.Header.SetContentType("image/png")
+	// 5min browser caching because the
+	// shared page may be edited
+	ctx.Response.Header.Set("Cache-Control", cacheScope+", max-age=300")
+	ctx.Response.Header.Set("ETag", etag.Generate(imgData, false))
+	ctx.SetBody(imgData)
+	return nil
+}
+
+// DELETE /shares/:id
+func (ws *WebServer) handlerDeleteShare(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	_uid := ctx.Param("uid")
+	uid, err := snowflake.ParseString(_uid)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	share, err := ws.access.Share(requestContext(ctx), user.UID, uid, objects.PermissionWrite)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if share == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	if err = ws.db.DeleteShare(requestContext(ctx), "", uid, -1); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if err = ws.db.DeleteShareAccesses(requestContext(ctx), uid); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// -----------------------------------------------------
+// --- TEAMS ---
+
+// POST /teams
+func (ws *WebServer) handlerCreateTeam(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	params := new(teamRequest)
+	if err := parseJSONBody(ctx, params); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	team, err := objects.NewTeam(params.Name)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	member, err := objects.NewTeamMember(team.UID, user.UID, objects.TeamRoleAdmin, user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	member.Accept()
+
+	if err = ws.db.SetTeam(requestContext(ctx), team); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if err = ws.db.SetTeamMember(requestContext(ctx), member); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, &teamResponse{
+		Team: team,
+		Role: member.Role,
+	}, fasthttp.StatusCreated)
+}
+
+// GET /teams
+func (ws *WebServer) handlerGetTeams(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	members, err := ws.db.GetUserTeamMembers(requestContext(ctx), user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	teams := make([]*teamResponse, 0, len(members))
+	for _, m := range members {
+		team, err := ws.db.GetTeam(requestContext(ctx), m.TeamID)
+		if err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+		if team == nil {
+			continue
+		}
+		teams = append(teams, &teamResponse{
+			Team:    team,
+			Role:    m.Role,
+			Pending: m.Pending,
+		})
+	}
+
+	return jsonResponse(ctx, &listResponse{N: len(teams), Data: teams}, fasthttp.StatusOK)
+}
+
+// GET /teams/:uid
+func (ws *WebServer) handlerGetTeam(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	team, member, err := ws.getTeamMembership(requestContext(ctx), uid, user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if team == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	res := &teamResponse{
+		Team:    team,
+		Role:    member.Role,
+		Pending: member.Pending,
+	}
+
+	if member.Can(objects.PermissionRead) {
+		if res.Members, err = ws.getTeamMemberResponses(requestContext(ctx), team.UID); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+	}
+
+	return jsonResponse(ctx, res, fasthttp.StatusOK)
+}
+
+// POST /teams/:uid
+func (ws *WebServer) handlerPostTeam(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	team, member, err := ws.getTeamMembership(requestContext(ctx), uid, user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if team == nil || !member.Can(objects.PermissionRead) {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+	if !member.Can(objects.PermissionManage) {
+		return jsonError(ctx, errNoAccess, fasthttp.StatusForbidden)
+	}
+
+	params := new(teamRequest)
+	if err = parseJSONBody(ctx, params); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if err = team.SetName(params.Name); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if err = ws.db.SetTeam(requestContext(ctx), team); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, &teamResponse{
+		Team: team,
+		Role: member.Role,
+	}, fasthttp.StatusOK)
+}
+
+// DELETE /teams/:uid
+func (ws *WebServer) handlerDeleteTeam(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	team, member, err := ws.getTeamMembership(requestContext(ctx), uid, user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if team == nil || !member.Can(objects.PermissionRead) {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+	if !member.Can(objects.PermissionManage) {
+		return jsonError(ctx, errNoAccess, fasthttp.StatusForbidden)
+	}
+
+	if err = ws.deleteTeam(requestContext(ctx), team.UID); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// POST /teams/:uid/accept
+func (ws *WebServer) handlerPostTeamAccept(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	team, member, err := ws.getTeamMembership(requestContext(ctx), uid, user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if team == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	if member.Pending {
+		member.Accept()
+		if err = ws.db.SetTeamMember(requestContext(ctx), member); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+	}
+
+	return jsonResponse(ctx, &teamResponse{
+		Team: team,
+		Role: member.Role,
+	}, fasthttp.StatusOK)
+}
+
+// POST /teams/:uid/members
+func (ws *WebServer) handlerPostTeamMember(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	team, member, err := ws.getTeamMembership(requestContext(ctx), uid, user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if team == nil || !member.Can(objects.PermissionRead) {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+	if !member.Can(objects.PermissionManage) {
+		return jsonError(ctx, errNoAccess, fasthttp.StatusForbidden)
+	}
+
+	params := new(teamMemberRequest)
+	if err = parseJSONBody(ctx, params); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	invitee, err := ws.db.GetUser(requestContext(ctx), snowflake.ID(-1), strings.ToLower(params.UserName))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if invitee == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	if existing, err := ws.db.GetTeamMember(requestContext(ctx), team.UID, invitee.UID); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	} else if existing != nil {
+		return jsonError(ctx, errTeamMemberExists, fasthttp.StatusConflict)
+	}
+
+	invitation, err := objects.NewTeamMember(team.UID, invitee.UID, params.Role, user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if err = ws.db.SetTeamMember(requestContext(ctx), invitation); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, &teamMemberResponse{
+		Member: invitation,
+		User:   invitee.Sanitize(),
+	}, fasthttp.StatusCreated)
+}
+
+// POST /teams/:uid/members/:userid
+func (ws *WebServer) handlerPostTeamMemberRole(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	userID, err := snowflake.ParseString(ctx.Param("userid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	team, member, err := ws.getTeamMembership(requestContext(ctx), uid, user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if team == nil || !member.Can(objects.PermissionRead) {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+	if !member.Can(objects.PermissionManage) {
+		return jsonError(ctx, errNoAccess, fasthttp.StatusForbidden)
+	}
+
+	params := new(teamMemberRequest)
+	if err = parseJSONBody(ctx, params); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if !objects.IsValidTeamRole(params.Role) {
+		return jsonError(ctx, objects.ErrInvalidTeamRole, fasthttp.StatusBadRequest)
+	}
+
+	target, err := ws.db.GetTeamMember(requestContext(ctx), team.UID, userID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if target == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	if target.Role == objects.TeamRoleAdmin && params.Role != objects.TeamRoleAdmin && !target.Pending {
+		if ok, err := ws.hasOtherTeamAdmin(requestContext(ctx), target); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		} else if !ok {
+			return jsonError(ctx, errLastTeamAdmin, fasthttp.StatusBadRequest)
+		}
+	}
+
+	target.Role = params.Role
+	if err = ws.db.SetTeamMember(requestContext(ctx), target); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, target, fasthttp.StatusOK)
+}
+
+// DELETE /teams/:uid/members/:userid
+func (ws *WebServer) handlerDeleteTeamMember(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	userID, err := snowflake.ParseString(ctx.Param("userid"))
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	team, member, err := ws.getTeamMembership(requestContext(ctx), uid, user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	if team == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	target := member
+	if userID != user.UID {
+		if !member.Can(objects.PermissionRead) {
+			return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+		}
+		if !member.Can(objects.PermissionManage) {
+			return jsonError(ctx, errNoAccess, fasthttp.StatusForbidden)
+		}
+
+		if target, err = ws.db.GetTeamMember(requestContext(ctx), team.UID, userID); err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+		if target == nil {
+			return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+		}
+	}
+
+	if err = ws.removeTeamMember(requestContext(ctx), target, false); err == errLastTeamAdmin {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	} else if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
+
+// -----------------------------------------------------
+// --- PREVIEWS ---
+
+// GET /s/:ident
+func (ws *WebServer) handlerGetSharePreview(ctx *routing.Context) error {
+	preview, status, err := ws.getSharePreview(requestContext(ctx), ctx.Param("ident"))
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	buff := new(bytes.Buffer)
+	if err = sharePreviewTemplate.Execute(buff, preview); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	ctx.Response.Header.SetContentType("text/html; charset=utf-8")
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetBody(buff.Bytes())
+	return nil
+}
+
+// GET /oembed
+func (ws *WebServer) handlerGetOEmbed(ctx *routing.Context) error {
+	queryArgs := ctx.QueryArgs()
+
+	if format := string(queryArgs.Peek("format")); format != "" && format != "json" {
+		return jsonError(ctx, errUnsupportedFormat, fasthttp.StatusNotImplemented)
+	}
+
+	ident := shareIdentFromURL(string(queryArgs.Peek("url")))
+	if ident == "" {
+		return jsonError(ctx, errInvalidArguments, fasthttp.StatusBadRequest)
+	}
+
+	preview, status, err := ws.getSharePreview(requestContext(ctx), ident)
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	return jsonResponse(ctx, preview.oEmbed(ws.config.PublicAddr), fasthttp.StatusOK)
+}
+
+// -----------------------------------------------------
+// --- API TOKEN ---
+
+// POST /apitoken
+func (ws *WebServer) handlerPostAPIToken(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+	var err error
+	token := new(objects.APIToken)
+
+	if token.Token, err = random.Base64(apiTokenLength); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	token.UserID = user.UID
+	token.Created = time.Now()
+
+	if err = ws.db.SetAPIToken(requestContext(ctx), token); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+	ws.cache.SetUserByToken(requestContext(ctx), token.Token, user)
+
+	return jsonResponse(ctx, token, fasthttp.StatusOK)
+}
+
+// GET /apitoken
+func (ws *WebServer) handlerGetAPIToken(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	token, err := ws.db.GetAPIToken(requestContext(ctx), user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if token == nil {
+		return jsonError(ctx, errNotFound, fasthttp.StatusNotFound)
+	}
+
+	return jsonResponse(ctx, token, fasthttp.StatusOK)
+}
+
+// DELETE /apitoken
+func (ws *WebServer) handlerDeleteAPIToken(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	token, err := ws.db.GetAPIToken(requestContext(ctx), user.UID)
+	if err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	if token != nil {
+		ws.cache.SetUserByToken(requestContext(ctx), token.Token, nil)
+	}
+
+	if err := ws.db.ResetAPIToken(requestContext(ctx), user.UID); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+	}
+
+	return jsonResponse(ctx, nil, fasthttp.StatusOK)
+}
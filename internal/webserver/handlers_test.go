@@ -0,0 +1,558 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/valyala/fasthttp"
+
+	"github.com/myrunes/backend/internal/health"
+	"github.com/myrunes/backend/internal/objects"
+)
+
+// editPageBody returns the body of an edit page
+// request setting the passed title on the page.
+func editPageBody(t *testing.T, page *objects.Page, title string) []byte {
+	edit := *page
+	edit.Title = title
+
+	body, err := json.Marshal(&edit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestEditPageConditional(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	auth := addTestUser(ws, db, 1, "alice")
+
+	page := newTestPage(1)
+	page.Edited = time.Now().Add(-time.Hour)
+	db.CreatePage(context.Background(), page)
+	path := fmt.Sprintf("/pages/%s", page.UID)
+
+	ctx := ws.request("GET", path, auth, nil)
+	eTag := string(ctx.Response.Header.Peek("ETag"))
+	if eTag != pageETag(page) {
+		t.Fatalf("unexpected ETag %s", eTag)
+	}
+
+	edit := func(title string, headers map[string]string) *fasthttp.RequestCtx {
+		ctx := new(fasthttp.RequestCtx)
+		ctx.Request.Header.SetMethod("POST")
+		ctx.Request.SetRequestURI(testPathPrefix + path)
+		ctx.Request.Header.Set("Authorization", auth)
+		for k, v := range headers {
+			ctx.Request.Header.Set(k, v)
+		}
+		ctx.Request.SetBody(editPageBody(t, page, title))
+		ws.router.HandleRequest(ctx)
+		return ctx
+	}
+
+	ctx = edit("first edit", map[string]string{"If-Match": eTag})
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", code, ctx.Response.Body())
+	}
+	newETag := string(ctx.Response.Header.Peek("ETag"))
+	if newETag == "" || newETag == eTag {
+		t.Fatalf("expected a new ETag, got %s", newETag)
+	}
+
+	// Writes based on the former state of
+	// the page are rejected.
+	ctx = edit("stale edit", map[string]string{"If-Match": eTag})
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusPreconditionFailed {
+		t.Errorf("expected status 412 for stale ETag, got %d", code)
+	}
+
+	since := page.Edited.UTC().Format(http.TimeFormat)
+	ctx = edit("stale edit", map[string]string{"If-Unmodified-Since": since})
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusPreconditionFailed {
+		t.Errorf("expected status 412 for stale date, got %d", code)
+	}
+
+	stored, _ := db.GetPage(context.Background(), page.UID)
+	if stored.Title != "first edit" {
+		t.Errorf("unexpected stored title %s", stored.Title)
+	}
+
+	ctx = edit("second edit", map[string]string{"If-Match": newETag})
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Errorf("expected status 200, got %d: %s", code, ctx.Response.Body())
+	}
+}
+
+func TestPostShareFork(t *testing.T) {
+	const forkers = 4
+
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	addTestUser(ws, db, 1, "owner")
+
+	share := newTestShare(t, db, 1)
+
+	auth := addTestUser(ws, db, 2, "forker")
+	ctx := ws.request("POST", "/shares/"+share.Ident+"/fork", auth, []byte("{invalid"))
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusBadRequest {
+		t.Fatalf("expected status 400 for invalid body, got %d", code)
+	}
+
+	// Forks must not revert changes of the owner.
+	changed := db.getShare(share.UID)
+	changed.MaxAccesses = 100
+	db.SetShare(context.Background(), changed)
+
+	var wg sync.WaitGroup
+	for i := 0; i < forkers; i++ {
+		auth := addTestUser(ws, db, snowflake.ID(10+i), fmt.Sprintf("forker%d", i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := ws.request("POST", "/shares/"+share.Ident+"/fork", auth, nil)
+			if code := ctx.Response.StatusCode(); code != fasthttp.StatusCreated {
+				t.Errorf("expected status 201, got %d: %s", code, ctx.Response.Body())
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	stored := db.getShare(share.UID)
+	if stored.Forks != forkers {
+		t.Errorf("expected %d forks, got %d", forkers, stored.Forks)
+	}
+	if stored.MaxAccesses != 100 {
+		t.Error("expected changes of the owner to be kept")
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	ok := func(ctx context.Context) (interface{}, error) { return nil, nil }
+	degraded := func(ctx context.Context) (interface{}, error) { return nil, health.Degraded(errors.New("slow")) }
+	failed := func(ctx context.Context) (interface{}, error) { return nil, errors.New("unavailable") }
+
+	cases := []struct {
+		name   string
+		checks map[string]health.Check
+		status int
+		report string
+	}{
+		{"ok", map[string]health.Check{"database": ok, "cache": ok}, fasthttp.StatusOK, health.StatusOK},
+		{"degraded", map[string]health.Check{"database": ok, "cache": degraded}, fasthttp.StatusOK, health.StatusDegraded},
+		{"failed", map[string]health.Check{"database": failed, "cache": degraded}, fasthttp.StatusServiceUnavailable, health.StatusFailed},
+	}
+
+	for _, c := range cases {
+		ws := newTestWebServer(t, newTestDatabase(), nil)
+		ws.health = health.New(time.Second)
+		for name, check := range c.checks {
+			ws.health.Register(name, check)
+		}
+
+		ctx := ws.request("GET", "/readyz", "", nil)
+		if code := ctx.Response.StatusCode(); code != c.status {
+			t.Errorf("%s: expected status %d, got %d", c.name, c.status, code)
+		}
+
+		report := new(health.Report)
+		if err := json.Unmarshal(ctx.Response.Body(), report); err != nil {
+			t.Fatalf("%s: %s", c.name, err.Error())
+		}
+		if report.Status != c.report || len(report.Components) != len(c.checks) {
+			t.Errorf("%s: unexpected report %+v", c.name, report)
+		}
+
+		// Liveness does not depend on the checks.
+		if code := ws.request("GET", "/healthz", "", nil).Response.StatusCode(); code != fasthttp.StatusOK {
+			t.Errorf("%s: expected liveness status 200, got %d", c.name, code)
+		}
+	}
+}
+
+func TestGetSync(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	auth := addTestUser(ws, db, 1, "user")
+
+	now := time.Now()
+	since := now.Add(-time.Minute)
+
+	newPage := func(owner snowflake.ID, created, edited time.Time) *objects.Page {
+		page := newTestPage(owner)
+		page.Created, page.Edited = created, edited
+		db.CreatePage(context.Background(), page)
+		return page
+	}
+
+	newPage(1, now.Add(-time.Hour), now.Add(-time.Hour))
+	updated := newPage(1, now.Add(-time.Hour), now.Add(-10*time.Second))
+	created := newPage(1, now.Add(-10*time.Second), now.Add(-10*time.Second))
+	newPage(2, now.Add(-10*time.Second), now.Add(-10*time.Second))
+
+	db.AddPageTombstone(context.Background(), &objects.PageTombstone{PageID: 100, Owner: 1, Deleted: now.Add(-10 * time.Second)})
+	db.AddPageTombstone(context.Background(), &objects.PageTombstone{PageID: 101, Owner: 1, Deleted: now.Add(-time.Hour)})
+	db.AddPageTombstone(context.Background(), &objects.PageTombstone{PageID: 102, Owner: 2, Deleted: now.Add(-10 * time.Second)})
+
+	getSync := func(cursor string) (int, *syncResponse) {
+		ctx := ws.request("GET", "/sync?since="+cursor, auth, nil)
+		res := new(syncResponse)
+		if ctx.Response.StatusCode() == fasthttp.StatusOK {
+			if err := json.Unmarshal(ctx.Response.Body(), res); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return ctx.Response.StatusCode(), res
+	}
+
+	code, res := getSync(formatSyncCursor(since))
+	if code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if len(res.Created) != 1 || res.Created[0].UID != created.UID {
+		t.Errorf("expected created page %s, got %+v", created.UID, res.Created)
+	}
+	if len(res.Updated) != 1 || res.Updated[0].UID != updated.UID {
+		t.Errorf("expected updated page %s, got %+v", updated.UID, res.Updated)
+	}
+	if len(res.Deleted) != 1 || res.Deleted[0] != 100 {
+		t.Errorf("expected deleted page 100, got %v", res.Deleted)
+	}
+
+	// The next cursor overlaps the current time, so
+	// that concurrently edited pages are not missed.
+	next, err := parseSyncCursor(res.Cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.Before(now) || next.Before(now.Add(-syncCursorOverlap-time.Second)) {
+		t.Errorf("unexpected next cursor %s", next)
+	}
+
+	code, res = getSync("")
+	if code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if len(res.Created) != 3 || len(res.Updated) != 0 || len(res.Deleted) != 0 {
+		t.Errorf("expected all 3 pages as created without deletions on full sync, got %+v", res)
+	}
+
+	if code, _ = getSync("yesterday"); code != fasthttp.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid cursor, got %d", code)
+	}
+
+	expired := formatSyncCursor(now.Add(-objects.PageTombstoneLifetime - time.Hour))
+	if code, _ = getSync(expired); code != fasthttp.StatusGone {
+		t.Errorf("expected status 410 for expired cursor, got %d", code)
+	}
+}
+
+func TestGetPageSuggestions(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	auth := addTestUser(ws, db, 1, "user")
+
+	now := time.Now()
+	newPage := func(owner snowflake.ID, champion string, edited time.Time) *objects.Page {
+		page := newTestPage(owner)
+		page.Champions = []string{champion}
+		page.Edited = edited
+		db.CreatePage(context.Background(), page)
+		return page
+	}
+
+	exact := newPage(1, "jinx", now.Add(-time.Hour))
+	similar := newPage(1, "caitlyn", now)
+	similar.Secondary.Rows = [2]string{"arcanecomet", "gatheringstorm"}
+	db.EditPage(context.Background(), similar)
+	// Same runes as the exact match.
+	newPage(1, "jinx", now.Add(-2*time.Hour))
+	// Not similar to the champion.
+	newPage(1, "lux", now)
+	// Page of another user.
+	newPage(2, "jinx", now)
+
+	db.stats["jinx"] = &objects.ChampionStats{
+		Champion:       "jinx",
+		Keystones:      []*objects.RuneStatsEntry{{UID: "lethaltempo"}, {UID: "unknown"}},
+		SecondaryTrees: []*objects.RuneStatsEntry{{UID: "precision"}, {UID: "sorcery"}},
+		StatShards:     []*objects.StatShardsStatsEntry{{Rows: [3]string{"time", "circle", "shield"}}},
+	}
+
+	ctx := ws.request("GET", "/pages/suggest?champion=jinx", auth, nil)
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", code, ctx.Response.Body())
+	}
+
+	var res struct {
+		N    int                       `json:"n"`
+		Data []*objects.PageSuggestion `json:"data"`
+	}
+	if err := json.Unmarshal(ctx.Response.Body(), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []struct {
+		source  string
+		basedOn string
+	}{
+		{objects.SuggestionSourceOwn, exact.UID.String()},
+		{objects.SuggestionSourceOwn, similar.UID.String()},
+		{objects.SuggestionSourceCommunity, ""},
+	}
+	if res.N != len(exp) || len(res.Data) != len(exp) {
+		t.Fatalf("expected %d suggestions, got %d", len(exp), len(res.Data))
+	}
+	for i, e := range exp {
+		s := res.Data[i]
+		if s.Source != e.source || s.BasedOn != e.basedOn {
+			t.Errorf("suggestion %d: expected %s suggestion based on %q, got %s based on %q",
+				i, e.source, e.basedOn, s.Source, s.BasedOn)
+		}
+		if len(s.Page.Champions) != 1 || s.Page.Champions[0] != "jinx" {
+			t.Errorf("suggestion %d: expected page for jinx, got %v", i, s.Page.Champions)
+		}
+	}
+	if perks := res.Data[2].Page.Perks.Rows; perks != [3]string{"time", "circle", "shield"} {
+		t.Errorf("expected community stat shards, got %v", perks)
+	}
+
+	for _, query := range []string{"", "?champion=unknown"} {
+		ctx := ws.request("GET", "/pages/suggest"+query, auth, nil)
+		if code := ctx.Response.StatusCode(); code != fasthttp.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, code)
+		}
+	}
+}
+
+func TestPageTags(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	auth := addTestUser(ws, db, 1, "user")
+
+	newPage := func(owner snowflake.ID, tags ...string) *objects.Page {
+		page := newTestPage(owner)
+		page.Tags = tags
+		db.CreatePage(context.Background(), page)
+		return page
+	}
+
+	mid := newPage(1, "mid", "ranked")
+	middle := newPage(1, "middle")
+	ranked := newPage(1, "ranked")
+	other := newPage(2, "mid")
+
+	getTags := func() []*tagResponse {
+		ctx := ws.request("GET", "/pages/tags", auth, nil)
+		if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", code, ctx.Response.Body())
+		}
+		var res struct {
+			Data []*tagResponse `json:"data"`
+		}
+		if err := json.Unmarshal(ctx.Response.Body(), &res); err != nil {
+			t.Fatal(err)
+		}
+		return res.Data
+	}
+
+	tags := getTags()
+	exp := []tagResponse{{"ranked", 2}, {"mid", 1}, {"middle", 1}}
+	if len(tags) != len(exp) {
+		t.Fatalf("expected %d tags, got %d", len(exp), len(tags))
+	}
+	for i, e := range exp {
+		if *tags[i] != e {
+			t.Errorf("tag %d: expected %+v, got %+v", i, e, *tags[i])
+		}
+	}
+
+	ctx := ws.request("POST", "/pages/tags/rename", auth, []byte(`{"from":"mid","to":"  "}`))
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid tag, got %d", code)
+	}
+
+	// Renaming into an existing tag merges both.
+	ctx = ws.request("POST", "/pages/tags/rename", auth, []byte(`{"from":" MID ","to":"Ranked"}`))
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", code, ctx.Response.Body())
+	}
+
+	for _, c := range []struct {
+		page *objects.Page
+		tags []string
+	}{
+		{mid, []string{"ranked"}},
+		{middle, []string{"middle"}},
+		{ranked, []string{"ranked"}},
+		{other, []string{"mid"}},
+	} {
+		stored, _ := db.GetPage(context.Background(), c.page.UID)
+		if fmt.Sprint(stored.Tags) != fmt.Sprint(c.tags) {
+			t.Errorf("expected tags %v, got %v", c.tags, stored.Tags)
+		}
+	}
+
+	tags = getTags()
+	if len(tags) != 2 || *tags[0] != (tagResponse{"ranked", 2}) {
+		t.Errorf("unexpected tags after merge %+v", tags)
+	}
+}
+
+func TestFolders(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	auth := addTestUser(ws, db, 1, "user")
+	addTestUser(ws, db, 2, "other")
+
+	createFolder := func(body string) (*objects.Folder, int) {
+		ctx := ws.request("POST", "/folders", auth, []byte(body))
+		folder := new(objects.Folder)
+		if ctx.Response.StatusCode() == fasthttp.StatusCreated {
+			if err := json.Unmarshal(ctx.Response.Body(), folder); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return folder, ctx.Response.StatusCode()
+	}
+
+	root, code := createFolder(`{"name":"root"}`)
+	if code != fasthttp.StatusCreated {
+		t.Fatalf("expected status 201, got %d", code)
+	}
+	child, _ := createFolder(fmt.Sprintf(`{"name":"child","parent":"%s"}`, root.UID))
+	grandchild, _ := createFolder(fmt.Sprintf(`{"name":"grandchild","parent":"%s"}`, child.UID))
+	if child.Parent != root.UID || grandchild.Parent != child.UID {
+		t.Fatalf("unexpected folder parents %s, %s", child.Parent, grandchild.Parent)
+	}
+
+	foreign, _ := objects.NewFolder(2, "foreign", 0)
+	db.SetFolder(context.Background(), foreign)
+	for _, parent := range []snowflake.ID{foreign.UID, 12345} {
+		if _, code = createFolder(fmt.Sprintf(`{"name":"invalid","parent":"%s"}`, parent)); code != fasthttp.StatusBadRequest {
+			t.Errorf("expected status 400 for invalid parent, got %d", code)
+		}
+	}
+
+	// Folders can not be moved into
+	// themselves or their children.
+	for _, parent := range []snowflake.ID{root.UID, child.UID, grandchild.UID} {
+		body := fmt.Sprintf(`{"parent":"%s"}`, parent)
+		ctx := ws.request("POST", "/folders/"+root.UID.String(), auth, []byte(body))
+		if code := ctx.Response.StatusCode(); code != fasthttp.StatusBadRequest {
+			t.Errorf("expected status 400 for cycle, got %d", code)
+		}
+	}
+
+	ctx := ws.request("POST", "/folders/"+grandchild.UID.String(), auth, []byte(`{"parent":"0"}`))
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Errorf("expected status 200, got %d: %s", code, ctx.Response.Body())
+	}
+	ctx = ws.request("POST", "/folders/"+grandchild.UID.String(), auth, []byte(fmt.Sprintf(`{"parent":"%s"}`, child.UID)))
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Errorf("expected status 200, got %d: %s", code, ctx.Response.Body())
+	}
+
+	ctx = ws.request("DELETE", "/folders/"+foreign.UID.String(), auth, nil)
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusNotFound {
+		t.Errorf("expected status 404 for foreign folder, got %d", code)
+	}
+
+	page := newTestPage(1)
+	page.Folder = child.UID
+	db.CreatePage(context.Background(), page)
+
+	// Children and pages of deleted folders are
+	// moved to the parent of the deleted folder.
+	ctx = ws.request("DELETE", "/folders/"+child.UID.String(), auth, nil)
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", code, ctx.Response.Body())
+	}
+
+	if f, _ := db.GetFolder(context.Background(), child.UID); f != nil {
+		t.Error("expected folder to be deleted")
+	}
+	if f, _ := db.GetFolder(context.Background(), grandchild.UID); f.Parent != root.UID {
+		t.Errorf("expected child folder to be moved to %s, got %s", root.UID, f.Parent)
+	}
+	if p, _ := db.GetPage(context.Background(), page.UID); p.Folder != root.UID {
+		t.Errorf("expected page to be moved to %s, got %s", root.UID, p.Folder)
+	}
+}
+
+func TestGetBestPage(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	auth := addTestUser(ws, db, 1, "user")
+
+	now := time.Now()
+	newPage := func(owner snowflake.ID, edited time.Duration, role, mode string, against ...string) *objects.Page {
+		page := newTestPage(owner)
+		page.Edited = now.Add(-edited)
+		page.Role = role
+		page.Mode = mode
+		page.Against = against
+		db.CreatePage(context.Background(), page)
+		return page
+	}
+
+	newPage(1, 0, "", "")
+	newPage(1, 0, objects.RoleBot, objects.ModeClassic)
+	botVsLux := newPage(1, time.Hour, objects.RoleBot, "", "lux")
+	aram := newPage(1, 2*time.Hour, objects.RoleBot, objects.ModeARAM, "lux")
+	// Page of another user.
+	newPage(2, 0, objects.RoleBot, objects.ModeClassic, "lux")
+
+	for _, query := range []string{
+		"",
+		"?champion=unknown",
+		"?champion=jinx&against=unknown",
+		"?champion=jinx&role=middle",
+		"?champion=jinx&mode=ranked",
+	} {
+		ctx := ws.request("GET", "/pages/best"+query, auth, nil)
+		if code := ctx.Response.StatusCode(); code != fasthttp.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, code)
+		}
+	}
+
+	ctx := ws.request("GET", "/pages/best?champion=lux", auth, nil)
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusNotFound {
+		t.Errorf("expected status 404 without pages, got %d", code)
+	}
+
+	for _, c := range []struct {
+		query string
+		exp   *objects.Page
+	}{
+		// Equal scores are sorted by modification.
+		{"?champion=jinx&role=bot&against=lux", botVsLux},
+		// Pages of other game modes are excluded.
+		{"?champion=jinx&role=bot&against=lux&mode=classic", botVsLux},
+		{"?champion=jinx&role=bot&against=lux&mode=aram", aram},
+	} {
+		ctx := ws.request("GET", "/pages/best"+c.query, auth, nil)
+		if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+			t.Errorf("%q: expected status 200, got %d: %s", c.query, code, ctx.Response.Body())
+			continue
+		}
+		var res struct {
+			Page *objects.Page `json:"page"`
+		}
+		if err := json.Unmarshal(ctx.Response.Body(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Page == nil || res.Page.UID != c.exp.UID {
+			t.Errorf("%q: expected page %s, got %+v", c.query, c.exp.UID, res.Page)
+		}
+	}
+}
@@ -2,13 +2,25 @@ package webserver
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha1"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
-
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/myrunes/backend/internal/events"
+	"github.com/myrunes/backend/internal/logger"
+	"github.com/myrunes/backend/internal/metrics"
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/internal/shared"
 	"github.com/myrunes/backend/internal/static"
+	"github.com/myrunes/backend/pkg/comparison"
+	"github.com/myrunes/backend/pkg/ddragon"
 	"github.com/myrunes/backend/pkg/recapatcha"
 
 	routing "github.com/qiangxue/fasthttp-routing"
@@ -18,9 +30,16 @@ import (
 var emptyResponseBody = []byte("{}")
 
 var (
-	headerUserAgent    = []byte("User-Agent")
-	headerCacheControl = []byte("Cache-Control")
-	headerETag         = []byte("ETag")
+	headerUserAgent     = []byte("User-Agent")
+	headerCacheControl  = []byte("Cache-Control")
+	headerETag          = []byte("ETag")
+	headerReferer       = []byte("Referer")
+	headerSharePassword = []byte("X-Share-Password")
+	headerIfMatch       = []byte("If-Match")
+	headerIfUnmodSince  = []byte("If-Unmodified-Since")
+	headerDeprecation   = []byte("Deprecation")
+	headerSunset        = []byte("Sunset")
+	headerLink          = []byte("Link")
 
 	headerCacheControlValue = []byte("max-age=2592000; must-revalidate; proxy-revalidate;  public")
 
@@ -38,10 +57,21 @@ var defStatusBoddies = map[int][]byte{
 // passed status to response context and aborts the
 // execution of following registered handlers ONLY IF
 // err != nil.
+// Errors with a status of 500 or above are logged
+// with the ID of the request.
 // This function always returns a nil error that the
 // default error handler can be bypassed.
 func jsonError(ctx *routing.Context, err error, status int) error {
 	if err != nil {
+		if status >= fasthttp.StatusInternalServerError {
+			requestLogger(ctx).WithFields(logger.Fields{
+				"method": string(ctx.Method()),
+				"path":   string(ctx.Path()),
+				"status": status,
+				"error":  err,
+			}).Error("WEBSERVER :: request failed")
+		}
+
 		ctx.Response.Header.SetContentType("application/json")
 		ctx.SetStatusCode(status)
 		ctx.SetBodyString(fmt.Sprintf("{\n  \"code\": %d,\n  \"message\": \"%s\"\n}",
@@ -136,7 +166,8 @@ func (ws *WebServer) addHeaders(ctx *routing.Context) error {
 
 	if ws.config.PublicAddr != "" && ws.config.EnableCors {
 		ctx.Response.Header.Set("Access-Control-Allow-Origin", ws.config.PublicAddr)
-		ctx.Response.Header.Set("Access-Control-Allow-Headers", "authorization, content-type, set-cookie, cookie, server")
+		ctx.Response.Header.Set("Access-Control-Allow-Headers", "authorization, content-type, set-cookie, cookie, server, x-share-password, if-match, if-unmodified-since")
+		ctx.Response.Header.Set("Access-Control-Expose-Headers", "ETag")
 		ctx.Response.Header.Set("Access-Control-Allow-Methods", "POST, GET, DELETE, OPTIONS")
 		ctx.Response.Header.Set("Access-Control-Allow-Credentials", "true")
 	}
@@ -162,6 +193,356 @@ func (ws *WebServer) validateReCaptcha(ctx *routing.Context, rcr *reCaptchaRespo
 	return true, nil
 }
 
+// getSharePages returns the pages shared by the passed
+// share. For share collections, only pages which are
+// still owned by the owner of the share are returned.
+// If the page of a single page share does not exist
+// anymore, an empty slice is returned.
+func (ws *WebServer) getSharePages(ctx context.Context, share *objects.SharePage) ([]*objects.Page, error) {
+	if share.Filter != nil {
+		return ws.db.GetPages(ctx, share.OwnerID, share.Filter.Champion, "", nil)
+	}
+
+	pageIDs := share.PageIDs
+	if !share.IsCollection() {
+		pageIDs = []snowflake.ID{share.PageID}
+	}
+
+	pages := make([]*objects.Page, 0, len(pageIDs))
+	for _, id := range pageIDs {
+		page, err := ws.cache.GetPageByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if page != nil && page.Owner == share.OwnerID {
+			pages = append(pages, page)
+		}
+	}
+
+	return pages, nil
+}
+
+// getOwner returns the user or the team by the
+// passed owner ID of a page or share. If neither
+// a user nor a team exists with this ID, both
+// returned values are nil.
+func (ws *WebServer) getOwner(ctx context.Context, ownerID snowflake.ID) (*objects.User, *objects.Team, error) {
+	user, err := ws.cache.GetUserByID(ctx, ownerID)
+	if err != nil || user != nil {
+		return user, nil, err
+	}
+
+	team, err := ws.db.GetTeam(ctx, ownerID)
+	return nil, team, err
+}
+
+// getTeamMembership returns the team by the passed
+// uid and the membership of the passed user in the
+// team. If the team or the membership does not
+// exist, both returned values are nil.
+func (ws *WebServer) getTeamMembership(ctx context.Context, uid, userID snowflake.ID) (*objects.Team, *objects.TeamMember, error) {
+	member, err := ws.db.GetTeamMember(ctx, uid, userID)
+	if err != nil || member == nil {
+		return nil, nil, err
+	}
+
+	team, err := ws.db.GetTeam(ctx, uid)
+	if err != nil || team == nil {
+		return nil, nil, err
+	}
+
+	return team, member, nil
+}
+
+// getTeamMemberResponses returns the members of the
+// passed team wrapped with their sanitized users.
+func (ws *WebServer) getTeamMemberResponses(ctx context.Context, teamID snowflake.ID) ([]*teamMemberResponse, error) {
+	members, err := ws.db.GetTeamMembers(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*teamMemberResponse, 0, len(members))
+	for _, m := range members {
+		user, err := ws.cache.GetUserByID(ctx, m.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			continue
+		}
+		res = append(res, &teamMemberResponse{
+			Member: m,
+			User:   user.Sanitize(),
+		})
+	}
+
+	return res, nil
+}
+
+// hasOtherTeamAdmin returns true if the team of the
+// passed member has another accepted admin member.
+func (ws *WebServer) hasOtherTeamAdmin(ctx context.Context, member *objects.TeamMember) (bool, error) {
+	members, err := ws.db.GetTeamMembers(ctx, member.TeamID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, m := range members {
+		if m.UserID != member.UserID && !m.Pending && m.Role == objects.TeamRoleAdmin {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// removeTeamMember removes the passed member from its
+// team. If the member is the last accepted member, the
+// team is deleted with all of its pages. If the member
+// is the last admin of the team, the longest joined
+// remaining member is promoted to admin if promote is
+// true. Otherwise, errLastTeamAdmin is returned.
+func (ws *WebServer) removeTeamMember(ctx context.Context, member *objects.TeamMember, promote bool) error {
+	if member.Pending {
+		return ws.db.DeleteTeamMember(ctx, member.TeamID, member.UserID)
+	}
+
+	members, err := ws.db.GetTeamMembers(ctx, member.TeamID)
+	if err != nil {
+		return err
+	}
+
+	var successor *objects.TeamMember
+	var hasAdmin bool
+	var remaining int
+	for _, m := range members {
+		if m.UserID == member.UserID || m.Pending {
+			continue
+		}
+		remaining++
+		if m.Role == objects.TeamRoleAdmin {
+			hasAdmin = true
+		}
+		if successor == nil || m.Joined.Before(successor.Joined) {
+			successor = m
+		}
+	}
+
+	if remaining == 0 {
+		return ws.deleteTeam(ctx, member.TeamID)
+	}
+
+	if member.Role == objects.TeamRoleAdmin && !hasAdmin {
+		if !promote {
+			return errLastTeamAdmin
+		}
+
+		successor.Role = objects.TeamRoleAdmin
+		if err = ws.db.SetTeamMember(ctx, successor); err != nil {
+			return err
+		}
+	}
+
+	return ws.db.DeleteTeamMember(ctx, member.TeamID, member.UserID)
+}
+
+// deleteTeam removes the team by the passed uid
+// including its memberships, pages, shares, folders
+// and webhooks.
+func (ws *WebServer) deleteTeam(ctx context.Context, uid snowflake.ID) error {
+	if err := ws.db.DeleteUserPages(ctx, uid); err != nil {
+		return err
+	}
+
+	if err := ws.db.DeleteUserShares(ctx, uid); err != nil {
+		return err
+	}
+
+	if err := ws.db.DeleteUserFolders(ctx, uid); err != nil {
+		return err
+	}
+
+	if err := ws.db.DeleteUserWebhooks(ctx, uid); err != nil {
+		return err
+	}
+
+	return ws.db.DeleteTeam(ctx, uid)
+}
+
+// setShareAccessRestrictions sets the password and the
+// audience of the passed share from the passed request
+// parameters, if they are specified. Audience members
+// are passed by their user names and resolved to their
+// user IDs.
+func (ws *WebServer) setShareAccessRestrictions(ctx *routing.Context, share *objects.SharePage, params *createShareRequest) (bool, error) {
+	if params.Password != nil {
+		if err := share.SetPassword(requestContext(ctx), *params.Password, ws.auth); err != nil {
+			return false, jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+	}
+
+	if params.Audience != nil {
+		audience := make([]snowflake.ID, 0, len(*params.Audience))
+		for _, uname := range *params.Audience {
+			user, err := ws.db.GetUser(requestContext(ctx), snowflake.ID(-1), strings.ToLower(uname))
+			if err != nil {
+				return false, jsonError(ctx, err, fasthttp.StatusInternalServerError)
+			}
+			if user == nil {
+				return false, jsonError(ctx, errUnknownAudienceUser, fasthttp.StatusBadRequest)
+			}
+			audience = append(audience, user.UID)
+		}
+		share.Audience = audience
+	}
+
+	return true, nil
+}
+
+// checkShareAccess checks if the requesting client is
+// allowed to access the passed share.
+// If the share is restricted to an audience, the
+// request must be authenticated (401) by a user which
+// is part of the audience or which is permitted to
+// read the resources of the share owner (403). If the share is
+// password protected, the password must be passed in
+// the 'X-Share-Password' header (401). Failed password
+// attempts are rate limited like login attempts.
+func (ws *WebServer) checkShareAccess(ctx *routing.Context, share *objects.SharePage) (bool, error) {
+	if share.IsRestricted() {
+		viewer, err := ws.auth.GetRequestUser(ctx)
+		if err != nil {
+			return false, jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+		if viewer == nil {
+			return false, jsonError(ctx, errShareLoginRequired, fasthttp.StatusUnauthorized)
+		}
+		if !share.InAudience(viewer.UID) {
+			ok, err := ws.access.Can(requestContext(ctx), viewer.UID, share.OwnerID, objects.PermissionRead)
+			if err != nil {
+				return false, jsonError(ctx, err, fasthttp.StatusInternalServerError)
+			}
+			if !ok {
+				return false, jsonError(ctx, errShareNotInAudience, fasthttp.StatusForbidden)
+			}
+		}
+	}
+
+	if share.Protected {
+		password := string(ctx.Request.Header.PeekBytes(headerSharePassword))
+		if password == "" {
+			return false, jsonError(ctx, errSharePasswordRequired, fasthttp.StatusUnauthorized)
+		}
+
+		limiter := ws.rlm.GetLimiter(fmt.Sprintf("sharePasswordAttempt#%s", shared.GetIPAddr(ctx)), attemptLimit, attemptBurst)
+		if limiter.Tokens() <= 0 {
+			metrics.RateLimitRejections.WithLabelValues("share_password_attempt").Inc()
+			return false, jsonError(ctx, errRateLimited, fasthttp.StatusTooManyRequests)
+		}
+
+		if !share.CheckPassword(password, ws.auth) {
+			limiter.Allow()
+			return false, jsonError(ctx, errShareInvalidPassword, fasthttp.StatusUnauthorized)
+		}
+	}
+
+	return true, nil
+}
+
+// recordShareAccess stores an access event of the
+// passed share by the client of the passed request
+// context. If the client did not access the share
+// before, the access count of the share is increased.
+// If the maximum count of accesses of the share was
+// reached in the meantime, the access is not recorded
+// and false is returned. Requests from internal
+// addresses and link preview pings of Discord are
+// not recorded.
+func (ws *WebServer) recordShareAccess(ctx *routing.Context, share *objects.SharePage) (bool, error) {
+	reqAddr := shared.GetIPAddr(ctx)
+	userAgent := string(ctx.Request.Header.PeekBytes(headerUserAgent))
+	if strings.HasPrefix(reqAddr, "192.168") ||
+		strings.HasPrefix(reqAddr, "10.23") ||
+		(static.Release == "TRUE" && reqAddr == "127.0.0.1") ||
+		userAgent == static.DiscordUserAgentPingHeaderVal {
+		return true, nil
+	}
+
+	access := objects.NewShareAccess(share.UID, ws.shareAccessKey, reqAddr, userAgent,
+		string(ctx.Request.Header.PeekBytes(headerReferer)))
+
+	known, err := ws.db.HasShareAccess(requestContext(ctx), share.UID, access.IPHash)
+	if err != nil {
+		return false, err
+	}
+
+	// The counters are updated atomically instead of
+	// writing back the whole share, which would revert
+	// changes of the owner and of concurrent accesses.
+	if known {
+		err = ws.db.SetShareLastAccess(requestContext(ctx), share.UID, access.Timestamp)
+	} else {
+		var updated *objects.SharePage
+		updated, err = ws.db.IncrementShareAccess(requestContext(ctx), share.UID, access.Timestamp)
+		if err == nil && updated == nil {
+			return false, nil
+		}
+		if updated != nil {
+			share.Accesses = updated.Accesses
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	share.LastAccess = access.Timestamp
+
+	if err = ws.db.AddShareAccess(requestContext(ctx), access); err != nil {
+		return false, err
+	}
+
+	ws.publish(events.TypeShareAccessed, share.OwnerID,
+		&shareAccessedEvent{share.Ident, share.Accesses, share.LastAccess})
+
+	return true, nil
+}
+
+// sortPagesBySimilarity returns the pages of the passed
+// pages which are assigned to the passed champion or to
+// a champion of the same class. Pages of the champion
+// itself are listed before pages of similar champions
+// while keeping the order of the passed pages.
+func sortPagesBySimilarity(pages []*objects.Page, champ *ddragon.Champion) []*objects.Page {
+	exact := make([]*objects.Page, 0)
+	similar := make([]*objects.Page, 0)
+
+	for _, p := range pages {
+		var isSimilar bool
+		for _, uid := range p.Champions {
+			if uid == champ.UID {
+				exact = append(exact, p)
+				isSimilar = false
+				break
+			}
+			if c := ddragon.DDragonInstance.GetChampion(uid); c != nil && champ.IsSimilar(c) {
+				isSimilar = true
+			}
+		}
+		if isSimilar {
+			similar = append(similar, p)
+		}
+	}
+
+	return append(exact, similar...)
+}
+
+// pageRunesKey returns a string identifying the
+// selection of trees, runes and stat shards of
+// the passed page.
+func pageRunesKey(page *objects.Page) string {
+	return fmt.Sprintf("%v|%v|%v", *page.Primary, *page.Secondary, *page.Perks)
+}
+
 // checkPageName takes an actual pageName, a guess and
 // a float value for tollerance between 0 and 1.
 // Both, the pageName and guess will be lowercased and
@@ -216,3 +597,324 @@ func getETag(body []byte, weak bool) string {
 func isOldPasswordHash(hash []byte) bool {
 	return bytes.HasPrefix(hash, bcryptPrefix)
 }
+
+// getPageOwners returns the IDs of the owners which
+// pages are requested by the user with the passed
+// ID. By default, this is only the user itself. If
+// team is passed, the ID of the team is returned
+// when the user is allowed to read its pages. If
+// teams is true, the user ID and the IDs of all
+// teams the user is member of are returned.
+// On failure, the returned status code describes
+// the HTTP status of the error.
+func (ws *WebServer) getPageOwners(ctx context.Context, userID snowflake.ID, team string, teams bool) ([]snowflake.ID, int, error) {
+	if team != "" {
+		owner, status, err := ws.getRequestedOwner(ctx, userID, team, objects.PermissionRead)
+		if err != nil {
+			return nil, status, err
+		}
+		return []snowflake.ID{owner}, fasthttp.StatusOK, nil
+	}
+
+	if teams {
+		owners, err := ws.access.Owners(ctx, userID)
+		if err != nil {
+			return nil, fasthttp.StatusInternalServerError, err
+		}
+		return owners, fasthttp.StatusOK, nil
+	}
+
+	return []snowflake.ID{userID}, fasthttp.StatusOK, nil
+}
+
+// getRequestedOwner returns the ID of the team
+// passed as team if the user with the passed ID
+// has the passed permission on its resources.
+// If team is empty, the user ID is returned.
+// On failure, the returned status code
+// describes the HTTP status of the error.
+func (ws *WebServer) getRequestedOwner(ctx context.Context, userID snowflake.ID, team string, perm objects.Permission) (snowflake.ID, int, error) {
+	if team == "" {
+		return userID, fasthttp.StatusOK, nil
+	}
+
+	teamID, err := snowflake.ParseString(team)
+	if err != nil {
+		return 0, fasthttp.StatusBadRequest, err
+	}
+
+	ok, err := ws.access.Can(ctx, userID, teamID, perm)
+	if err != nil {
+		return 0, fasthttp.StatusInternalServerError, err
+	}
+	if !ok {
+		return 0, fasthttp.StatusNotFound, errNotFound
+	}
+
+	return teamID, fasthttp.StatusOK, nil
+}
+
+// getOwnersPages returns the pages of all passed
+// owners matching the passed champion and filter.
+func (ws *WebServer) getOwnersPages(ctx context.Context, owners []snowflake.ID, champion, filter string) ([]*objects.Page, error) {
+	pages := make([]*objects.Page, 0)
+	for _, owner := range owners {
+		ownerPages, err := ws.db.GetPages(ctx, owner, champion, filter, nil)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, ownerPages...)
+	}
+	return pages, nil
+}
+
+// queryPages returns the pages of the owners requested
+// by the passed user matching the filters passed as
+// query parameters of the request, sorted by the
+// requested sort order. On failure, the returned
+// status code describes the HTTP status of the error.
+func (ws *WebServer) queryPages(ctx *routing.Context, user *objects.User) ([]*objects.Page, int, error) {
+	queryArgs := ctx.QueryArgs()
+
+	sortBy := string(queryArgs.Peek("sortBy"))
+	filter := string(queryArgs.Peek("filter"))
+	champion := string(queryArgs.Peek("champion"))
+	team := string(queryArgs.Peek("team"))
+	teams := string(queryArgs.Peek("teams"))
+
+	pf := &pageFilter{
+		Tag:     string(queryArgs.Peek("tag")),
+		Role:    string(queryArgs.Peek("role")),
+		Against: string(queryArgs.Peek("against")),
+		Mode:    string(queryArgs.Peek("mode")),
+	}
+
+	if f := queryArgs.Peek("folder"); len(f) > 0 {
+		var err error
+		if pf.Folder, err = snowflake.ParseBytes(f); err != nil {
+			return nil, fasthttp.StatusBadRequest, err
+		}
+	}
+
+	if champion == "" {
+		champion = "general"
+	}
+
+	var sortFunc func(i, j *objects.Page) bool
+
+	switch sortBy {
+	case "created":
+		sortFunc = func(i, j *objects.Page) bool {
+			return i.Created.After(j.Created)
+		}
+	case "title":
+		sortFunc = func(i, j *objects.Page) bool {
+			return comparison.Alphabetically(i.Title, j.Title)
+		}
+	case "custom":
+		if user.PageOrder != nil {
+			pageOrder, ok := user.PageOrder[champion]
+			if ok {
+				sortFunc = func(i, j *objects.Page) bool {
+					var pix, jix int
+					for ix, uid := range pageOrder {
+						if uid == i.UID {
+							pix = ix
+						} else if uid == j.UID {
+							jix = ix
+						}
+					}
+					return jix > pix
+				}
+			}
+		}
+	}
+
+	owners, status, err := ws.getPageOwners(requestContext(ctx), user.UID, team, comparison.IsTrue(teams))
+	if err != nil {
+		return nil, status, err
+	}
+
+	pages, err := ws.getOwnersPages(requestContext(ctx), owners, champion, filter)
+	if err != nil {
+		return nil, fasthttp.StatusInternalServerError, err
+	}
+
+	pages = filterPages(pages, pf)
+
+	if sortFunc != nil {
+		sort.Slice(pages, func(i, j int) bool {
+			return sortFunc(pages[i], pages[j])
+		})
+	}
+
+	return pages, fasthttp.StatusOK, nil
+}
+
+// getRequestedPage returns the page with the UID
+// passed as path parameter of the request if the
+// passed user is allowed to read the page. On
+// failure, the returned status code describes the
+// HTTP status of the error.
+func (ws *WebServer) getRequestedPage(ctx *routing.Context, user *objects.User) (*objects.Page, int, error) {
+	uid, err := snowflake.ParseString(ctx.Param("uid"))
+	if err != nil {
+		return nil, fasthttp.StatusBadRequest, err
+	}
+
+	page, err := ws.access.Page(requestContext(ctx), user.UID, uid, objects.PermissionRead)
+	if err != nil {
+		return nil, fasthttp.StatusInternalServerError, err
+	}
+	if page == nil {
+		return nil, fasthttp.StatusNotFound, errNotFound
+	}
+
+	return page, fasthttp.StatusOK, nil
+}
+
+// checkFolderParent checks if the folder with the
+// passed parent UID exists and is owned by the
+// passed owner. A parent UID of 0 describes the
+// root and is always valid. If the folder is
+// passed, it is checked that the folder is not
+// moved into itself or into one of its children.
+// On failure, the returned status code describes
+// the HTTP status of the error.
+func (ws *WebServer) checkFolderParent(ctx context.Context, owner, parent snowflake.ID, folder *objects.Folder) (int, error) {
+	for uid := parent; uid != 0; {
+		if folder != nil && uid == folder.UID {
+			return fasthttp.StatusBadRequest, errFolderCycle
+		}
+
+		f, err := ws.db.GetFolder(ctx, uid)
+		if err != nil {
+			return fasthttp.StatusInternalServerError, err
+		}
+		if f == nil || f.Owner != owner {
+			return fasthttp.StatusBadRequest, errInvalidFolder
+		}
+
+		uid = f.Parent
+	}
+
+	return fasthttp.StatusOK, nil
+}
+
+// pageFilter describes properties pages
+// are filtered by. Empty values are not
+// used for filtering.
+type pageFilter struct {
+	Tag     string
+	Folder  snowflake.ID
+	Role    string
+	Against string
+	Mode    string
+}
+
+// isEmpty returns true if no filter
+// properties are set.
+func (f *pageFilter) isEmpty() bool {
+	return *f == pageFilter{}
+}
+
+// match returns true if the passed page
+// matches all set filter properties.
+func (f *pageFilter) match(p *objects.Page) bool {
+	return (f.Tag == "" || p.HasTag(objects.NormalizeTag(f.Tag))) &&
+		(f.Folder == 0 || p.Folder == f.Folder) &&
+		(f.Role == "" || p.Role == f.Role) &&
+		(f.Against == "" || p.IsAgainst(f.Against)) &&
+		(f.Mode == "" || p.Mode == f.Mode)
+}
+
+// filterPages returns all of the passed
+// pages which match the passed filter.
+func filterPages(pages []*objects.Page, filter *pageFilter) []*objects.Page {
+	if filter.isEmpty() {
+		return pages
+	}
+
+	res := make([]*objects.Page, 0, len(pages))
+	for _, p := range pages {
+		if filter.match(p) {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
+
+// deletePage removes the passed page from the
+// database and records its deletion as page
+// tombstone for synchronizing clients.
+func (ws *WebServer) deletePage(ctx context.Context, page *objects.Page) error {
+	if err := ws.db.DeletePage(ctx, page.UID); err != nil {
+		return err
+	}
+
+	// The image was only rendered if the page was
+	// shared, so a missing image is no error. Images
+	// of earlier states of the page are removed by
+	// the lifecycle cleanup.
+	ws.pageImageRenderer.Delete(ctx, page)
+
+	return ws.db.AddPageTombstone(ctx, objects.NewPageTombstone(page))
+}
+
+// pageETag returns the ETag of the passed page
+// which is derived from the page ID and the time
+// of the last modification of the page in
+// milliseconds, which is the precision the
+// time is stored with in the database.
+func pageETag(page *objects.Page) string {
+	return fmt.Sprintf(`"%d-%d"`, page.UID, page.Edited.UnixNano()/int64(time.Millisecond))
+}
+
+// checkPagePreconditions returns errPreconditionFailed
+// if the If-Match or If-Unmodified-Since headers of
+// the request are set and the passed page does not
+// match them. If-Match takes precedence over
+// If-Unmodified-Since as specified in RFC 7232.
+func checkPagePreconditions(ctx *routing.Context, page *objects.Page) error {
+	if ifMatch := ctx.Request.Header.PeekBytes(headerIfMatch); len(ifMatch) > 0 {
+		eTag := pageETag(page)
+		for _, t := range strings.Split(string(ifMatch), ",") {
+			if t = strings.TrimSpace(t); t == "*" || t == eTag {
+				return nil
+			}
+		}
+		return errPreconditionFailed
+	}
+
+	if ifUnmodSince := ctx.Request.Header.PeekBytes(headerIfUnmodSince); len(ifUnmodSince) > 0 {
+		since, err := fasthttp.ParseHTTPDate(ifUnmodSince)
+		if err == nil && page.Edited.Truncate(time.Second).After(since) {
+			return errPreconditionFailed
+		}
+	}
+
+	return nil
+}
+
+// parseSyncCursor parses the passed sync cursor,
+// which is a unix timestamp in milliseconds. An
+// empty cursor results in a zero time.
+func parseSyncCursor(cursor string) (time.Time, error) {
+	if cursor == "" {
+		return time.Time{}, nil
+	}
+
+	ms, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(0, ms*int64(time.Millisecond)), nil
+}
+
+// formatSyncCursor formats the passed time as
+// sync cursor.
+func formatSyncCursor(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+}
@@ -0,0 +1,646 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/myrunes/backend/internal/objects"
+)
+
+// newTestContext creates a routing context of a
+// request with the passed method and URI.
+func newTestContext(method, uri string) *routing.Context {
+	rctx := new(fasthttp.RequestCtx)
+	rctx.Request.Header.SetMethod(method)
+	rctx.Request.SetRequestURI(uri)
+	return &routing.Context{RequestCtx: rctx}
+}
+
+func TestJSONError(t *testing.T) {
+	ctx := newTestContext("POST", "/api/shares")
+
+	if err := jsonError(ctx, errors.New("database unavailable"), fasthttp.StatusInternalServerError); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", code)
+	}
+
+	var body struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(ctx.Response.Body(), &body); err != nil {
+		t.Fatalf("body is no error envelope: %s", err.Error())
+	}
+	if body.Code != fasthttp.StatusInternalServerError || body.Message != "database unavailable" {
+		t.Errorf("unexpected error envelope: %+v", body)
+	}
+}
+
+func TestJSONErrorNil(t *testing.T) {
+	ctx := newTestContext("GET", "/api/version")
+	ctx.SetStatusCode(fasthttp.StatusOK)
+
+	jsonError(ctx, nil, fasthttp.StatusInternalServerError)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Errorf("nil error changed the status to %d", code)
+	}
+}
+
+func TestPageETag(t *testing.T) {
+	edited := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	page := &objects.Page{UID: 42, Edited: edited}
+
+	if eTag := pageETag(page); eTag != `"42-1601553600000"` {
+		t.Errorf("unexpected ETag %s", eTag)
+	}
+
+	page.Edited = edited.Add(time.Millisecond)
+	if pageETag(page) == `"42-1601553600000"` {
+		t.Error("ETag did not change after edit")
+	}
+}
+
+func TestCheckPagePreconditions(t *testing.T) {
+	edited := time.Date(2020, 10, 1, 12, 0, 0, 500*int(time.Millisecond), time.UTC)
+	page := &objects.Page{UID: 42, Edited: edited}
+	eTag := pageETag(page)
+
+	cases := []struct {
+		name          string
+		ifMatch       string
+		ifUnmodSince  string
+		expectSuccess bool
+	}{
+		{"no preconditions", "", "", true},
+		{"matching etag", eTag, "", true},
+		{"matching etag in list", `"1-1", ` + eTag, "", true},
+		{"wildcard", "*", "", true},
+		{"stale etag", `"42-1601553599000"`, "", false},
+		{"if-match precedes if-unmodified-since", eTag, "Thu, 01 Oct 2020 11:00:00 GMT", true},
+		{"unmodified since edit", "", "Thu, 01 Oct 2020 12:00:00 GMT", true},
+		{"unmodified since later", "", "Thu, 01 Oct 2020 13:00:00 GMT", true},
+		{"modified since", "", "Thu, 01 Oct 2020 11:59:59 GMT", false},
+		{"invalid date", "", "yesterday", true},
+	}
+
+	for _, c := range cases {
+		ctx := newTestContext("POST", "/api/pages/42")
+		if c.ifMatch != "" {
+			ctx.Request.Header.Set("If-Match", c.ifMatch)
+		}
+		if c.ifUnmodSince != "" {
+			ctx.Request.Header.Set("If-Unmodified-Since", c.ifUnmodSince)
+		}
+
+		err := checkPagePreconditions(ctx, page)
+		if c.expectSuccess && err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if !c.expectSuccess && err != errPreconditionFailed {
+			t.Errorf("%s: expected precondition failure, got %v", c.name, err)
+		}
+	}
+}
+
+func TestSyncCursor(t *testing.T) {
+	if since, err := parseSyncCursor(""); err != nil || !since.IsZero() {
+		t.Errorf("expected zero time for empty cursor, got %s, %v", since, err)
+	}
+
+	for _, cursor := range []string{"yesterday", "1.5", "0x10"} {
+		if _, err := parseSyncCursor(cursor); err == nil {
+			t.Errorf("expected error for cursor %q", cursor)
+		}
+	}
+
+	for _, tm := range []time.Time{
+		time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2020, 10, 1, 12, 0, 0, 123456789, time.UTC),
+		time.Unix(0, 0),
+		time.Now(),
+	} {
+		cursor := formatSyncCursor(tm)
+		since, err := parseSyncCursor(cursor)
+		if err != nil {
+			t.Fatalf("%s: %s", cursor, err.Error())
+		}
+		if exp := tm.Truncate(time.Millisecond); !since.Equal(exp) {
+			t.Errorf("%s: expected %s, got %s", cursor, exp, since)
+		}
+	}
+
+	if cursor := formatSyncCursor(time.Date(2020, 10, 1, 12, 0, 0, 500*int(time.Millisecond), time.UTC)); cursor != "1601553600500" {
+		t.Errorf("expected cursor 1601553600500, got %s", cursor)
+	}
+}
+
+func TestAddHeadersCORS(t *testing.T) {
+	ws := &WebServer{config: &Config{PublicAddr: "https://myrunes.com", EnableCors: true}}
+	ctx := newTestContext("OPTIONS", "/api/pages/42")
+
+	ws.addHeaders(ctx)
+
+	if v := string(ctx.Response.Header.Peek("Access-Control-Allow-Origin")); v != "https://myrunes.com" {
+		t.Errorf("unexpected allowed origin %s", v)
+	}
+
+	allowed := string(ctx.Response.Header.Peek("Access-Control-Allow-Headers"))
+	for _, h := range []string{"authorization", "if-match", "if-unmodified-since"} {
+		if !strings.Contains(allowed, h) {
+			t.Errorf("header %s is not allowed: %s", h, allowed)
+		}
+	}
+
+	if v := string(ctx.Response.Header.Peek("Access-Control-Expose-Headers")); !strings.Contains(v, "ETag") {
+		t.Errorf("ETag is not exposed: %s", v)
+	}
+}
+
+// newTestShare creates a share of a page of
+// the passed owner which is stored in the
+// passed database.
+func newTestShare(t *testing.T, db *testDatabase, owner snowflake.ID) *objects.SharePage {
+	t.Helper()
+
+	page := newTestPage(owner)
+	if err := db.CreatePage(context.Background(), page); err != nil {
+		t.Fatal(err)
+	}
+
+	share, err := objects.NewSharePage(owner, page.UID, 0, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	share.PageID = page.UID
+	if err = db.SetShare(context.Background(), share); err != nil {
+		t.Fatal(err)
+	}
+
+	return share
+}
+
+// newShareContext creates a routing context of a
+// request of a share from the passed address with
+// the passed authorization and share password.
+func newShareContext(ident, addr, authorization, password string) *routing.Context {
+	ctx := newTestContext("GET", testPathPrefix+"/shares/"+ident)
+	ctx.Request.Header.Set("X-Forwarded-For", addr)
+	if authorization != "" {
+		ctx.Request.Header.Set("Authorization", authorization)
+	}
+	if password != "" {
+		ctx.Request.Header.Set("X-Share-Password", password)
+	}
+	return ctx
+}
+
+func TestCheckShareAccess(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+
+	authOwner := addTestUser(ws, db, 1, "owner")
+	authMember := addTestUser(ws, db, 2, "member")
+	authViewer := addTestUser(ws, db, 3, "viewer")
+	authStranger := addTestUser(ws, db, 4, "stranger")
+	authPending := addTestUser(ws, db, 5, "pending")
+
+	const teamID = 10
+	db.SetTeamMember(context.Background(), &objects.TeamMember{TeamID: teamID, UserID: 2, Role: objects.TeamRoleViewer})
+	db.SetTeamMember(context.Background(), &objects.TeamMember{TeamID: teamID, UserID: 5, Role: objects.TeamRoleAdmin, Pending: true})
+
+	open := newTestShare(t, db, teamID)
+
+	restricted := newTestShare(t, db, teamID)
+	restricted.Audience = []snowflake.ID{3}
+
+	protected := newTestShare(t, db, teamID)
+	if err := protected.SetPassword(context.Background(), "secret", ws.auth); err != nil {
+		t.Fatal(err)
+	}
+
+	both := newTestShare(t, db, teamID)
+	both.Audience = []snowflake.ID{3}
+	both.PassHash, both.Protected = protected.PassHash, true
+
+	cases := []struct {
+		name          string
+		share         *objects.SharePage
+		authorization string
+		password      string
+		status        int
+		err           error
+	}{
+		{"open share anonymous", open, "", "", 0, nil},
+		{"open share with unused password", open, "", "secret", 0, nil},
+		{"restricted anonymous", restricted, "", "", fasthttp.StatusUnauthorized, errShareLoginRequired},
+		{"restricted audience member", restricted, authViewer, "", 0, nil},
+		{"restricted owner team member", restricted, authMember, "", 0, nil},
+		{"restricted pending team member", restricted, authPending, "", fasthttp.StatusForbidden, errShareNotInAudience},
+		{"restricted stranger", restricted, authStranger, "", fasthttp.StatusForbidden, errShareNotInAudience},
+		{"restricted invalid token", restricted, "Basic invalid", "", fasthttp.StatusUnauthorized, errShareLoginRequired},
+		{"protected missing password", protected, "", "", fasthttp.StatusUnauthorized, errSharePasswordRequired},
+		{"protected wrong password", protected, "", "wrong", fasthttp.StatusUnauthorized, errShareInvalidPassword},
+		{"protected correct password", protected, "", "secret", 0, nil},
+		{"protected owner without password", protected, authOwner, "", fasthttp.StatusUnauthorized, errSharePasswordRequired},
+		{"restricted and protected without login", both, "", "secret", fasthttp.StatusUnauthorized, errShareLoginRequired},
+		{"restricted and protected stranger", both, authStranger, "secret", fasthttp.StatusForbidden, errShareNotInAudience},
+		{"restricted and protected missing password", both, authViewer, "", fasthttp.StatusUnauthorized, errSharePasswordRequired},
+		{"restricted and protected", both, authViewer, "secret", 0, nil},
+	}
+
+	for i, c := range cases {
+		// Each case uses its own address, so that wrong
+		// passwords do not exhaust the rate limit.
+		ctx := newShareContext(c.share.Ident, fmt.Sprintf("203.0.113.%d", i), c.authorization, c.password)
+
+		ok, err := ws.checkShareAccess(ctx, c.share)
+		if err != nil {
+			t.Fatalf("%s: %s", c.name, err.Error())
+		}
+
+		if c.status == 0 {
+			if !ok {
+				t.Errorf("%s: expected access, got status %d: %s",
+					c.name, ctx.Response.StatusCode(), ctx.Response.Body())
+			}
+			continue
+		}
+
+		if ok {
+			t.Errorf("%s: expected access to be denied", c.name)
+			continue
+		}
+		if code := ctx.Response.StatusCode(); code != c.status {
+			t.Errorf("%s: expected status %d, got %d", c.name, c.status, code)
+		}
+		if body := string(ctx.Response.Body()); !strings.Contains(body, c.err.Error()) {
+			t.Errorf("%s: expected error %q, got %s", c.name, c.err.Error(), body)
+		}
+	}
+}
+
+func TestCheckSharePasswordRateLimit(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+
+	share := newTestShare(t, db, 1)
+	if err := share.SetPassword(context.Background(), "secret", ws.auth); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(addr, password string) int {
+		ctx := newShareContext(share.Ident, addr, "", password)
+		if ok, err := ws.checkShareAccess(ctx, share); ok || err != nil {
+			return 0
+		}
+		return ctx.Response.StatusCode()
+	}
+
+	// Correct passwords and missing passwords
+	// do not consume attempts.
+	for i := 0; i < attemptBurst+1; i++ {
+		if code := check("203.0.113.1", "secret"); code != 0 {
+			t.Fatalf("expected access with correct password, got status %d", code)
+		}
+		if code := check("203.0.113.1", ""); code != fasthttp.StatusUnauthorized {
+			t.Fatalf("expected status 401 without password, got %d", code)
+		}
+	}
+
+	for i := 0; i < attemptBurst; i++ {
+		if code := check("203.0.113.1", "wrong"); code != fasthttp.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected status 401, got %d", i, code)
+		}
+	}
+
+	// Once the attempts are exhausted, even the
+	// correct password is rejected.
+	if code := check("203.0.113.1", "wrong"); code != fasthttp.StatusTooManyRequests {
+		t.Errorf("expected status 429 for wrong password, got %d", code)
+	}
+	if code := check("203.0.113.1", "secret"); code != fasthttp.StatusTooManyRequests {
+		t.Errorf("expected status 429 for correct password, got %d", code)
+	}
+
+	// Attempts are limited per address.
+	if code := check("203.0.113.2", "secret"); code != 0 {
+		t.Errorf("expected access from other address, got status %d", code)
+	}
+}
+
+func TestSetShareAccessRestrictions(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+
+	addTestUser(ws, db, 2, "alice")
+	addTestUser(ws, db, 3, "bob")
+
+	password := func(s string) *string { return &s }
+	audience := func(names ...string) *[]string { return &names }
+
+	t.Run("password", func(t *testing.T) {
+		share := newTestShare(t, db, 1)
+
+		ok, err := ws.setShareAccessRestrictions(newTestContext("POST", "/"), share,
+			&createShareRequest{Password: password("secret")})
+		if !ok || err != nil {
+			t.Fatalf("expected restrictions to be set, got %v", err)
+		}
+		if !share.Protected || !share.CheckPassword("secret", ws.auth) || share.CheckPassword("wrong", ws.auth) {
+			t.Error("expected share to be protected by the password")
+		}
+
+		ok, err = ws.setShareAccessRestrictions(newTestContext("POST", "/"), share,
+			&createShareRequest{Password: password("")})
+		if !ok || err != nil {
+			t.Fatalf("expected restrictions to be set, got %v", err)
+		}
+		if share.Protected || share.PassHash != nil {
+			t.Error("expected empty password to remove the protection")
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		share := newTestShare(t, db, 1)
+		share.Protected, share.Audience = true, []snowflake.ID{2}
+
+		ok, err := ws.setShareAccessRestrictions(newTestContext("POST", "/"), share, &createShareRequest{})
+		if !ok || err != nil {
+			t.Fatalf("expected restrictions to be set, got %v", err)
+		}
+		if !share.Protected || len(share.Audience) != 1 {
+			t.Error("expected unspecified restrictions to be kept")
+		}
+	})
+
+	t.Run("audience", func(t *testing.T) {
+		share := newTestShare(t, db, 1)
+
+		ok, err := ws.setShareAccessRestrictions(newTestContext("POST", "/"), share,
+			&createShareRequest{Audience: audience("Alice", "bob")})
+		if !ok || err != nil {
+			t.Fatalf("expected restrictions to be set, got %v", err)
+		}
+		if len(share.Audience) != 2 || share.Audience[0] != 2 || share.Audience[1] != 3 {
+			t.Errorf("expected audience [2 3], got %v", share.Audience)
+		}
+
+		ok, err = ws.setShareAccessRestrictions(newTestContext("POST", "/"), share,
+			&createShareRequest{Audience: audience()})
+		if !ok || err != nil {
+			t.Fatalf("expected restrictions to be set, got %v", err)
+		}
+		if share.IsRestricted() {
+			t.Error("expected empty audience to remove the restriction")
+		}
+	})
+
+	t.Run("unknown audience user", func(t *testing.T) {
+		share := newTestShare(t, db, 1)
+		ctx := newTestContext("POST", "/")
+
+		ok, _ := ws.setShareAccessRestrictions(ctx, share,
+			&createShareRequest{Audience: audience("alice", "unknown")})
+		if ok {
+			t.Fatal("expected unknown audience user to be rejected")
+		}
+		if code := ctx.Response.StatusCode(); code != fasthttp.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", code)
+		}
+		if share.IsRestricted() {
+			t.Error("expected audience not to be changed")
+		}
+	})
+}
+
+func TestRecordShareAccess(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+
+	share := newTestShare(t, db, 1)
+
+	record := func(addr string) bool {
+		ok, err := ws.recordShareAccess(newShareContext(share.Ident, addr, "", ""), share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+
+	if !record("203.0.113.1") || !record("203.0.113.1") || !record("203.0.113.2") {
+		t.Fatal("expected accesses to be recorded")
+	}
+	if n := db.getShare(share.UID).Accesses; n != 2 {
+		t.Errorf("expected 2 accesses by distinct clients, got %d", n)
+	}
+
+	// Internal addresses are not counted.
+	record("192.168.0.1")
+	if n := db.getShare(share.UID).Accesses; n != 2 {
+		t.Errorf("expected internal access not to be counted, got %d accesses", n)
+	}
+}
+
+func TestRecordShareAccessKeepsOwnerChanges(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+
+	share := newTestShare(t, db, 1)
+	visited := db.getShare(share.UID)
+
+	// The owner protects the share while
+	// the visitors request is in flight.
+	if err := share.SetPassword(context.Background(), "secret", ws.auth); err != nil {
+		t.Fatal(err)
+	}
+	share.MaxAccesses = 10
+	db.SetShare(context.Background(), share)
+
+	if ok, err := ws.recordShareAccess(newShareContext(share.Ident, "203.0.113.1", "", ""), visited); !ok || err != nil {
+		t.Fatalf("expected access to be recorded, got %v", err)
+	}
+
+	stored := db.getShare(share.UID)
+	if !stored.Protected || stored.MaxAccesses != 10 {
+		t.Error("expected changes of the owner to be kept")
+	}
+	if stored.Accesses != 1 || visited.Accesses != 1 {
+		t.Errorf("expected 1 access, got %d", stored.Accesses)
+	}
+}
+
+func TestRecordShareAccessMaxAccesses(t *testing.T) {
+	const maxAccesses = 3
+
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+
+	share := newTestShare(t, db, 1)
+	share.MaxAccesses = maxAccesses
+	db.SetShare(context.Background(), share)
+
+	var wg sync.WaitGroup
+	var mx sync.Mutex
+	granted := 0
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := db.getShare(share.UID)
+			ok, err := ws.recordShareAccess(newShareContext(c.Ident, fmt.Sprintf("203.0.113.%d", i), "", ""), c)
+			if err != nil {
+				t.Error(err)
+			}
+			if ok {
+				mx.Lock()
+				granted++
+				mx.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if granted != maxAccesses {
+		t.Errorf("expected %d granted accesses, got %d", maxAccesses, granted)
+	}
+	stored := db.getShare(share.UID)
+	if stored.Accesses != maxAccesses {
+		t.Errorf("expected %d accesses, got %d", maxAccesses, stored.Accesses)
+	}
+	if stored.IsAccessible() {
+		t.Error("expected share with exhausted accesses not to be accessible")
+	}
+}
+
+func TestGetShareExhaustedOrExpired(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	addTestUser(ws, db, 1, "owner")
+
+	exhausted := newTestShare(t, db, 1)
+	exhausted.MaxAccesses, exhausted.Accesses = 2, 2
+	db.SetShare(context.Background(), exhausted)
+
+	expired := newTestShare(t, db, 1)
+	expired.Expires = time.Now().Add(-time.Minute)
+	db.SetShare(context.Background(), expired)
+
+	valid := newTestShare(t, db, 1)
+
+	for _, c := range []struct {
+		name   string
+		share  *objects.SharePage
+		status int
+	}{
+		{"exhausted", exhausted, fasthttp.StatusNotFound},
+		{"expired", expired, fasthttp.StatusNotFound},
+		{"valid", valid, fasthttp.StatusAccepted},
+	} {
+		ctx := ws.request("GET", "/shares/"+c.share.Ident, "", nil)
+		if code := ctx.Response.StatusCode(); code != c.status {
+			t.Errorf("%s: expected status %d, got %d: %s", c.name, c.status, code, ctx.Response.Body())
+		}
+	}
+}
+
+func TestDeleteTeam(t *testing.T) {
+	const teamID = 10
+
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+
+	db.SetTeamMember(context.Background(), &objects.TeamMember{TeamID: teamID, UserID: 2, Role: objects.TeamRoleAdmin})
+
+	teamShare := newTestShare(t, db, teamID)
+	userShare := newTestShare(t, db, 2)
+
+	for i, share := range []*objects.SharePage{teamShare, userShare} {
+		ok, err := ws.recordShareAccess(newShareContext(share.Ident, fmt.Sprintf("203.0.113.%d", i), "", ""), share)
+		if !ok || err != nil {
+			t.Fatalf("expected access to be recorded, got %v", err)
+		}
+	}
+
+	if err := ws.deleteTeam(context.Background(), teamID); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if share, _ := db.GetShare(ctx, "", teamShare.UID, -1); share != nil {
+		t.Error("expected share of the team to be deleted")
+	}
+	if page, _ := db.GetPage(ctx, teamShare.PageID); page != nil {
+		t.Error("expected page of the team to be deleted")
+	}
+	if member, _ := db.GetTeamMember(ctx, teamID, 2); member != nil {
+		t.Error("expected membership to be deleted")
+	}
+
+	if share, _ := db.GetShare(ctx, "", userShare.UID, -1); share == nil {
+		t.Error("expected share of the user to be kept")
+	}
+	if len(db.accesses) != 1 || db.accesses[0].ShareID != userShare.UID {
+		t.Error("expected accesses of the user share to be kept")
+	}
+}
+
+func TestFilterPages(t *testing.T) {
+	newPage := func(role, mode string, folder snowflake.ID, tags []string, against ...string) *objects.Page {
+		page := newTestPage(1)
+		page.Role = role
+		page.Mode = mode
+		page.Folder = folder
+		page.Tags = tags
+		page.Against = against
+		return page
+	}
+
+	general := newPage("", "", 0, nil)
+	bot := newPage(objects.RoleBot, objects.ModeClassic, 5, []string{"solo queue"}, "lux")
+	aram := newPage(objects.RoleBot, objects.ModeARAM, 0, []string{"fun"}, "caitlyn")
+
+	pages := []*objects.Page{general, bot, aram}
+
+	cases := []struct {
+		filter pageFilter
+		exp    []*objects.Page
+	}{
+		{pageFilter{}, pages},
+		{pageFilter{Role: objects.RoleBot}, []*objects.Page{bot, aram}},
+		{pageFilter{Mode: objects.ModeARAM}, []*objects.Page{aram}},
+		{pageFilter{Against: "lux"}, []*objects.Page{bot}},
+		{pageFilter{Tag: " Solo  Queue"}, []*objects.Page{bot}},
+		{pageFilter{Folder: 5}, []*objects.Page{bot}},
+		{pageFilter{Role: objects.RoleBot, Against: "caitlyn"}, []*objects.Page{aram}},
+		{pageFilter{Role: objects.RoleMid}, []*objects.Page{}},
+	}
+
+	for _, c := range cases {
+		res := filterPages(pages, &c.filter)
+		if len(res) != len(c.exp) {
+			t.Errorf("%+v: expected %d pages, got %d", c.filter, len(c.exp), len(res))
+			continue
+		}
+		for i, p := range res {
+			if p != c.exp[i] {
+				t.Errorf("%+v: unexpected page %d", c.filter, i)
+			}
+		}
+	}
+}
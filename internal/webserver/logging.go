@@ -0,0 +1,133 @@
+package webserver
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/myrunes/backend/internal/logger"
+	"github.com/myrunes/backend/internal/shared"
+	"github.com/myrunes/backend/pkg/random"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// key of the request ID in the
+	// request context
+	ctxKeyRequestID = "requestid"
+	// number of random bytes of
+	// generated request IDs
+	requestIDLen = 16
+	// maximum length of request IDs
+	// passed by clients or proxies
+	requestIDMaxLen = 128
+)
+
+var headerRequestID = []byte("X-Request-Id")
+
+// addRequestID provides a handler which assigns an ID
+// to the request and sets it as X-Request-Id response
+// header. IDs passed by clients or proxies in the
+// X-Request-Id request header are taken over if they
+// are valid. Otherwise, a random ID is generated.
+// Errors returned by the following handlers are
+// written like the error handler of the router does,
+// which would otherwise reset the response headers.
+func (ws *WebServer) addRequestID(ctx *routing.Context) error {
+	id := string(ctx.Request.Header.PeekBytes(headerRequestID))
+	if !isValidRequestID(id) {
+		b, err := random.ByteArray(requestIDLen)
+		if err != nil {
+			return jsonError(ctx, err, fasthttp.StatusInternalServerError)
+		}
+		id = hex.EncodeToString(b)
+	}
+
+	ctx.Set(ctxKeyRequestID, id)
+	ctx.Response.Header.SetBytesK(headerRequestID, id)
+
+	if err := ctx.Next(); err != nil {
+		ctx.Error(err.Error(), responseStatus(ctx, err))
+		ctx.Response.Header.SetBytesK(headerRequestID, id)
+	}
+
+	return nil
+}
+
+// logAccess provides a handler which executes the
+// following handlers and logs the request with its
+// response status, size and duration.
+func (ws *WebServer) logAccess(ctx *routing.Context) error {
+	start := time.Now()
+	err := ctx.Next()
+
+	fields := logger.Fields{
+		"method":      string(ctx.Method()),
+		"path":        string(ctx.Path()),
+		"status":      responseStatus(ctx, err),
+		"duration_ms": time.Since(start).Milliseconds(),
+		"remote_addr": shared.GetIPAddr(ctx),
+		"user_agent":  string(ctx.Request.Header.PeekBytes(headerUserAgent)),
+	}
+
+	// Reading the body of streamed responses, like
+	// event streams, would block until the stream
+	// ends, so their size is not logged.
+	if !ctx.Response.IsBodyStream() {
+		fields["bytes"] = len(ctx.Response.Body())
+	}
+
+	requestLogger(ctx).WithFields(fields).Info("WEBSERVER :: request")
+
+	return err
+}
+
+// requestLogger returns a log entry containing the
+// ID of the request of the passed context and, if
+// the request is traced, the ID of its trace.
+func requestLogger(ctx *routing.Context) *logger.Entry {
+	id, _ := ctx.Get(ctxKeyRequestID).(string)
+	fields := logger.Fields{
+		"request_id": id,
+	}
+	if sc := trace.SpanContextFromContext(requestContext(ctx)); sc.IsValid() {
+		fields["trace_id"] = sc.TraceID().String()
+	}
+	return logger.WithFields(fields)
+}
+
+// responseStatus returns the status code of the
+// response to the request of the passed context.
+// Errors returned by handlers are written by the
+// error handler of the router after all other
+// handlers, so their status is derived from the
+// passed error.
+func responseStatus(ctx *routing.Context, err error) int {
+	if err == nil {
+		return ctx.Response.StatusCode()
+	}
+	if httpErr, ok := err.(routing.HTTPError); ok {
+		return httpErr.StatusCode()
+	}
+	return fasthttp.StatusInternalServerError
+}
+
+// isValidRequestID returns true if the passed ID
+// is not empty, not longer than requestIDMaxLen
+// and only contains alphanumeric characters,
+// dashes, underscores and dots.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > requestIDMaxLen {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
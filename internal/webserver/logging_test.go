@@ -0,0 +1,78 @@
+package webserver
+
+import (
+	"bufio"
+	"testing"
+	"time"
+
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+func TestAddRequestID(t *testing.T) {
+	ws := new(WebServer)
+	router := routing.New()
+	router.Use(ws.addRequestID)
+	router.Get("/", func(ctx *routing.Context) error {
+		return nil
+	})
+
+	cases := []struct {
+		name     string
+		passed   string
+		accepted bool
+	}{
+		{"generated", "", false},
+		{"passed", "req-1.a_B", true},
+		{"invalid characters", "req 1", false},
+		{"too long", string(make([]byte, 129)), false},
+	}
+
+	for _, c := range cases {
+		ctx := new(fasthttp.RequestCtx)
+		ctx.Request.SetRequestURI("/")
+		if c.passed != "" {
+			ctx.Request.Header.Set("X-Request-Id", c.passed)
+		}
+
+		router.HandleRequest(ctx)
+
+		id := string(ctx.Response.Header.Peek("X-Request-Id"))
+		if id == "" {
+			t.Errorf("%s: no request ID set", c.name)
+		}
+		if (id == c.passed) != c.accepted {
+			t.Errorf("%s: unexpected request ID %q", c.name, id)
+		}
+	}
+}
+
+func TestLogAccessStreamedResponse(t *testing.T) {
+	ws := new(WebServer)
+	router := routing.New()
+	router.Use(ws.logAccess)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	router.Get("/stream", func(ctx *routing.Context) error {
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			<-release
+		})
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		ctx := new(fasthttp.RequestCtx)
+		ctx.Request.SetRequestURI("/stream")
+		router.HandleRequest(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging the request waits for the end of the stream")
+	}
+}
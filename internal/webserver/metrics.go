@@ -0,0 +1,55 @@
+package webserver
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/myrunes/backend/internal/metrics"
+	routing "github.com/qiangxue/fasthttp-routing"
+)
+
+// Labels of requests which do not match any
+// operation of the API or use a method which
+// is not supported by the router.
+const (
+	metricsRouteUnmatched = "unmatched"
+	metricsMethodOther    = "OTHER"
+)
+
+// recordMetrics provides a handler which executes
+// the following handlers and records the request
+// by route, method and response status code in the
+// HTTP request metrics. Routes are recorded as their
+// OpenAPI path templates to limit the number of
+// recorded series.
+func (ws *WebServer) recordMetrics(ctx *routing.Context) error {
+	start := time.Now()
+	err := ctx.Next()
+
+	method := string(ctx.Method())
+	route := metricsRouteUnmatched
+	path := strings.TrimPrefix(string(ctx.Path()), ws.config.PathPrefix)
+	if r := ws.matchAPIRoute(method, path); r != nil {
+		route = r.path
+	} else if !isRoutingMethod(method) {
+		method = metricsMethodOther
+	}
+
+	code := strconv.Itoa(responseStatus(ctx, err))
+	metrics.HTTPRequests.WithLabelValues(route, method, code).Inc()
+	metrics.HTTPRequestDuration.WithLabelValues(route, method, code).Observe(metrics.Since(start))
+
+	return err
+}
+
+// isRoutingMethod returns true if the passed
+// method is supported by the router.
+func isRoutingMethod(method string) bool {
+	for _, m := range routing.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,511 @@
+package webserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/myrunes/backend/internal/health"
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/internal/search"
+	"github.com/myrunes/backend/internal/static"
+	"github.com/myrunes/backend/pkg/ddragon"
+	"github.com/myrunes/backend/pkg/openapi"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+// Content types of responses which
+// are not JSON encoded.
+const (
+	contentTypePNG         = "image/png"
+	contentTypeHTML        = "text/html"
+	contentTypeEventStream = "text/event-stream"
+)
+
+// apiOperation describes an operation of the REST
+// API in the OpenAPI specification. Path is the route
+// path as registered relative to the path prefix and
+// the prefix of the API version. Operations with
+// Version 2 are only registered in v2, all other
+// operations are registered in v1.
+// If List is true, the response is a listResponse
+// containing elements of the type of Response. If
+// Response is nil and Content is empty, the response
+// is a status object.
+type apiOperation struct {
+	Method   string
+	Path     string
+	Version  int
+	Tag      string
+	Summary  string
+	Auth     bool
+	Query    []*openapi.Parameter
+	Request  interface{}
+	Response interface{}
+	List     bool
+	Status   int
+	Content  string
+}
+
+// statusResponse describes the response
+// model of requests without response data
+// and of errors.
+type statusResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// runesResponse describes the response
+// model of the runes resource.
+type runesResponse struct {
+	Trees []*ddragon.RuneTree `json:"trees"`
+	Perks [][]string          `json:"perks"`
+}
+
+// Query parameters shared by
+// multiple operations.
+var (
+	queryTeam     = queryParam("team", "string")
+	queryTeams    = queryParam("teams", "boolean")
+	queryChampion = queryParam("champion", "string")
+
+	queryPageFilters = []*openapi.Parameter{
+		queryParam("sortBy", "string"), queryChampion,
+		queryParam("filter", "string"), queryParam("tag", "string"), queryParam("folder", "string"),
+		queryParam("role", "string"), queryParam("against", "string"), queryParam("mode", "string"),
+		queryTeam, queryTeams,
+	}
+)
+
+// apiOperations lists all operations of the
+// REST API in the order of their registration.
+var apiOperations = []*apiOperation{
+	{Method: "POST", Path: "/login", Tag: "Authentication", Summary: "Log in",
+		Request: loginRequest{}},
+	{Method: "GET", Path: "/accesstoken", Tag: "Authentication", Summary: "Get an access token",
+		Response: objects.AccessToken{}},
+	{Method: "POST", Path: "/logout", Tag: "Authentication", Summary: "Log out", Auth: true},
+
+	{Method: "GET", Path: "/version", Tag: "Information", Summary: "Get version information",
+		Response: map[string]string{}},
+	{Method: "GET", Path: "/healthz", Tag: "Information", Summary: "Check if the service is alive"},
+	{Method: "GET", Path: "/readyz", Tag: "Information", Summary: "Check if the service and its dependencies are ready",
+		Response: health.Report{}},
+	{Method: "GET", Path: "/recaptchainfo", Tag: "Information", Summary: "Get ReCAPTCHA information",
+		Response: map[string]string{}},
+	{Method: "GET", Path: "/openapi.json", Tag: "Information", Summary: "Get the OpenAPI specification",
+		Response: openapi.Document{}},
+
+	{Method: "GET", Path: "/refreshtokens", Tag: "Sessions", Summary: "List refresh tokens", Auth: true,
+		Response: objects.RefreshToken{}, List: true},
+	{Method: "DELETE", Path: "/refreshtokens/<id>", Tag: "Sessions", Summary: "Revoke a refresh token", Auth: true},
+
+	{Method: "GET", Path: "/assets/champions/avatars/<id>", Tag: "Resources", Summary: "Get a champion avatar",
+		Content: contentTypePNG},
+
+	{Method: "GET", Path: "/resources/champions", Tag: "Resources", Summary: "List champions",
+		Response: ddragon.Champion{}, List: true},
+	{Method: "GET", Path: "/resources/champions/<uid>/stats", Tag: "Resources", Summary: "Get champion stats",
+		Response: objects.ChampionStats{}},
+	{Method: "GET", Path: "/resources/runes", Tag: "Resources", Summary: "List rune trees and perks",
+		Response: runesResponse{}},
+
+	{Method: "POST", Path: "/users", Tag: "Users", Summary: "Create a user",
+		Request: loginRequest{}, Response: objects.User{}, Status: fasthttp.StatusCreated},
+	{Method: "POST", Path: "/users/me", Tag: "Users", Summary: "Update the self user", Auth: true,
+		Request: userRequest{}},
+	{Method: "GET", Path: "/users/me", Tag: "Users", Summary: "Get the self user", Auth: true,
+		Response: objects.User{}},
+	{Method: "DELETE", Path: "/users/me", Tag: "Users", Summary: "Delete the self user", Auth: true,
+		Request: userRequest{}},
+	{Method: "GET", Path: "/users/<uname>", Tag: "Users", Summary: "Check if a user name is taken"},
+	{Method: "GET", Path: "/users/<uname>/profile", Tag: "Users", Summary: "Get a user profile",
+		Query: []*openapi.Parameter{queryChampion}, Response: profileResponse{}},
+	{Method: "POST", Path: "/users/me/privacy", Tag: "Users", Summary: "Update privacy settings", Auth: true,
+		Request: objects.UserPrivacy{}, Response: objects.UserPrivacy{}},
+	{Method: "POST", Path: "/users/me/pageorder", Tag: "Users", Summary: "Update the page order", Auth: true,
+		Query: []*openapi.Parameter{queryChampion}, Request: pageOrderRequest{}},
+	{Method: "POST", Path: "/users/me/mail", Tag: "Users", Summary: "Set the mail address", Auth: true,
+		Request: setMailRequest{}},
+	{Method: "POST", Path: "/users/me/mail/confirm", Tag: "Users", Summary: "Confirm the mail address",
+		Request: confirmMail{}},
+	{Method: "POST", Path: "/users/me/passwordreset", Tag: "Users", Summary: "Request a password reset",
+		Request: passwordReset{}},
+	{Method: "POST", Path: "/users/me/passwordreset/confirm", Tag: "Users", Summary: "Confirm a password reset",
+		Request: confirmPasswordReset{}},
+
+	{Method: "POST", Path: "/pages", Tag: "Pages", Summary: "Create a page", Auth: true,
+		Query: []*openapi.Parameter{queryTeam}, Request: objects.Page{}, Response: objects.Page{},
+		Status: fasthttp.StatusCreated},
+	{Method: "GET", Path: "/pages", Tag: "Pages", Summary: "List pages", Auth: true,
+		Query:    append([]*openapi.Parameter{queryParam("short", "boolean")}, queryPageFilters...),
+		Response: objects.Page{}, List: true},
+	{Method: "GET", Path: "/pages/suggest", Tag: "Pages", Summary: "Get page suggestions", Auth: true,
+		Query: []*openapi.Parameter{queryChampion}, Response: objects.PageSuggestion{}, List: true},
+	{Method: "GET", Path: "/pages/search", Tag: "Pages", Summary: "Search pages", Auth: true,
+		Query:    []*openapi.Parameter{queryParam("q", "string"), queryTeam, queryTeams},
+		Response: search.Result{}, List: true},
+	{Method: "POST", Path: "/pages/bulk", Tag: "Pages", Summary: "Execute bulk page operations", Auth: true,
+		Request: bulkRequest{}, Response: bulkResult{}, List: true},
+	{Method: "GET", Path: "/pages/best", Tag: "Pages", Summary: "Get the best matching page", Auth: true,
+		Query: []*openapi.Parameter{
+			queryChampion, queryParam("against", "string"), queryParam("role", "string"),
+			queryParam("mode", "string"), queryTeam, queryTeams,
+		},
+		Response: search.Result{}},
+	{Method: "GET", Path: "/pages/tags", Tag: "Pages", Summary: "List page tags", Auth: true,
+		Query: []*openapi.Parameter{queryTeam, queryTeams}, Response: tagResponse{}, List: true},
+	{Method: "POST", Path: "/pages/tags/rename", Tag: "Pages", Summary: "Rename a page tag", Auth: true,
+		Query: []*openapi.Parameter{queryTeam}, Request: tagRenameRequest{}, Response: objects.Page{}, List: true},
+	{Method: "GET", Path: `/pages/<uid:\d+>`, Tag: "Pages", Summary: "Get a page", Auth: true,
+		Response: objects.Page{}},
+	{Method: "POST", Path: `/pages/<uid:\d+>`, Tag: "Pages", Summary: "Update a page", Auth: true,
+		Request: objects.Page{}, Response: objects.Page{}},
+	{Method: "DELETE", Path: `/pages/<uid:\d+>`, Tag: "Pages", Summary: "Delete a page", Auth: true},
+
+	{Method: "POST", Path: "/folders", Tag: "Folders", Summary: "Create a folder", Auth: true,
+		Query: []*openapi.Parameter{queryTeam}, Request: folderRequest{}, Response: objects.Folder{},
+		Status: fasthttp.StatusCreated},
+	{Method: "GET", Path: "/folders", Tag: "Folders", Summary: "List folders", Auth: true,
+		Query: []*openapi.Parameter{queryTeam}, Response: objects.Folder{}, List: true},
+	{Method: "POST", Path: `/folders/<uid:\d+>`, Tag: "Folders", Summary: "Update a folder", Auth: true,
+		Request: folderRequest{}, Response: objects.Folder{}},
+	{Method: "DELETE", Path: `/folders/<uid:\d+>`, Tag: "Folders", Summary: "Delete a folder", Auth: true},
+
+	{Method: "GET", Path: "/sync", Tag: "Sync", Summary: "Sync pages", Auth: true,
+		Query:    []*openapi.Parameter{queryParam("since", "string"), queryTeam, queryTeams},
+		Response: syncResponse{}},
+	{Method: "GET", Path: "/events", Tag: "Events", Summary: "Subscribe to events", Auth: true,
+		Content: contentTypeEventStream},
+
+	{Method: "POST", Path: "/webhooks", Tag: "Webhooks", Summary: "Create a webhook", Auth: true,
+		Query: []*openapi.Parameter{queryTeam}, Request: webhookRequest{}, Response: objects.Webhook{},
+		Status: fasthttp.StatusCreated},
+	{Method: "GET", Path: "/webhooks", Tag: "Webhooks", Summary: "List webhooks", Auth: true,
+		Query: []*openapi.Parameter{queryTeam}, Response: objects.Webhook{}, List: true},
+	{Method: "GET", Path: `/webhooks/<uid:\d+>/deliveries`, Tag: "Webhooks", Summary: "List webhook deliveries", Auth: true,
+		Response: objects.WebhookDelivery{}, List: true},
+	{Method: "POST", Path: `/webhooks/<uid:\d+>`, Tag: "Webhooks", Summary: "Update a webhook", Auth: true,
+		Request: webhookRequest{}, Response: objects.Webhook{}},
+	{Method: "DELETE", Path: `/webhooks/<uid:\d+>`, Tag: "Webhooks", Summary: "Delete a webhook", Auth: true},
+
+	{Method: "GET", Path: "/favorites", Tag: "Favorites", Summary: "List favorite champions", Auth: true,
+		Response: "", List: true},
+	{Method: "POST", Path: "/favorites", Tag: "Favorites", Summary: "Set favorite champions", Auth: true,
+		Request: alterFavoriteRequest{}, Response: "", List: true},
+
+	{Method: "POST", Path: "/shares", Tag: "Shares", Summary: "Create a share", Auth: true,
+		Request: createShareRequest{}, Response: objects.SharePage{}, Status: fasthttp.StatusCreated},
+	{Method: "GET", Path: `/shares/<uid:\d+>/stats`, Tag: "Shares", Summary: "Get share stats", Auth: true,
+		Query: []*openapi.Parameter{queryParam("days", "integer")}, Response: objects.ShareStats{}},
+	{Method: "GET", Path: `/shares/<ident:\d+>`, Tag: "Shares", Summary: "Get a share by its UID", Auth: true,
+		Response: shareResponse{}, Status: fasthttp.StatusAccepted},
+	{Method: "GET", Path: "/shares/<ident:[^/]+>/image.png", Tag: "Shares", Summary: "Get the image of a share",
+		Content: contentTypePNG},
+	{Method: "POST", Path: "/shares/<ident:[^/]+>/fork", Tag: "Shares", Summary: "Fork a shared page", Auth: true,
+		Request: shareForkRequest{}, Response: objects.Page{}, Status: fasthttp.StatusCreated},
+	{Method: "GET", Path: "/shares/<ident:.+>", Tag: "Shares", Summary: "Get a share",
+		Response: shareResponse{}, Status: fasthttp.StatusAccepted},
+	{Method: "POST", Path: `/shares/<uid:\d+>`, Tag: "Shares", Summary: "Update a share", Auth: true,
+		Request: createShareRequest{}, Response: objects.SharePage{}, Status: fasthttp.StatusCreated},
+	{Method: "DELETE", Path: `/shares/<uid:\d+>`, Tag: "Shares", Summary: "Delete a share", Auth: true},
+
+	{Method: "POST", Path: "/teams", Tag: "Teams", Summary: "Create a team", Auth: true,
+		Request: teamRequest{}, Response: teamResponse{}, Status: fasthttp.StatusCreated},
+	{Method: "GET", Path: "/teams", Tag: "Teams", Summary: "List teams", Auth: true,
+		Response: teamResponse{}, List: true},
+	{Method: "GET", Path: `/teams/<uid:\d+>`, Tag: "Teams", Summary: "Get a team", Auth: true,
+		Response: teamResponse{}},
+	{Method: "POST", Path: `/teams/<uid:\d+>`, Tag: "Teams", Summary: "Update a team", Auth: true,
+		Request: teamRequest{}, Response: teamResponse{}},
+	{Method: "DELETE", Path: `/teams/<uid:\d+>`, Tag: "Teams", Summary: "Delete a team", Auth: true},
+	{Method: "POST", Path: `/teams/<uid:\d+>/accept`, Tag: "Teams", Summary: "Accept a team invitation", Auth: true,
+		Response: teamResponse{}},
+	{Method: "POST", Path: `/teams/<uid:\d+>/members`, Tag: "Teams", Summary: "Invite a team member", Auth: true,
+		Request: teamMemberRequest{}, Response: teamMemberResponse{}, Status: fasthttp.StatusCreated},
+	{Method: "POST", Path: `/teams/<uid:\d+>/members/<userid:\d+>`, Tag: "Teams", Summary: "Update the role of a team member", Auth: true,
+		Request: teamMemberRequest{}, Response: objects.TeamMember{}},
+	{Method: "DELETE", Path: `/teams/<uid:\d+>/members/<userid:\d+>`, Tag: "Teams", Summary: "Remove a team member", Auth: true},
+
+	{Method: "GET", Path: "/s/<ident>", Tag: "Previews", Summary: "Get the link preview of a share",
+		Content: contentTypeHTML},
+	{Method: "GET", Path: "/oembed", Tag: "Previews", Summary: "Get the oEmbed representation of a share",
+		Query:    []*openapi.Parameter{queryParam("url", "string"), queryParam("format", "string")},
+		Response: oEmbedResponse{}},
+
+	{Method: "GET", Path: "/apitoken", Tag: "API Token", Summary: "Get the API token", Auth: true,
+		Response: objects.APIToken{}},
+	{Method: "POST", Path: "/apitoken", Tag: "API Token", Summary: "Generate a new API token", Auth: true,
+		Response: objects.APIToken{}},
+	{Method: "DELETE", Path: "/apitoken", Tag: "API Token", Summary: "Revoke the API token", Auth: true},
+
+	{Method: "GET", Path: "/pages", Version: 2, Tag: "Pages", Summary: "List pages", Auth: true,
+		Query: append([]*openapi.Parameter{
+			queryParam("limit", "integer"), queryParam("offset", "integer"),
+		}, queryPageFilters...),
+		Response: pageListResponse{}},
+	{Method: "GET", Path: `/pages/<uid:\d+>`, Version: 2, Tag: "Pages", Summary: "Get a page", Auth: true,
+		Response: pageV2{}},
+}
+
+// routeParamRx matches the parameter tokens
+// of route paths like <name> or <name:pattern>.
+var routeParamRx = regexp.MustCompile(`<([^:>]*)(?::([^>]*))?>`)
+
+// queryParam returns an optional query parameter
+// with the passed name and schema type.
+func queryParam(name, typ string) *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:   name,
+		In:     "query",
+		Schema: &openapi.Schema{Type: typ},
+	}
+}
+
+// routePaths returns the paths relative to the path
+// prefix the operation is registered for. The first
+// path is the versioned path of the operation.
+func (op *apiOperation) routePaths() []string {
+	if op.Version == 2 {
+		return []string{apiV2Prefix + op.Path}
+	}
+	return []string{apiV1Prefix + op.Path, op.Path}
+}
+
+// hasSuccessor returns true if the passed operation
+// is a v1 operation and the passed operations contain
+// a v2 operation with the same method and path.
+func hasSuccessor(ops []*apiOperation, op *apiOperation) bool {
+	if op.Version == 2 {
+		return false
+	}
+	for _, o := range ops {
+		if o.Version == 2 && o.Method == op.Method && o.Path == op.Path {
+			return true
+		}
+	}
+	return false
+}
+
+// openAPIPath converts the passed route path
+// to an OpenAPI path template by replacing
+// the parameter tokens with {name}.
+func openAPIPath(path string) string {
+	return routeParamRx.ReplaceAllString(path, "{$1}")
+}
+
+// routeRegexp compiles the passed route path
+// to a regular expression matching request
+// paths the route is registered for.
+func routeRegexp(path string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteByte('^')
+
+	last := 0
+	for _, m := range routeParamRx.FindAllStringSubmatchIndex(path, -1) {
+		sb.WriteString(regexp.QuoteMeta(path[last:m[0]]))
+		pattern := "[^/]*"
+		if m[4] >= 0 {
+			pattern = path[m[4]:m[5]]
+		}
+		sb.WriteString("(?:" + pattern + ")")
+		last = m[1]
+	}
+	sb.WriteString(regexp.QuoteMeta(path[last:]))
+	sb.WriteByte('$')
+
+	return regexp.MustCompile(sb.String())
+}
+
+// buildOpenAPISpec creates the OpenAPI document of
+// the passed operations served below the passed
+// path prefix. Operations are described by their
+// versioned paths and v1 operations with a v2
+// counterpart are marked as deprecated.
+func buildOpenAPISpec(ops []*apiOperation, prefix string) *openapi.Document {
+	components := openapi.NewComponents()
+	components.SecuritySchemes["accessToken"] = &openapi.SecurityScheme{
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "Access token obtained from /accesstoken",
+	}
+	components.SecuritySchemes["apiToken"] = &openapi.SecurityScheme{
+		Type:        "http",
+		Scheme:      "basic",
+		Description: "API token passed as 'Basic <token>'",
+	}
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: &openapi.Info{
+			Title:       "MYRUNES REST API",
+			Description: "Routes of v1 are also served without the /v1 path prefix.",
+			Version:     static.APIVersion,
+		},
+		Servers: []*openapi.Server{
+			{URL: prefix},
+		},
+		Paths:      make(map[string]openapi.PathItem),
+		Components: components,
+	}
+
+	statusSchema := components.SchemaOf(statusResponse{})
+
+	for _, op := range ops {
+		routePath := op.routePaths()[0]
+		path := openAPIPath(routePath)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(openapi.PathItem)
+			doc.Paths[path] = item
+		}
+
+		o := &openapi.Operation{
+			Summary:    op.Summary,
+			Tags:       []string{op.Tag},
+			Responses:  make(map[string]*openapi.Response),
+			Deprecated: hasSuccessor(ops, op),
+		}
+
+		for _, m := range routeParamRx.FindAllStringSubmatch(routePath, -1) {
+			o.Parameters = append(o.Parameters, &openapi.Parameter{
+				Name:     m[1],
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			})
+		}
+		o.Parameters = append(o.Parameters, op.Query...)
+
+		if op.Request != nil {
+			o.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  openapi.JSON(components.SchemaOf(op.Request)),
+			}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = fasthttp.StatusOK
+		}
+
+		res := &openapi.Response{Description: fasthttp.StatusMessage(status)}
+		switch {
+		case op.Content != "":
+			res.Content = map[string]*openapi.MediaType{
+				op.Content: {Schema: &openapi.Schema{Type: "string"}},
+			}
+		case op.Response == nil:
+			res.Content = openapi.JSON(statusSchema)
+		case op.List:
+			res.Content = openapi.JSON(&openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"n": {Type: "integer"},
+					"data": {
+						Type:  "array",
+						Items: components.SchemaOf(op.Response),
+					},
+				},
+			})
+		default:
+			res.Content = openapi.JSON(components.SchemaOf(op.Response))
+		}
+		o.Responses[strconv.Itoa(status)] = res
+		o.Responses["default"] = &openapi.Response{
+			Description: "Error",
+			Content:     openapi.JSON(statusSchema),
+		}
+
+		if op.Auth {
+			o.Security = []map[string][]string{
+				{"accessToken": {}},
+				{"apiToken": {}},
+			}
+		}
+
+		item[strings.ToLower(op.Method)] = o
+	}
+
+	return doc
+}
+
+// apiRoute wraps an API operation with one of its
+// paths as OpenAPI path template, the compiled regular
+// expression of the path, the schema of its request
+// body and whether the operation has a v2 counterpart.
+type apiRoute struct {
+	op         *apiOperation
+	path       string
+	rx         *regexp.Regexp
+	request    *openapi.Schema
+	deprecated bool
+}
+
+// newAPIRoutes creates the apiRoutes of all paths of
+// the passed operations using the schemas of the
+// passed document.
+func newAPIRoutes(ops []*apiOperation, doc *openapi.Document) []*apiRoute {
+	routes := make([]*apiRoute, 0, len(ops))
+	for _, op := range ops {
+		var request *openapi.Schema
+		if op.Request != nil {
+			request = doc.Components.SchemaOf(op.Request)
+		}
+		deprecated := hasSuccessor(ops, op)
+
+		for _, path := range op.routePaths() {
+			routes = append(routes, &apiRoute{
+				op:         op,
+				path:       openAPIPath(path),
+				rx:         routeRegexp(path),
+				request:    request,
+				deprecated: deprecated,
+			})
+		}
+	}
+	return routes
+}
+
+// matchAPIRoute returns the first apiRoute matching
+// the passed method and path relative to the path
+// prefix. If no route matches, nil is returned.
+func (ws *WebServer) matchAPIRoute(method, path string) *apiRoute {
+	for _, r := range ws.apiRoutes {
+		if r.op.Method == method && r.rx.MatchString(path) {
+			return r
+		}
+	}
+	return nil
+}
+
+// validateRequest provides a handler which rejects
+// requests with a body not matching the request
+// schema of the requested operation with a 400
+// Bad Request response.
+func (ws *WebServer) validateRequest(ctx *routing.Context) error {
+	body := ctx.PostBody()
+	if len(body) == 0 {
+		return nil
+	}
+
+	path := strings.TrimPrefix(string(ctx.Path()), ws.config.PathPrefix)
+	r := ws.matchAPIRoute(string(ctx.Method()), path)
+	if r == nil || r.request == nil {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return jsonError(ctx, err, fasthttp.StatusBadRequest)
+	}
+
+	if err := ws.openAPISpec.Components.Validate(r.request, v); err != nil {
+		return jsonError(ctx, fmt.Errorf("invalid request body: %s", err.Error()), fasthttp.StatusBadRequest)
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package webserver
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+// registeredRoutes returns the method and path of
+// all routes registered in the passed router.
+// As the router does not expose its routes, they
+// are collected from the route trees of the router
+// via reflection.
+func registeredRoutes(router *routing.Router) []string {
+	routes := make([]string, 0)
+
+	stores := reflect.ValueOf(router).Elem().FieldByName("stores")
+	for _, method := range stores.MapKeys() {
+		root := stores.MapIndex(method).Elem().Elem().FieldByName("root")
+		for _, path := range collectRoutePaths(root, "") {
+			routes = append(routes, method.String()+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+
+	return routes
+}
+
+// collectRoutePaths returns the paths of all
+// nodes containing handlers in the route tree
+// below the passed node, where prefix is the
+// path of the parent node.
+func collectRoutePaths(node reflect.Value, prefix string) []string {
+	if node.IsNil() {
+		return nil
+	}
+	n := node.Elem()
+
+	path := prefix + n.FieldByName("key").String()
+	paths := make([]string, 0)
+	if !n.FieldByName("data").IsNil() {
+		paths = append(paths, path)
+	}
+
+	for _, name := range []string{"children", "pchildren"} {
+		children := n.FieldByName(name)
+		for i := 0; i < children.Len(); i++ {
+			paths = append(paths, collectRoutePaths(children.Index(i), path)...)
+		}
+	}
+
+	return paths
+}
+
+func TestAPIOperationsDescribeAllRoutes(t *testing.T) {
+	ws := newTestWebServer(t, newTestDatabase(), nil)
+
+	described := make(map[string]bool)
+	for _, op := range apiOperations {
+		for _, path := range op.routePaths() {
+			described[op.Method+" "+testPathPrefix+path] = true
+		}
+	}
+
+	registered := registeredRoutes(ws.router)
+	if len(registered) == 0 {
+		t.Fatal("no registered routes found")
+	}
+
+	for _, r := range registered {
+		if !described[r] {
+			t.Errorf("route %s is missing in the OpenAPI specification", r)
+		}
+		delete(described, r)
+	}
+
+	for r := range described {
+		t.Errorf("operation %s is not registered", r)
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	ws := newTestWebServer(t, newTestDatabase(), &Config{ValidateRequests: true})
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		valid  bool
+	}{
+		{"valid body", "POST", "/login", `{"username": "alice", "password": "secret", "remember": true}`, true},
+		{"null value", "POST", "/login", `{"username": null}`, true},
+		{"unknown property", "POST", "/login", `{"username": "alice", "captcha": 1}`, true},
+		{"empty body", "POST", "/login", ``, true},
+		{"invalid json", "POST", "/login", `{"username": `, false},
+		{"wrong type", "POST", "/login", `{"username": 1}`, false},
+		{"wrong boolean type", "POST", "/login", `{"remember": "yes"}`, false},
+		{"no object", "POST", "/login", `["alice"]`, false},
+		{"versioned path", "POST", "/v1/login", `{"username": 1}`, false},
+		{"nested array", "POST", "/pages/bulk", `{"operations": [{"op": "delete", "page": "1"}]}`, true},
+		{"nested wrong type", "POST", "/pages/bulk", `{"operations": [{"op": 1}]}`, false},
+		{"string array", "POST", "/webhooks", `{"events": ["page.created", 1]}`, false},
+		{"operation without request model", "POST", "/logout", `{"username": 1}`, true},
+		{"unknown route", "POST", "/unknown", `{"username": 1}`, true},
+		{"other method", "GET", "/login", `{"username": 1}`, true},
+	}
+
+	for _, c := range cases {
+		ctx := newTestContext(c.method, testPathPrefix+c.path)
+		ctx.Request.SetBodyString(c.body)
+
+		ws.validateRequest(ctx)
+
+		if valid := ctx.Response.StatusCode() != fasthttp.StatusBadRequest; valid != c.valid {
+			t.Errorf("%s: expected valid to be %t, got status %d: %s",
+				c.name, c.valid, ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+	}
+}
+
+func TestRouteRegexp(t *testing.T) {
+	cases := []struct {
+		route string
+		path  string
+		match bool
+	}{
+		{"/pages", "/pages", true},
+		{"/pages", "/pages/1", false},
+		{`/pages/<uid:\d+>`, "/pages/123", true},
+		{`/pages/<uid:\d+>`, "/pages/abc", false},
+		{"/users/<uname>/profile", "/users/alice/profile", true},
+		{"/users/<uname>/profile", "/users/alice/bob/profile", false},
+		{"/shares/<ident:.+>", "/shares/a/b", true},
+	}
+
+	for _, c := range cases {
+		if match := routeRegexp(c.route).MatchString(c.path); match != c.match {
+			t.Errorf("%s on %s: expected match to be %t", c.route, c.path, c.match)
+		}
+	}
+}
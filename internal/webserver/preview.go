@@ -0,0 +1,293 @@
+package webserver
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+
+	"github.com/myrunes/backend/internal/assets"
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/pkg/ddragon"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// name of the service displayed
+	// in link previews
+	previewSiteName = "MYRUNES"
+	// title of link previews of shares
+	// which content must not be revealed
+	previewProtectedTitle = "Protected rune page"
+	// oEmbed specification version
+	oEmbedVersion = "1.0"
+)
+
+// sharePreviewTemplate renders a HTML document containing
+// the OpenGraph and Twitter card meta tags of a share
+// preview which redirects browsers to the share page
+// of the front end.
+var sharePreviewTemplate = template.Must(template.New("sharepreview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{ .Title }} | {{ .SiteName }}</title>
+  <meta name="description" content="{{ .Description }}">
+  <meta property="og:type" content="website">
+  <meta property="og:site_name" content="{{ .SiteName }}">
+  <meta property="og:title" content="{{ .Title }}">
+  <meta property="og:description" content="{{ .Description }}">
+  <meta property="og:url" content="{{ .URL }}">
+  {{ if .ImageURL }}<meta property="og:image" content="{{ .ImageURL }}">
+  <meta property="og:image:type" content="image/png">
+  <meta property="og:image:width" content="{{ .ImageWidth }}">
+  <meta property="og:image:height" content="{{ .ImageHeight }}">
+  <meta name="twitter:image" content="{{ .ImageURL }}">
+  <meta name="twitter:card" content="summary_large_image">{{ else }}
+  <meta name="twitter:card" content="summary">{{ end }}
+  <meta name="twitter:title" content="{{ .Title }}">
+  <meta name="twitter:description" content="{{ .Description }}">
+  <link rel="alternate" type="application/json+oembed" href="{{ .OEmbedURL }}" title="{{ .Title }}">
+  <meta http-equiv="refresh" content="0; url={{ .URL }}">
+</head>
+<body>
+  <a href="{{ .URL }}">{{ .Title }}</a>
+</body>
+</html>`))
+
+// sharePreview wraps the values displayed
+// in link previews of a shared page.
+type sharePreview struct {
+	SiteName    string
+	Title       string
+	Description string
+	Author      string
+	URL         string
+	ImageURL    string
+	ImageWidth  int
+	ImageHeight int
+	OEmbedURL   string
+}
+
+// oEmbedResponse describes the response model
+// of the oEmbed endpoint as specified in
+// https://oembed.com/#section2.3
+type oEmbedResponse struct {
+	Version         string `json:"version"`
+	Type            string `json:"type"`
+	Title           string `json:"title"`
+	AuthorName      string `json:"author_name,omitempty"`
+	ProviderName    string `json:"provider_name"`
+	ProviderURL     string `json:"provider_url"`
+	ThumbnailURL    string `json:"thumbnail_url,omitempty"`
+	ThumbnailWidth  int    `json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int    `json:"thumbnail_height,omitempty"`
+}
+
+// newSharePreview creates a sharePreview from the
+// passed share, the shared pages and the name of
+// the owner of the pages. If the share is password protected or
+// restricted to an audience, the page contents are
+// not included in the preview.
+func (ws *WebServer) newSharePreview(share *objects.SharePage, pages []*objects.Page, author string) *sharePreview {
+	p := &sharePreview{
+		SiteName:    previewSiteName,
+		Title:       previewProtectedTitle,
+		ImageWidth:  assets.PageImageWidth,
+		ImageHeight: assets.PageImageHeight,
+		URL:         ws.shareFrontendURL(share.Ident),
+		OEmbedURL:   fmt.Sprintf("%s/oembed?url=%s", ws.apiBaseURL(), url.QueryEscape(ws.shareFrontendURL(share.Ident))),
+		Author:      author,
+	}
+
+	if share.Protected || share.IsRestricted() {
+		p.Description = fmt.Sprintf("A rune page shared by %s.", p.Author)
+		return p
+	}
+
+	if share.IsCollection() {
+		p.Title = fmt.Sprintf("%d rune pages", len(pages))
+		p.Description = collectionSummary(pages, p.Author)
+	} else {
+		p.Title = pages[0].Title
+		p.Description = pageSummary(pages[0], p.Author)
+	}
+
+	if len(pages) > 0 {
+		p.ImageURL = fmt.Sprintf("%s/shares/%s/image.png", ws.apiBaseURL(), share.Ident)
+	}
+
+	return p
+}
+
+// getSharePreview returns the sharePreview of the
+// share with the passed ident. If the share does not
+// exist or is not accessable anymore, errNotFound is
+// returned. On failure, the returned status code
+// describes the HTTP status of the error.
+// Getting the preview of a share does not count as
+// access of the share.
+func (ws *WebServer) getSharePreview(ctx context.Context, ident string) (*sharePreview, int, error) {
+	share, err := ws.db.GetShare(ctx, ident, -1, -1)
+	if err != nil {
+		return nil, fasthttp.StatusInternalServerError, err
+	}
+	if share == nil || !share.IsAccessible() {
+		return nil, fasthttp.StatusNotFound, errNotFound
+	}
+
+	pages, err := ws.getSharePages(ctx, share)
+	if err != nil {
+		return nil, fasthttp.StatusInternalServerError, err
+	}
+	if !share.IsCollection() && len(pages) == 0 {
+		return nil, fasthttp.StatusNotFound, errNotFound
+	}
+
+	owner, team, err := ws.getOwner(ctx, share.OwnerID)
+	if err != nil {
+		return nil, fasthttp.StatusInternalServerError, err
+	}
+
+	var author string
+	if owner != nil {
+		author = owner.DisplayName
+	} else if team != nil {
+		author = team.Name
+	}
+
+	return ws.newSharePreview(share, pages, author), fasthttp.StatusOK, nil
+}
+
+// oEmbed returns the oEmbed representation
+// of the share preview.
+func (p *sharePreview) oEmbed(providerURL string) *oEmbedResponse {
+	res := &oEmbedResponse{
+		Version:      oEmbedVersion,
+		Type:         "link",
+		Title:        p.Title,
+		AuthorName:   p.Author,
+		ProviderName: p.SiteName,
+		ProviderURL:  providerURL,
+		ThumbnailURL: p.ImageURL,
+	}
+
+	if p.ImageURL != "" {
+		res.ThumbnailWidth = p.ImageWidth
+		res.ThumbnailHeight = p.ImageHeight
+	}
+
+	return res
+}
+
+// shareFrontendURL returns the public URL of
+// the front end page displaying the share with
+// the passed ident.
+func (ws *WebServer) shareFrontendURL(ident string) string {
+	return fmt.Sprintf("%s/share/%s", ws.config.PublicAddr, ident)
+}
+
+// apiBaseURL returns the public base URL
+// of the REST API.
+func (ws *WebServer) apiBaseURL() string {
+	return ws.config.PublicAddr + ws.config.PathPrefix
+}
+
+// shareIdentFromURL extracts the share ident
+// from the passed share URL, which is the last
+// element of the URLs path.
+func shareIdentFromURL(shareURL string) string {
+	u, err := url.Parse(shareURL)
+	if err != nil {
+		return ""
+	}
+
+	path := strings.TrimRight(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return ""
+	}
+
+	return path[i+1:]
+}
+
+// pageSummary creates a short textual summary of
+// the passed page containing the champions, the
+// keystone and the rune trees of the page.
+func pageSummary(page *objects.Page, author string) string {
+	dd := ddragon.DDragonInstance
+
+	champs := make([]string, 0, len(page.Champions))
+	for _, uid := range page.Champions {
+		if c := dd.GetChampion(uid); c != nil {
+			champs = append(champs, c.Name)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Rune page")
+	if len(champs) > 0 {
+		sb.WriteString(" for ")
+		sb.WriteString(strings.Join(champs, ", "))
+	}
+	if author != "" {
+		sb.WriteString(" by ")
+		sb.WriteString(author)
+	}
+
+	if page.Primary == nil || page.Secondary == nil {
+		return sb.String()
+	}
+
+	var keystone, primary, secondary string
+	if r := dd.GetRune(page.Primary.Rows[0]); r != nil {
+		keystone = r.Name
+	}
+	if t := dd.GetRuneTree(page.Primary.Tree); t != nil {
+		primary = t.Name
+	}
+	if t := dd.GetRuneTree(page.Secondary.Tree); t != nil {
+		secondary = t.Name
+	}
+
+	if keystone != "" && primary != "" && secondary != "" {
+		sb.WriteString(fmt.Sprintf(" - %s (%s / %s)", keystone, primary, secondary))
+	}
+
+	return sb.String()
+}
+
+// collectionSummary creates a short textual summary
+// of the passed pages of a share collection
+// containing the distinct champions of the pages.
+func collectionSummary(pages []*objects.Page, author string) string {
+	dd := ddragon.DDragonInstance
+
+	champs := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, page := range pages {
+		for _, uid := range page.Champions {
+			if _, ok := seen[uid]; ok {
+				continue
+			}
+			seen[uid] = struct{}{}
+			if c := dd.GetChampion(uid); c != nil {
+				champs = append(champs, c.Name)
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Rune page collection")
+	if len(champs) > 0 {
+		sb.WriteString(" for ")
+		sb.WriteString(strings.Join(champs, ", "))
+	}
+	if author != "" {
+		sb.WriteString(" by ")
+		sb.WriteString(author)
+	}
+
+	return sb.String()
+}
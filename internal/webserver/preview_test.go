@@ -0,0 +1,156 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/valyala/fasthttp"
+
+	"github.com/myrunes/backend/internal/objects"
+)
+
+const testPublicAddr = "https://myrunes.example"
+
+func TestSharePreview(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, &Config{PublicAddr: testPublicAddr})
+	addTestUser(ws, db, 1, "owner")
+
+	open := newTestShare(t, db, 1)
+
+	protected := newTestShare(t, db, 1)
+	if err := protected.SetPassword(context.Background(), "secret", ws.auth); err != nil {
+		t.Fatal(err)
+	}
+	db.SetShare(context.Background(), protected)
+
+	restricted := newTestShare(t, db, 1)
+	restricted.Audience = []snowflake.ID{2}
+	db.SetShare(context.Background(), restricted)
+
+	expired := newTestShare(t, db, 1)
+	expired.Expires = time.Now().Add(-time.Minute)
+	db.SetShare(context.Background(), expired)
+
+	ctx := ws.request("GET", "/s/"+open.Ident, "", nil)
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	body := string(ctx.Response.Body())
+	for _, exp := range []string{
+		`<meta property="og:title" content="test page">`,
+		`<meta property="og:url" content="` + testPublicAddr + `/share/` + open.Ident + `">`,
+		`<meta property="og:image" content="` + testPublicAddr + testPathPrefix + `/shares/` + open.Ident + `/image.png">`,
+		`by owner`,
+	} {
+		if !strings.Contains(body, exp) {
+			t.Errorf("expected preview to contain %s, got %s", exp, body)
+		}
+	}
+
+	for _, share := range []*objects.SharePage{protected, restricted} {
+		ctx := ws.request("GET", "/s/"+share.Ident, "", nil)
+		if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+			t.Fatalf("expected status 200, got %d", code)
+		}
+		body := string(ctx.Response.Body())
+		if !strings.Contains(body, `content="`+previewProtectedTitle+`"`) {
+			t.Errorf("expected protected title, got %s", body)
+		}
+		if strings.Contains(body, "test page") || strings.Contains(body, "og:image") || strings.Contains(body, "Rune page for") {
+			t.Errorf("expected page contents to be hidden, got %s", body)
+		}
+	}
+
+	if code := ws.request("GET", "/s/"+expired.Ident, "", nil).Response.StatusCode(); code != fasthttp.StatusNotFound {
+		t.Errorf("expected status 404 for expired share, got %d", code)
+	}
+	if code := ws.request("GET", "/s/unknown", "", nil).Response.StatusCode(); code != fasthttp.StatusNotFound {
+		t.Errorf("expected status 404 for unknown share, got %d", code)
+	}
+
+	// Previews do not count as accesses.
+	if n := db.getShare(open.UID).Accesses; n != 0 {
+		t.Errorf("expected no accesses, got %d", n)
+	}
+}
+
+func TestOEmbed(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, &Config{PublicAddr: testPublicAddr})
+	addTestUser(ws, db, 1, "owner")
+
+	open := newTestShare(t, db, 1)
+	protected := newTestShare(t, db, 1)
+	protected.Audience = []snowflake.ID{2}
+	db.SetShare(context.Background(), protected)
+
+	oEmbed := func(shareURL, format string) (int, *oEmbedResponse) {
+		path := "/oembed?url=" + url.QueryEscape(shareURL)
+		if format != "" {
+			path += "&format=" + format
+		}
+		ctx := ws.request("GET", path, "", nil)
+		res := new(oEmbedResponse)
+		if ctx.Response.StatusCode() == fasthttp.StatusOK {
+			if err := json.Unmarshal(ctx.Response.Body(), res); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return ctx.Response.StatusCode(), res
+	}
+
+	code, res := oEmbed(testPublicAddr+"/share/"+open.Ident, "json")
+	if code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if res.Version != oEmbedVersion || res.Type != "link" || res.Title != "test page" ||
+		res.AuthorName != "owner" || res.ProviderURL != testPublicAddr || res.ThumbnailURL == "" {
+		t.Errorf("unexpected oEmbed response %+v", res)
+	}
+
+	code, res = oEmbed(testPublicAddr+"/share/"+protected.Ident+"/", "")
+	if code != fasthttp.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if res.Title != previewProtectedTitle || res.ThumbnailURL != "" || res.ThumbnailWidth != 0 {
+		t.Errorf("expected protected oEmbed response, got %+v", res)
+	}
+
+	if code, _ = oEmbed(testPublicAddr+"/share/"+open.Ident, "xml"); code != fasthttp.StatusNotImplemented {
+		t.Errorf("expected status 501 for xml format, got %d", code)
+	}
+	if code, _ = oEmbed("", ""); code != fasthttp.StatusBadRequest {
+		t.Errorf("expected status 400 without url, got %d", code)
+	}
+	if code, _ = oEmbed(testPublicAddr+"/share/unknown", ""); code != fasthttp.StatusNotFound {
+		t.Errorf("expected status 404 for unknown share, got %d", code)
+	}
+}
+
+func TestShareIdentFromURL(t *testing.T) {
+	cases := []struct {
+		url string
+		exp string
+	}{
+		{"https://myrunes.com/share/abc", "abc"},
+		{"https://myrunes.com/share/abc/", "abc"},
+		{"https://myrunes.com/share/abc?x=1#y", "abc"},
+		{"/share/abc", "abc"},
+		{"https://myrunes.com", ""},
+		{"abc", ""},
+		{"", ""},
+		{"%zz", ""},
+	}
+
+	for _, c := range cases {
+		if res := shareIdentFromURL(c.url); res != c.exp {
+			t.Errorf("%q: expected %q, got %q", c.url, c.exp, res)
+		}
+	}
+}
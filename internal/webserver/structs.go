@@ -36,21 +36,30 @@ type alterFavoriteRequest struct {
 // request body for creating a page
 // share.
 type createShareRequest struct {
-	MaxAccesses int       `json:"maxaccesses"`
-	Expires     time.Time `json:"expires"`
-	Page        string    `json:"page"`
+	MaxAccesses int                  `json:"maxaccesses"`
+	Expires     time.Time            `json:"expires"`
+	Page        string               `json:"page"`
+	Pages       []string             `json:"pages"`
+	Filter      *objects.ShareFilter `json:"filter"`
+	Team        string               `json:"team"`
+	Password    *string              `json:"password"`
+	Audience    *[]string            `json:"audience"`
 }
 
 // shareResponse wraps the response
 // data when requesting a page share
 // object containing the data for the
 // share and the liquified data for
-// the page which is shared and the
-// user which owns the page.
+// the page which is shared - or the
+// pages of a share collection - and
+// the user or the team which owns
+// the page.
 type shareResponse struct {
 	Share *objects.SharePage `json:"share"`
 	Page  *objects.Page      `json:"page"`
+	Pages []*objects.Page    `json:"pages,omitempty"`
 	User  *objects.User      `json:"user"`
+	Team  *objects.Team      `json:"team,omitempty"`
 }
 
 // pageOrderRequest describes the request
@@ -59,6 +68,16 @@ type pageOrderRequest struct {
 	PageOrder []snowflake.ID `json:"pageorder"`
 }
 
+// profileResponse wraps the public
+// profile of a user containing the
+// sanitized user object and the pages
+// published by the user.
+type profileResponse struct {
+	User  *objects.User   `json:"user"`
+	N     int             `json:"n"`
+	Pages []*objects.Page `json:"pages"`
+}
+
 // setMailRequest describes the reuqest
 // model for setting or resetting a
 // users e-mail specification.
@@ -105,3 +124,127 @@ type mailConfirmationData struct {
 type reCaptchaResponse struct {
 	ReCaptchaResponse string `json:"recaptcharesponse"`
 }
+
+// shareForkRequest describes the request
+// model to fork a page of a share.
+type shareForkRequest struct {
+	Page string `json:"page"`
+}
+
+// teamRequest describes the request
+// model to create or update a team.
+type teamRequest struct {
+	Name string `json:"name"`
+}
+
+// folderRequest describes the request
+// model to create or update a folder.
+// If Parent is nil on updates, the
+// folder is not moved.
+type folderRequest struct {
+	Name   string        `json:"name"`
+	Parent *snowflake.ID `json:"parent"`
+}
+
+// webhookRequest describes the request
+// model to create or update a webhook.
+// Fields which are nil are not changed
+// on updates.
+type webhookRequest struct {
+	URL     *string   `json:"url"`
+	Secret  *string   `json:"secret"`
+	Events  *[]string `json:"events"`
+	Enabled *bool     `json:"enabled"`
+}
+
+// tagRenameRequest describes the request
+// model to rename a tag or to merge it
+// into another tag.
+type tagRenameRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// tagResponse wraps a tag and the
+// number of pages tagged with it.
+type tagResponse struct {
+	Tag   string `json:"tag"`
+	Pages int    `json:"pages"`
+}
+
+// teamMemberRequest describes the request
+// model to invite a user to a team or to
+// change the role of a team member.
+type teamMemberRequest struct {
+	UserName string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// teamResponse wraps a team with the role
+// of the requesting user in the team and,
+// if requested, the members of the team.
+type teamResponse struct {
+	Team    *objects.Team         `json:"team"`
+	Role    string                `json:"role"`
+	Pending bool                  `json:"pending"`
+	Members []*teamMemberResponse `json:"members,omitempty"`
+}
+
+// teamMemberResponse wraps a team
+// membership with the sanitized
+// user object of the member.
+type teamMemberResponse struct {
+	Member *objects.TeamMember `json:"member"`
+	User   *objects.User       `json:"user"`
+}
+
+// syncResponse wraps the pages created,
+// updated and deleted since the passed
+// sync cursor and the cursor to be used
+// for the next synchronization.
+type syncResponse struct {
+	Cursor  string          `json:"cursor"`
+	Created []*objects.Page `json:"created"`
+	Updated []*objects.Page `json:"updated"`
+	Deleted []snowflake.ID  `json:"deleted"`
+}
+
+// pageDeletedEvent describes the payload
+// of page deleted events.
+type pageDeletedEvent struct {
+	UID snowflake.ID `json:"uid"`
+}
+
+// shareAccessedEvent describes the payload
+// of share accessed events.
+type shareAccessedEvent struct {
+	Share      string    `json:"share"`
+	Accesses   int       `json:"accesses"`
+	LastAccess time.Time `json:"lastaccess"`
+}
+
+// favoritesChangedEvent describes the
+// payload of favorites changed events.
+type favoritesChangedEvent struct {
+	Favorites []string `json:"favorites"`
+}
+
+// pageV2 describes the response model of
+// pages in v2 of the API, which extends
+// the page by its representation in the
+// League client.
+type pageV2 struct {
+	*objects.Page
+	LCU *objects.LCUPage `json:"lcu"`
+}
+
+// pageListResponse describes the response
+// model of paginated page lists in v2 of
+// the API. Total is the number of pages
+// matching the request.
+type pageListResponse struct {
+	N      int       `json:"n"`
+	Total  int       `json:"total"`
+	Offset int       `json:"offset"`
+	Data   []*pageV2 `json:"data"`
+}
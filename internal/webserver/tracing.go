@@ -0,0 +1,105 @@
+package webserver
+
+import (
+	"context"
+	"strings"
+
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/myrunes/backend/internal/tracing"
+)
+
+// key of the trace context in the
+// request context
+const ctxKeyTraceContext = "tracecontext"
+
+// headerCarrier adapts the headers of a request
+// to a propagation.TextMapCarrier, so that trace
+// contexts can be extracted from requests.
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+var _ propagation.TextMapCarrier = headerCarrier{}
+
+func (c headerCarrier) Get(key string) string {
+	return string(c.header.Peek(key))
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.header.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0)
+	c.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// traceRequest provides a handler which records the
+// request as server span. If the request carries
+// trace context headers, like traceparent, the span
+// continues the trace of the caller. The context
+// containing the span is set to the request context
+// and can be obtained by requestContext to trace the
+// calls executed by the following handlers.
+func (ws *WebServer) traceRequest(ctx *routing.Context) error {
+	method := string(ctx.Method())
+	path := strings.TrimPrefix(string(ctx.Path()), ws.config.PathPrefix)
+
+	// Spans are named by their OpenAPI path
+	// templates to keep the number of span
+	// names low.
+	name, route := method, ""
+	if r := ws.matchAPIRoute(method, path); r != nil {
+		route = r.path
+		name += " " + route
+	}
+
+	tctx := otel.GetTextMapPropagator().Extract(context.Background(),
+		headerCarrier{&ctx.Request.Header})
+
+	id, _ := ctx.Get(ctxKeyRequestID).(string)
+	tctx, span := tracing.Tracer().Start(tctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.method", method),
+			attribute.String("http.target", string(ctx.RequestURI())),
+			attribute.String("http.user_agent", string(ctx.Request.Header.PeekBytes(headerUserAgent))),
+			attribute.String("request_id", id)))
+	if route != "" {
+		span.SetAttributes(attribute.String("http.route", route))
+	}
+
+	ctx.Set(ctxKeyTraceContext, tctx)
+
+	err := ctx.Next()
+
+	status := responseStatus(ctx, err)
+	span.SetAttributes(attribute.Int("http.status_code", status))
+	if status >= fasthttp.StatusInternalServerError {
+		span.SetStatus(codes.Error, fasthttp.StatusMessage(status))
+	}
+	span.End()
+
+	return err
+}
+
+// requestContext returns the context containing
+// the span of the request of the passed context.
+// If the request is not traced, an empty context
+// is returned.
+func requestContext(ctx *routing.Context) context.Context {
+	if tctx, ok := ctx.Get(ctxKeyTraceContext).(context.Context); ok {
+		return tctx
+	}
+	return context.Background()
+}
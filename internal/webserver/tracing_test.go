@@ -0,0 +1,118 @@
+package webserver
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordTestSpans sets a tracer provider recording
+// all spans and the trace context propagator as
+// global provider and propagator until cleanup.
+func recordTestSpans(t *testing.T) *tracetest.SpanRecorder {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	return sr
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTraceRequest(t *testing.T) {
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+
+	sr := recordTestSpans(t)
+	ws := newTestWebServer(t, newTestDatabase(), nil)
+
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod("GET")
+	ctx.Request.SetRequestURI(testPathPrefix + "/version")
+	ctx.Request.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	ws.router.HandleRequest(ctx)
+
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+
+	if name := span.Name(); name != "GET /version" {
+		t.Errorf("expected span name 'GET /version', got %q", name)
+	}
+	if kind := span.SpanKind(); kind != trace.SpanKindServer {
+		t.Errorf("expected server span, got %s", kind)
+	}
+	if id := span.SpanContext().TraceID().String(); id != traceID {
+		t.Errorf("expected trace ID %s of the caller, got %s", traceID, id)
+	}
+	if id := span.Parent().SpanID().String(); id != parentID || !span.Parent().IsRemote() {
+		t.Errorf("expected remote parent span %s, got %s", parentID, id)
+	}
+	if v, _ := spanAttribute(span, "http.route"); v.AsString() != "/version" {
+		t.Errorf("expected route /version, got %q", v.AsString())
+	}
+	if v, _ := spanAttribute(span, "http.status_code"); v.AsInt64() != fasthttp.StatusOK {
+		t.Errorf("expected status code 200, got %d", v.AsInt64())
+	}
+	if v, ok := spanAttribute(span, "request_id"); !ok || v.AsString() == "" {
+		t.Error("expected request ID to be recorded")
+	}
+}
+
+func TestTraceRequestUnmatchedRoute(t *testing.T) {
+	sr := recordTestSpans(t)
+	ws := newTestWebServer(t, newTestDatabase(), nil)
+
+	ws.request("GET", "/unknown", "", nil)
+
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if name := spans[0].Name(); name != "GET" {
+		t.Errorf("expected span name 'GET', got %q", name)
+	}
+	if _, ok := spanAttribute(spans[0], "http.route"); ok {
+		t.Error("expected no route to be recorded")
+	}
+	if spans[0].Parent().IsValid() {
+		t.Error("expected span to start a new trace")
+	}
+}
+
+func TestHeaderCarrier(t *testing.T) {
+	header := new(fasthttp.RequestHeader)
+	c := headerCarrier{header}
+
+	c.Set("traceparent", "value")
+	if v := c.Get("Traceparent"); v != "value" {
+		t.Errorf("expected value, got %q", v)
+	}
+
+	keys := c.Keys()
+	if len(keys) != 1 || keys[0] != "Traceparent" {
+		t.Errorf("expected keys [Traceparent], got %v", keys)
+	}
+}
@@ -0,0 +1,117 @@
+package webserver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/myrunes/backend/internal/objects"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+// Path prefixes of the API versions
+// relative to the path prefix.
+const (
+	apiV1Prefix = "/v1"
+	apiV2Prefix = "/v2"
+)
+
+// apiV1Deprecated is the date v1 routes with a
+// v2 counterpart were deprecated by the release
+// of their v2 counterparts.
+var apiV1Deprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// addDeprecationHeaders provides a handler which sets
+// the Deprecation, Sunset and Link headers on responses
+// of v1 routes which have a v2 counterpart. The
+// Deprecation header contains the date of deprecation
+// as Unix timestamp (RFC 9745) and the Link header
+// references the v2 counterpart of the route.
+func (ws *WebServer) addDeprecationHeaders(ctx *routing.Context) error {
+	path := strings.TrimPrefix(string(ctx.Path()), ws.config.PathPrefix)
+
+	r := ws.matchAPIRoute(string(ctx.Method()), path)
+	if r == nil || !r.deprecated {
+		return nil
+	}
+
+	successor := ws.config.PathPrefix + apiV2Prefix + strings.TrimPrefix(path, apiV1Prefix)
+
+	ctx.Response.Header.SetBytesK(headerDeprecation, "@"+strconv.FormatInt(apiV1Deprecated.Unix(), 10))
+	if ws.v1Sunset != "" {
+		ctx.Response.Header.SetBytesK(headerSunset, ws.v1Sunset)
+	}
+	ctx.Response.Header.SetBytesK(headerLink, fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+
+	return nil
+}
+
+// newPageV2 wraps the passed page into
+// the v2 response model of pages.
+func newPageV2(page *objects.Page) *pageV2 {
+	return &pageV2{
+		Page: page,
+		LCU:  page.LCU(),
+	}
+}
+
+// GET /v2/pages
+func (ws *WebServer) handlerGetPagesV2(ctx *routing.Context) error {
+	var err error
+	user := ctx.Get("user").(*objects.User)
+	queryArgs := ctx.QueryArgs()
+
+	limit := pagesLimitDefault
+	if v := queryArgs.Peek("limit"); len(v) > 0 {
+		if limit, err = strconv.Atoi(string(v)); err != nil || limit < 1 || limit > pagesLimitMax {
+			return jsonError(ctx, errInvalidArguments, fasthttp.StatusBadRequest)
+		}
+	}
+
+	var offset int
+	if v := queryArgs.Peek("offset"); len(v) > 0 {
+		if offset, err = strconv.Atoi(string(v)); err != nil || offset < 0 {
+			return jsonError(ctx, errInvalidArguments, fasthttp.StatusBadRequest)
+		}
+	}
+
+	pages, status, err := ws.queryPages(ctx, user)
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	res := &pageListResponse{
+		Total:  len(pages),
+		Offset: offset,
+		Data:   make([]*pageV2, 0),
+	}
+
+	if offset < len(pages) {
+		pages = pages[offset:]
+		if len(pages) > limit {
+			pages = pages[:limit]
+		}
+		for _, p := range pages {
+			res.Data = append(res.Data, newPageV2(p))
+		}
+	}
+	res.N = len(res.Data)
+
+	return jsonResponse(ctx, res, fasthttp.StatusOK)
+}
+
+// GET /v2/pages/:id
+func (ws *WebServer) handlerGetPageV2(ctx *routing.Context) error {
+	user := ctx.Get("user").(*objects.User)
+
+	page, status, err := ws.getRequestedPage(ctx, user)
+	if err != nil {
+		return jsonError(ctx, err, status)
+	}
+
+	ctx.Response.Header.SetBytesK(headerETag, pageETag(page))
+
+	return jsonResponse(ctx, newPageV2(page), fasthttp.StatusOK)
+}
@@ -0,0 +1,78 @@
+package webserver
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestDeprecationHeaders(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, &Config{V1Sunset: "2027-06-30"})
+	auth := addTestUser(ws, db, 1, "user")
+
+	page := newTestPage(1)
+	if err := db.CreatePage(context.Background(), page); err != nil {
+		t.Fatal(err)
+	}
+	pagePath := "/pages/" + page.UID.String()
+
+	deprecation := "@" + strconv.FormatInt(apiV1Deprecated.Unix(), 10)
+	sunset := time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+
+	for _, path := range []string{pagePath, "/v1" + pagePath} {
+		ctx := ws.request("GET", path, auth, nil)
+		if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", path, code)
+		}
+
+		h := &ctx.Response.Header
+		if v := string(h.Peek("Deprecation")); v != deprecation {
+			t.Errorf("%s: expected Deprecation %q, got %q", path, deprecation, v)
+		}
+		if v := string(h.Peek("Sunset")); v != sunset {
+			t.Errorf("%s: expected Sunset %q, got %q", path, sunset, v)
+		}
+		if v, exp := string(h.Peek("Link")), `<`+testPathPrefix+`/v2`+pagePath+`>; rel="successor-version"`; v != exp {
+			t.Errorf("%s: expected Link %q, got %q", path, exp, v)
+		}
+	}
+
+	for _, path := range []string{"/v2" + pagePath, "/users/me"} {
+		ctx := ws.request("GET", path, auth, nil)
+		if v := ctx.Response.Header.Peek("Deprecation"); v != nil {
+			t.Errorf("%s: expected no Deprecation header, got %q", path, v)
+		}
+	}
+}
+
+func TestDeprecationHeadersWithoutSunset(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+	auth := addTestUser(ws, db, 1, "user")
+
+	page := newTestPage(1)
+	if err := db.CreatePage(context.Background(), page); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := ws.request("GET", "/v1/pages/"+page.UID.String(), auth, nil)
+	if v := ctx.Response.Header.Peek("Deprecation"); v == nil {
+		t.Error("expected Deprecation header")
+	}
+	if v := ctx.Response.Header.Peek("Sunset"); v != nil {
+		t.Errorf("expected no Sunset header, got %q", v)
+	}
+}
+
+func TestSunsetBeforeDeprecation(t *testing.T) {
+	_, err := NewWebServer(newTestDatabase(), nil, nil, nil, nil, nil, nil,
+		&Config{ShareAccessKey: "test-share-access-key", V1Sunset: apiV1Deprecated.AddDate(0, 0, -1).Format("2006-01-02")})
+	if err != errSunsetBeforeDeprecation {
+		t.Errorf("expected errSunsetBeforeDeprecation, got %v", err)
+	}
+}
@@ -1,7 +1,10 @@
 package webserver
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/zekroTJA/timedmap"
@@ -9,8 +12,13 @@ import (
 	"github.com/myrunes/backend/internal/assets"
 	"github.com/myrunes/backend/internal/caching"
 	"github.com/myrunes/backend/internal/database"
+	"github.com/myrunes/backend/internal/events"
+	"github.com/myrunes/backend/internal/health"
+	"github.com/myrunes/backend/internal/logger"
 	"github.com/myrunes/backend/internal/mailserver"
 	"github.com/myrunes/backend/internal/ratelimit"
+	"github.com/myrunes/backend/pkg/openapi"
+	"github.com/myrunes/backend/pkg/random"
 
 	routing "github.com/qiangxue/fasthttp-routing"
 	"github.com/valyala/fasthttp"
@@ -24,6 +32,79 @@ var (
 	errNoAccess                 = errors.New("access denied")
 	errMissingReCaptchaResponse = errors.New("missing recaptcha challenge response")
 	errEmailAlreadyTaken        = errors.New("e-mail address is already taken by another account")
+	errSharePasswordRequired    = errors.New("share password required")
+	errShareInvalidPassword     = errors.New("invalid share password")
+	errShareLoginRequired       = errors.New("login required to access this share")
+	errShareNotInAudience       = errors.New("share is not shared with this account")
+	errUnknownAudienceUser      = errors.New("unknown user in share audience")
+	errUnsupportedFormat        = errors.New("unsupported format")
+	errMixedShareOwners         = errors.New("all shared pages must have the same owner")
+	errTeamMemberExists         = errors.New("user is already a member of this team")
+	errLastTeamAdmin            = errors.New("team must have at least one admin")
+	errEmptySearchQuery         = errors.New("empty search query")
+	errSearchQueryTooLong       = errors.New("search query too long")
+	errInvalidFolder            = errors.New("invalid folder")
+	errFolderCycle              = errors.New("folder can not be moved into itself")
+	errInvalidBulkOperation     = errors.New("invalid bulk operation")
+	errTooManyBulkOperations    = errors.New("too many bulk operations")
+	errTooManyBulkCreates       = errors.New("too many page creating bulk operations")
+	errPreconditionFailed       = errors.New("precondition failed")
+	errSyncCursorExpired        = errors.New("sync cursor expired, full sync required")
+	errTooManyWebhooks          = errors.New("too many webhooks")
+	errSunsetBeforeDeprecation  = errors.New("v1 sunset is before the deprecation of v1 routes")
+)
+
+const (
+	// default number of days covered by
+	// share stats timelines
+	shareStatsDaysDefault = 30
+	// maximum number of days covered by
+	// share stats timelines
+	shareStatsDaysMax = 365
+	// maximum number of referrers listed
+	// in share stats
+	shareStatsTopReferrers = 10
+	// number of random bytes of generated
+	// share access keys
+	shareAccessKeyLength = 32
+	// maximum number of page suggestions
+	// derived from the users own pages
+	pageSuggestionsOwnMax = 5
+	// maximum number of page suggestions
+	// derived from the community stats
+	pageSuggestionsCommunityMax = 3
+	// maximum length of page search
+	// query strings
+	searchQueryMaxLen = 256
+	// interval of keep alive comments
+	// sent on event streams
+	eventStreamKeepAlive = 15 * time.Second
+	// duration sync cursors are set back
+	// to include writes which were in
+	// progress while synchronizing
+	syncCursorOverlap = 2 * time.Second
+	// maximum number of webhooks
+	// per user or team
+	webhooksMax = 10
+	// maximum number of deliveries
+	// listed in webhook delivery logs
+	webhookDeliveriesMax = 50
+	// default number of pages listed
+	// per page list request in v2
+	pagesLimitDefault = 50
+	// maximum number of pages listed
+	// per page list request in v2
+	pagesLimitMax = 200
+	// maximum duration keep alive connections
+	// are kept open waiting for the next request,
+	// which also limits the time idle connections
+	// delay a shutdown
+	idleTimeout = 15 * time.Second
+	// interval in which page creation tokens
+	// are refilled and the maximum number of
+	// page creations in a row
+	pageCreateLimit = 5 * time.Second
+	pageCreateBurst = 5
 )
 
 // Config wraps properties for the
@@ -36,6 +117,24 @@ type Config struct {
 	PublicAddr string           `json:"publicaddress"`
 	EnableCors bool             `json:"enablecors"`
 	JWTKey     string           `json:"jwtkey"`
+	// ShareAccessKey is the secret key the IP
+	// addresses of share visitors are hashed with.
+	// If empty, a random key is generated on each
+	// start, so that returning visitors are counted
+	// again after a restart.
+	ShareAccessKey string `json:"shareaccesskey"`
+	// ValidateRequests enables the validation
+	// of request bodies against the OpenAPI
+	// specification before they are passed
+	// to the handlers.
+	ValidateRequests bool `json:"validaterequests"`
+	// V1Sunset is the date (YYYY-MM-DD) after
+	// which v1 routes with a v2 counterpart
+	// are removed. If set, it is announced in
+	// the Sunset header of their responses.
+	// It must not be before the deprecation
+	// of these routes.
+	V1Sunset string `json:"v1sunset"`
 }
 
 // TLSConfig wraps properties for
@@ -59,65 +158,148 @@ type WebServer struct {
 	server *fasthttp.Server
 	router *routing.Router
 
-	db    database.Middleware
-	cache caching.CacheMiddleware
-	ms    *mailserver.MailServer
-	auth  *Authorization
-	rlm   *ratelimit.RateLimitManager
+	db     database.Middleware
+	cache  caching.CacheMiddleware
+	events events.Broker
+	ms     *mailserver.MailServer
+	health *health.Health
+	auth   *Authorization
+	access *AccessControl
+	rlm    *ratelimit.RateLimitManager
+
+	reservePageCreates ratelimit.Reserver
 
 	avatarAssetsHandler *assets.AvatarHandler
+	pageImageRenderer   *assets.PageImageRenderer
+
+	shareAccessKey []byte
 
 	mailConfirmation *timedmap.TimedMap
 	pwReset          *timedmap.TimedMap
 
+	openAPISpec *openapi.Document
+	apiRoutes   []*apiRoute
+	v1Sunset    string
+
+	cShutdown    chan struct{}
+	shutdownOnce sync.Once
+
 	config *Config
 }
 
 // NewWebServer initializes a WebServer instance using
-// the specified database driver, cache driver, mail
-// server instance and configuration instance.
+// the specified database driver, cache driver, event
+// broker, mail server instance, health checks, asset
+// handlers and configuration instance.
 func NewWebServer(db database.Middleware, cache caching.CacheMiddleware,
-	ms *mailserver.MailServer, avatarAssetsHandler *assets.AvatarHandler,
-	config *Config) (ws *WebServer, err error) {
+	evt events.Broker, ms *mailserver.MailServer, hc *health.Health, avatarAssetsHandler *assets.AvatarHandler,
+	pageImageRenderer *assets.PageImageRenderer, config *Config) (ws *WebServer, err error) {
 
 	ws = new(WebServer)
 
 	ws.config = config
 	ws.db = db
 	ws.cache = cache
+	ws.events = evt
 	ws.ms = ms
+	ws.health = hc
 	ws.rlm = ratelimit.New()
 	ws.router = routing.New()
 	ws.server = &fasthttp.Server{
-		Handler: ws.router.HandleRequest,
+		Handler:     ws.router.HandleRequest,
+		IdleTimeout: idleTimeout,
 	}
+	ws.cShutdown = make(chan struct{})
 
 	ws.avatarAssetsHandler = avatarAssetsHandler
+	ws.pageImageRenderer = pageImageRenderer
 
 	if ws.auth, err = NewAuthorization([]byte(config.JWTKey), db, cache, ws.rlm); err != nil {
 		return
 	}
 
+	ws.access = NewAccessControl(db, cache)
+
+	if config.ShareAccessKey != "" {
+		ws.shareAccessKey = []byte(config.ShareAccessKey)
+	} else {
+		logger.Warning("WEBSERVER :: no share access key set, using a random key")
+		if ws.shareAccessKey, err = random.ByteArray(shareAccessKeyLength); err != nil {
+			return
+		}
+	}
+
 	ws.mailConfirmation = timedmap.New(1 * time.Hour)
 	ws.pwReset = timedmap.New(1 * time.Minute)
 
+	if config.V1Sunset != "" {
+		var sunset time.Time
+		if sunset, err = time.Parse("2006-01-02", config.V1Sunset); err != nil {
+			return
+		}
+		if sunset.Before(apiV1Deprecated) {
+			err = errSunsetBeforeDeprecation
+			return
+		}
+		ws.v1Sunset = sunset.Format(http.TimeFormat)
+	}
+
+	ws.openAPISpec = buildOpenAPISpec(apiOperations, config.PathPrefix)
+	ws.apiRoutes = newAPIRoutes(apiOperations, ws.openAPISpec)
+
 	ws.registerHandlers()
 
 	return
 }
 
+// rateLimiters wraps the rate limiter handlers
+// which are shared by the routes of all API
+// versions.
+type rateLimiters struct {
+	global      routing.Handler
+	usersCreate routing.Handler
+	pageCreate  routing.Handler
+	postMail    routing.Handler
+	pwReset     routing.Handler
+}
+
 // registerHandlers creates all rate limiter buckets and
 // registers all routes and request handlers.
 func (ws *WebServer) registerHandlers() {
-	rlGlobal := ws.rlm.GetHandler(500*time.Millisecond, 50)
-	rlUsersCreate := ws.rlm.GetHandler(15*time.Second, 1)
-	rlPageCreate := ws.rlm.GetHandler(5*time.Second, 5)
-	rlPostMail := ws.rlm.GetHandler(60*time.Second, 3)
-	rlPwReset := ws.rlm.GetHandler(60*time.Second, 3)
+	// Bulk requests create a variable number of
+	// pages and therefore reserve the page creation
+	// tokens themselves.
+	ws.reservePageCreates = ws.rlm.GetReserver("page_create", pageCreateLimit, pageCreateBurst)
+
+	rl := &rateLimiters{
+		global:      ws.rlm.GetHandler("global", 500*time.Millisecond, 50),
+		usersCreate: ws.rlm.GetHandler("users_create", 15*time.Second, 1),
+		pageCreate:  ws.reservePageCreates.Handler(),
+		postMail:    ws.rlm.GetHandler("post_mail", 60*time.Second, 3),
+		pwReset:     ws.rlm.GetHandler("password_reset", 60*time.Second, 3),
+	}
 
-	ws.router.Use(ws.addHeaders, rlGlobal)
+	// Groups only inherit the handlers registered
+	// before their creation and only if they are
+	// created without own handlers. Handlers of
+	// groups must therefore be added via Use.
+	ws.router.Use(ws.logAccess, ws.addRequestID, ws.traceRequest, ws.recordMetrics, ws.addHeaders, rl.global, ws.addDeprecationHeaders)
+	if ws.config.ValidateRequests {
+		ws.router.Use(ws.validateRequest)
+	}
 
 	api := ws.router.Group(ws.config.PathPrefix)
+
+	// The unversioned routes are kept as aliases
+	// of the v1 routes for existing clients.
+	ws.registerV1Handlers(api, rl)
+	ws.registerV1Handlers(api.Group(apiV1Prefix), rl)
+	ws.registerV2Handlers(api.Group(apiV2Prefix))
+}
+
+// registerV1Handlers registers all routes and request
+// handlers of v1 of the API in the passed group.
+func (ws *WebServer) registerV1Handlers(api *routing.RouteGroup, rl *rateLimiters) {
 	api.
 		Post("/login", ws.handlerLogin)
 	api.
@@ -126,7 +308,10 @@ func (ws *WebServer) registerHandlers() {
 		Post("/logout", ws.auth.CheckRequestAuth, ws.auth.Logout)
 
 	api.Get("/version", ws.handlerGetVersion)
+	api.Get("/healthz", ws.handlerGetHealthz)
+	api.Get("/readyz", ws.handlerGetReadyz)
 	api.Get("/recaptchainfo", ws.handlerGetReCaptchaInfo)
+	api.Get("/openapi.json", ws.handlerGetOpenAPI)
 
 	refreshTokens := api.Group("/refreshtokens")
 	refreshTokens.
@@ -141,64 +326,147 @@ func (ws *WebServer) registerHandlers() {
 	resources := api.Group("/resources")
 	resources.
 		Get("/champions", ws.handlerGetChamps)
+	resources.
+		Get("/champions/<uid>/stats", ws.handlerGetChampStats)
 	resources.
 		Get("/runes", ws.handlerGetRunes)
 
 	users := api.Group("/users")
 	users.
-		Post("", rlUsersCreate, ws.handlerCreateUser)
+		Post("", rl.usersCreate, ws.handlerCreateUser)
 	users.
 		Post("/me", ws.auth.CheckRequestAuth, ws.handlerPostMe).
 		Get(ws.auth.CheckRequestAuth, ws.handlerGetMe).
 		Delete(ws.auth.CheckRequestAuth, ws.handlerDeleteMe)
 	users.
 		Get("/<uname>", ws.handlerCheckUsername)
+	users.
+		Get("/<uname>/profile", ws.handlerGetProfile)
+	users.
+		Post("/me/privacy", ws.auth.CheckRequestAuth, ws.handlerPostPrivacy)
 	users.
 		Post("/me/pageorder", ws.auth.CheckRequestAuth, ws.handlerPostPageOrder)
 
 	email := users.Group("/me/mail")
 	email.
-		Post("", ws.auth.CheckRequestAuth, rlPostMail, ws.handlerPostMail)
+		Post("", ws.auth.CheckRequestAuth, rl.postMail, ws.handlerPostMail)
 	email.
 		Post("/confirm", ws.handlerPostConfirmMail)
 
 	pwReset := users.Group("/me/passwordreset")
 	pwReset.
-		Post("", rlPwReset, ws.handlerPostPwReset)
+		Post("", rl.pwReset, ws.handlerPostPwReset)
 	pwReset.
-		Post("/confirm", rlPwReset, ws.handlerPostPwResetConfirm)
+		Post("/confirm", rl.pwReset, ws.handlerPostPwResetConfirm)
 
-	pages := api.Group("/pages", ws.addHeaders, rlGlobal, ws.auth.CheckRequestAuth)
+	pages := api.Group("/pages")
+	pages.Use(ws.auth.CheckRequestAuth)
 	pages.
-		Post("", rlPageCreate, ws.handlerCreatePage).
+		Post("", rl.pageCreate, ws.handlerCreatePage).
 		Get(ws.handlerGetPages)
+	pages.
+		Get("/suggest", ws.handlerGetPageSuggestions)
+	pages.
+		Get("/search", ws.handlerGetPagesSearch)
+	pages.
+		Post("/bulk", ws.handlerPostPagesBulk)
+	pages.
+		Get("/best", ws.handlerGetBestPage)
+	pages.
+		Get("/tags", ws.handlerGetPageTags)
+	pages.
+		Post("/tags/rename", ws.handlerPostPageTagsRename)
 	pages.
 		Get(`/<uid:\d+>`, ws.handlerGetPage).
 		Post(ws.handlerEditPage).
 		Delete(ws.handlerDeletePage)
 
-	favorites := api.Group("/favorites", ws.addHeaders, rlGlobal, ws.auth.CheckRequestAuth)
+	folders := api.Group("/folders")
+	folders.Use(ws.auth.CheckRequestAuth)
+	folders.
+		Post("", ws.handlerCreateFolder).
+		Get(ws.handlerGetFolders)
+	folders.
+		Post(`/<uid:\d+>`, ws.handlerPostFolder).
+		Delete(ws.handlerDeleteFolder)
+
+	api.Get("/sync", ws.auth.CheckRequestAuth, ws.handlerGetSync)
+	api.Get("/events", ws.auth.CheckRequestAuth, ws.handlerGetEvents)
+
+	webhooks := api.Group("/webhooks")
+	webhooks.Use(ws.auth.CheckRequestAuth)
+	webhooks.
+		Post("", ws.handlerCreateWebhook).
+		Get(ws.handlerGetWebhooks)
+	webhooks.
+		Get(`/<uid:\d+>/deliveries`, ws.handlerGetWebhookDeliveries)
+	webhooks.
+		Post(`/<uid:\d+>`, ws.handlerPostWebhook).
+		Delete(ws.handlerDeleteWebhook)
+
+	favorites := api.Group("/favorites")
+	favorites.Use(ws.auth.CheckRequestAuth)
 	favorites.
 		Get("", ws.handlerGetFavorites).
 		Post(ws.handlerPostFavorite)
 
-	shares := api.Group("/shares", ws.addHeaders, rlGlobal)
+	shares := api.Group("/shares")
 	shares.
 		Post("", ws.auth.CheckRequestAuth, ws.handlerCreateShare)
+	shares.
+		Get(`/<uid:\d+>/stats`, ws.auth.CheckRequestAuth, ws.handlerGetShareStats)
 	shares.
 		Get(`/<ident:\d+>`, ws.auth.CheckRequestAuth, ws.handlerGetShare)
+	shares.
+		Get("/<ident:[^/]+>/image.png", ws.handlerGetShareImage)
+	shares.
+		Post("/<ident:[^/]+>/fork", ws.auth.CheckRequestAuth, rl.pageCreate, ws.handlerPostShareFork)
 	shares.
 		Get("/<ident:.+>", ws.handlerGetShare)
 	shares.
 		Post(`/<uid:\d+>`, ws.auth.CheckRequestAuth, ws.handlerPostShare).
 		Delete(ws.auth.CheckRequestAuth, ws.handlerDeleteShare)
 
-	apitoken := api.Group("/apitoken", ws.addHeaders, rlGlobal, ws.auth.CheckRequestAuth)
+	teams := api.Group("/teams")
+	teams.Use(ws.auth.CheckRequestAuth)
+	teams.
+		Post("", ws.handlerCreateTeam).
+		Get(ws.handlerGetTeams)
+	teams.
+		Get(`/<uid:\d+>`, ws.handlerGetTeam).
+		Post(ws.handlerPostTeam).
+		Delete(ws.handlerDeleteTeam)
+	teams.
+		Post(`/<uid:\d+>/accept`, ws.handlerPostTeamAccept)
+	teams.
+		Post(`/<uid:\d+>/members`, ws.handlerPostTeamMember)
+	teams.
+		Post(`/<uid:\d+>/members/<userid:\d+>`, ws.handlerPostTeamMemberRole).
+		Delete(ws.handlerDeleteTeamMember)
+
+	api.Get("/s/<ident>", ws.handlerGetSharePreview)
+	api.Get("/oembed", ws.handlerGetOEmbed)
+
+	apitoken := api.Group("/apitoken")
+	apitoken.Use(ws.auth.CheckRequestAuth)
 	apitoken.
 		Get("", ws.handlerGetAPIToken).
 		Post(ws.handlerPostAPIToken).
 		Delete(ws.handlerDeleteAPIToken)
+}
 
+// registerV2Handlers registers the routes and request
+// handlers of v2 of the API in the passed group. v2
+// only contains the routes which request or response
+// models changed compared to v1. All other routes are
+// only available in v1.
+func (ws *WebServer) registerV2Handlers(api *routing.RouteGroup) {
+	pages := api.Group("/pages")
+	pages.Use(ws.auth.CheckRequestAuth)
+	pages.
+		Get("", ws.handlerGetPagesV2)
+	pages.
+		Get(`/<uid:\d+>`, ws.handlerGetPageV2)
 }
 
 // ListenAndServeBLocing starts the web servers
@@ -216,3 +484,26 @@ func (ws *WebServer) ListenAndServeBlocking() error {
 
 	return ws.server.ListenAndServe(ws.config.Addr)
 }
+
+// Shutdown stops accepting new connections, ends all
+// event streams and blocks until all in-flight requests
+// are finished or the passed context is done. In the
+// latter case, the error of the context is returned.
+// Shutdown may be called multiple times.
+func (ws *WebServer) Shutdown(ctx context.Context) error {
+	ws.shutdownOnce.Do(func() {
+		close(ws.cShutdown)
+	})
+
+	cErr := make(chan error, 1)
+	go func() {
+		cErr <- ws.server.Shutdown()
+	}()
+
+	select {
+	case err := <-cErr:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
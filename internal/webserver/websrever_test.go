@@ -0,0 +1,597 @@
+package webserver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/valyala/fasthttp"
+
+	"github.com/myrunes/backend/internal/assets"
+	"github.com/myrunes/backend/internal/caching"
+	"github.com/myrunes/backend/internal/database"
+	"github.com/myrunes/backend/internal/events"
+	"github.com/myrunes/backend/internal/objects"
+	"github.com/myrunes/backend/internal/storage"
+	"github.com/myrunes/backend/pkg/ddragon"
+)
+
+const testPathPrefix = "/api"
+
+// testDatabase is an in-memory database used by the
+// tests of the handlers. Methods which are not
+// implemented panic by calling the embedded nil
+// middleware.
+type testDatabase struct {
+	database.Middleware
+
+	mx         sync.Mutex
+	users      map[snowflake.ID]*objects.User
+	tokens     map[string]snowflake.ID
+	members    []*objects.TeamMember
+	pages      map[snowflake.ID]*objects.Page
+	folders    map[snowflake.ID]*objects.Folder
+	tombstones []*objects.PageTombstone
+	shares     map[snowflake.ID]*objects.SharePage
+	accesses   []*objects.ShareAccess
+	stats      map[string]*objects.ChampionStats
+}
+
+func newTestDatabase() *testDatabase {
+	return &testDatabase{
+		users:   make(map[snowflake.ID]*objects.User),
+		tokens:  make(map[string]snowflake.ID),
+		pages:   make(map[snowflake.ID]*objects.Page),
+		folders: make(map[snowflake.ID]*objects.Folder),
+		shares:  make(map[snowflake.ID]*objects.SharePage),
+		stats:   make(map[string]*objects.ChampionStats),
+	}
+}
+
+func (db *testDatabase) GetUser(ctx context.Context, uid snowflake.ID, username string) (*objects.User, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	if u, ok := db.users[uid]; ok {
+		return u, nil
+	}
+	for _, u := range db.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (db *testDatabase) VerifyAPIToken(ctx context.Context, tokenStr string) (*objects.User, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	uid, ok := db.tokens[tokenStr]
+	if !ok {
+		return nil, nil
+	}
+	return db.users[uid], nil
+}
+
+func (db *testDatabase) GetAPIToken(ctx context.Context, uid snowflake.ID) (*objects.APIToken, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	for token, tUID := range db.tokens {
+		if tUID == uid {
+			return &objects.APIToken{UserID: uid, Token: token}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (db *testDatabase) ResetAPIToken(ctx context.Context, uid snowflake.ID) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	for token, tUID := range db.tokens {
+		if tUID == uid {
+			delete(db.tokens, token)
+		}
+	}
+	return nil
+}
+
+func (db *testDatabase) SetTeamMember(ctx context.Context, member *objects.TeamMember) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	db.members = append(db.members, member)
+	return nil
+}
+
+func (db *testDatabase) GetTeamMember(ctx context.Context, teamID, userID snowflake.ID) (*objects.TeamMember, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	for _, m := range db.members {
+		if m.TeamID == teamID && m.UserID == userID {
+			return m, nil
+		}
+	}
+	return nil, nil
+}
+
+func (db *testDatabase) GetUserTeamMembers(ctx context.Context, userID snowflake.ID) ([]*objects.TeamMember, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	members := make([]*objects.TeamMember, 0)
+	for _, m := range db.members {
+		if m.UserID == userID {
+			members = append(members, m)
+		}
+	}
+	return members, nil
+}
+
+func (db *testDatabase) DeleteTeamMember(ctx context.Context, teamID, userID snowflake.ID) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	members := db.members[:0]
+	for _, m := range db.members {
+		if m.TeamID != teamID || m.UserID != userID {
+			members = append(members, m)
+		}
+	}
+	db.members = members
+	return nil
+}
+
+func (db *testDatabase) CreatePage(ctx context.Context, page *objects.Page) error {
+	return db.EditPage(ctx, page)
+}
+
+func (db *testDatabase) GetPages(ctx context.Context, uid snowflake.ID, champion, filter string, sortLess func(i, j *objects.Page) bool) ([]*objects.Page, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	res := make([]*objects.Page, 0)
+	for _, page := range db.pages {
+		if page.Owner != uid {
+			continue
+		}
+		if champion != "" && champion != "general" && !page.HasChampion(champion) {
+			continue
+		}
+		if filter != "" && !strings.Contains(strings.ToLower(page.Title), strings.ToLower(filter)) {
+			continue
+		}
+		c := *page
+		res = append(res, &c)
+	}
+
+	if sortLess != nil {
+		sort.Slice(res, func(i, j int) bool {
+			return sortLess(res[i], res[j])
+		})
+	}
+	return res, nil
+}
+
+func (db *testDatabase) GetPage(ctx context.Context, uid snowflake.ID) (*objects.Page, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	page, ok := db.pages[uid]
+	if !ok {
+		return nil, nil
+	}
+	c := *page
+	return &c, nil
+}
+
+func (db *testDatabase) EditPage(ctx context.Context, page *objects.Page) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	c := *page
+	db.pages[page.UID] = &c
+	return nil
+}
+
+func (db *testDatabase) EditPageIfUnmodified(ctx context.Context, page *objects.Page, edited time.Time) (bool, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	stored, ok := db.pages[page.UID]
+	if !ok || !stored.Edited.Equal(edited) {
+		return false, nil
+	}
+	c := *page
+	db.pages[page.UID] = &c
+	return true, nil
+}
+
+func (db *testDatabase) DeletePage(ctx context.Context, uid snowflake.ID) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	delete(db.pages, uid)
+	return nil
+}
+
+func (db *testDatabase) DeleteUserPages(ctx context.Context, uid snowflake.ID) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	for id, page := range db.pages {
+		if page.Owner == uid {
+			delete(db.pages, id)
+		}
+	}
+	return nil
+}
+
+func (db *testDatabase) SetFolder(ctx context.Context, folder *objects.Folder) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	c := *folder
+	db.folders[folder.UID] = &c
+	return nil
+}
+
+func (db *testDatabase) GetFolder(ctx context.Context, uid snowflake.ID) (*objects.Folder, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	folder, ok := db.folders[uid]
+	if !ok {
+		return nil, nil
+	}
+	c := *folder
+	return &c, nil
+}
+
+func (db *testDatabase) GetFolders(ctx context.Context, owner snowflake.ID) ([]*objects.Folder, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	res := make([]*objects.Folder, 0)
+	for _, folder := range db.folders {
+		if folder.Owner == owner {
+			c := *folder
+			res = append(res, &c)
+		}
+	}
+	return res, nil
+}
+
+func (db *testDatabase) DeleteFolder(ctx context.Context, uid snowflake.ID) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	delete(db.folders, uid)
+	return nil
+}
+
+func (db *testDatabase) DeleteUserFolders(ctx context.Context, owner snowflake.ID) error {
+	return nil
+}
+
+func (db *testDatabase) DeleteUserWebhooks(ctx context.Context, owner snowflake.ID) error {
+	return nil
+}
+
+func (db *testDatabase) DeleteTeam(ctx context.Context, uid snowflake.ID) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	members := db.members[:0]
+	for _, m := range db.members {
+		if m.TeamID != uid {
+			members = append(members, m)
+		}
+	}
+	db.members = members
+	return nil
+}
+
+func (db *testDatabase) AddPageTombstone(ctx context.Context, tombstone *objects.PageTombstone) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	db.tombstones = append(db.tombstones, tombstone)
+	return nil
+}
+
+func (db *testDatabase) GetPagesEditedSince(ctx context.Context, owner snowflake.ID, since time.Time) ([]*objects.Page, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	res := make([]*objects.Page, 0)
+	for _, page := range db.pages {
+		if page.Owner == owner && page.Edited.After(since) {
+			c := *page
+			res = append(res, &c)
+		}
+	}
+	return res, nil
+}
+
+func (db *testDatabase) GetPageTombstones(ctx context.Context, owner snowflake.ID, since time.Time) ([]*objects.PageTombstone, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	res := make([]*objects.PageTombstone, 0)
+	for _, t := range db.tombstones {
+		if t.Owner == owner && t.Deleted.After(since) {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (db *testDatabase) SetShare(ctx context.Context, share *objects.SharePage) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	c := *share
+	db.shares[share.UID] = &c
+	return nil
+}
+
+func (db *testDatabase) GetShare(ctx context.Context, ident string, uid, pageID snowflake.ID) (*objects.SharePage, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	for _, share := range db.shares {
+		if (ident != "" && share.Ident == ident) || share.UID == uid || share.PageID == pageID {
+			c := *share
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (db *testDatabase) DeleteUserShares(ctx context.Context, owner snowflake.ID) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	accesses := db.accesses[:0]
+	for _, a := range db.accesses {
+		if share, ok := db.shares[a.ShareID]; !ok || share.OwnerID != owner {
+			accesses = append(accesses, a)
+		}
+	}
+	db.accesses = accesses
+
+	for uid, share := range db.shares {
+		if share.OwnerID == owner {
+			delete(db.shares, uid)
+		}
+	}
+	return nil
+}
+
+func (db *testDatabase) IncrementShareAccess(ctx context.Context, uid snowflake.ID, lastAccess time.Time) (*objects.SharePage, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	share, ok := db.shares[uid]
+	if !ok || (share.MaxAccesses >= 0 && share.Accesses >= share.MaxAccesses) {
+		return nil, nil
+	}
+	share.Accesses++
+	share.LastAccess = lastAccess
+	c := *share
+	return &c, nil
+}
+
+func (db *testDatabase) SetShareLastAccess(ctx context.Context, uid snowflake.ID, lastAccess time.Time) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	if share, ok := db.shares[uid]; ok {
+		share.LastAccess = lastAccess
+	}
+	return nil
+}
+
+func (db *testDatabase) IncrementShareForks(ctx context.Context, uid snowflake.ID) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	if share, ok := db.shares[uid]; ok {
+		share.Forks++
+	}
+	return nil
+}
+
+func (db *testDatabase) AddShareAccess(ctx context.Context, access *objects.ShareAccess) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	db.accesses = append(db.accesses, access)
+	return nil
+}
+
+func (db *testDatabase) HasShareAccess(ctx context.Context, shareID snowflake.ID, ipHash string) (bool, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	for _, a := range db.accesses {
+		if a.ShareID == shareID && a.IPHash == ipHash {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (db *testDatabase) GetChampionStats(ctx context.Context, champion string) (*objects.ChampionStats, error) {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	return db.stats[champion], nil
+}
+
+func (db *testDatabase) GetTeam(ctx context.Context, uid snowflake.ID) (*objects.Team, error) {
+	return nil, nil
+}
+
+// getShare returns the stored share by its uid.
+func (db *testDatabase) getShare(uid snowflake.ID) *objects.SharePage {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	c := *db.shares[uid]
+	return &c
+}
+
+// numPages returns the number of stored pages.
+func (db *testDatabase) numPages() int {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+
+	return len(db.pages)
+}
+
+// setupTestDDragon sets a DDragon instance containing
+// the champions, trees and runes used by test pages.
+func setupTestDDragon() {
+	tree := func(uid string, runes ...string) *ddragon.RuneTree {
+		t := &ddragon.RuneTree{UID: uid}
+		for _, r := range runes {
+			t.Slots = append(t.Slots, &ddragon.RuneSlot{Runes: []*ddragon.Rune{{UID: r}}})
+		}
+		return t
+	}
+
+	ddragon.DDragonInstance = &ddragon.DDragon{
+		Champions: []*ddragon.Champion{
+			{UID: "jinx", Name: "Jinx", Tags: []string{"Marksman"}},
+			{UID: "caitlyn", Name: "Caitlyn", Tags: []string{"Marksman"}},
+			{UID: "lux", Name: "Lux", Tags: []string{"Mage"}},
+		},
+		Runes: []*ddragon.RuneTree{
+			tree("precision", "lethaltempo", "triumph", "legendalacrity", "coupdegrace"),
+			tree("sorcery", "arcanecomet", "manaflowband", "transcendence", "gatheringstorm"),
+		},
+	}
+}
+
+// newTestPage creates a valid page owned
+// by the passed owner.
+func newTestPage(owner snowflake.ID) *objects.Page {
+	setupTestDDragon()
+
+	page := objects.NewEmptyPage()
+	page.Title = "test page"
+	page.Champions = []string{"jinx"}
+	page.Primary = &objects.PrimaryTree{
+		Tree: "precision",
+		Rows: [4]string{"lethaltempo", "triumph", "legendalacrity", "coupdegrace"},
+	}
+	page.Secondary = &objects.SecondaryTree{
+		Tree: "sorcery",
+		Rows: [2]string{"manaflowband", "gatheringstorm"},
+	}
+	page.Perks = &objects.Perks{Rows: [3]string{"diamond", "shield", "heart"}}
+	page.FinalizeCreate(owner)
+
+	return page
+}
+
+// newTestWebServer creates a web server using the passed
+// database, an internal cache and an internal event broker.
+func newTestWebServer(t *testing.T, db database.Middleware, cfg *Config) *WebServer {
+	t.Helper()
+
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	cfg.PathPrefix = testPathPrefix
+	if cfg.ShareAccessKey == "" {
+		cfg.ShareAccessKey = "test-share-access-key"
+	}
+
+	cache := caching.NewInternal()
+	cache.SetDatabase(db)
+	t.Cleanup(func() { cache.Close() })
+
+	dir, err := ioutil.TempDir("", "myrunes-webserver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	st := new(storage.File)
+	if err = st.Init(storage.FileConfig{Location: dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	ws, err := NewWebServer(db, cache, events.NewInternal(), nil, nil, nil,
+		assets.NewPageImageRenderer(st, nil, nil), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ws
+}
+
+// addTestUser adds a user to the database of the web
+// server and returns the authorization header value
+// authenticating requests as this user.
+func addTestUser(ws *WebServer, db *testDatabase, uid snowflake.ID, username string) string {
+	user := &objects.User{UID: uid, Username: username, DisplayName: username}
+
+	db.mx.Lock()
+	db.users[uid] = user
+	token := "token-" + username
+	db.tokens[token] = uid
+	db.mx.Unlock()
+
+	return "Basic " + token
+}
+
+// request passes a request with the passed method, path,
+// authorization and body through the router of the web
+// server and returns the context of the processed request.
+func (ws *WebServer) request(method, path, authorization string, body []byte) *fasthttp.RequestCtx {
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(testPathPrefix + path)
+	if authorization != "" {
+		ctx.Request.Header.Set("Authorization", authorization)
+	}
+	if body != nil {
+		ctx.Request.Header.SetContentType("application/json")
+		ctx.Request.SetBody(body)
+	}
+
+	ws.router.HandleRequest(ctx)
+
+	return ctx
+}
+
+func TestProfileRouteRateLimit(t *testing.T) {
+	db := newTestDatabase()
+	ws := newTestWebServer(t, db, nil)
+
+	ctx := ws.request("GET", "/users/unknown/profile", "", nil)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", code)
+	}
+
+	// The global rate limiter must only be passed once
+	// per request, so that one token is consumed.
+	if rem := string(ctx.Response.Header.Peek("X-RateLimit-Remaining")); rem != "49" {
+		t.Errorf("expected 49 remaining requests, got %s", rem)
+	}
+}
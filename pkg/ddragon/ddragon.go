@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // endpoint definitions
@@ -33,6 +34,8 @@ func Fetch(version string) (d *DDragon, err error) {
 		return
 	}
 
+	d.Fetched = time.Now()
+
 	return
 }
 
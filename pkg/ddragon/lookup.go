@@ -0,0 +1,80 @@
+package ddragon
+
+// GetChampion returns the Champion object
+// by the passed champion UID. If no champion
+// could be found, nil is returned.
+func (d *DDragon) GetChampion(uid string) *Champion {
+	for _, c := range d.Champions {
+		if c.UID == uid {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// Class returns the primary class of the
+// champion, which is the first tag of the
+// champion. If the champion has no tags,
+// an empty string is returned.
+func (c *Champion) Class() string {
+	if len(c.Tags) == 0 {
+		return ""
+	}
+	return c.Tags[0]
+}
+
+// IsSimilar returns true if the passed champion
+// has the same primary class as the champion.
+func (c *Champion) IsSimilar(o *Champion) bool {
+	return c.Class() != "" && c.Class() == o.Class()
+}
+
+// GetRuneTree returns the RuneTree object
+// by the passed rune tree UID. If no tree
+// could be found, nil is returned.
+func (d *DDragon) GetRuneTree(uid string) *RuneTree {
+	for _, t := range d.Runes {
+		if t.UID == uid {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// GetRune returns the Rune object by the
+// passed rune UID. If no rune could be
+// found in any of the trees, nil is
+// returned.
+func (d *DDragon) GetRune(uid string) *Rune {
+	for _, t := range d.Runes {
+		for _, s := range t.Slots {
+			for _, r := range s.Runes {
+				if r.UID == uid {
+					return r
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetKeystoneTree returns the RuneTree object which
+// contains the passed keystone rune UID in its first
+// slot. If no tree could be found, nil is returned.
+func (d *DDragon) GetKeystoneTree(uid string) *RuneTree {
+	for _, t := range d.Runes {
+		if len(t.Slots) == 0 {
+			continue
+		}
+		for _, r := range t.Slots[0].Runes {
+			if r.UID == uid {
+				return t
+			}
+		}
+	}
+
+	return nil
+}
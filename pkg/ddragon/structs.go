@@ -1,5 +1,7 @@
 package ddragon
 
+import "time"
+
 // DDragon wraps the current LoL patch version and
 // information about champions and runes collected
 // from Riot's Datadragon API.
@@ -7,19 +9,25 @@ type DDragon struct {
 	Version   string      `json:"version"`
 	Champions []*Champion `json:"champions"`
 	Runes     []*RuneTree `json:"runes"`
+	Fetched   time.Time   `json:"fetched"`
 }
 
 // Champion describes a champion object.
+// Tags contains the classes of the champion
+// where the first tag is the primary class.
 type Champion struct {
-	UID  string `json:"uid"`
-	Name string `json:"name"`
+	UID  string   `json:"uid"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
 }
 
 // RuneTree describes a rune tree and
 // contains the rune slots for this tree.
 type RuneTree struct {
 	UID   string      `json:"uid"`
+	ID    int         `json:"id"`
 	Name  string      `json:"name"`
+	Icon  string      `json:"icon"`
 	Slots []*RuneSlot `json:"slots"`
 }
 
@@ -32,7 +40,9 @@ type RuneSlot struct {
 // a rune in a rune tree row.
 type Rune struct {
 	UID       string `json:"uid"`
+	ID        int    `json:"id"`
 	Name      string `json:"name"`
+	Icon      string `json:"icon"`
 	ShortDesc string `json:"shortDesc"`
 	LongDesc  string `json:"longDesc"`
 }
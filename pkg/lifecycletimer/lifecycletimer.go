@@ -3,11 +3,18 @@
 // asyncronously during program lifetime.
 package lifecycletimer
 
-import "time"
+import (
+	"context"
+	"sync"
+	"time"
+)
 
 // Handler is a function which will be executed
-// on each lifecycle elapse.
-type Handler func()
+// on each lifecycle elapse. The passed context
+// is cancelled when the timer is stopped, so
+// long running handlers should return early
+// when the context is done.
+type Handler func(ctx context.Context)
 
 // Timer manages the lifecycle ticker
 // and provides functions for registering
@@ -16,7 +23,9 @@ type Handler func()
 type Timer struct {
 	ticker *time.Ticker
 
-	cStop chan bool
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 
 	interval time.Duration
 	handlers []Handler
@@ -28,7 +37,10 @@ type Timer struct {
 // This does not automatically start the
 // lifecycle timer.
 func New(interval time.Duration) *Timer {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Timer{
+		ctx:      ctx,
+		cancel:   cancel,
 		handlers: make([]Handler, 0),
 		interval: interval,
 	}
@@ -48,17 +60,23 @@ func (t *Timer) Handle(h Handler) *Timer {
 func (t *Timer) Start() *Timer {
 	t.ticker = time.NewTicker(t.interval)
 
+	t.wg.Add(1)
 	go func() {
+		defer t.wg.Done()
+
 		for {
 			select {
 
-			case <-t.cStop:
+			case <-t.ctx.Done():
 				return
 
 			case <-t.ticker.C:
 				for _, h := range t.handlers {
+					if t.ctx.Err() != nil {
+						return
+					}
 					if h != nil {
-						h()
+						h(t.ctx)
 					}
 				}
 			}
@@ -68,9 +86,34 @@ func (t *Timer) Start() *Timer {
 	return t
 }
 
-// Stop stops the lifecycle timer.
+// Stop stops the lifecycle timer and cancels
+// the context of the currently executed
+// handler. This does not wait for the
+// handler to return.
 func (t *Timer) Stop() {
+	if t.ticker != nil {
+		t.ticker.Stop()
+	}
+	t.cancel()
+}
+
+// Shutdown stops the lifecycle timer and blocks
+// until the currently executed handler returned
+// or the passed context is done. In the latter
+// case, the error of the context is returned.
+func (t *Timer) Shutdown(ctx context.Context) error {
+	t.Stop()
+
+	done := make(chan struct{})
 	go func() {
-		t.cStop <- true
+		t.wg.Wait()
+		close(done)
 	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
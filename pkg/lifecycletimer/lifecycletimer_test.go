@@ -0,0 +1,103 @@
+package lifecycletimer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHandlersAreExecuted(t *testing.T) {
+	var calls int32
+	timer := New(10 * time.Millisecond).
+		Handle(func(ctx context.Context) { atomic.AddInt32(&calls, 1) }).
+		Handle(nil).
+		Start()
+	defer timer.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&calls) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("handler was not executed on each elapse")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestShutdownCancelsRunningHandler(t *testing.T) {
+	started := make(chan struct{})
+	var returned int32
+
+	timer := New(time.Millisecond).
+		Handle(func(ctx context.Context) {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-ctx.Done()
+			atomic.StoreInt32(&returned, 1)
+		}).
+		Start()
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := timer.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown failed: %s", err.Error())
+	}
+	if atomic.LoadInt32(&returned) != 1 {
+		t.Error("shutdown returned before the running handler")
+	}
+}
+
+func TestShutdownSkipsRemainingHandlers(t *testing.T) {
+	started := make(chan struct{})
+	var skipped int32
+
+	timer := New(time.Millisecond).
+		Handle(func(ctx context.Context) {
+			close(started)
+			<-ctx.Done()
+		}).
+		Handle(func(ctx context.Context) { atomic.StoreInt32(&skipped, 1) }).
+		Start()
+
+	<-started
+
+	if err := timer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %s", err.Error())
+	}
+	if atomic.LoadInt32(&skipped) != 0 {
+		t.Error("handler was executed after shutdown")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	timer := New(time.Millisecond).
+		Handle(func(ctx context.Context) {
+			close(started)
+			<-release
+		}).
+		Start()
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := timer.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestShutdownNotStarted(t *testing.T) {
+	if err := New(time.Second).Shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown failed: %s", err.Error())
+	}
+}
@@ -0,0 +1,244 @@
+// Package markdown provides functionalities to make
+// user provided Markdown safe to be rendered to
+// HTML by clients.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// maxPasses is the maximum number of times the
+// Markdown is parsed and rewritten. Rewriting a
+// link can change how the following text is
+// parsed, so the Markdown is parsed again until
+// it does not change anymore.
+const maxPasses = 8
+
+// safeSchemes contains all URL schemes which
+// are allowed as link and image destinations.
+var safeSchemes = map[string]struct{}{
+	"http":   {},
+	"https":  {},
+	"mailto": {},
+}
+
+var (
+	// rxScheme matches the scheme of an URL.
+	rxScheme = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.\-]*):`)
+	// rxEscape matches backslash escaped
+	// ASCII punctuation characters.
+	rxEscape = regexp.MustCompile("\\\\([!\"#$%&'()*+,\\-./:;<=>?@\\[\\\\\\]^_`{|}~])")
+)
+
+// rangesKey is the key of the source ranges of
+// links, images and autolinks in the context
+// of the parser.
+var rangesKey = parser.NewContextKey()
+
+// mdParser is a CommonMark parser which records
+// the source ranges of links, images and
+// autolinks in the parser context.
+var mdParser = parser.NewParser(
+	parser.WithBlockParsers(parser.DefaultBlockParsers()...),
+	parser.WithInlineParsers(
+		util.Prioritized(parser.NewCodeSpanParser(), 100),
+		util.Prioritized(&rangeParser{parser.NewLinkParser()}, 200),
+		util.Prioritized(&rangeParser{parser.NewAutoLinkParser()}, 300),
+		util.Prioritized(parser.NewRawHTMLParser(), 400),
+		util.Prioritized(parser.NewEmphasisParser(), 500)),
+	parser.WithParagraphTransformers(parser.DefaultParagraphTransformers()...))
+
+// Sanitize returns the passed Markdown with all raw
+// HTML escaped and all links and images with unsafe
+// URL schemes, like 'javascript:', pointing to '#'.
+// The Markdown is parsed as CommonMark, so links
+// spanning multiple lines, reference links and
+// destinations containing escapes or entities are
+// covered, while code spans and code blocks are not
+// altered. Control characters except line feeds and
+// tabs are removed and line endings are normalized.
+//
+// Sanitize is idempotent, so sanitizing already
+// sanitized Markdown does not change it.
+func Sanitize(md string) string {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	md = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, md)
+
+	src := []byte(md)
+	for i := 0; i < maxPasses; i++ {
+		edits := findEdits(src)
+		if len(edits) == 0 {
+			break
+		}
+		src = applyEdits(src, edits)
+	}
+
+	return string(src)
+}
+
+// edit replaces the source between
+// start and stop with repl.
+type edit struct {
+	start, stop int
+	repl        string
+}
+
+// findEdits parses the passed Markdown and returns
+// the edits required to escape raw HTML and to make
+// unsafe links, images and autolinks point to '#'.
+func findEdits(src []byte) []edit {
+	ranges := make(map[ast.Node]text.Segment)
+	pc := parser.NewContext()
+	pc.Set(rangesKey, ranges)
+
+	doc := mdParser.Parse(text.NewReader(src), parser.WithContext(pc))
+
+	edits := make([]edit, 0)
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+
+		case *ast.Link:
+			if seg, ok := ranges[node]; ok && !isSafeURL(string(node.Destination)) {
+				edits = append(edits, edit{seg.Start, seg.Stop, "](#)"})
+			}
+
+		case *ast.Image:
+			if seg, ok := ranges[node]; ok && !isSafeURL(string(node.Destination)) {
+				edits = append(edits, edit{seg.Start, seg.Stop, "](#)"})
+			}
+
+		case *ast.AutoLink:
+			if seg, ok := ranges[node]; ok && !isSafeURL(string(node.URL(src))) {
+				edits = append(edits, edit{seg.Start, seg.Start + 1, "&lt;"})
+			}
+
+		case *ast.RawHTML:
+			for i := 0; i < node.Segments.Len(); i++ {
+				edits = append(edits, escapeHTML(src, node.Segments.At(i))...)
+			}
+
+		case *ast.HTMLBlock:
+			for i := 0; i < node.Lines().Len(); i++ {
+				edits = append(edits, escapeHTML(src, node.Lines().At(i))...)
+			}
+			if node.HasClosure() {
+				edits = append(edits, escapeHTML(src, node.ClosureLine)...)
+			}
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	return edits
+}
+
+// escapeHTML returns the edits replacing all '<'
+// in the passed segment of src with '&lt;'.
+func escapeHTML(src []byte, seg text.Segment) []edit {
+	edits := make([]edit, 0)
+	for i := seg.Start; i < seg.Stop; i++ {
+		if src[i] == '<' {
+			edits = append(edits, edit{i, i + 1, "&lt;"})
+		}
+	}
+	return edits
+}
+
+// applyEdits applies the passed edits to src.
+// Edits overlapping a previously applied edit
+// are skipped; they are found again in the
+// next pass.
+func applyEdits(src []byte, edits []edit) []byte {
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+
+	res := src
+	last := len(src) + 1
+	for _, e := range edits {
+		if e.stop > last {
+			continue
+		}
+		res = append(res[:e.start:e.start], append([]byte(e.repl), res[e.stop:]...)...)
+		last = e.start
+	}
+
+	return res
+}
+
+// rangeParser wraps an inline parser and records
+// the source range each parsed node was parsed
+// from in the parser context. Links and images are
+// parsed when their closing bracket is reached, so
+// their ranges span from the closing bracket of the
+// link text to the end of the destination or of
+// the reference label.
+type rangeParser struct {
+	parser.InlineParser
+}
+
+func (p *rangeParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	_, start := block.Position()
+
+	n := p.InlineParser.Parse(parent, block, pc)
+	if n == nil {
+		return nil
+	}
+
+	if ranges, ok := pc.Get(rangesKey).(map[ast.Node]text.Segment); ok {
+		_, stop := block.Position()
+		ranges[n] = text.NewSegment(start.Start, stop.Start)
+	}
+
+	return n
+}
+
+func (p *rangeParser) CloseBlock(parent ast.Node, block text.Reader, pc parser.Context) {
+	if cb, ok := p.InlineParser.(parser.CloseBlocker); ok {
+		cb.CloseBlock(parent, block, pc)
+	}
+}
+
+// isSafeURL returns true if the passed URL has no
+// scheme or a scheme listed in safeSchemes. Backslash
+// escapes and HTML entities are resolved, whitespace
+// and control characters are removed and enclosing
+// angle brackets are stripped before checking,
+// because renderers do so for link destinations.
+func isSafeURL(u string) bool {
+	u = rxEscape.ReplaceAllString(u, "$1")
+	u = html.UnescapeString(u)
+	u = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, u)
+	u = strings.TrimLeft(u, "<")
+
+	sm := rxScheme.FindStringSubmatch(u)
+	if sm == nil {
+		return true
+	}
+
+	_, ok := safeSchemes[strings.ToLower(sm[1])]
+	return ok
+}
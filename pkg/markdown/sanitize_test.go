@@ -0,0 +1,110 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// unsafeRenderer renders Markdown without removing
+// raw HTML and dangerous URLs, so that the output
+// shows what Sanitize lets through.
+var unsafeRenderer = goldmark.New(goldmark.WithRendererOptions(html.WithUnsafe()))
+
+func render(t *testing.T, md string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := unsafeRenderer.Convert([]byte(md), &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestSanitize(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{"plain text", "some *notes*", "some *notes*"},
+		{"safe link", "[a](https://example.com)", "[a](https://example.com)"},
+		{"relative link", "[a](/pages/1)", "[a](/pages/1)"},
+		{"mailto link", "[a](mailto:a@example.com)", "[a](mailto:a@example.com)"},
+		{"javascript link", "[a](javascript:alert(1))", "[a](#)"},
+		{"uppercase scheme", "[a](JavaScript:alert(1))", "[a](#)"},
+		{"link with title", `[a](javascript:alert(1) "title") b`, "[a](#) b"},
+		{"javascript image", "![a](javascript:alert(1))", "![a](#)"},
+		{"data image", "![a](data:text/html;base64,PHNjcmlwdD4=)", "![a](#)"},
+		{"image in link", "[![a](javascript:x)](javascript:y)", "[![a](#)](#)"},
+		{"escaped scheme", `[a](javascript\:alert(1))`, "[a](#)"},
+		{"destination on next line", "[a](\njavascript:alert(1))", "[a](#)"},
+		{"angle bracket destination", "[a](<javascript:alert(1)>)", "[a](#)"},
+		{"safe angle bracket destination", "[a](<https://example.com/a b>)", "[a](<https://example.com/a b>)"},
+		{"entity encoded scheme", "[a](&#106;avascript:alert(1))", "[a](#)"},
+		{"named entity in scheme", "[a](javascript&colon;alert(1))", "[a](#)"},
+		{"reference definition on next line", "[x]:\njavascript:alert(1)\n\n[x]", "[x]:\njavascript:alert(1)\n\n[x](#)"},
+		{"full reference link", "[a][x]\n\n[x]: javascript:alert(1)", "[a](#)\n\n[x]: javascript:alert(1)"},
+		{"collapsed reference link", "[x][]\n\n[x]: javascript:alert(1)", "[x](#)\n\n[x]: javascript:alert(1)"},
+		{"safe reference link", "[x]\n\n[x]: https://example.com", "[x]\n\n[x]: https://example.com"},
+		{"unsafe autolink", "<javascript:alert(1)>", "&lt;javascript:alert(1)>"},
+		{"safe autolink", "<https://example.com>", "<https://example.com>"},
+		{"email autolink", "<a@example.com>", "<a@example.com>"},
+		{"raw html", `a <img src=x onerror="alert(1)"> b`, `a &lt;img src=x onerror="alert(1)"> b`},
+		{"html block", "<script>\nalert(1)\n</script>", "&lt;script>\nalert(1)\n&lt;/script>"},
+		{"less than sign", "a < b", "a < b"},
+		{"code span", "`[a](javascript:alert(1)) <b>`", "`[a](javascript:alert(1)) <b>`"},
+		{"code block", "```\n[a](javascript:alert(1))\n<b>\n```", "```\n[a](javascript:alert(1))\n<b>\n```"},
+		{"indented code block", "    [a](javascript:alert(1))", "    [a](javascript:alert(1))"},
+		{"control characters", "a\x00b\r\nc\td", "ab\nc\td"},
+	}
+
+	for _, c := range cases {
+		out := Sanitize(c.in)
+		if out != c.out {
+			t.Errorf("%s: expected %q, got %q", c.name, c.out, out)
+		}
+
+		if again := Sanitize(out); again != out {
+			t.Errorf("%s: not idempotent, got %q", c.name, again)
+		}
+
+		rendered := render(t, out)
+		if strings.Contains(rendered, `="javascript:`) ||
+			strings.Contains(rendered, `="data:`) ||
+			strings.Contains(rendered, "<script") ||
+			strings.Contains(rendered, "<img src=\"x\"") {
+			t.Errorf("%s: unsafe output rendered as %q", c.name, rendered)
+		}
+	}
+}
+
+func TestIsSafeURL(t *testing.T) {
+	cases := []struct {
+		url  string
+		safe bool
+	}{
+		{"", true},
+		{"#", true},
+		{"/relative/path", true},
+		{"https://example.com", true},
+		{"HTTP://example.com", true},
+		{"mailto:a@example.com", true},
+		{"javascript:alert(1)", false},
+		{"java\tscript:alert(1)", false},
+		{"<javascript:alert(1)>", false},
+		{`javascript\:alert(1)`, false},
+		{"&#x6A;avascript:alert(1)", false},
+		{"vbscript:msgbox", false},
+		{"file:///etc/passwd", false},
+	}
+
+	for _, c := range cases {
+		if safe := isSafeURL(c.url); safe != c.safe {
+			t.Errorf("%q: expected safe to be %t", c.url, c.safe)
+		}
+	}
+}
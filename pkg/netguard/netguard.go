@@ -0,0 +1,118 @@
+// Package netguard restricts outgoing connections
+// to addresses of the public internet, so that
+// URLs passed by users can not be used to reach
+// services of the internal network.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+var ErrNonPublicAddress = errors.New("address is not public")
+
+// nonPublicNets contains the networks which are
+// not routed in the public internet.
+var nonPublicNets = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+)
+
+// IsPublic returns true if the passed IP address
+// is a public unicast address. Loopback, private,
+// link-local, multicast and unspecified addresses
+// are not public.
+func IsPublic(ip net.IP) bool {
+	if ip == nil || ip.IsMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost resolves the passed host name, which can
+// also be an IP address, and returns ErrNonPublicAddress
+// if any of its addresses is not public.
+func CheckHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		return checkIP(ip)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if err = checkIP(addr.IP); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DialContext returns a dial function for
+// http.Transport which refuses connections to
+// addresses which are not public. The address is
+// checked after resolving the host name right
+// before connecting, so that host names resolving
+// to other addresses on later lookups can not be
+// used to get around the check.
+func DialContext(timeout time.Duration) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return checkIP(net.ParseIP(host))
+		},
+	}
+
+	return dialer.DialContext
+}
+
+// checkIP returns ErrNonPublicAddress if the
+// passed IP address is not public.
+func checkIP(ip net.IP) error {
+	if !IsPublic(ip) {
+		return ErrNonPublicAddress
+	}
+	return nil
+}
+
+// parseCIDRs parses the passed networks in
+// CIDR notation and panics on invalid ones.
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
@@ -0,0 +1,84 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	cases := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"127.1.2.3", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"172.31.255.255", false},
+		{"172.32.0.1", true},
+		{"192.168.178.1", false},
+		{"100.64.0.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"ff02::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+	}
+
+	for _, c := range cases {
+		if IsPublic(net.ParseIP(c.ip)) != c.public {
+			t.Errorf("%s: expected public to be %t", c.ip, c.public)
+		}
+	}
+
+	if IsPublic(nil) {
+		t.Error("nil address is public")
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	ctx := context.Background()
+
+	for _, host := range []string{"127.0.0.1", "::1", "10.0.0.1", "localhost"} {
+		if err := CheckHost(ctx, host); err != ErrNonPublicAddress {
+			t.Errorf("%s: expected ErrNonPublicAddress, got %v", host, err)
+		}
+	}
+
+	if err := CheckHost(ctx, "93.184.216.34"); err != nil {
+		t.Errorf("public address was rejected: %s", err.Error())
+	}
+}
+
+func TestDialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &http.Transport{DialContext: DialContext(time.Second)},
+	}
+
+	_, err := client.Get(server.URL)
+	if !errors.Is(err, ErrNonPublicAddress) {
+		t.Errorf("expected ErrNonPublicAddress, got %v", err)
+	}
+
+	// Host names are resolved before the check.
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	_, err = client.Get("http://localhost:" + port)
+	if !errors.Is(err, ErrNonPublicAddress) {
+		t.Errorf("expected ErrNonPublicAddress for localhost, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package openapi provides the object model of
+// OpenAPI 3 documents, the generation of schemas
+// from Go types and the validation of decoded
+// JSON values against those schemas.
+package openapi
+
+import "reflect"
+
+// Version is the version of the OpenAPI
+// specification documents are written in.
+const Version = "3.0.3"
+
+// Document describes the root object
+// of an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       *Info               `json:"info"`
+	Servers    []*Server           `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components *Components         `json:"components,omitempty"`
+}
+
+// Info describes the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server describes the base URL of the API.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem maps lower case HTTP methods
+// to the operations of a path.
+type PathItem map[string]*Operation
+
+// Operation describes a single API
+// operation on a path.
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+// Parameter describes a path, query
+// or header parameter of an operation.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the request
+// body of an operation.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response
+// of an operation.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes the schema of
+// a request or response body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the reusable schemas
+// and security schemes of a document.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+
+	types map[string]reflect.Type
+}
+
+// SecurityScheme describes an authentication
+// method of the API.
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema describes the structure of a
+// JSON value. If Ref is set, the schema
+// refers to a schema in the components.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+// NewComponents creates a new empty
+// instance of Components.
+func NewComponents() *Components {
+	return &Components{
+		Schemas:         make(map[string]*Schema),
+		SecuritySchemes: make(map[string]*SecurityScheme),
+		types:           make(map[string]reflect.Type),
+	}
+}
+
+// JSON returns a MediaType map containing
+// the passed schema as JSON body schema.
+func JSON(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{
+		"application/json": {Schema: schema},
+	}
+}
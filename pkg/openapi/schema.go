@@ -0,0 +1,176 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// prefix of references to schemas
+// of the components
+const refPrefix = "#/components/schemas/"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// SchemaOf returns the schema of the JSON encoding
+// of the type of v. Named struct types are added to
+// the schemas of the components and referenced by
+// their type name. If the type name is already used
+// by another type, the name is prefixed with the
+// package name of the type.
+func (c *Components) SchemaOf(v interface{}) *Schema {
+	return c.schemaOf(reflect.TypeOf(v))
+}
+
+// Ref returns a schema referencing the
+// component schema with the passed name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: refPrefix + name}
+}
+
+// Resolve returns the component schema referenced
+// by the passed schema. If the passed schema is not
+// a reference, it is returned as is. If the referenced
+// schema does not exist, nil is returned.
+func (c *Components) Resolve(s *Schema) *Schema {
+	if s == nil || s.Ref == "" {
+		return s
+	}
+	return c.Schemas[strings.TrimPrefix(s.Ref, refPrefix)]
+}
+
+func (c *Components) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return c.schemaOf(t.Elem())
+	case reflect.Struct:
+		if t.Name() == "" {
+			return c.structSchema(t)
+		}
+		return Ref(c.register(t))
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{
+			Type:     "array",
+			Items:    c.schemaOf(t.Elem()),
+			Nullable: t.Kind() == reflect.Slice,
+		}
+	case reflect.Map:
+		return &Schema{
+			Type:                 "object",
+			AdditionalProperties: c.schemaOf(t.Elem()),
+			Nullable:             true,
+		}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// Integer types with custom JSON encoding,
+		// like snowflake IDs, are encoded as strings.
+		if t.Implements(marshalerType) {
+			return &Schema{Type: "string"}
+		}
+		if t.Kind() == reflect.Int64 || t.Kind() == reflect.Uint64 {
+			return &Schema{Type: "integer", Format: "int64"}
+		}
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	}
+
+	return &Schema{}
+}
+
+// register adds the schema of the passed named
+// struct type to the component schemas, if not
+// already registered, and returns its name.
+func (c *Components) register(t reflect.Type) string {
+	name := t.Name()
+	if other, ok := c.types[name]; ok && other != t {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + name
+	}
+
+	if _, ok := c.types[name]; ok {
+		return name
+	}
+
+	// The schema is registered before it is
+	// generated, so that recursive types
+	// reference themselves.
+	s := new(Schema)
+	c.types[name] = t
+	c.Schemas[name] = s
+	*s = *c.structSchema(t)
+
+	return name
+}
+
+// structSchema returns the object schema of the
+// passed struct type containing the JSON encoded
+// fields of the struct. Fields of embedded structs
+// without JSON name are inlined.
+func (c *Components) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if name == "" && f.Anonymous && ft.Kind() == reflect.Struct {
+			for k, v := range c.structSchema(ft).Properties {
+				s.Properties[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		if strings.Contains(opts, "string") {
+			s.Properties[name] = &Schema{Type: "string"}
+		} else {
+			s.Properties[name] = c.schemaOf(f.Type)
+		}
+	}
+
+	return s
+}
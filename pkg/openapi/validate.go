@@ -0,0 +1,122 @@
+package openapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ValidationError describes a JSON value
+// which does not match its schema. Path
+// locates the value in the validated
+// document.
+type ValidationError struct {
+	Path   string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+}
+
+// Validate checks if the passed decoded JSON value
+// matches the passed schema. References are resolved
+// using the schemas of the components. Properties
+// which are not specified in the schema are not
+// checked. Null is accepted for all schemas, as
+// null values are ignored when JSON is decoded into
+// Go values.
+func (c *Components) Validate(s *Schema, v interface{}) error {
+	return c.validate(s, v, "")
+}
+
+func (c *Components) validate(s *Schema, v interface{}, path string) error {
+	if s = c.Resolve(s); s == nil || v == nil {
+		return nil
+	}
+
+	switch s.Type {
+	case "object":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch(path, s)
+		}
+		for k, pv := range m {
+			ps := s.Properties[k]
+			if ps == nil {
+				ps = s.AdditionalProperties
+			}
+			if err := c.validate(ps, pv, joinPath(path, k)); err != nil {
+				return err
+			}
+		}
+
+	case "array":
+		a, ok := v.([]interface{})
+		if !ok {
+			return mismatch(path, s)
+		}
+		for i, iv := range a {
+			if err := c.validate(s.Items, iv, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return mismatch(path, s)
+		}
+		var err error
+		switch s.Format {
+		case "date-time":
+			_, err = time.Parse(time.RFC3339, str)
+		case "byte":
+			_, err = base64.StdEncoding.DecodeString(str)
+		}
+		if err != nil {
+			return mismatch(path, s)
+		}
+
+	case "integer":
+		if f, ok := v.(float64); !ok || f != math.Trunc(f) {
+			return mismatch(path, s)
+		}
+
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return mismatch(path, s)
+		}
+
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return mismatch(path, s)
+		}
+	}
+
+	return nil
+}
+
+// mismatch returns a ValidationError describing
+// that the value at path is not of the type of
+// the passed schema.
+func mismatch(path string, s *Schema) error {
+	typ := s.Type
+	if s.Format != "" {
+		typ = fmt.Sprintf("%s (%s)", typ, s.Format)
+	}
+	return &ValidationError{path, "expected " + typ}
+}
+
+// joinPath appends the passed property
+// name to the passed value path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -35,6 +35,10 @@ func New(size int) *WorkerPool {
 		results: make(chan interface{}),
 	}
 
+	// The workers are added to the wait group before
+	// they are spawned, so that WaitBlocking can not
+	// return before a worker picked up its first job.
+	w.wg.Add(size)
 	for i := 0; i < size; i++ {
 		go w.spawnWorker(i)
 	}
@@ -65,7 +69,8 @@ func (w *WorkerPool) Results() <-chan interface{} {
 	return w.results
 }
 
-// WaitBlocking blocks until all jobs are finished.
+// WaitBlocking blocks until all jobs are finished
+// and all workers are stopped.
 func (w *WorkerPool) WaitBlocking() {
 	w.wg.Wait()
 	close(w.results)
@@ -74,11 +79,11 @@ func (w *WorkerPool) WaitBlocking() {
 // spawnWorker spawns a new worker with the passed
 // worker id and starts listening for incomming jobs.
 func (w *WorkerPool) spawnWorker(id int) {
+	defer w.wg.Done()
+
 	for job := range w.jobs {
 		if job.job != nil {
-			w.wg.Add(1)
 			w.results <- job.job(id, job.params...)
-			w.wg.Done()
 		}
 	}
 }
@@ -0,0 +1,50 @@
+package workerpool
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestWaitBlockingFinishesEnqueuedJobs(t *testing.T) {
+	w := New(3)
+
+	results := make([]int, 0)
+	done := make(chan struct{})
+	go func() {
+		for r := range w.Results() {
+			results = append(results, r.(int))
+		}
+		close(done)
+	}()
+
+	for i := 0; i < 10; i++ {
+		w.Push(func(workerId int, params ...interface{}) interface{} {
+			return params[0].(int) * 2
+		}, i)
+	}
+	w.Push(nil)
+
+	w.Close()
+	w.WaitBlocking()
+	<-done
+
+	if len(results) != 10 {
+		t.Fatalf("expected 10 results, got %d", len(results))
+	}
+	sort.Ints(results)
+	for i, r := range results {
+		if r != i*2 {
+			t.Errorf("expected result %d, got %d", i*2, r)
+		}
+	}
+}
+
+func TestWaitBlockingWithoutJobs(t *testing.T) {
+	w := New(2)
+	w.Close()
+	w.WaitBlocking()
+
+	if _, ok := <-w.Results(); ok {
+		t.Error("expected results channel to be closed")
+	}
+}